@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Selectors the built-in adapters rely on; the crawl code and the adapters'
+// sanity checks use the same ones
+const (
+	uberCardSelector       = `a[data-baseweb="card"][href]`
+	uberPageSelectSelector = `[data-baseweb="select"] div[value]`
+	linkedInPageSelector   = `a[href*="page0="]`
+	mediumYearSelector     = `a[href*="/archive/"]`
+	// The star on the card of a member-only Medium post
+	mediumMemberOnlySelector = `[aria-label="Member-only story"], [title="Member-only story"]`
+)
+
+// fullListingPage is the number of posts from which a listing page is taken
+// to be a full one, which has pagination controls when there are more
+const fullListingPage = 10
+
+// adapterCheck is something a built-in adapter expects to find on a
+// listing page. A site redesign usually breaks one of them first, long
+// before the crawl finds no posts at all.
+type adapterCheck struct {
+	adapter  string
+	what     string
+	selector string
+	minPosts int // Only checked on pages with this many posts: a short listing needs no pagination
+}
+
+var (
+	uberCardsCheck       = adapterCheck{adapter: "uber", what: "post cards", selector: uberCardSelector}
+	uberPaginationCheck  = adapterCheck{adapter: "uber", what: "page dropdown", selector: uberPageSelectSelector, minPosts: fullListingPage}
+	linkedInPagesCheck   = adapterCheck{adapter: "linkedin", what: "page links", selector: linkedInPageSelector, minPosts: fullListingPage}
+	mediumYearLinksCheck = adapterCheck{adapter: "medium", what: "archive year links", selector: mediumYearSelector}
+)
+
+// StaleAdapter is a failed sanity check of a built-in adapter: something
+// it relies on wasn't on a page that otherwise loaded fine
+type StaleAdapter struct {
+	Adapter  string `json:"adapter"`
+	Expected string `json:"expected"`
+	Selector string `json:"selector"`
+	Page     string `json:"page"`
+}
+
+// checkAdapter runs checks on the listing page pageURL, now loaded, which
+// yielded posts post URLs
+func (bc *BlogCrawler) checkAdapter(ctx context.Context, pageURL string, posts int, checks ...adapterCheck) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var failed []adapterCheck
+	for _, check := range checks {
+		if posts < check.minPosts {
+			continue
+		}
+		elements, err := bc.page.Elements(ctx, check.selector)
+		if err == nil && len(elements) == 0 {
+			failed = append(failed, check)
+		}
+	}
+	if len(failed) == 0 {
+		return
+	}
+	// A blocked or broken page lacks everything; that's not a redesign
+	if reason := bc.emptyPageReason(ctx); reason != "" {
+		return
+	}
+	for _, check := range failed {
+		bc.adapterStale(check, pageURL)
+	}
+}
+
+// adapterStale reports a failed check, once per crawl
+func (bc *BlogCrawler) adapterStale(check adapterCheck, pageURL string) {
+	for _, stale := range bc.staleAdapters {
+		if stale.Adapter == check.adapter && stale.Selector == check.selector {
+			return
+		}
+	}
+	bc.staleAdapters = append(bc.staleAdapters, StaleAdapter{
+		Adapter:  check.adapter,
+		Expected: check.what,
+		Selector: check.selector,
+		Page:     pageURL,
+	})
+	bc.warnf("The %s adapter is stale: no %s (%s) on %s; the site may have been redesigned", check.adapter, check.what, check.selector, pageURL)
+}
+
+// staleAdapterNames lists the stale adapters for the stats summary
+func staleAdapterNames(stale []StaleAdapter) []string {
+	var names []string
+	for _, s := range stale {
+		names = append(names, fmt.Sprintf("%s (%s)", s.Adapter, s.Expected))
+	}
+	return names
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ysmood/gson"
+)
+
+// anchor is an element matching a link selector, as the page sees it
+type anchor struct {
+	Href       string // As written in the page, "" when it has none
+	Text       string // Visible text, whitespace collapsed
+	Context    string // Text of the closest article, list item or card around it, cut to 200 characters
+	MemberOnly bool   // The card around it has Medium's member-only star
+}
+
+// describeAnchorJS turns an element into the anchor fields. It is shared by
+// the scripts reading links in bulk.
+const describeAnchorJS = `(element) => {
+	const collapse = (text) => (text || '').replace(/\s+/g, ' ').trim();
+	const around = element.closest('article, li, [class*="card"], [class*="post"]');
+	return {
+		href: element.getAttribute('href') || '',
+		text: collapse(element.innerText || element.textContent),
+		context: around ? collapse(around.innerText || around.textContent).slice(0, 200) : '',
+		memberOnly: !!around && (!!around.querySelector('` + mediumMemberOnlySelector + `') || /member-only story/i.test(around.textContent)),
+	};
+}`
+
+// anchorsJS returns the anchor of every element matching a selector in a
+// single round trip, or null when the browser doesn't support it
+const anchorsJS = `(selector) => {
+	const describe = ` + describeAnchorJS + `;
+	try {
+		return Array.from(document.querySelectorAll(selector), describe);
+	} catch (e) {
+		return null;
+	}
+}`
+
+// anchors returns the elements matching selector on the loaded page. All
+// their hrefs and texts come back in one round trip, where reading them
+// element by element takes one per attribute; pages with thousands of
+// links spent most of their extraction time on those.
+func (bc *BlogCrawler) anchors(ctx context.Context, selector string) ([]anchor, error) {
+	res, err := bc.page.Eval(ctx, anchorsJS, selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read links: %w", err)
+	}
+	if res.Nil() {
+		return nil, fmt.Errorf("unsupported selector %q", selector)
+	}
+	return parseAnchors(res), nil
+}
+
+// parseAnchors decodes a list of describeAnchorJS results
+func parseAnchors(res gson.JSON) []anchor {
+	anchors := make([]anchor, 0, len(res.Arr()))
+	for _, a := range res.Arr() {
+		anchors = append(anchors, anchor{
+			Href:       a.Get("href").Str(),
+			Text:       a.Get("text").Str(),
+			Context:    a.Get("context").Str(),
+			MemberOnly: a.Get("memberOnly").Bool(),
+		})
+	}
+	return anchors
+}
+
+// anchorHrefs returns the hrefs of anchors, in order
+func anchorHrefs(anchors []anchor) []string {
+	hrefs := make([]string, len(anchors))
+	for i, a := range anchors {
+		hrefs[i] = a.Href
+	}
+	return hrefs
+}
+
+// keepAnchors remembers the anchors of the listing page being swept by
+// href. Of several with the same href, the first is kept, member-only when
+// any of them is.
+func (bc *BlogCrawler) keepAnchors(anchors []anchor) {
+	for _, a := range anchors {
+		kept, ok := bc.linkAnchors[a.Href]
+		if !ok {
+			kept = a
+		}
+		kept.MemberOnly = kept.MemberOnly || a.MemberOnly
+		bc.linkAnchors[a.Href] = kept
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// maxImageBytes limits an image downloaded by --download-images
+const maxImageBytes = 20 << 20
+
+// imageExtensions name downloaded images by their Content-Type
+var imageExtensions = map[string]string{
+	"image/png":     ".png",
+	"image/jpeg":    ".jpg",
+	"image/gif":     ".gif",
+	"image/webp":    ".webp",
+	"image/avif":    ".avif",
+	"image/svg+xml": ".svg",
+}
+
+// PostImage is an image of a post saved by --download-images
+type PostImage struct {
+	URL  string `json:"url"`
+	Path string `json:"path"` // Relative to the JSON output, like the captures
+}
+
+// archivePost writes the main content of the currently loaded post to the
+// capture directory as a page of its own. The images it shows are
+// downloaded to a <name>_files directory next to it and their srcs point
+// there, so the copy stays readable after the blog removes them. An image
+// that fails to download keeps its remote src.
+func (bc *BlogCrawler) archivePost(ctx context.Context, post *Post) error {
+	source, err := bc.page.HTML(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read the page: %w", err)
+	}
+	doc, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return fmt.Errorf("failed to parse the page: %w", err)
+	}
+	pageURL, err := url.Parse(post.URL)
+	if err != nil {
+		return err
+	}
+	if currentURL, err := bc.page.URL(ctx); err == nil {
+		if parsed, err := url.Parse(currentURL); err == nil {
+			pageURL = parsed // After redirects, for resolving srcs
+		}
+	}
+
+	name := captureFileName(post.URL)
+	filesDir := name + "_files"
+	relDir := filepath.Base(bc.opts.CaptureDir)
+	container := contentContainer(doc)
+
+	// Links lead back to the blog from the local copy
+	for _, a := range cascadia.QueryAll(container, cascadia.MustCompile("a[href]")) {
+		setAttr(a, "href", resolveHref(pageURL, attr(a, "href")))
+	}
+	// The browser would pick a <picture>'s sources over the local src
+	for _, source := range cascadia.QueryAll(container, cascadia.MustCompile("picture > source")) {
+		source.Parent.RemoveChild(source)
+	}
+
+	files := make(map[string]string) // Image URL -> file name, "" when it failed
+	for _, img := range cascadia.QueryAll(container, cascadia.MustCompile("img")) {
+		src := imageSource(img)
+		if src == "" || strings.HasPrefix(src, "data:") {
+			continue
+		}
+		imageURL := resolveHref(pageURL, src)
+		file, seen := files[imageURL]
+		if !seen {
+			file, err = bc.downloadImage(ctx, imageURL, post.URL, filepath.Join(bc.opts.CaptureDir, filesDir), len(post.Images)+1)
+			if err != nil {
+				bc.warnf("Could not download image %s of %s: %v", imageURL, post.URL, err)
+			} else {
+				post.Images = append(post.Images, PostImage{URL: imageURL, Path: filepath.Join(relDir, filesDir, file)})
+			}
+			files[imageURL] = file
+		}
+		if file == "" {
+			setAttr(img, "src", imageURL)
+			continue
+		}
+		setAttr(img, "src", filesDir+"/"+file)
+		removeAttr(img, "srcset")
+		removeAttr(img, "data-src")
+	}
+
+	title := post.Title
+	if node := cascadia.Query(doc, cascadia.MustCompile("title")); title == "" && node != nil {
+		title = strings.TrimSpace(nodeText(node))
+	}
+	if title == "" {
+		title = post.URL
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>%s</title><link rel=\"canonical\" href=\"%s\"></head>\n<body>\n",
+		html.EscapeString(title), html.EscapeString(post.URL))
+	if container.Type == html.ElementNode && container.Data == "body" {
+		for child := container.FirstChild; child != nil; child = child.NextSibling {
+			if err := html.Render(&b, child); err != nil {
+				return fmt.Errorf("failed to render the archive: %w", err)
+			}
+		}
+	} else if err := html.Render(&b, container); err != nil {
+		return fmt.Errorf("failed to render the archive: %w", err)
+	}
+	b.WriteString("\n</body></html>\n")
+
+	if err := os.WriteFile(filepath.Join(bc.opts.CaptureDir, name+".html"), b.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write the archive: %w", err)
+	}
+	post.Archive = filepath.Join(relDir, name+".html")
+	return nil
+}
+
+// downloadImage saves the image at imageURL to dir as <n> plus its
+// extension and returns the file name. The post goes along as the
+// referrer, which image CDNs guarding against hotlinking look for.
+func (bc *BlogCrawler) downloadImage(ctx context.Context, imageURL, postURL, dir string, n int) (string, error) {
+	if err := bc.budget.request(); err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; manual-blog-crawler)")
+	req.Header.Set("Referer", postURL)
+
+	resp, err := bc.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	contentType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if !strings.HasPrefix(contentType, "image/") {
+		return "", fmt.Errorf("not an image but %q", contentType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	bc.budget.transferred(int64(len(data)))
+	bc.traffic.fetched(imageURL, int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxImageBytes {
+		return "", fmt.Errorf("larger than %d MB", maxImageBytes>>20)
+	}
+
+	file := fmt.Sprint(n) + imageExtension(imageURL, contentType)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, file), data, 0644); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+// imageExtension is the file extension for an image, from its Content-Type
+// or else its URL
+func imageExtension(imageURL, contentType string) string {
+	if ext, ok := imageExtensions[contentType]; ok {
+		return ext
+	}
+	if parsed, err := url.Parse(imageURL); err == nil {
+		ext := strings.ToLower(path.Ext(parsed.Path))
+		if len(ext) > 1 && len(ext) <= 5 && !unsafeFileChars.MatchString(ext[1:]) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// imageSource returns the URL an img shows: its src or, for lazy-loaded
+// images whose src is still a placeholder, data-src
+func imageSource(img *html.Node) string {
+	src := attr(img, "src")
+	if lazy := attr(img, "data-src"); lazy != "" && (src == "" || strings.HasPrefix(src, "data:")) {
+		return lazy
+	}
+	return src
+}
+
+func setAttr(node *html.Node, key, value string) {
+	for i, a := range node.Attr {
+		if a.Key == key {
+			node.Attr[i].Val = value
+			return
+		}
+	}
+	node.Attr = append(node.Attr, html.Attribute{Key: key, Val: value})
+}
+
+func removeAttr(node *html.Node, key string) {
+	for i, a := range node.Attr {
+		if a.Key == key {
+			node.Attr = append(node.Attr[:i], node.Attr[i+1:]...)
+			return
+		}
+	}
+}
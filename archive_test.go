@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestArchivePostDownloadsImages(t *testing.T) {
+	postURL := fakeBlogURL + "scaling-the-ingest-queue"
+	diagramRequests := 0
+	images := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Referer") != postURL {
+			http.Error(w, "No hotlinking", http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/diagram": // No extension, named after its Content-Type
+			diagramRequests++
+			w.Header().Set("Content-Type", "image/png")
+			io.WriteString(w, "PNG diagram")
+		case "/photo.jpeg":
+			w.Header().Set("Content-Type", "image/jpeg; charset=binary")
+			io.WriteString(w, "JPEG photo")
+		case "/tracker.gif":
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, "<html>Moved</html>")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer images.Close()
+
+	post := strings.ReplaceAll(`<html><head><title>Scaling the ingest queue</title></head><body>
+<nav><img src="IMAGES/logo.png"></nav>
+<article><h1>Scaling the ingest queue</h1>
+<p>Follows <a href="/moving-search-to-rust">the search post</a>.</p>
+<picture><source srcset="IMAGES/diagram.webp"><img src="IMAGES/diagram" srcset="IMAGES/diagram-2x 2x"></picture>
+<img src="data:image/gif;base64,R0lGODlhAQABAAAAACw=" data-src="IMAGES/photo.jpeg">
+<img src="IMAGES/diagram">
+<img src="IMAGES/gone.png">
+<img src="IMAGES/tracker.gif">
+</article></body></html>`, "IMAGES", images.URL)
+	chrome := &fakeChrome{site: map[string]string{
+		fakeBlogURL: fakeListing("", "scaling-the-ingest-queue"),
+		postURL:     post,
+	}}
+	captureDir := filepath.Join(t.TempDir(), "results_captures")
+	bc := newFakeCrawler(fakeBlogURL, chrome, CrawlOptions{DownloadImages: true, CaptureDir: captureDir, Output: io.Discard})
+	ctx := context.Background()
+	if err := bc.initializeBrowser(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.navigateToPage(ctx); err != nil {
+		t.Fatal(err)
+	}
+	posts := []Post{{URL: postURL}}
+	bc.visitPosts(ctx, posts)
+
+	name := captureFileName(postURL)
+	if want := filepath.Join("results_captures", name+".html"); posts[0].Archive != want {
+		t.Errorf("archive = %q, want %q", posts[0].Archive, want)
+	}
+	wantImages := []PostImage{
+		{URL: images.URL + "/diagram", Path: filepath.Join("results_captures", name+"_files", "1.png")},
+		{URL: images.URL + "/photo.jpeg", Path: filepath.Join("results_captures", name+"_files", "2.jpg")},
+	}
+	if !reflect.DeepEqual(posts[0].Images, wantImages) {
+		t.Errorf("images\n got: %+v\nwant: %+v", posts[0].Images, wantImages)
+	}
+	if diagramRequests != 1 {
+		t.Errorf("diagram downloaded %d times, want once for both of its imgs", diagramRequests)
+	}
+	if data, err := os.ReadFile(filepath.Join(captureDir, name+"_files", "2.jpg")); err != nil || string(data) != "JPEG photo" {
+		t.Errorf("saved photo = %q, %v", data, err)
+	}
+	if len(bc.errors) != 2 {
+		t.Errorf("errors = %q, want warnings about the missing image and the page posing as one", bc.errors)
+	}
+
+	data, err := os.ReadFile(filepath.Join(captureDir, name+".html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	archived := string(data)
+	for _, want := range []string{
+		`<title>Scaling the ingest queue</title>`,
+		`<img src="` + name + `_files/1.png"/>`,
+		`<img src="` + name + `_files/2.jpg"/>`,
+		`<img src="` + images.URL + `/gone.png"/>`,
+		`<a href="https://blog.example.com/moving-search-to-rust">`,
+	} {
+		if !strings.Contains(archived, want) {
+			t.Errorf("archive lacks %s:\n%s", want, archived)
+		}
+	}
+	for _, unwanted := range []string{"srcset", "<source", "logo.png"} {
+		if strings.Contains(archived, unwanted) {
+			t.Errorf("archive still has %s:\n%s", unwanted, archived)
+		}
+	}
+}
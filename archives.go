@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"manual-blog-crawler/urlfilter"
+)
+
+// archivePageLimit caps how many archive pages a single crawl follows
+const archivePageLimit = 100
+
+// listingKey identifies a listing page for cycle detection, ignoring a
+// trailing slash
+func listingKey(pageURL string) string {
+	return strings.TrimSuffix(pageURL, "/")
+}
+
+// collectListingURLs queues the listing-like links on the loaded page in
+// the frontier, so crawlArchives follows them one level further down
+func (bc *BlogCrawler) collectListingURLs(ctx context.Context) {
+	depth := max(bc.listingLevel, 1) + 1
+	if bc.frontier == nil || depth > bc.opts.listingDepth() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	baseURLParsed, err := url.Parse(bc.baseURL)
+	if err != nil {
+		return
+	}
+
+	anchors, err := bc.anchors(ctx, "a[href]")
+	if err != nil {
+		return
+	}
+
+	var listings []string
+	for _, a := range anchors {
+		normalizedURL, err := bc.normalizeURL(a.Href, false)
+		if err != nil {
+			continue
+		}
+		parsedURL, err := url.Parse(normalizedURL)
+		if err != nil || parsedURL.Host != baseURLParsed.Host {
+			continue
+		}
+
+		if urlfilter.IsListing(normalizedURL, bc.opts.ExpandAuthorsTags) {
+			listings = append(listings, normalizedURL)
+		}
+	}
+	if err := bc.frontier.Add(depth, listings...); err != nil {
+		bc.warnf("Error queuing archive pages: %v", err)
+	}
+}
+
+// crawlArchives follows the listing pages queued in the frontier, level by
+// level, until opts.listingDepth() is reached. Pages already crawled are
+// never revisited, so archives linking to each other don't loop. A
+// frontier kept on disk holds what an interrupted crawl had left to do and
+// found; it is cleared once the archives are done.
+func (bc *BlogCrawler) crawlArchives(ctx context.Context, urlSet map[string]bool) {
+	paginated := map[string]bool{listingKey(bc.baseURL): true}
+	visited := []string{bc.baseURL}
+	for _, page := range bc.pages {
+		paginated[listingKey(page.URL)] = true
+		visited = append(visited, page.URL)
+	}
+	if err := bc.frontier.Seen(visited...); err != nil {
+		bc.warnf("Error updating the frontier: %v", err)
+		return
+	}
+	found, err := bc.frontier.Found()
+	if err != nil {
+		bc.warnf("Error reading the frontier: %v", err)
+		return
+	}
+	if len(found) > 0 {
+		bc.printf("Resuming the archives of an interrupted crawl, which found %d URLs\n", len(found))
+		for _, url := range found {
+			urlSet[url] = true
+		}
+	}
+
+	pageNum := len(bc.pages)
+	followed := 0
+	defer func() { bc.listingLevel = 0 }()
+
+	for ctx.Err() == nil {
+		pageURL, depth, ok, err := bc.frontier.Next()
+		if err != nil {
+			bc.warnf("Error reading the frontier: %v", err)
+			return
+		}
+		if !ok || depth > bc.opts.listingDepth() {
+			bc.printf("No archive pages left to follow at depth %d\n", max(bc.listingLevel, 1)+1)
+			break
+		}
+		if depth != bc.listingLevel {
+			queued, _ := bc.frontier.Len()
+			bc.printf("Following archive pages at depth %d (%d queued)...\n", depth, queued)
+			bc.listingLevel = depth
+		}
+		if paginated[listingKey(pageURL)] {
+			if err := bc.frontier.Done(pageURL, nil); err != nil {
+				bc.warnf("Error updating the frontier: %v", err)
+				return
+			}
+			continue
+		}
+
+		if followed >= archivePageLimit {
+			bc.printf("Reached safety limit of %d archive pages. Stopping.\n", archivePageLimit)
+			break
+		}
+		followed++
+		pageNum++
+
+		bc.printf("Crawling archive page: %s\n", pageURL)
+		bc.setSource("archive", followed, pageURL)
+		urls, err := bc.crawlSinglePage(ctx, pageURL)
+		if ctx.Err() != nil {
+			return // Left queued for the next run
+		}
+		bc.recordPage(pageNum, pageURL, len(urls), err)
+		if err := bc.frontier.Done(pageURL, urls); err != nil {
+			bc.warnf("Error updating the frontier: %v", err)
+			return
+		}
+		if err != nil {
+			bc.warnf("Error crawling archive page %s: %v", pageURL, err)
+			continue
+		}
+
+		for _, url := range urls {
+			urlSet[url] = true
+		}
+		bc.printf("  Found %d blog URLs (total: %d unique URLs)\n", len(urls), len(urlSet))
+		bc.reportProgress(ProgressEvent{Kind: "archive", Step: followed, URL: pageURL, URLsFound: len(urls), TotalURLs: len(urlSet)})
+
+		if err := sleepContext(ctx, 1*time.Second); err != nil {
+			return
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	if err := bc.frontier.Reset(); err != nil {
+		bc.warnf("Error clearing the frontier: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// maxAuthorName is the longest byline part taken for a name; longer ones
+// are sentences that happened to match a byline selector
+const maxAuthorName = 60
+
+// bylinePrefix is the wording before the names in a byline
+var bylinePrefix = regexp.MustCompile(`(?i)^(written|posted|published|authored)?\s*by\s+`)
+
+// bylineSeparator splits a byline naming several authors
+var bylineSeparator = regexp.MustCompile(`(?i)\s*(,|;|&|\band\b|\|)\s*`)
+
+// authorsIndex is the --authors file: every author named on a crawled post,
+// with their posts across blogs
+type authorsIndex struct {
+	Updated string        `json:"updated"`
+	Authors []indexAuthor `json:"authors"` // By name
+}
+
+type indexAuthor struct {
+	Name  string        `json:"name"` // As first seen
+	Blogs []string      `json:"blogs"`
+	Posts []authorEntry `json:"posts"` // Newest first
+}
+
+type authorEntry struct {
+	URL       string `json:"url"`
+	Blog      string `json:"blog"`
+	Title     string `json:"title,omitempty"`
+	Published string `json:"published,omitempty"`
+}
+
+// parseByline returns the author names in a byline such as "By Jane Doe
+// and JOHN SMITH", in their usual spelling: "Jane Doe", "John Smith"
+func parseByline(byline string) []string {
+	byline = normalizeContent(byline)
+	byline = bylinePrefix.ReplaceAllString(byline, "")
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, part := range bylineSeparator.Split(byline, -1) {
+		name := strings.Trim(part, " .:-–—@")
+		if name == "" || len(name) > maxAuthorName || strings.Contains(name, "://") {
+			continue
+		}
+		if name == strings.ToUpper(name) || name == strings.ToLower(name) {
+			name = titleCase(name)
+		}
+		if key := authorKey(name); key != "" && !seen[key] {
+			seen[key] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// authorKey is the form author names are matched on: lower case, letters
+// and digits only, so "J. Doe" and "j doe" are the same author
+func authorKey(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+func titleCase(name string) string {
+	words := strings.Fields(strings.ToLower(name))
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
+
+// updateAuthorsIndex adds the authors of the result's posts to the index
+// in filename, creating it if missing. Posts of the result already in the
+// index are replaced, so a changed byline moves them.
+func updateAuthorsIndex(filename string, result *CrawlResult) error {
+	var index authorsIndex
+	if data, err := os.ReadFile(filename); err == nil {
+		if err := json.Unmarshal(data, &index); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", filename, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+
+	crawled := make(map[string]bool, len(result.Posts))
+	for _, post := range result.Posts {
+		crawled[post.URL] = true
+	}
+	authors := make(map[string]*indexAuthor)
+	var keys []string
+	add := func(name string, entry authorEntry) {
+		key := authorKey(name)
+		author := authors[key]
+		if author == nil {
+			author = &indexAuthor{Name: name}
+			authors[key] = author
+			keys = append(keys, key)
+		}
+		author.Posts = append(author.Posts, entry)
+	}
+	for _, author := range index.Authors {
+		for _, entry := range author.Posts {
+			if !crawled[entry.URL] {
+				add(author.Name, entry)
+			}
+		}
+	}
+	for _, post := range result.Posts {
+		for _, name := range post.Authors {
+			add(name, authorEntry{URL: post.URL, Blog: result.BaseURL, Title: post.Title, Published: post.Published})
+		}
+	}
+
+	sort.Strings(keys)
+	index = authorsIndex{Updated: time.Now().Format(time.RFC3339), Authors: []indexAuthor{}}
+	for _, key := range keys {
+		author := authors[key]
+		sort.SliceStable(author.Posts, func(i, j int) bool {
+			a, aDated := parsePublished(author.Posts[i].Published)
+			b, bDated := parsePublished(author.Posts[j].Published)
+			if aDated != bDated {
+				return aDated
+			}
+			return a.After(b)
+		})
+		author.Blogs = nil
+		for _, entry := range author.Posts {
+			if !contains(author.Blogs, entry.Blog) {
+				author.Blogs = append(author.Blogs, entry.Blog)
+			}
+		}
+		sort.Strings(author.Blogs)
+		index.Authors = append(index.Authors, *author)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(index)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestParseByline(t *testing.T) {
+	cases := map[string][]string{
+		"Jane Doe":                   {"Jane Doe"},
+		"By JANE DOE and john smith": {"Jane Doe", "John Smith"},
+		"Written by Ana Gómez, Li Wei & Sam O'Neil": {"Ana Gómez", "Li Wei", "Sam O'Neil"},
+		"  by   Jane  Doe | Jane Doe ":              {"Jane Doe"},
+		"https://medium.com/@janedoe":               nil,
+		"":                                          nil,
+	}
+	for byline, want := range cases {
+		if got := parseByline(byline); !reflect.DeepEqual(got, want) {
+			t.Errorf("parseByline(%q) = %q, want %q", byline, got, want)
+		}
+	}
+	if authorKey("J. Doe") != authorKey("j doe") {
+		t.Errorf("authorKey(%q) = %q differs from authorKey(%q) = %q", "J. Doe", authorKey("J. Doe"), "j doe", authorKey("j doe"))
+	}
+}
+
+func TestBylineFromHTML(t *testing.T) {
+	page := `<html><body><article><h1>Scaling our queue</h1>
+		<p class="byline">By <a rel="author" href="/authors/jane">Jane Doe</a></p><p>Text</p></article></body></html>`
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := documentContent(doc, &url.URL{Scheme: "https", Host: "eng.example.com", Path: "/queue"})
+	if got := parseByline(content.byline); !reflect.DeepEqual(got, []string{"Jane Doe"}) {
+		t.Errorf("authors = %q, want [Jane Doe]", got)
+	}
+}
+
+func TestAuthorsIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "authors.json")
+	company := &CrawlResult{
+		BaseURL: "https://eng.example.com/",
+		Posts: []Post{
+			{URL: "https://eng.example.com/queue", Title: "Scaling our queue", Published: "2026-03-01", Authors: []string{"Jane Doe"}},
+			{URL: "https://eng.example.com/cache", Title: "Cache warming", Published: "2026-01-15", Authors: []string{"Jane Doe", "John Smith"}},
+		},
+	}
+	medium := &CrawlResult{
+		BaseURL: "https://medium.com/@janedoe",
+		Posts: []Post{
+			{URL: "https://medium.com/@janedoe/talk-notes-1a2b", Title: "Talk notes", Published: "2026-02-10", Authors: []string{"JANE DOE"}},
+		},
+	}
+	for _, result := range []*CrawlResult{company, medium} {
+		if err := updateAuthorsIndex(path, result); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A re-crawl with a corrected byline moves the post
+	company.Posts[1].Authors = []string{"John Smith"}
+	if err := updateAuthorsIndex(path, company); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index authorsIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Authors) != 2 {
+		t.Fatalf("got %d authors, want Jane Doe and John Smith: %+v", len(index.Authors), index.Authors)
+	}
+	jane := index.Authors[0]
+	if jane.Name != "Jane Doe" || !reflect.DeepEqual(jane.Blogs, []string{"https://eng.example.com/", "https://medium.com/@janedoe"}) {
+		t.Errorf("first author = %s on %v, want Jane Doe on both blogs", jane.Name, jane.Blogs)
+	}
+	var urls []string
+	for _, entry := range jane.Posts {
+		urls = append(urls, entry.URL)
+	}
+	if want := []string{"https://eng.example.com/queue", "https://medium.com/@janedoe/talk-notes-1a2b"}; !reflect.DeepEqual(urls, want) {
+		t.Errorf("Jane Doe's posts = %v, want %v, newest first", urls, want)
+	}
+	if john := index.Authors[1]; john.Name != "John Smith" || len(john.Posts) != 1 {
+		t.Errorf("second author = %+v, want John Smith with one post", john)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/ysmood/gson"
+)
+
+// Browser is what the crawl needs of Chrome: tabs, a liveness check, its
+// version and a way to close it. rodBrowser drives a real one; the tests
+// use a fake, so crawl orchestration can be tested without Chrome.
+type Browser interface {
+	NewPage() (Page, error)
+	Ping(ctx context.Context) error              // Fails if the browser doesn't answer
+	Version(ctx context.Context) (string, error) // Product and version, like "HeadlessChrome/126.0.6478.126"
+	Close(ctx context.Context) error
+}
+
+// Page is a browser tab
+type Page interface {
+	Navigate(ctx context.Context, pageURL string) error
+	WaitLoad(ctx context.Context) error
+	WaitStable(ctx context.Context, d time.Duration) error // Until the DOM stops changing for d
+	Elements(ctx context.Context, selector string) ([]Element, error)
+	Eval(ctx context.Context, js string, args ...any) (gson.JSON, error) // Calls the JavaScript function js with args
+	HTML(ctx context.Context) (string, error)
+	URL(ctx context.Context) (string, error)
+	Close(ctx context.Context) error
+}
+
+// Element is an element found by Page.Elements
+type Element interface {
+	Attribute(name string) (*string, error) // nil if the element has no such attribute
+}
+
+// rodBrowser is a Browser over a Chrome driven by rod
+type rodBrowser struct {
+	browser *rod.Browser
+}
+
+func (b rodBrowser) NewPage() (Page, error) {
+	page, err := b.browser.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create page: %w", err)
+	}
+	return rodPage{page: page}, nil
+}
+
+func (b rodBrowser) Ping(ctx context.Context) error {
+	_, err := b.Version(ctx)
+	return err
+}
+
+func (b rodBrowser) Version(ctx context.Context) (string, error) {
+	version, err := (proto.BrowserGetVersion{}).Call(b.browser.Context(ctx))
+	if err != nil {
+		return "", err
+	}
+	return version.Product, nil
+}
+
+func (b rodBrowser) Close(ctx context.Context) error {
+	return b.browser.Context(ctx).Close()
+}
+
+// rodPage is a Page over a rod tab
+type rodPage struct {
+	page *rod.Page
+}
+
+func (p rodPage) Navigate(ctx context.Context, pageURL string) error {
+	return p.page.Context(ctx).Navigate(pageURL)
+}
+
+func (p rodPage) WaitLoad(ctx context.Context) error {
+	return p.page.Context(ctx).WaitLoad()
+}
+
+func (p rodPage) WaitStable(ctx context.Context, d time.Duration) error {
+	return p.page.Context(ctx).WaitStable(d)
+}
+
+func (p rodPage) Elements(ctx context.Context, selector string) ([]Element, error) {
+	elements, err := p.page.Context(ctx).Elements(selector)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Element, len(elements))
+	for i, elem := range elements {
+		result[i] = elem
+	}
+	return result, nil
+}
+
+func (p rodPage) Eval(ctx context.Context, js string, args ...any) (gson.JSON, error) {
+	res, err := p.page.Context(ctx).Eval(js, args...)
+	if err != nil {
+		return gson.JSON{}, err
+	}
+	return res.Value, nil
+}
+
+func (p rodPage) HTML(ctx context.Context) (string, error) {
+	return p.page.Context(ctx).HTML()
+}
+
+func (p rodPage) URL(ctx context.Context) (string, error) {
+	info, err := p.page.Context(ctx).Info()
+	if err != nil {
+		return "", err
+	}
+	return info.URL, nil
+}
+
+func (p rodPage) Close(ctx context.Context) error {
+	return p.page.Context(ctx).Close()
+}
+
+// rodPageOf returns the rod tab behind page, or nil if it isn't one. The
+// features that talk CDP directly (HAR recording, the budget, rate-limit
+// detection and captures) use it, and are skipped on a fake.
+func rodPageOf(page Page) *rod.Page {
+	if p, ok := page.(rodPage); ok {
+		return p.page
+	}
+	return nil
+}
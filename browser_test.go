@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const fakeBlogURL = "https://blog.example.com/"
+
+// fakeListing renders a listing page linking to the given post slugs and,
+// unless next is "", to its next page
+func fakeListing(next string, slugs ...string) string {
+	var b strings.Builder
+	b.WriteString(`<html><head><title>Engineering blog</title></head><body><header><a href="/">Home</a></header><main>`)
+	for _, slug := range slugs {
+		fmt.Fprintf(&b, `<article><h2><a href="/%s">%s</a></h2></article>`, slug, strings.ReplaceAll(slug, "-", " "))
+	}
+	b.WriteString(`</main>`)
+	if next != "" {
+		fmt.Fprintf(&b, `<a rel="next" href="%s">Older posts</a>`, next)
+	}
+	b.WriteString(`</body></html>`)
+	return b.String()
+}
+
+// fakePagedBlog is three listing pages of two posts each
+func fakePagedBlog() *fakeChrome {
+	return &fakeChrome{site: map[string]string{
+		fakeBlogURL:             fakeListing("/page/2/", "scaling-the-ingest-queue", "moving-search-to-rust"),
+		fakeBlogURL + "page/2/": fakeListing("/page/3/", "our-cache-eviction-policy", "a-year-of-feature-flags"),
+		fakeBlogURL + "page/3/": fakeListing("", "why-we-shard-by-tenant", "testing-with-fake-clocks"),
+	}}
+}
+
+// fakePagedURLs are the post URLs of fakePagedBlog, sorted
+var fakePagedURLs = []string{
+	fakeBlogURL + "a-year-of-feature-flags",
+	fakeBlogURL + "moving-search-to-rust",
+	fakeBlogURL + "our-cache-eviction-policy",
+	fakeBlogURL + "scaling-the-ingest-queue",
+	fakeBlogURL + "testing-with-fake-clocks",
+	fakeBlogURL + "why-we-shard-by-tenant",
+}
+
+// pageURLs returns the URLs of the result's listing pages in crawl order
+func pageURLs(result *CrawlResult) []string {
+	urls := make([]string, len(result.Pages))
+	for i, page := range result.Pages {
+		urls[i] = page.URL
+	}
+	return urls
+}
+
+func TestFakeCrawlFollowsNextLinks(t *testing.T) {
+	chrome := fakePagedBlog()
+	result, err := newFakeCrawler(fakeBlogURL, chrome, CrawlOptions{Sort: "url"}).crawl(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(result.BlogURLs, fakePagedURLs) {
+		t.Errorf("post URLs\n got: %q\nwant: %q", result.BlogURLs, fakePagedURLs)
+	}
+	wantPages := []string{fakeBlogURL, fakeBlogURL + "page/2/", fakeBlogURL + "page/3/"}
+	if got := pageURLs(result); !reflect.DeepEqual(got, wantPages) {
+		t.Errorf("listing pages\n got: %q\nwant: %q", got, wantPages)
+	}
+	if chrome.launches != 1 {
+		t.Errorf("browser launched %d times, want once", chrome.launches)
+	}
+	if run := result.Run; run == nil || run.Browser != "FakeChrome/1.0" || run.Strategy != "auto" || len(run.Config.PostLinkSelectors) == 0 {
+		t.Errorf("run info = %+v, want the fake browser, auto strategy and the selectors", run)
+	}
+}
+
+func TestFakeCrawlRestartsCrashedBrowser(t *testing.T) {
+	chrome := fakePagedBlog()
+	chrome.crashes = map[string]int{fakeBlogURL + "page/2/": 1}
+	result, err := newFakeCrawler(fakeBlogURL, chrome, CrawlOptions{Sort: "url"}).crawl(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(result.BlogURLs, fakePagedURLs) {
+		t.Errorf("post URLs\n got: %q\nwant: %q", result.BlogURLs, fakePagedURLs)
+	}
+	if chrome.launches != 2 {
+		t.Errorf("browser launched %d times, want a restart after the crash", chrome.launches)
+	}
+	loads := 0
+	for _, visited := range chrome.visited() {
+		if visited == fakeBlogURL+"page/2/" {
+			loads++
+		}
+	}
+	if loads != 2 {
+		t.Errorf("page 2 loaded %d times, want it loaded again after the restart", loads)
+	}
+	if len(result.Errors) == 0 || !strings.Contains(strings.Join(result.Errors, "\n"), "restarting") {
+		t.Errorf("errors %q don't mention the restart", result.Errors)
+	}
+}
+
+func TestFakeCrawlFollowsViewAll(t *testing.T) {
+	front := strings.Replace(fakeListing("", "moving-search-to-rust"), "</main>", `</main><a href="/all/">See all posts</a>`, 1)
+	chrome := &fakeChrome{site: map[string]string{
+		fakeBlogURL:          front,
+		fakeBlogURL + "all/": fakeListing("", "moving-search-to-rust", "why-we-shard-by-tenant", "testing-with-fake-clocks"),
+	}}
+	result, err := newFakeCrawler(fakeBlogURL, chrome, CrawlOptions{Strategy: "next-link", Sort: "url"}).crawl(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		fakeBlogURL + "moving-search-to-rust",
+		fakeBlogURL + "testing-with-fake-clocks",
+		fakeBlogURL + "why-we-shard-by-tenant",
+	}
+	if !reflect.DeepEqual(result.BlogURLs, want) {
+		t.Errorf("post URLs\n got: %q\nwant: %q", result.BlogURLs, want)
+	}
+	if got := pageURLs(result); !reflect.DeepEqual(got, []string{fakeBlogURL + "all/"}) {
+		t.Errorf("listing pages %q, want only the full listing", got)
+	}
+}
+
+func TestFakeCrawlExitCodes(t *testing.T) {
+	blocked := &fakeChrome{site: map[string]string{
+		fakeBlogURL: `<html><head><title>Just a moment...</title></head><body><p>Checking your browser before accessing blog.example.com.</p></body></html>`,
+	}}
+	_, err := newFakeCrawler(fakeBlogURL, blocked, CrawlOptions{Strategy: "next-link"}).crawl(context.Background())
+	if code := exitCode(err); code != exitBlocked {
+		t.Errorf("bot wall: exit code %d (%v), want %d", code, err, exitBlocked)
+	}
+
+	crawler := newFakeCrawler(fakeBlogURL, fakePagedBlog(), CrawlOptions{})
+	crawler.newBrowser = func(ctx context.Context) (Browser, error) { return nil, fmt.Errorf("chrome not found") }
+	_, err = crawler.crawl(context.Background())
+	if code := exitCode(err); code != exitBrowser {
+		t.Errorf("launch failure: exit code %d (%v), want %d", code, err, exitBrowser)
+	}
+
+	run := &crawlRun{baseURL: fakeBlogURL, failOnEmpty: true}
+	if code := exitCode(run.checkEmpty(&CrawlResult{})); code != exitEmpty {
+		t.Errorf("empty result with --fail-on-empty: exit code %d, want %d", code, exitEmpty)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if code := exitCode(withExitCode(exitNavigation, fmt.Errorf("crawl cancelled: %w", ctx.Err()))); code != exitInterrupted {
+		t.Errorf("interrupted crawl: exit code %d, want %d", code, exitInterrupted)
+	}
+}
+
+func TestFakeCrawlDiagnostics(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "results_diagnostics")
+	chrome := &fakeChrome{site: map[string]string{
+		fakeBlogURL: `<html><head><title>Engineering blog</title></head><body><div class="feed">` +
+			`<div class="entry"><a href="/tag/go">Go</a></div><div class="entry"><a href="/about">About us</a></div>` +
+			`</div><p>` + strings.Repeat("Posts load here. ", 20) + `</p></body></html>`,
+	}}
+	result, err := newFakeCrawler(fakeBlogURL, chrome, CrawlOptions{Strategy: "next-link", DiagnosticsDir: dir}).crawl(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := result.Diagnostics
+	if result.TotalCount != 0 || d == nil {
+		t.Fatalf("found %d posts, diagnostics %v; want none and a diagnosis", result.TotalCount, d)
+	}
+	if d.Verdict != "layout-changed" || d.Title != "Engineering blog" || d.Status != 200 || d.Links != 2 {
+		t.Errorf("diagnosis %s of %q (HTTP %d, %d links), want layout-changed of the front page", d.Verdict, d.Title, d.Status, d.Links)
+	}
+	if !strings.HasPrefix(d.Excerpt, "<body>") {
+		t.Errorf("DOM excerpt starts %.20q, want the body", d.Excerpt)
+	}
+	for _, name := range []string{"diagnostics.json", "page.html"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("bundle is missing %s: %v", name, err)
+		}
+	}
+}
+
+func TestFakeCrawlStaleAdapter(t *testing.T) {
+	const uberBlog = "https://www.uber.com/blog/engineering/backend/"
+	var slugs []string
+	for i := 1; i <= fullListingPage; i++ {
+		slugs = append(slugs, fmt.Sprintf("blog/engineering/backend/redesigned-post-%d", i))
+	}
+	// The redesigned listing has plain article links, no cards and no page
+	// dropdown
+	chrome := &fakeChrome{site: map[string]string{uberBlog: fakeListing("", slugs...)}}
+	result, err := newFakeCrawler(uberBlog, chrome, CrawlOptions{MaxEmptyPages: 1}).crawl(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.TotalCount != fullListingPage {
+		t.Errorf("found %d posts, want the %d the generic selectors still find", result.TotalCount, fullListingPage)
+	}
+	var expected []string
+	for _, stale := range result.Stats.StaleAdapters {
+		if stale.Adapter != "uber" || stale.Page != uberBlog {
+			t.Errorf("stale adapter %+v, want uber on the first page", stale)
+		}
+		expected = append(expected, stale.Expected)
+	}
+	if want := []string{"post cards", "page dropdown"}; !reflect.DeepEqual(expected, want) {
+		t.Errorf("stale checks %q, want %q", expected, want)
+	}
+	if !strings.Contains(strings.Join(result.Errors, "\n"), "uber adapter is stale") {
+		t.Errorf("errors %q don't warn about the stale adapter", result.Errors)
+	}
+}
+
+func TestExtractBlogURLsSweepsInOneScript(t *testing.T) {
+	ctx := context.Background()
+	chrome := &fakeChrome{site: map[string]string{
+		fakeBlogURL: fakeListing("", "scaling-the-ingest-queue", "moving-search-to-rust"),
+	}}
+	bc := newFakeCrawler(fakeBlogURL, chrome, CrawlOptions{})
+	if err := bc.initializeBrowser(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.navigateToPage(ctx); err != nil {
+		t.Fatal(err)
+	}
+	page := bc.page.(*fakePage)
+	queries := page.queries
+
+	urls, err := bc.extractBlogURLs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 2 {
+		t.Errorf("found %q, want the 2 posts", urls)
+	}
+	if page.sweeps != 1 || page.queries != queries {
+		t.Errorf("%d scripts and %d element queries, want one script", page.sweeps, page.queries-queries)
+	}
+
+	// The posts count for the first selector finding them, while every
+	// selector matching them counts the match
+	stats := make(map[string]SelectorStat)
+	for _, stat := range bc.selectorStats {
+		stats[stat.Selector] = stat
+	}
+	if stat := stats["article a[href]"]; stat.Matched != 2 || stat.URLs != 2 {
+		t.Errorf("article selector: %+v, want 2 matched and 2 posts", stat)
+	}
+	if stat := stats[fallbackSelector]; stat.Matched != 3 || stat.URLs != 0 {
+		t.Errorf("fallback selector: %+v, want 3 matched and no posts", stat)
+	}
+}
+
+func TestFakeCrawlRecordsLinkText(t *testing.T) {
+	chrome := fakePagedBlog()
+	result, err := newFakeCrawler(fakeBlogURL, chrome, CrawlOptions{Sort: "url"}).crawl(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range result.Discovery {
+		want := strings.ReplaceAll(strings.TrimPrefix(d.URL, fakeBlogURL), "-", " ")
+		if d.Text != want || d.Context != want {
+			t.Errorf("%s discovered with text %q and context %q, want %q", d.URL, d.Text, d.Context, want)
+		}
+	}
+	if len(result.Discovery) != len(fakePagedURLs) {
+		t.Errorf("%d discovery records, want %d", len(result.Discovery), len(fakePagedURLs))
+	}
+}
+
+func TestFakeCrawlFlagsMemberOnlyPosts(t *testing.T) {
+	listing := strings.Replace(fakeListing("", "free-for-everyone", "behind-the-paywall"),
+		`behind the paywall</a></h2>`, `behind the paywall</a></h2><span aria-label="Member-only story"></span>`, 1)
+	paywalled := fakeBlogURL + "behind-the-paywall"
+
+	result, err := newFakeCrawler(fakeBlogURL, &fakeChrome{site: map[string]string{fakeBlogURL: listing}}, CrawlOptions{Sort: "url"}).crawl(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{paywalled}; !reflect.DeepEqual(result.MemberOnly, want) {
+		t.Errorf("member-only posts = %q, want %q", result.MemberOnly, want)
+	}
+	if result.TotalCount != 2 {
+		t.Errorf("%d posts, want both", result.TotalCount)
+	}
+
+	result, err = newFakeCrawler(fakeBlogURL, &fakeChrome{site: map[string]string{fakeBlogURL: listing}}, CrawlOptions{Sort: "url", ExcludeMemberOnly: true}).crawl(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{fakeBlogURL + "free-for-everyone"}; !reflect.DeepEqual(result.BlogURLs, want) {
+		t.Errorf("post URLs with member-only posts left out = %q, want %q", result.BlogURLs, want)
+	}
+	if n := result.Stats.RejectedByRule["member-only"]; n != 1 {
+		t.Errorf("%d posts rejected as member-only, want 1", n)
+	}
+}
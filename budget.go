@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// errBudgetExhausted is the cause of a crawl context ended by its budget
+var errBudgetExhausted = errors.New("crawl budget exhausted")
+
+// crawlBudget caps what the crawl of one blog may spend across all of its
+// phases, so one endless feed can't use up a batch crawl. Zero fields
+// don't limit anything.
+type crawlBudget struct {
+	MaxRequests int          `json:"max_requests,omitempty"` // Page loads and HTTP fetches, retries included
+	MaxMB       int          `json:"max_mb,omitempty"`       // Megabytes transferred, page resources included
+	MaxDuration jsonDuration `json:"max_duration,omitempty"` // Like "30m"
+}
+
+// jsonDuration is a time.Duration written as a string like "1h30m" in JSON
+type jsonDuration time.Duration
+
+func (d *jsonDuration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30m\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = jsonDuration(parsed)
+	return nil
+}
+
+func (d jsonDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// or fills the fields b leaves unset from fallback
+func (b crawlBudget) or(fallback crawlBudget) crawlBudget {
+	if b.MaxRequests == 0 {
+		b.MaxRequests = fallback.MaxRequests
+	}
+	if b.MaxMB == 0 {
+		b.MaxMB = fallback.MaxMB
+	}
+	if b.MaxDuration == 0 {
+		b.MaxDuration = fallback.MaxDuration
+	}
+	return b
+}
+
+// budgetTracker counts what a crawl has spent against its budget. Post
+// workers share their crawler's tracker.
+type budgetTracker struct {
+	limits   crawlBudget
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	requests atomic.Int64
+	bytes    atomic.Int64
+}
+
+// startBudget applies the blog's budget (its site profile's, field by
+// field over opts.Budget) to ctx. The returned context ends once the
+// budget runs out.
+func (bc *BlogCrawler) startBudget(ctx context.Context) (context.Context, context.CancelFunc) {
+	limits := bc.site.Budget.or(bc.opts.Budget)
+	if limits == (crawlBudget{}) {
+		return ctx, func() {}
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := func() { cancel(nil) }
+	if limits.MaxDuration > 0 {
+		d := time.Duration(limits.MaxDuration)
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeoutCause(ctx, d, fmt.Errorf("%w: ran for %v", errBudgetExhausted, d))
+		stop = func() { cancelTimeout(); cancel(nil) }
+	}
+	bc.budget = &budgetTracker{limits: limits, ctx: ctx, cancel: cancel}
+	return ctx, stop
+}
+
+// request counts a page load or HTTP fetch about to be made and refuses
+// it once the budget's requests are used up
+func (b *budgetTracker) request() error {
+	if b == nil || b.limits.MaxRequests == 0 {
+		return nil
+	}
+	if b.requests.Add(1) > int64(b.limits.MaxRequests) {
+		err := fmt.Errorf("%w: %d requests", errBudgetExhausted, b.limits.MaxRequests)
+		b.cancel(err)
+		return err
+	}
+	return nil
+}
+
+// transferred counts n bytes received
+func (b *budgetTracker) transferred(n int64) {
+	if b == nil {
+		return
+	}
+	total := b.bytes.Add(n)
+	if b.limits.MaxMB > 0 && total >= int64(b.limits.MaxMB)<<20 {
+		b.cancel(fmt.Errorf("%w: %.1f MB transferred", errBudgetExhausted, float64(total)/(1<<20)))
+	}
+}
+
+// watch counts the bytes page receives, its resources and the requests
+// made while scrolling included
+func (b *budgetTracker) watch(page *rod.Page) {
+	if b == nil || b.limits.MaxMB == 0 {
+		return
+	}
+	wait := page.Context(b.ctx).EachEvent(func(e *proto.NetworkLoadingFinished) {
+		b.transferred(int64(e.EncodedDataLength))
+	})
+	go wait()
+}
+
+// exhausted returns why the budget ended the crawl, or "" if it didn't
+func (b *budgetTracker) exhausted() string {
+	if b == nil {
+		return ""
+	}
+	if cause := context.Cause(b.ctx); errors.Is(cause, errBudgetExhausted) {
+		return cause.Error()
+	}
+	return ""
+}
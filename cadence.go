@@ -0,0 +1,252 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// topCategories is how many of a blog's categories the report lists
+const topCategories = 5
+
+// blogCadence summarizes how actively a blog posts, from the runs and posts
+// stored in a SQLite database
+type blogCadence struct {
+	BaseURL           string          `json:"base_url"`
+	Runs              int             `json:"runs"`
+	FirstRun          string          `json:"first_run"`
+	LastRun           string          `json:"last_run"`
+	Posts             int             `json:"posts"`
+	DatedPosts        int             `json:"dated_posts"` // Posts with a publish date, or first seen after the first run
+	Monthly           []monthCount    `json:"monthly"`     // Posts per month over the report's months, oldest first
+	PostsPerMonth     float64         `json:"posts_per_month"`
+	LongestGap        *postingGap     `json:"longest_gap,omitempty"`
+	DaysSinceLastPost *int            `json:"days_since_last_post,omitempty"`
+	Categories        []categoryCount `json:"categories,omitempty"` // Most active first
+}
+
+type monthCount struct {
+	Month string `json:"month"` // Like "2026-03"
+	Posts int    `json:"posts"`
+}
+
+// postingGap is the longest stretch without a post
+type postingGap struct {
+	Days int    `json:"days"`
+	From string `json:"from"` // Date of the post before the gap
+	To   string `json:"to"`   // Date of the post after it
+}
+
+type categoryCount struct {
+	Category string `json:"category"`
+	Posts    int    `json:"posts"`
+}
+
+func runReport(args []string) {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	dbPath := fs.String("db", "", "SQLite database written with --output sqlite:FILE")
+	months := fs.Int("months", 12, "months of posting history to summarize")
+	blog := fs.String("blog", "", "only report on the blog with this base URL")
+	jsonOutput := fs.Bool("json", false, "print the report as JSON")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . report --db FILE [--months N] [--blog URL] [--json]")
+		fmt.Println()
+		fmt.Println("Summarizes the posting cadence of every blog stored in the database:")
+		fmt.Println("posts per month, the longest gap between posts and the most active categories.")
+		fmt.Println()
+		fmt.Println("Flags:")
+		fs.PrintDefaults()
+	}
+	parseArgs(fs, args)
+	if *dbPath == "" || *months < 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if _, err := os.Stat(*dbPath); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	db, err := openSQLite(*dbPath)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	report, err := cadenceReport(db, *blog, *months, time.Now())
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+		return
+	}
+	if len(report) == 0 {
+		fmt.Println("No runs in the database")
+		return
+	}
+	for _, cadence := range report {
+		printCadence(cadence)
+	}
+}
+
+// cadenceReport summarizes every blog with runs in db, or only blog when
+// set, over the months before now
+func cadenceReport(db *sql.DB, blog string, months int, now time.Time) ([]blogCadence, error) {
+	rows, err := db.Query(`
+		SELECT base_url, COUNT(*), MIN(crawled_at), MAX(crawled_at) FROM runs
+		WHERE ? = '' OR base_url = ?
+		GROUP BY base_url ORDER BY base_url`, blog, blog)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read runs: %w", err)
+	}
+	var report []blogCadence
+	for rows.Next() {
+		var cadence blogCadence
+		if err := rows.Scan(&cadence.BaseURL, &cadence.Runs, &cadence.FirstRun, &cadence.LastRun); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read runs: %w", err)
+		}
+		report = append(report, cadence)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read runs: %w", err)
+	}
+
+	for i := range report {
+		if err := report[i].summarize(db, months, now); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// summarize fills in the cadence from the blog's posts. A post is dated by
+// its publish date or, without one, by when a run first saw it. Posts the
+// first run found have no such date: that run saw the whole back
+// catalogue at once.
+func (c *blogCadence) summarize(db *sql.DB, months int, now time.Time) error {
+	rows, err := db.Query(`SELECT published, first_seen, category FROM posts WHERE base_url = ?`, c.BaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to read posts of %s: %w", c.BaseURL, err)
+	}
+	defer rows.Close()
+
+	var dates []time.Time
+	categories := make(map[string]int)
+	for rows.Next() {
+		var published, category sql.NullString
+		var firstSeen string
+		if err := rows.Scan(&published, &firstSeen, &category); err != nil {
+			return fmt.Errorf("failed to read posts of %s: %w", c.BaseURL, err)
+		}
+		c.Posts++
+		if category.String != "" {
+			categories[category.String]++
+		}
+
+		if date, ok := parsePublished(published.String); ok {
+			dates = append(dates, date)
+		} else if firstSeen > c.FirstRun {
+			if date, ok := parsePublished(firstSeen); ok {
+				dates = append(dates, date)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read posts of %s: %w", c.BaseURL, err)
+	}
+	c.DatedPosts = len(dates)
+
+	// Months of the report, counted in the posts' own calendar
+	start := time.Date(now.Year(), now.Month()-time.Month(months-1), 1, 0, 0, 0, 0, time.UTC)
+	monthly := make(map[string]int)
+	for _, date := range dates {
+		monthly[date.Format("2006-01")]++
+	}
+	total := 0
+	for month := start; !month.After(now); month = month.AddDate(0, 1, 0) {
+		key := month.Format("2006-01")
+		c.Monthly = append(c.Monthly, monthCount{Month: key, Posts: monthly[key]})
+		total += monthly[key]
+	}
+	c.PostsPerMonth = float64(total) / float64(len(c.Monthly))
+
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	for i := 1; i < len(dates); i++ {
+		days := int(dates[i].Sub(dates[i-1]).Hours() / 24)
+		if c.LongestGap == nil || days > c.LongestGap.Days {
+			c.LongestGap = &postingGap{Days: days, From: dates[i-1].Format("2006-01-02"), To: dates[i].Format("2006-01-02")}
+		}
+	}
+	if len(dates) > 0 {
+		days := max(0, int(now.Sub(dates[len(dates)-1]).Hours()/24))
+		c.DaysSinceLastPost = &days
+	}
+
+	for category, posts := range categories {
+		c.Categories = append(c.Categories, categoryCount{Category: category, Posts: posts})
+	}
+	sort.Slice(c.Categories, func(i, j int) bool {
+		if c.Categories[i].Posts != c.Categories[j].Posts {
+			return c.Categories[i].Posts > c.Categories[j].Posts
+		}
+		return c.Categories[i].Category < c.Categories[j].Category
+	})
+	if len(c.Categories) > topCategories {
+		c.Categories = c.Categories[:topCategories]
+	}
+	return nil
+}
+
+func printCadence(c blogCadence) {
+	fmt.Printf("%s\n", c.BaseURL)
+	fmt.Printf("  Runs:        %d (%s to %s)\n", c.Runs, c.FirstRun[:min(10, len(c.FirstRun))], c.LastRun[:min(10, len(c.LastRun))])
+	fmt.Printf("  Posts:       %d (%d dated)\n", c.Posts, c.DatedPosts)
+	if len(c.Monthly) > 0 {
+		fmt.Printf("  Posts/month: %.1f from %s to %s  %s\n", c.PostsPerMonth, c.Monthly[0].Month, c.Monthly[len(c.Monthly)-1].Month, sparkline(c.Monthly))
+	}
+	if c.LongestGap != nil {
+		fmt.Printf("  Longest gap: %d days (%s to %s)\n", c.LongestGap.Days, c.LongestGap.From, c.LongestGap.To)
+	}
+	if c.DaysSinceLastPost != nil {
+		fmt.Printf("  Last post:   %d days ago\n", *c.DaysSinceLastPost)
+	}
+	if len(c.Categories) > 0 {
+		var categories []string
+		for _, category := range c.Categories {
+			categories = append(categories, fmt.Sprintf("%s (%d)", category.Category, category.Posts))
+		}
+		fmt.Printf("  Categories:  %s\n", strings.Join(categories, ", "))
+	}
+	fmt.Println()
+}
+
+// sparkline draws the monthly counts as a row of block characters
+func sparkline(monthly []monthCount) string {
+	blocks := []rune("▁▂▃▄▅▆▇█")
+	most := 0
+	for _, month := range monthly {
+		most = max(most, month.Posts)
+	}
+	var line strings.Builder
+	for _, month := range monthly {
+		if month.Posts == 0 {
+			line.WriteRune(' ')
+			continue
+		}
+		line.WriteRune(blocks[(month.Posts*len(blocks)-1)/most])
+	}
+	return line.String()
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCadenceReport(t *testing.T) {
+	sink, err := newSQLiteSink(filepath.Join(t.TempDir(), "posts.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	blog := "https://example.com/blog/"
+	runs := []*CrawlResult{
+		{
+			BaseURL:   blog,
+			CrawledAt: "2026-01-10T08:00:00Z",
+			BlogURLs:  []string{blog + "a", blog + "b", blog + "c", blog + "old"},
+			Posts: []Post{
+				{URL: blog + "a", Published: "2025-11-03", Category: "engineering"},
+				{URL: blog + "b", Published: "2025-11-20", Category: "engineering"},
+				{URL: blog + "c", Published: "2026-01-05", Category: "culture"},
+			},
+		},
+		{
+			// "d" has no publish date, so the run that first saw it dates it
+			BaseURL:   blog,
+			CrawledAt: "2026-03-02T08:00:00Z",
+			BlogURLs:  []string{blog + "a", blog + "b", blog + "c", blog + "old", blog + "d"},
+		},
+	}
+	for _, result := range runs {
+		if err := sink.Write(context.Background(), result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Write(context.Background(), &CrawlResult{BaseURL: "https://other.example/", BlogURLs: []string{"https://other.example/x"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC)
+	report, err := cadenceReport(sink.db, blog, 6, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 1 {
+		t.Fatalf("got %d blogs, want only %s", len(report), blog)
+	}
+	c := report[0]
+
+	if c.Runs != 2 || c.Posts != 5 || c.DatedPosts != 4 {
+		t.Errorf("runs, posts, dated = %d, %d, %d, want 2, 5, 4", c.Runs, c.Posts, c.DatedPosts)
+	}
+	want := []monthCount{{"2025-10", 0}, {"2025-11", 2}, {"2025-12", 0}, {"2026-01", 1}, {"2026-02", 0}, {"2026-03", 1}}
+	if len(c.Monthly) != len(want) {
+		t.Fatalf("monthly = %v, want %v", c.Monthly, want)
+	}
+	for i := range want {
+		if c.Monthly[i] != want[i] {
+			t.Errorf("monthly = %v, want %v", c.Monthly, want)
+			break
+		}
+	}
+	if c.PostsPerMonth != 4.0/6 {
+		t.Errorf("posts per month = %v, want %v", c.PostsPerMonth, 4.0/6)
+	}
+	if c.LongestGap == nil || c.LongestGap.Days != 56 || c.LongestGap.From != "2026-01-05" || c.LongestGap.To != "2026-03-02" {
+		t.Errorf("longest gap = %+v, want 56 days from 2026-01-05 to 2026-03-02", c.LongestGap)
+	}
+	if c.DaysSinceLastPost == nil || *c.DaysSinceLastPost != 17 {
+		t.Errorf("days since last post = %v, want 17", c.DaysSinceLastPost)
+	}
+	if len(c.Categories) != 2 || c.Categories[0] != (categoryCount{"engineering", 2}) {
+		t.Errorf("categories = %v, want engineering (2) first", c.Categories)
+	}
+
+	all, err := cadenceReport(sink.db, "", 6, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Errorf("got %d blogs without --blog, want 2", len(all))
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+
+	"manual-blog-crawler/urlfilter"
+)
+
+// defaultPorts are dropped from URL keys, so example.com:443 and
+// example.com are the same site
+var defaultPorts = map[string]string{"http": "80", "https": "443"}
+
+// trackingParams are query parameters added by campaigns and referrers,
+// which never tell two posts apart
+var trackingParams = urlfilter.QueryParamRule{Drop: []string{"utm_*", "fbclid", "gclid", "msclkid", "mc_cid", "mc_eid", "_hsenc", "_hsmi"}}
+
+// indexPages are directory index files that name the same page as the
+// directory itself
+var indexPages = []string{"index.html", "index.htm"}
+
+// urlKey reduces a URL to a key shared by its harmless variants: scheme,
+// host case, a www. prefix, default port, trailing slash, an index.html
+// suffix, the fragment, tracking parameters and the order of the other
+// query parameters don't change the key
+func urlKey(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+
+	scheme := strings.ToLower(parsedURL.Scheme)
+	host := strings.TrimPrefix(strings.ToLower(parsedURL.Hostname()), "www.")
+	if port := parsedURL.Port(); port != "" && port != defaultPorts[scheme] {
+		host += ":" + port
+	}
+
+	path := parsedURL.EscapedPath()
+	for _, index := range indexPages {
+		if strings.HasSuffix(path, "/"+index) {
+			path = strings.TrimSuffix(path, index)
+			break
+		}
+	}
+	path = strings.TrimRight(path, "/")
+
+	key := "//" + host + path
+	trackingParams.Apply(parsedURL)
+	if parsedURL.RawQuery != "" {
+		key += "?" + parsedURL.Query().Encode()
+	}
+	return key
+}
+
+// canonicalURL returns the first URL of this crawl that has the same key as
+// rawURL, registering rawURL if it's the first. Variants of a post found on
+// different pages thus collapse into whichever form was seen first.
+func (bc *BlogCrawler) canonicalURL(rawURL string) string {
+	if bc.seenURLs == nil {
+		bc.seenURLs = make(map[string]string)
+	}
+
+	key := urlKey(rawURL)
+	if first, ok := bc.seenURLs[key]; ok {
+		return first
+	}
+	bc.seenURLs[key] = rawURL
+	return rawURL
+}
@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestURLKey(t *testing.T) {
+	const post = "https://blog.example.com/posts/scaling-queues"
+	tests := []struct {
+		variant string
+		same    bool
+	}{
+		{"https://blog.example.com/posts/scaling-queues/", true},
+		{"https://BLOG.Example.com/posts/scaling-queues", true},
+		{"https://www.blog.example.com/posts/scaling-queues", true},
+		{"http://blog.example.com/posts/scaling-queues", true},
+		{"https://blog.example.com:443/posts/scaling-queues", true},
+		{"https://blog.example.com/posts/scaling-queues/index.html", true},
+		{"https://blog.example.com/posts/scaling-queues#comments", true},
+		{"https://blog.example.com/posts/scaling-queues?utm_source=twitter&utm_medium=social", true},
+		{"https://blog.example.com/posts/scaling-queues?fbclid=abc123", true},
+
+		{"https://blog.example.com:8443/posts/scaling-queues", false},
+		{"https://blog.example.com/posts/Scaling-Queues", false}, // Paths are case-sensitive
+		{"https://blog.example.com/posts/scaling-queues-2", false},
+		{"https://blog.example.com/posts/scaling-queues?page=2", false},
+		{"https://other.example.com/posts/scaling-queues", false},
+	}
+	for _, tt := range tests {
+		if same := urlKey(tt.variant) == urlKey(post); same != tt.same {
+			t.Errorf("urlKey(%q) = %q, urlKey(%q) = %q; same %v, want %v", tt.variant, urlKey(tt.variant), post, urlKey(post), same, tt.same)
+		}
+	}
+
+	// Query parameters that identify the post count in any order
+	if a, b := urlKey("https://blog.example.com/?p=42&lang=en&utm_campaign=x"), urlKey("https://blog.example.com/?lang=en&p=42"); a != b {
+		t.Errorf("reordered query: %q != %q", a, b)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// captureFileName derives a filesystem-safe base name for a post URL.
+// A short hash of the full URL keeps names unique when slugs collide.
+func captureFileName(postURL string) string {
+	slug := postURL
+	if parsedURL, err := url.Parse(postURL); err == nil {
+		slug = parsedURL.Path
+	}
+	slug = strings.Trim(unsafeFileChars.ReplaceAllString(strings.ToLower(slug), "-"), "-")
+	if len(slug) > 80 {
+		slug = slug[len(slug)-80:]
+	}
+
+	sum := sha1.Sum([]byte(postURL))
+	hash := hex.EncodeToString(sum[:])[:8]
+	if slug == "" {
+		return hash
+	}
+	return slug + "-" + hash
+}
+
+// capturePost writes the requested captures of the currently loaded post to
+// the capture directory. Paths recorded on the post are relative to the JSON output.
+func (bc *BlogCrawler) capturePost(ctx context.Context, post *Post) error {
+	ctx, cancel := context.WithTimeout(ctx, bc.timeout)
+	defer cancel()
+
+	tab := rodPageOf(bc.page)
+	if tab == nil {
+		return fmt.Errorf("captures need a Chrome tab")
+	}
+	name := captureFileName(post.URL)
+	relDir := filepath.Base(bc.opts.CaptureDir)
+
+	if bc.opts.Screenshot {
+		data, err := tab.Context(ctx).Screenshot(true, &proto.PageCaptureScreenshot{
+			Format: proto.PageCaptureScreenshotFormatPng,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to capture screenshot: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(bc.opts.CaptureDir, name+".png"), data, 0644); err != nil {
+			return fmt.Errorf("failed to write screenshot: %w", err)
+		}
+		post.Screenshot = filepath.Join(relDir, name+".png")
+	}
+
+	if bc.opts.PDF {
+		stream, err := tab.Context(ctx).PDF(&proto.PagePrintToPDF{PrintBackground: true})
+		if err != nil {
+			return fmt.Errorf("failed to print PDF: %w", err)
+		}
+		data, err := io.ReadAll(stream)
+		if err != nil {
+			return fmt.Errorf("failed to read PDF stream: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(bc.opts.CaptureDir, name+".pdf"), data, 0644); err != nil {
+			return fmt.Errorf("failed to write PDF: %w", err)
+		}
+		post.PDF = filepath.Join(relDir, name+".pdf")
+	}
+
+	return nil
+}
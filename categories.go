@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"manual-blog-crawler/urlfilter"
+)
+
+// navSelector finds the links in a page's navigation
+const navSelector = `header a[href], nav a[href], [role="navigation"] a[href]`
+
+// postGridMinPosts is how many post links outside the navigation a page
+// needs to count as a post grid. Posts link to a few related posts,
+// category pages to many more.
+const postGridMinPosts = 6
+
+// categoryPatterns compiles the blog's category patterns: --category-pages,
+// or the site profile's. Invalid ones were reported when the profile and
+// flags were read.
+func categoryPatterns(site siteProfile, opts CrawlOptions) []urlfilter.CategoryPattern {
+	sources := site.CategoryPages
+	if len(opts.CategoryPages) > 0 {
+		sources = opts.CategoryPages
+	}
+
+	var patterns []urlfilter.CategoryPattern
+	for _, source := range sources {
+		if pattern, err := urlfilter.CompileCategory(source); err == nil {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// learnNavigation reads the paths linked from the navigation of the first
+// listing page, for the nav: category patterns. The base URL counts as
+// linked unless the page has no navigation at all.
+func (bc *BlogCrawler) learnNavigation(find hrefFinder) {
+	if bc.navPaths != nil {
+		return
+	}
+	bc.navPaths = make(map[string]bool)
+
+	hrefs, err := find(navSelector)
+	if err != nil || len(hrefs) == 0 {
+		return
+	}
+	for _, href := range append(hrefs, bc.baseURL) {
+		linkURL, err := bc.normalizeURL(href, false)
+		if err != nil {
+			continue
+		}
+		if parsedURL, err := url.Parse(linkURL); err == nil {
+			bc.navPaths[urlfilter.NavKey(parsedURL.Path)] = true
+		}
+	}
+}
+
+// usesNavigation reports whether a category pattern needs the navigation
+func (bc *BlogCrawler) usesNavigation() bool {
+	for _, pattern := range bc.categories {
+		if pattern.Nav {
+			return true
+		}
+	}
+	return false
+}
+
+// categoryCandidate returns the pattern that makes postURL a possible
+// category page, to be confirmed by a post grid on the page: a grid:
+// pattern, or a nav: pattern when the navigation couldn't be read
+func (bc *BlogCrawler) categoryCandidate(postURL string) (urlfilter.CategoryPattern, bool) {
+	parsedURL, err := url.Parse(postURL)
+	if err != nil {
+		return urlfilter.CategoryPattern{}, false
+	}
+	pathLower := strings.ToLower(parsedURL.Path)
+	for _, pattern := range bc.categories {
+		if !pattern.Grid && !(pattern.Nav && len(bc.navPaths) == 0) {
+			continue
+		}
+		if pattern.Matches(pathLower) {
+			return pattern, true
+		}
+	}
+	return urlfilter.CategoryPattern{}, false
+}
+
+// checkCategoryCandidates loads the post URLs that may be category pages
+// and removes those listing posts of their own. A page that can't be
+// fetched stays.
+func (bc *BlogCrawler) checkCategoryCandidates(ctx context.Context, urlSet map[string]bool) {
+	candidates := make(map[string]urlfilter.CategoryPattern)
+	for postURL := range urlSet {
+		if pattern, ok := bc.categoryCandidate(postURL); ok {
+			candidates[postURL] = pattern
+		}
+	}
+	if len(candidates) == 0 {
+		return
+	}
+	urls := make([]string, 0, len(candidates))
+	for postURL := range candidates {
+		urls = append(urls, postURL)
+	}
+	sort.Strings(urls)
+
+	bc.printf("Checking %d possible category pages for a post grid...\n", len(urls))
+	removed := 0
+	for _, postURL := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+		features, err := bc.pageFeaturesOf(ctx, postURL)
+		if err != nil {
+			bc.warnf("Error checking %s for a post grid: %v", postURL, err)
+			continue
+		}
+		if features.PostLinks < postGridMinPosts {
+			continue
+		}
+		bc.printf("  %s lists posts, dropping it as a category page\n", postURL)
+		delete(urlSet, postURL)
+		bc.recordRejection(postURL, fmt.Sprintf("category pattern %q with a post grid", candidates[postURL].Source))
+		removed++
+	}
+	bc.printf("Dropped %d category pages (total: %d unique URLs)\n", removed, len(urlSet))
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLearnNavigationFallsBackWithoutNavigation(t *testing.T) {
+	const category = "https://www.uber.com/blog/engineering/"
+	tests := []struct {
+		name      string
+		nav       []string
+		candidate bool // Whether the category page gets the post grid check
+	}{
+		// Nothing learned, so the nav: patterns fall back to the grid check
+		{"empty navigation", nil, true},
+		{"navigation", []string{"/blog/", "/blog/engineering/", "/careers/"}, false},
+	}
+	for _, tt := range tests {
+		bc := NewBlogCrawler("https://www.uber.com/blog/", 30*time.Second, CrawlOptions{})
+		bc.learnNavigation(func(string) ([]string, error) { return tt.nav, nil })
+
+		if tt.nav == nil && len(bc.navPaths) != 0 {
+			t.Errorf("%s: learned %v, want nothing, not even the base URL", tt.name, bc.navPaths)
+		}
+		if _, ok := bc.categoryCandidate(category); ok != tt.candidate {
+			t.Errorf("%s: %s is a candidate = %v, want %v", tt.name, category, ok, tt.candidate)
+		}
+	}
+}
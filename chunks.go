@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// embeddingBatchSize is how many chunks are sent per embedding request
+const embeddingBatchSize = 64
+
+// Chunk is one piece of a post's content, shaped for embedding pipelines
+type Chunk struct {
+	ID        string    `json:"id"` // Stable across runs: hash of the URL plus position
+	URL       string    `json:"url"`
+	Title     string    `json:"title"`
+	ChunkText string    `json:"chunk_text"`
+	Position  int       `json:"position"` // Index of the chunk within the post, from 0
+	Embedding []float64 `json:"embedding,omitempty"`
+}
+
+// chunkWords splits content into chunks of size words, each repeating the
+// last overlap words of the one before so no sentence loses its context
+func chunkWords(content string, size, overlap int) []string {
+	words := strings.Fields(content)
+	if len(words) == 0 || size <= 0 {
+		return nil
+	}
+	step := size - overlap
+	if step <= 0 {
+		step = size
+	}
+
+	var chunks []string
+	for start := 0; start < len(words); start += step {
+		end := start + size
+		if end > len(words) {
+			end = len(words)
+		}
+		chunks = append(chunks, strings.Join(words[start:end], " "))
+		if end == len(words) {
+			break
+		}
+	}
+	return chunks
+}
+
+// postChunks chunks every post with content
+func postChunks(result *CrawlResult, size, overlap int) []Chunk {
+	var chunks []Chunk
+	for _, post := range result.Posts {
+		title := post.Title
+		if title == "" {
+			title = postTitleFromURL(post.URL)
+		}
+		for position, text := range chunkWords(post.Content, size, overlap) {
+			chunks = append(chunks, Chunk{
+				ID:        fmt.Sprintf("%s-%d", contentHash(post.URL)[:16], position),
+				URL:       post.URL,
+				Title:     title,
+				ChunkText: text,
+				Position:  position,
+			})
+		}
+	}
+	return chunks
+}
+
+// embeddingClient calls an OpenAI-compatible embeddings endpoint
+type embeddingClient struct {
+	http   *http.Client
+	url    string
+	model  string
+	apiKey string
+}
+
+func newEmbeddingClient(url, model, apiKey string) *embeddingClient {
+	return &embeddingClient{
+		http:   &http.Client{Timeout: 2 * time.Minute},
+		url:    url,
+		model:  model,
+		apiKey: apiKey,
+	}
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model,omitempty"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// embed returns one vector per input text, in order
+func (ec *embeddingClient) embed(ctx context.Context, texts []string) ([][]float64, error) {
+	body, err := json.Marshal(embeddingRequest{Model: ec.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode embedding request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ec.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if ec.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+ec.apiKey)
+	}
+
+	resp, err := ec.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("embedding API returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+
+	var decoded embeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return nil, fmt.Errorf("failed to decode embedding response: %w", err)
+	}
+
+	vectors := make([][]float64, len(texts))
+	for _, item := range decoded.Data {
+		if item.Index >= 0 && item.Index < len(vectors) {
+			vectors[item.Index] = item.Embedding
+		}
+	}
+	for i, vector := range vectors {
+		if vector == nil {
+			return nil, fmt.Errorf("embedding response is missing input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+// chunkSink writes post chunks as JSONL, embedding them first when an
+// embedding API is configured
+type chunkSink struct {
+	path     string
+	compress string
+	size     int
+	overlap  int
+	embedder *embeddingClient
+	out      io.Writer
+}
+
+func (cs *chunkSink) Write(ctx context.Context, result *CrawlResult) error {
+	chunks := postChunks(result, cs.size, cs.overlap)
+	if len(chunks) == 0 {
+		fmt.Fprintf(cs.out, "Warning: No post content to chunk for %s (use --fetch-content)\n", cs.path)
+	}
+
+	if cs.embedder != nil {
+		for start := 0; start < len(chunks); start += embeddingBatchSize {
+			end := start + embeddingBatchSize
+			if end > len(chunks) {
+				end = len(chunks)
+			}
+			texts := make([]string, 0, end-start)
+			for _, chunk := range chunks[start:end] {
+				texts = append(texts, chunk.ChunkText)
+			}
+
+			vectors, err := cs.embedder.embed(ctx, texts)
+			if err != nil {
+				return err
+			}
+			for i, vector := range vectors {
+				chunks[start+i].Embedding = vector
+			}
+		}
+	}
+
+	path, err := writeOutputFile(cs.path, cs.compress, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		for _, chunk := range chunks {
+			if err := encoder.Encode(chunk); err != nil {
+				return fmt.Errorf("failed to encode chunk %s: %w", chunk.ID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cs.out, "Wrote %d chunks to: %s\n", len(chunks), path)
+	return nil
+}
+
+func (cs *chunkSink) Close() error {
+	return nil
+}
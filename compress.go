@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressionExtension maps a --compress algorithm to its file suffix
+func compressionExtension(algorithm string) string {
+	switch algorithm {
+	case "gzip":
+		return ".gz"
+	case "zstd":
+		return ".zst"
+	}
+	return ""
+}
+
+// compressedPath appends the algorithm's suffix unless path already has it
+func compressedPath(path, algorithm string) string {
+	ext := compressionExtension(algorithm)
+	if ext == "" || strings.HasSuffix(path, ext) {
+		return path
+	}
+	return path + ext
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// compressWriter wraps w so that everything written is compressed with
+// algorithm ("", "none", "gzip" or "zstd"). Close must be called to flush.
+func compressWriter(w io.Writer, algorithm string) (io.WriteCloser, error) {
+	switch algorithm {
+	case "", "none":
+		return nopWriteCloser{w}, nil
+	case "gzip":
+		return gzip.NewWriter(w), nil
+	case "zstd":
+		return zstd.NewWriter(w)
+	}
+	return nil, fmt.Errorf("unknown compression %q (use none, gzip or zstd)", algorithm)
+}
+
+// decompressReader detects gzip and zstd streams by their magic bytes and
+// transparently decompresses them; anything else is passed through
+func decompressReader(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	magic, _ := buffered.Peek(4)
+
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return gzip.NewReader(buffered)
+	case bytes.Equal(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		decoder, err := zstd.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	}
+	return buffered, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestCompressRoundTrip(t *testing.T) {
+	result := &CrawlResult{
+		BaseURL:  "https://blog.example.com/",
+		BlogURLs: []string{"https://blog.example.com/first-post", "https://blog.example.com/second-post"},
+	}
+	var ndjson bytes.Buffer
+	if err := writeNDJSON(&ndjson, result); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, algorithm := range []string{"gzip", "zstd", "none"} {
+		var compressed bytes.Buffer
+		w, err := compressWriter(&compressed, algorithm)
+		if err != nil {
+			t.Fatalf("%s: %v", algorithm, err)
+		}
+		if err := writeNDJSON(w, result); err != nil {
+			t.Fatalf("%s: %v", algorithm, err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: %v", algorithm, err)
+		}
+		if algorithm != "none" && bytes.Equal(compressed.Bytes(), ndjson.Bytes()) {
+			t.Errorf("%s: output wasn't compressed", algorithm)
+		}
+
+		r, err := decompressReader(&compressed)
+		if err != nil {
+			t.Fatalf("%s: %v", algorithm, err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("%s: %v", algorithm, err)
+		}
+		if !bytes.Equal(got, ndjson.Bytes()) {
+			t.Errorf("%s: read back %q, want %q", algorithm, got, ndjson.Bytes())
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configEnvPrefix starts the environment variables that override settings,
+// like BLOGCRAWLER_TELEGRAM_TOKEN for --telegram-token
+const configEnvPrefix = "BLOGCRAWLER_"
+
+// configVarPattern matches the ${NAME} references to environment variables
+// in config values
+var configVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// crawlerConfig is what the config file holds besides flag defaults
+type crawlerConfig struct {
+	Path  string        // File it was read from, "" without one
+	Sites []siteProfile // Site profiles written in the file, tried after those from --sites
+}
+
+// defaultConfigPath is where the config file is looked for without
+// --config: ~/.config/blogcrawler/config.yaml on Linux
+func defaultConfigPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(configDir, "blogcrawler", "config.yaml")
+}
+
+// configEnvName returns the environment variable overriding a flag
+func configEnvName(flagName string) string {
+	return configEnvPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyConfig fills in the flags of fs that weren't given on the command
+// line, first from the config file at path (the default path when empty,
+// where a missing file is fine), then from BLOGCRAWLER_* environment
+// variables, which win over the file. Settings in the file are flag names;
+// lists set repeatable flags once per item and others to the items joined
+// by commas, and ${NAME} in values is replaced by the environment
+// variable or the secrets file entry, so secrets can stay out of the file.
+func applyConfig(fs *flag.FlagSet, path string) (*crawlerConfig, error) {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	config := &crawlerConfig{}
+	if path == "" {
+		path = defaultConfigPath()
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			path = "" // Having no config file is fine
+		}
+	}
+	if path != "" {
+		if err := config.load(fs, path, explicit); err != nil {
+			return nil, err
+		}
+	}
+
+	var envErr error
+	fs.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(configEnvName(f.Name))
+		if !ok {
+			// The secrets file can stand in for the environment
+			if value, ok = secretsFileValue(configEnvName(f.Name)); ok {
+				trackSecret(value)
+			}
+		}
+		if !ok || explicit[f.Name] || envErr != nil {
+			return
+		}
+		if err := fs.Set(f.Name, value); err != nil {
+			envErr = fmt.Errorf("invalid %s: %w", configEnvName(f.Name), err)
+		}
+	})
+	if envErr != nil {
+		return nil, envErr
+	}
+	return config, nil
+}
+
+// load reads the config file at path and sets the flags it names that
+// aren't in explicit
+func (c *crawlerConfig) load(fs *flag.FlagSet, path string, explicit map[string]bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	var settings map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	c.Path = path
+
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		node := settings[name]
+		if name == "sites" && node.Kind == yaml.SequenceNode {
+			if err := c.loadSites(&node, path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		f := fs.Lookup(name)
+		if f == nil || name == "config" || name == "secrets" {
+			return fmt.Errorf("%s: unknown setting %q (settings are flag names, like timeout)", path, name)
+		}
+		if explicit[name] {
+			continue
+		}
+		values, err := configValues(&node)
+		if err != nil {
+			return fmt.Errorf("%s: %s: %w", path, name, err)
+		}
+		if _, repeatable := f.Value.(*outputSpecs); !repeatable {
+			values = []string{strings.Join(values, ",")}
+		}
+		for _, value := range values {
+			if err := fs.Set(name, value); err != nil {
+				return fmt.Errorf("%s: invalid %s: %w", path, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// loadSites reads the site profiles written in the config file. They have
+// the fields of a --sites file; scripts are relative to the config file.
+func (c *crawlerConfig) loadSites(node *yaml.Node, path string) error {
+	var decoded []interface{}
+	if err := node.Decode(&decoded); err != nil {
+		return fmt.Errorf("%s: sites: %w", path, err)
+	}
+	data, err := json.Marshal(decoded)
+	if err != nil {
+		return fmt.Errorf("%s: sites: %w", path, err)
+	}
+	var profiles []siteProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return fmt.Errorf("failed to parse site profiles in %s: %w", path, err)
+	}
+	c.Sites, err = checkSiteProfiles(profiles, path)
+	return err
+}
+
+// configValues returns the value of a setting, one per item for a list,
+// with ${NAME} references expanded
+func configValues(node *yaml.Node) ([]string, error) {
+	var nodes []*yaml.Node
+	switch node.Kind {
+	case yaml.ScalarNode:
+		nodes = []*yaml.Node{node}
+	case yaml.SequenceNode:
+		nodes = node.Content
+	default:
+		return nil, fmt.Errorf("must be a value or a list of values")
+	}
+
+	values := make([]string, len(nodes))
+	for i, item := range nodes {
+		if item.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("list items must be values")
+		}
+		var missing []string
+		values[i] = configVarPattern.ReplaceAllStringFunc(item.Value, func(ref string) string {
+			name := configVarPattern.FindStringSubmatch(ref)[1]
+			value, ok := lookupSecret(name)
+			if !ok {
+				missing = append(missing, name)
+			}
+			return value
+		})
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("environment variable %s is not set and not in the secrets file", strings.Join(missing, ", "))
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	config := `
+timeout: 45s
+format: ndjson
+post-workers: 4
+telegram-token: ${TEST_BLOGCRAWLER_TOKEN}
+exclude-patterns: [/careers, "re:^/p/\\d+$"]
+output: [sqlite:posts.db, "webhook:https://hooks.example.com/${TEST_BLOGCRAWLER_HOOK}"]
+sites:
+  - match: example.com/blog
+    query_params: {keep: [id]}
+`
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_BLOGCRAWLER_TOKEN", "secret")
+	t.Setenv("TEST_BLOGCRAWLER_HOOK", "abc")
+	t.Setenv("BLOGCRAWLER_POST_WORKERS", "8")
+	t.Setenv("BLOGCRAWLER_SORT", "url")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 30*time.Second, "")
+	format := fs.String("format", "json", "")
+	postWorkers := fs.Int("post-workers", 1, "")
+	token := fs.String("telegram-token", "", "")
+	excludes := fs.String("exclude-patterns", "", "")
+	sortOrder := fs.String("sort", "date", "")
+	var outputs outputSpecs
+	fs.Var(&outputs, "output", "")
+	if err := fs.Parse([]string{"--format", "rss"}); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := applyConfig(fs, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *timeout != 45*time.Second {
+		t.Errorf("timeout = %v, want 45s from the file", *timeout)
+	}
+	if *format != "rss" {
+		t.Errorf("format = %q, want rss from the command line", *format)
+	}
+	if *postWorkers != 8 {
+		t.Errorf("post-workers = %d, want 8 from the environment", *postWorkers)
+	}
+	if *sortOrder != "url" {
+		t.Errorf("sort = %q, want url from the environment", *sortOrder)
+	}
+	if *token != "secret" {
+		t.Errorf("telegram-token = %q, want ${TEST_BLOGCRAWLER_TOKEN} expanded", *token)
+	}
+	if *excludes != `/careers,re:^/p/\d+$` {
+		t.Errorf("exclude-patterns = %q, want the list joined by commas", *excludes)
+	}
+	if want := (outputSpecs{"sqlite:posts.db", "webhook:https://hooks.example.com/abc"}); !reflect.DeepEqual(outputs, want) {
+		t.Errorf("outputs = %q, want %q", outputs, want)
+	}
+	if len(loaded.Sites) != 1 || loaded.Sites[0].Match != "example.com/blog" || !reflect.DeepEqual(loaded.Sites[0].QueryParams.Keep, []string{"id"}) {
+		t.Errorf("sites = %+v, want the example.com/blog profile", loaded.Sites)
+	}
+
+	for _, bad := range []string{"no-such-flag: 1\n", "timeout: soon\n", "telegram-token: ${TEST_BLOGCRAWLER_UNSET}\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0600); err != nil {
+			t.Fatal(err)
+		}
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.Duration("timeout", 30*time.Second, "")
+		fs.String("telegram-token", "", "")
+		if _, err := applyConfig(fs, path); err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("config %q: error %v, want one naming the file", bad, err)
+		}
+	}
+}
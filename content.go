@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// bylineSelectors find a post's byline when it has no author meta tag, most
+// specific first; extractContentJS has the same list
+var bylineSelectors = []string{`[itemprop="author"] [itemprop="name"]`, `[itemprop="author"]`, `[rel="author"]`, ".byline", ".author"}
+
+// extractContentJS returns the post title, publish date, category, byline,
+// the visible text and links of its main content, preferring semantic
+// containers over the whole body, and what gives away a paywall, if any.
+const extractContentJS = `
+	() => {
+		const ogTitle = document.querySelector('meta[property="og:title"]');
+		const h1 = document.querySelector('h1');
+		const title = (ogTitle && ogTitle.content) || (h1 && h1.innerText) || document.title || '';
+
+		const container = document.querySelector('article') ||
+			document.querySelector('main') ||
+			document.querySelector('[role="main"]') ||
+			document.body;
+		const text = container ? container.innerText : '';
+		const links = container ? Array.from(container.querySelectorAll('a[href]'), a => ({
+			href: a.href,
+			text: (a.innerText || '').trim(),
+		})) : [];
+
+		const published = document.querySelector('meta[property="article:published_time"]');
+		const time = document.querySelector('article time[datetime]') || document.querySelector('time[datetime]');
+		const section = document.querySelector('meta[property="article:section"]');
+		const author = document.querySelector('meta[name="author"]');
+		const bylineNode = ['[itemprop="author"] [itemprop="name"]', '[itemprop="author"]', '[rel="author"]', '.byline', '.author']
+			.map(s => document.querySelector(s)).find(node => node);
+		const byline = (author && author.content) ||
+			(bylineNode && (bylineNode.getAttribute('content') || bylineNode.innerText)) || '';
+
+		const markup = Array.from(document.querySelectorAll('script[type="application/ld+json"]'))
+			.some(script => /` + paywallMarkup + `/i.test(script.textContent));
+		const wall = ['[class*="paywall"]', '[id*="paywall"]', '[data-testid*="paywall"]', '[class*="regwall"]', '[class*="registration-wall"]', '.tp-modal']
+			.find(s => document.querySelector(s));
+		const phrase = ((document.body && document.body.innerText) || '').replace(/\s+/g, ' ').toLowerCase()
+			.match(/` + paywallText + `/);
+		const paywall = markup ? 'isAccessibleForFree: false' : (wall || (phrase ? JSON.stringify(phrase[0]) : ''));
+
+		return {
+			title: title.trim(),
+			text: text,
+			published: (published && published.content) || (time && time.getAttribute('datetime')) || '',
+			category: (section && section.content) || '',
+			byline: byline,
+			links: links,
+			paywall: paywall,
+		};
+	}
+`
+
+// extractContent records the title, metadata, text and content hash of the
+// currently loaded post
+func (bc *BlogCrawler) extractContent(ctx context.Context, post *Post) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	res, err := bc.page.Eval(ctx, extractContentJS)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate content script: %w", err)
+	}
+
+	post.Title = res.Get("title").Str()
+	post.Published = res.Get("published").Str()
+	post.Category = res.Get("category").Str()
+	post.Authors = parseByline(res.Get("byline").Str())
+	post.Content = normalizeContent(res.Get("text").Str())
+	post.ContentHash = contentHash(post.Content)
+
+	var links []contentLink
+	for _, link := range res.Get("links").Arr() {
+		links = append(links, contentLink{href: link.Get("href").Str(), text: link.Get("text").Str()})
+	}
+	post.References = bc.references(links)
+	post.Repositories = repositories(post.References)
+	bc.markPaywalled(post, res.Get("paywall").Str())
+
+	if bc.site.script.has(hookExtractPost) {
+		html, err := bc.page.HTML(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read the page for %s: %w", hookExtractPost, err)
+		}
+		bc.scriptPost(post, html)
+	}
+
+	return nil
+}
+
+// normalizeContent collapses whitespace so layout-only changes (reflowed
+// paragraphs, extra blank lines) don't register as content edits
+func normalizeContent(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
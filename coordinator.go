@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// coordinatorSummaryFile is written next to the results of a coordinated run
+const coordinatorSummaryFile = "coordinator.json"
+
+// coordinatedJob is how one blog of a coordinated run went
+type coordinatedJob struct {
+	ID         string `json:"id"`
+	BaseURL    string `json:"base_url"`
+	Worker     string `json:"worker,omitempty"`
+	TotalCount int    `json:"total_count"`
+	New        int    `json:"new"`              // URLs missing from the blog's previous result in the directory
+	Output     string `json:"output,omitempty"` // Result file, relative to the directory
+	Error      string `json:"error,omitempty"`
+}
+
+// coordinatorSummary lists the blogs of a coordinated run and what became
+// of them
+type coordinatorSummary struct {
+	Run      string           `json:"run"`
+	Started  string           `json:"started"`  // RFC 3339
+	Finished string           `json:"finished"` // RFC 3339
+	Failed   int              `json:"failed"`
+	URLs     int              `json:"urls"` // Over all blogs
+	Jobs     []coordinatedJob `json:"jobs"`
+}
+
+// coordinator hands the blogs of a run out to workers over a job queue and
+// merges what comes back into one directory
+type coordinator struct {
+	queue      jobQueue
+	requests   string        // List or subject the workers take jobs from
+	replyTo    string        // List or subject results come back on
+	dir        string        // Where results are written
+	jobTimeout time.Duration // How long to wait for all results
+}
+
+// coordinate publishes jobs, one per blog, and waits for their results
+// until all are in, the job timeout passes or ctx is cancelled. Blogs
+// without a result count as failed.
+func (c *coordinator) coordinate(ctx context.Context, run string, jobs []CrawlJob) (*coordinatorSummary, error) {
+	summary := &coordinatorSummary{Run: run, Started: time.Now().Format(time.RFC3339)}
+	pending := make(map[string]int) // Job ID -> index in summary.Jobs
+	for _, job := range jobs {
+		job.ReplyTo = c.replyTo
+		payload, err := json.Marshal(job)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode job %s: %w", job.ID, err)
+		}
+		if err := c.queue.Publish(ctx, c.requests, payload); err != nil {
+			return nil, fmt.Errorf("failed to publish job %s: %w", job.ID, err)
+		}
+		pending[job.ID] = len(summary.Jobs)
+		summary.Jobs = append(summary.Jobs, coordinatedJob{ID: job.ID, BaseURL: job.BaseURL})
+	}
+	fmt.Printf("Published %d crawl jobs on %s; waiting for results on %s\n", len(jobs), c.requests, c.replyTo)
+
+	waitCtx, cancel := context.WithTimeout(ctx, c.jobTimeout)
+	defer cancel()
+	for len(pending) > 0 {
+		payload, err := c.queue.Receive(waitCtx)
+		if waitCtx.Err() != nil {
+			break
+		}
+		if err != nil {
+			fmt.Printf("Warning: Error receiving results: %v\n", err)
+			if err := sleepContext(waitCtx, time.Second); err != nil {
+				break
+			}
+			continue
+		}
+
+		var jobResult CrawlJobResult
+		if err := json.Unmarshal(payload, &jobResult); err != nil {
+			fmt.Printf("Warning: Skipping an invalid result: %v\n", err)
+			continue
+		}
+		i, ok := pending[jobResult.ID]
+		if !ok {
+			fmt.Printf("Warning: Skipping the result of job %q, which isn't of this run\n", jobResult.ID)
+			continue
+		}
+		delete(pending, jobResult.ID)
+		c.merge(&summary.Jobs[i], jobResult)
+		fmt.Printf("[%d/%d] %s\n", len(jobs)-len(pending), len(jobs), summary.Jobs[i].describe())
+	}
+
+	reason := "no result within --job-timeout"
+	if ctx.Err() != nil {
+		reason = "coordinator stopped before a result came"
+	}
+	for _, i := range pending {
+		summary.Jobs[i].Error = reason
+	}
+	for _, job := range summary.Jobs {
+		if job.Error != "" {
+			summary.Failed++
+		}
+		summary.URLs += job.TotalCount
+	}
+	summary.Finished = time.Now().Format(time.RFC3339)
+
+	if err := c.writeSummary(summary); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+// merge records a worker's result for job and writes the crawl result to
+// the directory, replacing the blog's previous one
+func (c *coordinator) merge(job *coordinatedJob, jobResult CrawlJobResult) {
+	job.Worker = jobResult.Worker
+	if jobResult.Error != "" {
+		job.Error = redact(jobResult.Error)
+		return
+	}
+	if jobResult.Result == nil {
+		job.Error = "worker sent no result"
+		return
+	}
+
+	result := jobResult.Result
+	job.Output = seedOutputName(job.BaseURL) + ".json"
+	path := filepath.Join(c.dir, job.Output)
+	if previous, err := loadResult(path); err == nil {
+		result.New = diffResults(previous, result).Added
+		job.New = len(result.New)
+	}
+	job.TotalCount = result.TotalCount
+
+	file, err := os.Create(path)
+	if err == nil {
+		err = writeJSON(file, result)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		job.Error = fmt.Sprintf("failed to write %s: %v", path, err)
+		job.Output = ""
+	}
+}
+
+func (c *coordinator) writeSummary(summary *coordinatorSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(c.dir, coordinatorSummaryFile)
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func (j coordinatedJob) describe() string {
+	if j.Error != "" {
+		return fmt.Sprintf("%s failed on %s: %s", j.BaseURL, orUnknown(j.Worker), j.Error)
+	}
+	return fmt.Sprintf("%s: %d URLs, %d new, from %s", j.BaseURL, j.TotalCount, j.New, orUnknown(j.Worker))
+}
+
+func orUnknown(worker string) string {
+	if worker == "" {
+		return "an unknown worker"
+	}
+	return worker
+}
+
+// defaultReplyTo names the list or subject a run's results come back on,
+// unique to the run so coordinators running at once don't take each
+// other's results
+func defaultReplyTo(queueURL, run string) string {
+	if strings.HasPrefix(queueURL, "redis") {
+		return "blogcrawler:results:" + run
+	}
+	return "blogcrawler.results." + run
+}
+
+// runCoordinator implements the coordinate subcommand
+func runCoordinator(args []string) {
+	fs := flag.NewFlagSet("coordinate", flag.ExitOnError)
+	queueURL := fs.String("queue", "redis://localhost:6379/0", "queue the workers consume from (redis://... or nats://...)")
+	requests := fs.String("requests", "blogcrawler:requests", "Redis list or NATS subject carrying crawl jobs")
+	results := fs.String("results", "", "Redis list or NATS subject the workers send results to (default one of the run's own)")
+	seedsSource := fs.String("seeds", "", "also crawl every blog listed on this aggregator page or OPML file")
+	outputDir := fs.String("out", "", "directory to write each blog's result and the run summary to")
+	timeout := fs.Duration("timeout", 30*time.Second, "page load timeout of the workers' crawls")
+	fetchContent := fs.Bool("fetch-content", false, "ask workers to fetch each post's content")
+	jobTimeout := fs.Duration("job-timeout", 2*time.Hour, "give up on results that haven't come back after this long")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . coordinate --out DIR [--queue URL] [--seeds SOURCE] [blog URL]...")
+		fmt.Println()
+		fmt.Println("Hands the blogs out to workers (go run . worker) over the queue, one job")
+		fmt.Println("per blog, and writes their results to DIR along with coordinator.json.")
+		fmt.Println()
+		fmt.Println("Flags:")
+		fs.PrintDefaults()
+	}
+	blogs, _ := parseArgs(fs, args)
+	if *outputDir == "" || (len(blogs) == 0 && *seedsSource == "") || *jobTimeout <= 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	for _, blog := range blogs {
+		if parsedURL, err := url.Parse(blog); err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" {
+			fmt.Printf("Invalid blog URL %q\n", blog)
+			os.Exit(1)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *seedsSource != "" {
+		seeds, err := loadSeeds(ctx, *seedsSource, *timeout, os.Stdout)
+		if err != nil {
+			fmt.Printf("Error loading seeds: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Found %d blogs in %s\n", len(seeds), *seedsSource)
+		blogs = append(blogs, seeds...)
+	}
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		fmt.Printf("Error creating output directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	run := time.Now().Format("20060102-150405")
+	replyTo := *results
+	if replyTo == "" {
+		replyTo = defaultReplyTo(*queueURL, run)
+	}
+	// Subscribed before the jobs go out, so no result is missed
+	queue, err := openJobQueue(*queueURL, replyTo, "")
+	if err != nil {
+		fmt.Printf("Error opening queue: %v\n", err)
+		os.Exit(1)
+	}
+	defer queue.Close()
+
+	var jobs []CrawlJob
+	seen := make(map[string]bool)
+	for _, blog := range blogs {
+		if seen[blog] {
+			continue
+		}
+		seen[blog] = true
+		jobs = append(jobs, CrawlJob{
+			ID:             fmt.Sprintf("%s-%d", run, len(jobs)+1),
+			BaseURL:        blog,
+			TimeoutSeconds: int(timeout.Seconds()),
+			FetchContent:   *fetchContent,
+		})
+	}
+
+	c := &coordinator{queue: queue, requests: *requests, replyTo: replyTo, dir: *outputDir, jobTimeout: *jobTimeout}
+	summary, err := c.coordinate(ctx, run, jobs)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		if summary == nil {
+			os.Exit(1)
+		}
+	}
+	fmt.Printf("\n%d of %d blogs crawled, %d URLs; summary in %s\n", len(summary.Jobs)-summary.Failed, len(summary.Jobs), summary.URLs, filepath.Join(*outputDir, coordinatorSummaryFile))
+	if ctx.Err() != nil || summary.Failed == len(summary.Jobs) {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeWorkers is a jobQueue whose workers answer every job published on
+// it with results from crawl, unless it returns nil
+type fakeWorkers struct {
+	crawl     func(job CrawlJob) *CrawlJobResult
+	published map[string][]CrawlJob
+	results   chan []byte
+}
+
+func newFakeWorkers(crawl func(job CrawlJob) *CrawlJobResult) *fakeWorkers {
+	return &fakeWorkers{crawl: crawl, published: make(map[string][]CrawlJob), results: make(chan []byte, 100)}
+}
+
+func (q *fakeWorkers) Receive(ctx context.Context) ([]byte, error) {
+	select {
+	case payload := <-q.results:
+		return payload, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (q *fakeWorkers) Publish(ctx context.Context, name string, payload []byte) error {
+	var job CrawlJob
+	if err := json.Unmarshal(payload, &job); err != nil {
+		return err
+	}
+	q.published[name] = append(q.published[name], job)
+	if jobResult := q.crawl(job); jobResult != nil {
+		encoded, _ := json.Marshal(jobResult)
+		q.results <- encoded
+	}
+	return nil
+}
+
+func (q *fakeWorkers) Close() error {
+	return nil
+}
+
+func TestCoordinate(t *testing.T) {
+	dir := t.TempDir()
+	previous := &CrawlResult{BaseURL: fakeBlogURL, BlogURLs: fakePagedURLs[:4], TotalCount: 4}
+	file, _ := os.Create(filepath.Join(dir, seedOutputName(fakeBlogURL)+".json"))
+	writeJSON(file, previous)
+	file.Close()
+
+	queue := newFakeWorkers(func(job CrawlJob) *CrawlJobResult {
+		switch job.BaseURL {
+		case fakeBlogURL:
+			return &CrawlJobResult{ID: job.ID, BaseURL: job.BaseURL, Worker: "host-a:1",
+				Result: &CrawlResult{BaseURL: job.BaseURL, BlogURLs: fakePagedURLs, TotalCount: len(fakePagedURLs)}}
+		case "https://broken.example.com/":
+			return &CrawlJobResult{ID: job.ID, BaseURL: job.BaseURL, Worker: "host-b:2", Error: "failed to navigate"}
+		}
+		return nil // Lost with its worker
+	})
+	queue.results <- []byte(`{"id": "older-run-1", "base_url": "https://blog.example.com/"}`)
+
+	c := &coordinator{queue: queue, requests: "requests", replyTo: "results:run", dir: dir, jobTimeout: 100 * time.Millisecond}
+	jobs := []CrawlJob{
+		{ID: "run-1", BaseURL: fakeBlogURL},
+		{ID: "run-2", BaseURL: "https://broken.example.com/"},
+		{ID: "run-3", BaseURL: "https://lost.example.com/"},
+	}
+	summary, err := c.coordinate(context.Background(), "run", jobs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, job := range queue.published["requests"] {
+		if job.ReplyTo != "results:run" {
+			t.Errorf("job %s replies to %q", job.ID, job.ReplyTo)
+		}
+	}
+	want := []coordinatedJob{
+		{ID: "run-1", BaseURL: fakeBlogURL, Worker: "host-a:1", TotalCount: 6, New: 2, Output: seedOutputName(fakeBlogURL) + ".json"},
+		{ID: "run-2", BaseURL: "https://broken.example.com/", Worker: "host-b:2", Error: "failed to navigate"},
+		{ID: "run-3", BaseURL: "https://lost.example.com/", Error: "no result within --job-timeout"},
+	}
+	if !reflect.DeepEqual(summary.Jobs, want) {
+		t.Errorf("jobs\n got: %+v\nwant: %+v", summary.Jobs, want)
+	}
+	if summary.Failed != 2 || summary.URLs != 6 {
+		t.Errorf("failed %d, URLs %d; want 2 and 6", summary.Failed, summary.URLs)
+	}
+
+	result, err := loadResult(filepath.Join(dir, want[0].Output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.New, fakePagedURLs[4:]) {
+		t.Errorf("new URLs in the result %q, want %q", result.New, fakePagedURLs[4:])
+	}
+	if _, err := os.Stat(filepath.Join(dir, coordinatorSummaryFile)); err != nil {
+		t.Errorf("no summary: %v", err)
+	}
+}
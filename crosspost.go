@@ -0,0 +1,82 @@
+package main
+
+import "sync"
+
+// crossPostIndex remembers the posts of every blog crawled in one session by
+// content hash, to tell when another blog has the same article: a company
+// blog and its Medium mirror, or a syndication site. Its methods do nothing
+// on a nil index, so single-blog runs don't need one.
+type crossPostIndex struct {
+	mu    sync.Mutex
+	first map[string]crossPost // By content hash, the copy crawled first
+}
+
+type crossPost struct {
+	URL     string
+	BaseURL string
+}
+
+func newCrossPostIndex() *crossPostIndex {
+	return &crossPostIndex{first: make(map[string]crossPost)}
+}
+
+// link sets CrossPostOf on the result's posts whose content was already
+// found on another blog, and records the others as the first copy. It
+// returns how many posts it linked.
+func (ci *crossPostIndex) link(result *CrawlResult) int {
+	if ci == nil {
+		return 0
+	}
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+
+	empty := contentHash("")
+	linked := 0
+	for i := range result.Posts {
+		post := &result.Posts[i]
+		if post.ContentHash == "" || post.ContentHash == empty {
+			continue
+		}
+		first, ok := ci.first[post.ContentHash]
+		if !ok {
+			ci.first[post.ContentHash] = crossPost{URL: post.URL, BaseURL: result.BaseURL}
+			continue
+		}
+		// The same content twice on one blog is left to the duplicate
+		// title check
+		if first.BaseURL != result.BaseURL {
+			post.CrossPostOf = first.URL
+			linked++
+		}
+	}
+	return linked
+}
+
+// withoutCrossPosts returns the result as notifiers get it: without the
+// new posts that are copies of a post on another blog, which was announced
+// with that blog or was already there. It returns result itself when there
+// are none.
+func withoutCrossPosts(result *CrawlResult) *CrawlResult {
+	crossPosted := make(map[string]bool)
+	for _, post := range result.Posts {
+		if post.CrossPostOf != "" {
+			crossPosted[post.URL] = true
+		}
+	}
+	if len(crossPosted) == 0 {
+		return result
+	}
+
+	var fresh []string
+	for _, url := range result.New {
+		if !crossPosted[url] {
+			fresh = append(fresh, url)
+		}
+	}
+	if len(fresh) == len(result.New) {
+		return result
+	}
+	notified := *result
+	notified.New = fresh
+	return &notified
+}
@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestCrossPosts(t *testing.T) {
+	article := contentHash("Scaling our queue to a million jobs a second")
+	company := &CrawlResult{
+		BaseURL: "https://eng.example.com/",
+		Posts: []Post{
+			{URL: "https://eng.example.com/scaling-our-queue", ContentHash: article},
+			{URL: "https://eng.example.com/hiring", ContentHash: contentHash("We're hiring")},
+		},
+	}
+	mirror := &CrawlResult{
+		BaseURL: "https://medium.com/example-eng",
+		New:     []string{"https://medium.com/example-eng/scaling-our-queue-1f2e", "https://medium.com/example-eng/only-here"},
+		Posts: []Post{
+			{URL: "https://medium.com/example-eng/scaling-our-queue-1f2e", ContentHash: article},
+			{URL: "https://medium.com/example-eng/only-here", ContentHash: contentHash("Only on Medium")},
+			{URL: "https://medium.com/example-eng/empty", ContentHash: contentHash("")},
+		},
+	}
+
+	index := newCrossPostIndex()
+	if linked := index.link(company); linked != 0 {
+		t.Errorf("first blog: linked %d posts, want 0", linked)
+	}
+	if linked := index.link(mirror); linked != 1 {
+		t.Errorf("mirror: linked %d posts, want 1", linked)
+	}
+	if got := mirror.Posts[0].CrossPostOf; got != "https://eng.example.com/scaling-our-queue" {
+		t.Errorf("cross_post_of = %q, want the company blog's post", got)
+	}
+	if mirror.Posts[1].CrossPostOf != "" || mirror.Posts[2].CrossPostOf != "" {
+		t.Errorf("posts without a copy elsewhere were linked: %+v", mirror.Posts[1:])
+	}
+
+	// Crawling the first blog again doesn't link it to its own posts
+	if linked := index.link(company); linked != 0 {
+		t.Errorf("first blog again: linked %d posts, want 0", linked)
+	}
+
+	notified := withoutCrossPosts(mirror)
+	if len(notified.New) != 1 || notified.New[0] != "https://medium.com/example-eng/only-here" {
+		t.Errorf("notified new posts = %v, want only the post not on the company blog", notified.New)
+	}
+	if len(mirror.New) != 2 {
+		t.Errorf("result's new posts = %v, want both kept in the output", mirror.New)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Limits of what a diagnostics bundle quotes from the page
+const (
+	diagnosticsExcerpt   = 4000 // Bytes of the body's HTML in the JSON
+	diagnosticsSamples   = 5    // Hrefs quoted per selector
+	diagnosticsRejected  = 10   // Rejected links quoted
+	diagnosticsTextQuote = 500  // Characters of the page's text
+)
+
+// Diagnostics explains a crawl that found no posts: what the page it ended
+// on looked like, what the post link selectors matched and what the
+// filters turned down. Verdict sums it up:
+//
+//	blocked         a bot wall, captcha or access-denied page
+//	page-failed     the page didn't load or render (HTTP error, blank page)
+//	layout-changed  no post link selector but the all-links fallback matches
+//	filtered        selectors matched links but the filters rejected them all
+type Diagnostics struct {
+	Verdict    string            `json:"verdict"`
+	Reason     string            `json:"reason,omitempty"` // What's wrong with the page, see emptyPageReason
+	PageURL    string            `json:"page_url"`         // The page loaded when the crawl ended
+	Title      string            `json:"title"`
+	Status     int               `json:"status"` // HTTP status of the page, 0 if unknown
+	TextLength int               `json:"text_length"`
+	Text       string            `json:"text,omitempty"` // Start of the page's visible text
+	Links      int               `json:"links"`          // Links on the page
+	Selectors  []SelectorMatch   `json:"selectors"`
+	Rejected   map[string]int    `json:"rejected_by_rule,omitempty"`
+	Samples    []string          `json:"rejected_samples,omitempty"` // Some of the links the filters turned down
+	Excerpt    string            `json:"dom_excerpt,omitempty"`      // Start of the body's HTML
+	Bundle     string            `json:"bundle,omitempty"`           // Directory with this report, the page's HTML and a screenshot
+	Pages      []PageStat        `json:"pages,omitempty"`
+	Files      map[string]string `json:"files,omitempty"` // Bundle files by kind: "html", "screenshot"
+}
+
+// SelectorMatch is what one post link selector matched on the page
+type SelectorMatch struct {
+	Selector string   `json:"selector"`
+	Matched  int      `json:"matched"`
+	Hrefs    []string `json:"hrefs,omitempty"` // The first few
+}
+
+// diagnoseEmpty looks into why the crawl found no posts. The bundle is
+// written to opts.DiagnosticsDir when set; failing to write it is only a
+// warning, since the crawl's own outcome matters more.
+func (bc *BlogCrawler) diagnoseEmpty(ctx context.Context) *Diagnostics {
+	d := &Diagnostics{Rejected: bc.rejections, Pages: bc.pages}
+	if bc.page == nil {
+		d.Verdict = "page-failed"
+		d.Reason = "no page loaded"
+		return d
+	}
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	d.PageURL, _ = bc.page.URL(ctx)
+	if state, err := bc.page.Eval(ctx, pageStateJS); err == nil {
+		d.Title = strings.TrimSpace(state.Get("title").Str())
+		d.Status = state.Get("status").Int()
+		d.TextLength = state.Get("textLength").Int()
+		d.Links = state.Get("links").Int()
+		d.Text = truncate(normalizeContent(state.Get("text").Str()), diagnosticsTextQuote)
+	}
+
+	matched := 0
+	for _, selector := range bc.postLinkSelectors() {
+		anchors, err := bc.anchors(ctx, selector)
+		if err != nil {
+			continue
+		}
+		match := SelectorMatch{Selector: selector, Matched: len(anchors)}
+		for _, a := range anchors {
+			if len(match.Hrefs) == diagnosticsSamples {
+				break
+			}
+			if a.Href != "" {
+				match.Hrefs = append(match.Hrefs, a.Href)
+			}
+		}
+		if selector != fallbackSelector {
+			matched += len(anchors)
+		}
+		d.Selectors = append(d.Selectors, match)
+	}
+	for link := range bc.rejected {
+		d.Samples = append(d.Samples, link)
+	}
+	sort.Strings(d.Samples)
+	d.Samples = d.Samples[:min(len(d.Samples), diagnosticsRejected)]
+
+	html, _ := bc.page.HTML(ctx)
+	if start := strings.Index(html, "<body"); start >= 0 {
+		d.Excerpt = html[start:]
+	} else {
+		d.Excerpt = html
+	}
+	d.Excerpt = d.Excerpt[:min(len(d.Excerpt), diagnosticsExcerpt)]
+
+	d.Reason = bc.blockedPage()
+	if d.Reason == "" {
+		d.Reason = bc.emptyPageReason(ctx)
+	}
+	switch {
+	case strings.HasPrefix(d.Reason, "blocked"):
+		d.Verdict = "blocked"
+	case d.Reason != "":
+		d.Verdict = "page-failed"
+	case matched == 0:
+		d.Verdict = "layout-changed"
+	default:
+		d.Verdict = "filtered"
+	}
+
+	if bc.opts.DiagnosticsDir != "" {
+		if err := bc.writeDiagnostics(ctx, d, html); err != nil {
+			bc.warnf("Failed to write the diagnostics bundle: %v", err)
+		}
+	}
+	printDiagnostics(bc.opts.output(), d, matched)
+	return d
+}
+
+// blockedPage returns the error of a listing page that was blocked, ""
+// when none was
+func (bc *BlogCrawler) blockedPage() string {
+	for _, page := range bc.pages {
+		if strings.HasPrefix(page.Error, "blocked") {
+			return page.Error
+		}
+	}
+	return ""
+}
+
+// writeDiagnostics writes the bundle: diagnostics.json, the page's HTML
+// and, in Chrome, a screenshot
+func (bc *BlogCrawler) writeDiagnostics(ctx context.Context, d *Diagnostics, html string) error {
+	dir := bc.opts.DiagnosticsDir
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	d.Bundle = dir
+	d.Files = make(map[string]string)
+
+	if err := os.WriteFile(filepath.Join(dir, "page.html"), []byte(html), 0644); err != nil {
+		return err
+	}
+	d.Files["html"] = "page.html"
+
+	if tab := rodPageOf(bc.page); tab != nil {
+		data, err := tab.Context(ctx).Screenshot(false, &proto.PageCaptureScreenshot{
+			Format: proto.PageCaptureScreenshotFormatPng,
+		})
+		if err != nil {
+			bc.warnf("Failed to capture a diagnostics screenshot: %v", err)
+		} else if err := os.WriteFile(filepath.Join(dir, "screenshot.png"), data, 0644); err != nil {
+			return err
+		} else {
+			d.Files["screenshot"] = "screenshot.png"
+		}
+	}
+
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "diagnostics.json"), data, 0644)
+}
+
+// printDiagnostics sums up the diagnosis on the console
+func printDiagnostics(w io.Writer, d *Diagnostics, matched int) {
+	fmt.Fprintf(w, "No posts found. Diagnosis: %s\n", d.Verdict)
+	switch d.Verdict {
+	case "blocked", "page-failed":
+		fmt.Fprintf(w, "  %s (%q, HTTP %d)\n", d.Reason, d.Title, d.Status)
+	case "layout-changed":
+		fmt.Fprintf(w, "  No post link selector but the all-links fallback matched the page's %d links; the site may have changed its layout\n", d.Links)
+	case "filtered":
+		fmt.Fprintf(w, "  Post link selectors matched %d links but the filters rejected them all; see rejected_by_rule\n", matched)
+	}
+	if d.Bundle != "" {
+		fmt.Fprintf(w, "  Diagnostics written to: %s\n", d.Bundle)
+	}
+}
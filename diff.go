@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ResultDiff describes how a crawl result differs from an earlier one
+type ResultDiff struct {
+	Added    []string `json:"added"`
+	Removed  []string `json:"removed"`
+	Modified []string `json:"modified"` // Only reported when both results carry content hashes
+}
+
+// loadResult reads a crawl result previously written in JSON format
+func loadResult(filename string) (*CrawlResult, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Results may have been written with --compress
+	reader, err := decompressReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress %s: %w", filename, err)
+	}
+
+	var result CrawlResult
+	if err := json.NewDecoder(reader).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", filename, err)
+	}
+
+	return &result, nil
+}
+
+// contentHashes maps each post URL to its content hash, skipping posts
+// whose content was never fetched
+func contentHashes(result *CrawlResult) map[string]string {
+	hashes := make(map[string]string)
+	for _, post := range result.Posts {
+		if post.ContentHash != "" {
+			hashes[post.URL] = post.ContentHash
+		}
+	}
+	return hashes
+}
+
+// diffResults compares two results by URL and, where both sides have a
+// content hash for a URL, by content
+func diffResults(old, latest *CrawlResult) ResultDiff {
+	oldURLs := make(map[string]bool)
+	for _, url := range old.BlogURLs {
+		oldURLs[url] = true
+	}
+	newURLs := make(map[string]bool)
+	for _, url := range latest.BlogURLs {
+		newURLs[url] = true
+	}
+
+	diff := ResultDiff{
+		Added:    []string{},
+		Removed:  []string{},
+		Modified: []string{},
+	}
+
+	for url := range newURLs {
+		if !oldURLs[url] {
+			diff.Added = append(diff.Added, url)
+		}
+	}
+	for url := range oldURLs {
+		if !newURLs[url] {
+			diff.Removed = append(diff.Removed, url)
+		}
+	}
+
+	oldHashes := contentHashes(old)
+	for url, hash := range contentHashes(latest) {
+		if oldHash, ok := oldHashes[url]; ok && oldHash != hash {
+			diff.Modified = append(diff.Modified, url)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Modified)
+
+	return diff
+}
+
+// printDiff writes a human-readable summary of diff
+func printDiff(diff ResultDiff) {
+	sections := []struct {
+		name   string
+		marker string
+		urls   []string
+	}{
+		{"Added", "+", diff.Added},
+		{"Removed", "-", diff.Removed},
+		{"Modified", "~", diff.Modified},
+	}
+
+	for _, section := range sections {
+		fmt.Printf("%s (%d):\n", section.name, len(section.urls))
+		for _, url := range section.urls {
+			fmt.Printf("  %s %s\n", section.marker, url)
+		}
+	}
+}
+
+// runDiff implements the diff subcommand: diff [--json] <old.json> <new.json>
+func runDiff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "print the diff as JSON")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . diff [--json] <old.json> <new.json>")
+		fmt.Println()
+		fmt.Println("Flags:")
+		fs.PrintDefaults()
+	}
+
+	files, _ := parseArgs(fs, args)
+	if len(files) != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	old, err := loadResult(files[0])
+	if err != nil {
+		fmt.Printf("Error loading %s: %v\n", files[0], err)
+		os.Exit(1)
+	}
+	latest, err := loadResult(files[1])
+	if err != nil {
+		fmt.Printf("Error loading %s: %v\n", files[1], err)
+		os.Exit(1)
+	}
+
+	diff := diffResults(old, latest)
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diff); err != nil {
+			fmt.Printf("Error encoding diff: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	printDiff(diff)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffResults(t *testing.T) {
+	post := func(url, content string) Post {
+		p := Post{URL: url}
+		if content != "" {
+			p.ContentHash = contentHash(content)
+		}
+		return p
+	}
+	result := func(posts ...Post) *CrawlResult {
+		r := &CrawlResult{}
+		for _, p := range posts {
+			r.BlogURLs = append(r.BlogURLs, p.URL)
+			r.Posts = append(r.Posts, p)
+		}
+		return r
+	}
+
+	tests := []struct {
+		name   string
+		old    *CrawlResult
+		latest *CrawlResult
+		want   ResultDiff
+	}{
+		{
+			name:   "unchanged",
+			old:    result(post("https://blog.example.com/a", "A")),
+			latest: result(post("https://blog.example.com/a", "A")),
+			want:   ResultDiff{Added: []string{}, Removed: []string{}, Modified: []string{}},
+		},
+		{
+			name:   "added",
+			old:    result(post("https://blog.example.com/a", "")),
+			latest: result(post("https://blog.example.com/c", ""), post("https://blog.example.com/a", ""), post("https://blog.example.com/b", "")),
+			want:   ResultDiff{Added: []string{"https://blog.example.com/b", "https://blog.example.com/c"}, Removed: []string{}, Modified: []string{}},
+		},
+		{
+			name:   "removed",
+			old:    result(post("https://blog.example.com/a", ""), post("https://blog.example.com/b", "")),
+			latest: result(post("https://blog.example.com/b", "")),
+			want:   ResultDiff{Added: []string{}, Removed: []string{"https://blog.example.com/a"}, Modified: []string{}},
+		},
+		{
+			name:   "content changed",
+			old:    result(post("https://blog.example.com/a", "A"), post("https://blog.example.com/b", "B")),
+			latest: result(post("https://blog.example.com/a", "A, edited"), post("https://blog.example.com/b", "B")),
+			want:   ResultDiff{Added: []string{}, Removed: []string{}, Modified: []string{"https://blog.example.com/a"}},
+		},
+		{
+			// Without a hash on both sides a post can't be compared
+			name:   "content fetched on one side only",
+			old:    result(post("https://blog.example.com/a", "")),
+			latest: result(post("https://blog.example.com/a", "A")),
+			want:   ResultDiff{Added: []string{}, Removed: []string{}, Modified: []string{}},
+		},
+	}
+	for _, tt := range tests {
+		if got := diffResults(tt.old, tt.latest); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: diffResults = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
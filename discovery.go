@@ -0,0 +1,76 @@
+package main
+
+// Discovery records where a post URL was first found
+type Discovery struct {
+	URL      string `json:"url"`
+	Source   string `json:"source"`             // "page", "scroll", "archive", "tab", "search" (like ProgressEvent.Kind), "search-engine" or "wayback"
+	Step     int    `json:"step"`               // Listing page number, scroll iteration, archive page, tab or search page, from 1
+	PageURL  string `json:"page_url"`           // Listing page the URL was found on
+	Selector string `json:"selector,omitempty"` // Link selector that matched it, see postLinkSelectors
+	Text     string `json:"text,omitempty"`     // Text of the link
+	Context  string `json:"context,omitempty"`  // Text around the link, such as the post's card or list item
+
+	order int // Position in discovery order, for --sort discovery
+}
+
+// discoverySource is the listing step URLs are currently extracted from
+type discoverySource struct {
+	kind    string
+	step    int
+	pageURL string
+}
+
+// setSource tells recordDiscovery which listing step the following
+// extractions belong to
+func (bc *BlogCrawler) setSource(kind string, step int, pageURL string) {
+	bc.source = discoverySource{kind: kind, step: step, pageURL: pageURL}
+}
+
+// recordDiscovery remembers where and in which order post URLs were first
+// found. Later sightings of a URL don't change its record.
+func (bc *BlogCrawler) recordDiscovery(postURL, selector string) {
+	if bc.discovered == nil {
+		bc.discovered = make(map[string]Discovery)
+	}
+	if _, ok := bc.discovered[postURL]; ok {
+		return
+	}
+	bc.discovered[postURL] = Discovery{
+		URL:      postURL,
+		Source:   bc.source.kind,
+		Step:     bc.source.step,
+		PageURL:  bc.source.pageURL,
+		Selector: selector,
+		order:    len(bc.discovered),
+	}
+	bc.journal(journalEvent{
+		Event:    "accepted",
+		URL:      postURL,
+		PageURL:  bc.source.pageURL,
+		Source:   bc.source.kind,
+		Step:     bc.source.step,
+		Selector: selector,
+	})
+}
+
+// discoveries returns the discovery records of urls, in the same order
+func (bc *BlogCrawler) discoveries(urls []string) []Discovery {
+	records := make([]Discovery, 0, len(urls))
+	for _, postURL := range urls {
+		if record, ok := bc.discovered[postURL]; ok {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// recordLinkText adds the text of a link to postURL to its discovery
+// record, unless it has some already
+func (bc *BlogCrawler) recordLinkText(postURL string, a anchor) {
+	d, ok := bc.discovered[postURL]
+	if !ok || d.Text != "" || d.Context != "" {
+		return
+	}
+	d.Text, d.Context = a.Text, a.Context
+	bc.discovered[postURL] = d
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod/lib/launcher"
+)
+
+// doctorTestPages are public bot-detection pages the doctor loads by
+// default, to see whether the browser gets through them
+var doctorTestPages = []string{
+	"https://bot.sannysoft.com/",
+	"https://arh.antoinevastel.com/bots/areyouheadless",
+}
+
+// fingerprintJS reads the properties bot detection looks at first
+const fingerprintJS = `async () => {
+	let webgl = '';
+	try {
+		const gl = document.createElement('canvas').getContext('webgl');
+		const info = gl && gl.getExtension('WEBGL_debug_renderer_info');
+		webgl = info ? gl.getParameter(info.UNMASKED_RENDERER_WEBGL) : '';
+	} catch (e) {}
+	let permission = '';
+	try {
+		permission = (await navigator.permissions.query({name: 'notifications'})).state;
+	} catch (e) {}
+	return {
+		userAgent: navigator.userAgent,
+		webdriver: navigator.webdriver === true,
+		plugins: navigator.plugins.length,
+		languages: (navigator.languages || []).length,
+		chrome: typeof window.chrome !== 'undefined',
+		notification: typeof Notification !== 'undefined' ? Notification.permission : '',
+		permission: permission,
+		webgl: webgl,
+		outerWidth: window.outerWidth,
+	};
+}`
+
+// doctorCheck is one line of the doctor's report
+type doctorCheck struct {
+	Group  string `json:"group"`
+	Name   string `json:"name"`
+	Status string `json:"status"` // "ok", "warn" or "fail"
+	Detail string `json:"detail"`
+}
+
+// doctorReport collects the checks of a doctor run
+type doctorReport struct {
+	Checks []doctorCheck `json:"checks"`
+}
+
+func (r *doctorReport) add(group, name, status, detail string) {
+	r.Checks = append(r.Checks, doctorCheck{Group: group, Name: name, Status: status, Detail: detail})
+}
+
+// failed reports whether any check failed
+func (r *doctorReport) failed() bool {
+	for _, check := range r.Checks {
+		if check.Status == "fail" {
+			return true
+		}
+	}
+	return false
+}
+
+func runDoctor(args []string) {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	proxy := fs.String("proxy", "", "send the browser's and the HTTP checks' traffic through this proxy")
+	profile := fs.String("profile", "", "launch the browser with this named profile, as the crawl would")
+	timeout := fs.Duration("timeout", 30*time.Second, "page load timeout")
+	testPages := fs.String("test-pages", strings.Join(doctorTestPages, ","), "comma-separated bot-detection pages to load, \"\" for none")
+	jsonOutput := fs.Bool("json", false, "print the report as JSON")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . doctor [--proxy URL] [--profile NAME] [blog URL]")
+		fmt.Println()
+		fmt.Println("Launches the browser the crawler would use and reports its Chrome path and")
+		fmt.Println("version, the signals that give a headless browser away (the webdriver flag,")
+		fmt.Println("missing plugins, a HeadlessChrome user agent), whether bot-detection test")
+		fmt.Println("pages load, and whether the blog can be reached and isn't blocking it.")
+		fmt.Println()
+		fmt.Println("Flags:")
+		fs.PrintDefaults()
+	}
+
+	positional, _ := parseArgs(fs, args)
+	if len(positional) > 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	var baseURL string
+	if len(positional) == 1 {
+		baseURL = positional[0]
+	}
+	if *proxy != "" {
+		if err := validateProxy(*proxy); err != nil {
+			fmt.Printf("Invalid --proxy: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var pages []string
+	if *testPages != "" {
+		pages = strings.Split(*testPages, ",")
+	}
+
+	opts := CrawlOptions{Proxy: *proxy, Profile: *profile}
+	report := &doctorReport{}
+	report.checkChromePath()
+	browser, launcher, err := launchBrowser(ctx, opts)
+	if err != nil {
+		report.add("Browser", "launch", "fail", err.Error())
+	} else {
+		report.checkBrowser(ctx, rodBrowser{browser: browser}, pages, baseURL, *timeout, *proxy)
+		browser.Close()
+		launcher.Kill()
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	} else {
+		report.print()
+	}
+	if report.failed() {
+		os.Exit(exitError)
+	}
+}
+
+// checkChromePath reports the Chrome launchBrowser starts: one of
+// chromePaths, else the one rod finds on the system
+func (r *doctorReport) checkChromePath() {
+	for _, path := range chromePaths {
+		if _, err := os.Stat(path); err == nil {
+			r.add("Browser", "path", "ok", path)
+			return
+		}
+	}
+	if path, found := launcher.LookPath(); found {
+		r.add("Browser", "path", "ok", path)
+		return
+	}
+	r.add("Browser", "path", "warn", "no Chrome or Chromium found; rod downloads a Chromium on the first launch")
+}
+
+// checkBrowser runs the checks needing the launched browser: its version,
+// its fingerprint, the test pages and the blog
+func (r *doctorReport) checkBrowser(ctx context.Context, browser Browser, testPages []string, baseURL string, timeout time.Duration, proxy string) {
+	if version, err := browser.Version(ctx); err != nil {
+		r.add("Browser", "version", "fail", err.Error())
+	} else {
+		r.add("Browser", "version", "ok", version)
+	}
+
+	page, err := browser.NewPage()
+	if err != nil {
+		r.add("Browser", "tab", "fail", err.Error())
+		return
+	}
+	defer page.Close(context.Background())
+
+	fingerprinted := false
+	for _, testPage := range testPages {
+		loadCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := page.Navigate(loadCtx, testPage)
+		if err == nil {
+			err = page.WaitLoad(loadCtx)
+		}
+		cancel()
+		if err != nil {
+			r.add("Test pages", testPage, "warn", fmt.Sprintf("didn't load: %v", err))
+			continue
+		}
+		state, _ := page.Eval(ctx, pageStateJS)
+		r.add("Test pages", testPage, "ok", fmt.Sprintf("loaded %q", strings.TrimSpace(state.Get("title").Str())))
+		if !fingerprinted {
+			r.checkFingerprint(ctx, page)
+			fingerprinted = true
+		}
+	}
+	if !fingerprinted {
+		r.checkFingerprint(ctx, page)
+	}
+
+	if baseURL != "" {
+		r.checkBlog(ctx, page, baseURL, timeout, proxy)
+	}
+}
+
+// checkFingerprint looks for what gives the browser away as automated
+func (r *doctorReport) checkFingerprint(ctx context.Context, page Page) {
+	const group = "Bot signals"
+	fp, err := page.Eval(ctx, fingerprintJS)
+	if err != nil {
+		r.add(group, "fingerprint", "fail", fmt.Sprintf("couldn't read: %v", err))
+		return
+	}
+
+	userAgent := fp.Get("userAgent").Str()
+	if strings.Contains(userAgent, "HeadlessChrome") {
+		r.add(group, "user agent", "fail", "says HeadlessChrome, which many sites block outright: "+userAgent)
+	} else {
+		r.add(group, "user agent", "ok", userAgent)
+	}
+	if fp.Get("webdriver").Bool() {
+		r.add(group, "webdriver flag", "fail", "navigator.webdriver is true")
+	} else {
+		r.add(group, "webdriver flag", "ok", "navigator.webdriver is not set")
+	}
+	signal := func(name string, ok bool, good, bad string) {
+		if ok {
+			r.add(group, name, "ok", good)
+		} else {
+			r.add(group, name, "warn", bad)
+		}
+	}
+	plugins := fp.Get("plugins").Int()
+	signal("plugins", plugins > 0, fmt.Sprintf("%d plugins", plugins), "no plugins, as in headless Chrome")
+	signal("languages", fp.Get("languages").Int() > 0, "navigator.languages is set", "navigator.languages is empty")
+	signal("window.chrome", fp.Get("chrome").Bool(), "present", "missing, as in headless Chrome")
+	notification, permission := fp.Get("notification").Str(), fp.Get("permission").Str()
+	signal("permissions", !(notification == "denied" && permission == "prompt"),
+		"notification permissions agree", "Notification.permission is denied while the permissions API says prompt, a headless tell")
+	webgl := fp.Get("webgl").Str()
+	software := strings.Contains(webgl, "SwiftShader") || strings.Contains(webgl, "llvmpipe")
+	signal("WebGL renderer", !software, webgl, "software renderer "+webgl+", typical of headless and virtual machines")
+	signal("window size", fp.Get("outerWidth").Int() > 0, "has an outer window", "window.outerWidth is 0, as in headless Chrome")
+}
+
+// checkBlog checks that the blog resolves, answers plain HTTP and loads in
+// the browser without a bot wall
+func (r *doctorReport) checkBlog(ctx context.Context, page Page, baseURL string, timeout time.Duration, proxy string) {
+	const group = "Blog"
+	parsedURL, err := url.Parse(baseURL)
+	if err != nil || parsedURL.Host == "" {
+		r.add(group, "URL", "fail", fmt.Sprintf("invalid URL %q", baseURL))
+		return
+	}
+
+	if proxy == "" {
+		lookupCtx, cancel := context.WithTimeout(ctx, timeout)
+		addrs, err := net.DefaultResolver.LookupHost(lookupCtx, parsedURL.Hostname())
+		cancel()
+		if err != nil {
+			r.add(group, "DNS", "fail", err.Error())
+			return
+		}
+		r.add(group, "DNS", "ok", strings.Join(addrs, ", "))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL, nil)
+	if err == nil {
+		req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; manual-blog-crawler)")
+		start := time.Now()
+		resp, err := crawlClient(timeout, proxy).Do(req)
+		switch {
+		case err != nil:
+			r.add(group, "HTTP", "fail", err.Error())
+		case resp.StatusCode >= 400:
+			resp.Body.Close()
+			r.add(group, "HTTP", "warn", fmt.Sprintf("%s in %v without a browser", resp.Status, time.Since(start).Round(time.Millisecond)))
+		default:
+			resp.Body.Close()
+			r.add(group, "HTTP", "ok", fmt.Sprintf("%s in %v", resp.Status, time.Since(start).Round(time.Millisecond)))
+		}
+	}
+
+	loadCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	if err := page.Navigate(loadCtx, baseURL); err != nil {
+		r.add(group, "browser", "fail", err.Error())
+		return
+	}
+	if err := page.WaitLoad(loadCtx); err != nil {
+		r.add(group, "browser", "fail", err.Error())
+		return
+	}
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	bc := NewBlogCrawler(baseURL, timeout, CrawlOptions{})
+	bc.page = page
+	state, _ := page.Eval(ctx, pageStateJS)
+	title := strings.TrimSpace(state.Get("title").Str())
+	switch reason := bc.emptyPageReason(ctx); {
+	case strings.HasPrefix(reason, "blocked"):
+		r.add(group, "browser", "fail", fmt.Sprintf("%s: %q", reason, title))
+	case reason != "":
+		r.add(group, "browser", "warn", fmt.Sprintf("%s: %q", reason, title))
+	default:
+		r.add(group, "browser", "ok", fmt.Sprintf("loaded %q in %v, %d links", title, elapsed, state.Get("links").Int()))
+	}
+}
+
+// print writes the report grouped as it was checked
+func (r *doctorReport) print() {
+	group := ""
+	for _, check := range r.Checks {
+		if check.Group != group {
+			group = check.Group
+			fmt.Printf("%s\n", group)
+		}
+		fmt.Printf("  %-5s %-16s %s\n", check.Status, check.Name, check.Detail)
+	}
+	if r.failed() {
+		fmt.Println("\nSome checks failed: the crawler is likely to be flagged or can't reach the blog")
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDoctorChecks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body>Blog</body></html>"))
+	}))
+	defer server.Close()
+
+	const testPage = "https://bot-test.example.com/"
+	chrome := &fakeChrome{site: map[string]string{
+		testPage: `<html><head><title>Bot test</title></head><body>Tests</body></html>`,
+		server.URL + "/": `<html><head><title>Blocked</title></head><body>` +
+			`<h1>Just a moment...</h1><p>Checking if the site connection is secure</p></body></html>`,
+	}}
+	browser, _ := chrome.launch(context.Background())
+	report := &doctorReport{}
+	report.checkBrowser(context.Background(), browser, []string{testPage}, server.URL+"/", 10*time.Second, "")
+
+	statuses := make(map[string]string)
+	for _, check := range report.Checks {
+		statuses[check.Group+": "+check.Name] = check.Status
+	}
+	want := map[string]string{
+		"Browser: version":            "ok",
+		"Test pages: " + testPage:     "ok",
+		"Bot signals: user agent":     "fail",
+		"Bot signals: webdriver flag": "ok",
+		"Bot signals: plugins":        "warn",
+		"Bot signals: permissions":    "warn",
+		"Bot signals: WebGL renderer": "warn",
+		"Bot signals: window size":    "ok",
+		"Blog: DNS":                   "ok",
+		"Blog: HTTP":                  "ok",
+		"Blog: browser":               "fail",
+	}
+	for name, status := range want {
+		if statuses[name] != status {
+			t.Errorf("%s is %q, want %q", name, statuses[name], status)
+		}
+	}
+	if !report.failed() {
+		t.Error("report with a headless user agent and a blocked blog didn't fail")
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+// explain prints the classification of a candidate link in --dry-run mode.
+// Every link is printed once per crawl, however often it appears.
+func (bc *BlogCrawler) explain(linkURL string, accepted bool, rule string) {
+	if !bc.opts.DryRun || bc.explained[linkURL] {
+		return
+	}
+	if bc.explained == nil {
+		bc.explained = make(map[string]bool)
+	}
+	bc.explained[linkURL] = true
+
+	decision := "reject"
+	if accepted {
+		decision = "accept"
+	}
+	bc.printf("  %s %s (%s)\n", decision, linkURL, rule)
+}
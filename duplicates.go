@@ -0,0 +1,97 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxDuplicateGroup is the largest number of posts sharing a title that
+// still counts as duplicates. Bigger groups usually share a generic title,
+// like the blog's name on pages whose own title couldn't be extracted.
+const maxDuplicateGroup = 5
+
+// DuplicateTitle is a group of posts with the same title under different
+// URLs, often variants that URL normalization missed (tracking parameters,
+// locale prefixes)
+type DuplicateTitle struct {
+	Title     string   `json:"title"`
+	URLs      []string `json:"urls"`      // The URL kept first
+	Collapsed bool     `json:"collapsed"` // The others were removed from the result
+}
+
+// titleKey is the form of a title duplicates are matched on
+func titleKey(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+// keepFirst orders the URLs of a duplicate group: the shortest one, usually
+// the one without tracking parameters or locale prefix, is kept
+func keepFirst(urls []string) {
+	sort.Slice(urls, func(i, j int) bool {
+		if len(urls[i]) != len(urls[j]) {
+			return len(urls[i]) < len(urls[j])
+		}
+		return urls[i] < urls[j]
+	})
+}
+
+// findDuplicateTitles groups the posts sharing a title. Every post but the
+// one kept of each group gets DuplicateOf set; with opts.CollapseDuplicates
+// they're removed from urls and posts instead, which are returned.
+func (bc *BlogCrawler) findDuplicateTitles(urls []string, posts []Post) ([]string, []Post) {
+	groups := make(map[string][]string)
+	titles := make(map[string]string)
+	for _, post := range posts {
+		key := titleKey(post.Title)
+		if key == "" {
+			continue
+		}
+		groups[key] = append(groups[key], post.URL)
+		if _, ok := titles[key]; !ok {
+			titles[key] = post.Title
+		}
+	}
+
+	duplicateOf := make(map[string]string)
+	for key, group := range groups {
+		if len(group) < 2 || len(group) > maxDuplicateGroup {
+			continue
+		}
+		keepFirst(group)
+		for _, url := range group[1:] {
+			duplicateOf[url] = group[0]
+		}
+		bc.duplicates = append(bc.duplicates, DuplicateTitle{
+			Title:     titles[key],
+			URLs:      group,
+			Collapsed: bc.opts.CollapseDuplicates,
+		})
+	}
+	if len(bc.duplicates) == 0 {
+		return urls, posts
+	}
+	sort.Slice(bc.duplicates, func(i, j int) bool { return bc.duplicates[i].URLs[0] < bc.duplicates[j].URLs[0] })
+
+	if !bc.opts.CollapseDuplicates {
+		for i := range posts {
+			posts[i].DuplicateOf = duplicateOf[posts[i].URL]
+		}
+		bc.printf("Found %d titles shared by several URLs (see stats.duplicate_titles)\n", len(bc.duplicates))
+		return urls, posts
+	}
+
+	keptURLs := urls[:0]
+	for _, url := range urls {
+		if duplicateOf[url] == "" {
+			keptURLs = append(keptURLs, url)
+		}
+	}
+	keptPosts := posts[:0]
+	for _, post := range posts {
+		if duplicateOf[post.URL] == "" {
+			keptPosts = append(keptPosts, post)
+		}
+	}
+	bc.printf("Collapsed %d duplicate URLs with the same title as another post\n", len(duplicateOf))
+	return keptURLs, keptPosts
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFindDuplicateTitles(t *testing.T) {
+	const (
+		queue        = "https://blog.example.com/scaling-the-ingest-queue"
+		queueTracked = "https://blog.example.com/scaling-the-ingest-queue?ref=feed"
+		queueLocale  = "https://blog.example.com/en/scaling-the-ingest-queue"
+		search       = "https://blog.example.com/moving-search-to-rust"
+	)
+	// More posts sharing a title than a duplicate group has, like pages
+	// whose own title couldn't be extracted
+	var untitled []Post
+	for i := 0; i <= maxDuplicateGroup; i++ {
+		untitled = append(untitled, Post{URL: fmt.Sprintf("https://blog.example.com/p/%d", i), Title: "Engineering Blog"})
+	}
+
+	tests := []struct {
+		name        string
+		posts       []Post
+		collapse    bool
+		wantURLs    []string
+		duplicateOf map[string]string
+		wantGroups  []DuplicateTitle
+	}{
+		{
+			name:     "no duplicates",
+			posts:    []Post{{URL: queue, Title: "Scaling the ingest queue"}, {URL: search, Title: "Moving search to Rust"}, {URL: queueTracked}},
+			wantURLs: []string{queue, search, queueTracked},
+		},
+		{
+			name: "case and whitespace",
+			posts: []Post{
+				{URL: queueTracked, Title: "Scaling the Ingest Queue"},
+				{URL: queue, Title: "  scaling the\tingest  queue "},
+				{URL: search, Title: "Moving search to Rust"},
+				{URL: queueLocale, Title: "SCALING THE INGEST QUEUE"},
+			},
+			wantURLs:    []string{queueTracked, queue, search, queueLocale},
+			duplicateOf: map[string]string{queueTracked: queue, queueLocale: queue},
+			wantGroups:  []DuplicateTitle{{Title: "Scaling the Ingest Queue", URLs: []string{queue, queueLocale, queueTracked}}},
+		},
+		{
+			name:       "collapsed",
+			posts:      []Post{{URL: queueTracked, Title: "Scaling the ingest queue"}, {URL: queue, Title: "Scaling the ingest queue"}, {URL: search, Title: "Moving search to Rust"}},
+			collapse:   true,
+			wantURLs:   []string{queue, search},
+			wantGroups: []DuplicateTitle{{Title: "Scaling the ingest queue", URLs: []string{queue, queueTracked}, Collapsed: true}},
+		},
+		{
+			name:     "generic title",
+			posts:    untitled,
+			wantURLs: []string{untitled[0].URL, untitled[1].URL, untitled[2].URL, untitled[3].URL, untitled[4].URL, untitled[5].URL},
+		},
+	}
+	for _, tt := range tests {
+		var urls []string
+		for _, post := range tt.posts {
+			urls = append(urls, post.URL)
+		}
+		bc := NewBlogCrawler("https://blog.example.com/", 5*time.Second, CrawlOptions{CollapseDuplicates: tt.collapse, Output: io.Discard})
+		gotURLs, gotPosts := bc.findDuplicateTitles(urls, append([]Post(nil), tt.posts...))
+
+		if !reflect.DeepEqual(gotURLs, tt.wantURLs) {
+			t.Errorf("%s: urls = %q, want %q", tt.name, gotURLs, tt.wantURLs)
+		}
+		if len(gotPosts) != len(tt.wantURLs) {
+			t.Errorf("%s: kept %d posts, want %d", tt.name, len(gotPosts), len(tt.wantURLs))
+		}
+		for _, post := range gotPosts {
+			if post.DuplicateOf != tt.duplicateOf[post.URL] {
+				t.Errorf("%s: %s is a duplicate of %q, want %q", tt.name, post.URL, post.DuplicateOf, tt.duplicateOf[post.URL])
+			}
+		}
+		if !reflect.DeepEqual(bc.duplicates, tt.wantGroups) {
+			t.Errorf("%s: duplicate titles = %+v, want %+v", tt.name, bc.duplicates, tt.wantGroups)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod/lib/launcher"
+)
+
+// requireBrowser skips end-to-end tests where there's no Chrome or
+// Chromium to drive, and in -short mode
+func requireBrowser(t *testing.T) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("end-to-end test skipped in -short mode")
+	}
+	if _, found := launcher.LookPath(); !found {
+		t.Skip("no Chrome or Chromium found")
+	}
+}
+
+// crawlFixture runs a full crawl of the synthetic blog at path on server
+func crawlFixture(t *testing.T, server *httptest.Server, path string, opts CrawlOptions) *CrawlResult {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	result, err := NewBlogCrawler(server.URL+path, 30*time.Second, opts).crawl(ctx)
+	if err != nil {
+		t.Fatalf("crawl of %s failed: %v", path, err)
+	}
+	for _, warning := range result.Errors {
+		t.Logf("crawl warning: %s", warning)
+	}
+	return result
+}
+
+// sortedURLs returns the result's post URLs in order, for comparison
+func sortedURLs(result *CrawlResult) []string {
+	urls := append([]string(nil), result.BlogURLs...)
+	sort.Strings(urls)
+	return urls
+}
+
+func TestCrawlPaginatedBlog(t *testing.T) {
+	requireBrowser(t)
+	server := newFixtureBlog(t)
+
+	result := crawlFixture(t, server, "/paged/", CrawlOptions{})
+
+	want := fixtureURLs(server, "paged", fixturePagedPosts)
+	if got := sortedURLs(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("post URLs\n got: %q\nwant: %q", got, want)
+	}
+	if pages := (fixturePagedPosts + fixturePageSize - 1) / fixturePageSize; len(result.Pages) != pages {
+		t.Errorf("crawled %d listing pages, want %d", len(result.Pages), pages)
+	}
+	for _, page := range result.Pages {
+		if page.Error != "" {
+			t.Errorf("listing page %s failed: %s", page.URL, page.Error)
+		}
+	}
+}
+
+func TestCrawlInfiniteScroll(t *testing.T) {
+	requireBrowser(t)
+	server := newFixtureBlog(t)
+
+	result := crawlFixture(t, server, "/scroll/", CrawlOptions{
+		Strategy:        "scroll",
+		ScrollDelay:     500 * time.Millisecond,
+		ScrollIdleLimit: 3,
+	})
+
+	want := fixtureURLs(server, "scroll", fixtureScrollPosts)
+	if got := sortedURLs(result); !reflect.DeepEqual(got, want) {
+		t.Errorf("post URLs\n got: %q\nwant: %q", got, want)
+	}
+	if result.Stats == nil || result.Stats.ScrollIterations < fixtureScrollPosts/fixtureScrollBatch {
+		t.Errorf("stats = %+v, want at least %d scrolls", result.Stats, fixtureScrollPosts/fixtureScrollBatch)
+	}
+}
+
+func TestCrawlStopsAtMaxURLs(t *testing.T) {
+	requireBrowser(t)
+	server := newFixtureBlog(t)
+
+	result := crawlFixture(t, server, "/scroll/", CrawlOptions{
+		Strategy:    "scroll",
+		ScrollDelay: 500 * time.Millisecond,
+		MaxURLs:     2 * fixtureScrollBatch,
+	})
+
+	if len(result.BlogURLs) < 2*fixtureScrollBatch || len(result.BlogURLs) >= fixtureScrollPosts {
+		t.Errorf("found %d post URLs, want the crawl to stop soon after %d", len(result.BlogURLs), 2*fixtureScrollBatch)
+	}
+}
+
+func TestCrawlFetchContent(t *testing.T) {
+	requireBrowser(t)
+	server := newFixtureBlog(t)
+
+	result := crawlFixture(t, server, "/paged/", CrawlOptions{FetchContent: true, Sort: "url"})
+
+	if len(result.Posts) != fixturePagedPosts {
+		t.Fatalf("got %d posts, want %d", len(result.Posts), fixturePagedPosts)
+	}
+	titles := make(map[string]string)
+	for _, post := range fixturePosts("paged", fixturePagedPosts) {
+		titles[server.URL+"/paged/"+post.Slug] = post.Title
+	}
+	for _, post := range result.Posts {
+		if post.Title != titles[post.URL] {
+			t.Errorf("title of %s = %q, want %q", post.URL, post.Title, titles[post.URL])
+		}
+		if post.Status != 200 || post.Published == "" || !strings.Contains(post.Content, "Paragraph 1") {
+			t.Errorf("post %s: status %d, published %q, content %.40q", post.URL, post.Status, post.Published, post.Content)
+		}
+	}
+}
+
+func TestCrawlRecordsCrossLinks(t *testing.T) {
+	requireBrowser(t)
+	server := newFixtureBlog(t)
+
+	// Visiting the posts runs pageLinksJS on each in the browser
+	result := crawlFixture(t, server, "/paged/", CrawlOptions{FetchContent: true, Sort: "url"})
+
+	posts := fixturePosts("paged", fixturePagedPosts)
+	previous := make(map[string]string)
+	for i := 0; i+1 < len(posts); i++ {
+		previous[server.URL+"/paged/"+posts[i].Slug] = server.URL + "/paged/" + posts[i+1].Slug
+	}
+	if len(result.Posts) != fixturePagedPosts {
+		t.Fatalf("got %d posts, want %d", len(result.Posts), fixturePagedPosts)
+	}
+	for _, post := range result.Posts {
+		var want []string
+		if link, ok := previous[post.URL]; ok {
+			want = []string{link}
+		}
+		if !reflect.DeepEqual(post.Links, want) {
+			t.Errorf("links of %s = %q, want %q", post.URL, post.Links, want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// blockMarkers are phrases of bot checks and access-denied pages, matched
+// against the lowercased title and text of a page
+var blockMarkers = []string{
+	"captcha",
+	"access denied",
+	"just a moment",      // Cloudflare
+	"attention required", // Cloudflare
+	"unusual traffic",    // Google and others
+	"are you a robot",
+	"verify you are human",
+	"request blocked",
+	"too many requests",
+}
+
+// defaultMaxEmptyPages is the default of --max-empty-pages. One more than
+// a single page, so one page that hiccups even after its retry doesn't end
+// a crawl of dozens.
+const defaultMaxEmptyPages = 2
+
+// maxEmptyPages is the number of empty or failed listing pages in a row
+// that ends a paginated crawl
+func (o CrawlOptions) maxEmptyPages() int {
+	if o.MaxEmptyPages > 0 {
+		return o.MaxEmptyPages
+	}
+	return defaultMaxEmptyPages
+}
+
+// crawlListingPage crawls one page of a paginated listing. A page that
+// fails to load is tried once more before the error counts, since a single
+// transient timeout shouldn't end the crawl.
+func (bc *BlogCrawler) crawlListingPage(ctx context.Context, pageNum int, pageURL string) ([]string, error) {
+	if urls, ok := bc.listingFromCache(ctx, pageNum, pageURL); ok {
+		return urls, nil
+	}
+
+	urls, err := bc.crawlSinglePage(ctx, pageURL)
+	if err != nil && ctx.Err() == nil {
+		bc.printf("  Error on page %d: %v. Retrying...\n", pageNum, err)
+		if err := sleepContext(ctx, 5*time.Second); err != nil {
+			return nil, err
+		}
+		urls, err = bc.crawlSinglePage(ctx, pageURL)
+	}
+	if err == nil {
+		bc.cacheListing(pageURL, urls, "")
+	}
+	return urls, err
+}
+
+// minRenderedText is the amount of text below which a page is taken to
+// have failed to render
+const minRenderedText = 200
+
+// pageStateJS reports the current page's HTTP status, title, text and
+// number of links
+const pageStateJS = `() => {
+	const nav = performance.getEntriesByType('navigation')[0];
+	const text = (document.body && document.body.innerText) || '';
+	return {
+		status: (nav && nav.responseStatus) || 0,
+		title: document.title || '',
+		text: text.slice(0, 5000),
+		textLength: text.length,
+		links: document.querySelectorAll('a[href]').length,
+	};
+}`
+
+// emptyPageReason tells a listing page that is really past the end (""),
+// which includes 404s, from one that failed to load or was blocked, in
+// which case it says what's wrong
+func (bc *BlogCrawler) emptyPageReason(ctx context.Context) string {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := bc.page.Eval(ctx, pageStateJS)
+	if err != nil {
+		return fmt.Sprintf("page not readable: %v", err)
+	}
+	state := res
+	status := state.Get("status").Int()
+	content := strings.ToLower(state.Get("title").Str() + "\n" + state.Get("text").Str())
+
+	switch {
+	case status == 404 || status == 410:
+		return ""
+	case status == 403 || status == 429:
+		return fmt.Sprintf("blocked (HTTP %d)", status)
+	case status >= 400:
+		return fmt.Sprintf("HTTP %d", status)
+	}
+	for _, marker := range blockMarkers {
+		if strings.Contains(content, marker) {
+			return fmt.Sprintf("blocked (page says %q)", marker)
+		}
+	}
+	if state.Get("textLength").Int() < minRenderedText || state.Get("links").Int() == 0 {
+		return "page did not render"
+	}
+	return ""
+}
+
+// retryEmptyPage is called when a listing page yields no posts. Past the
+// end of the listing that's expected and it returns nothing. A page that
+// failed to load or was blocked is loaded once more, since stopping there
+// would silently truncate the crawl; if that doesn't help either, the
+// page's error says why the crawl stopped early.
+func (bc *BlogCrawler) retryEmptyPage(ctx context.Context, pageNum int, pageURL string) []string {
+	reason := bc.emptyPageReason(ctx)
+	if reason == "" {
+		return nil
+	}
+
+	bc.printf("  Page %d came back empty: %s. Retrying...\n", pageNum, reason)
+	if err := sleepContext(ctx, 5*time.Second); err != nil {
+		return nil
+	}
+	urls, err := bc.crawlSinglePage(ctx, pageURL)
+	if err == nil && len(urls) > 0 {
+		bc.pages[len(bc.pages)-1].URLsFound = len(urls)
+		return urls
+	}
+	if err == nil {
+		reason = bc.emptyPageReason(ctx)
+	} else {
+		reason = err.Error()
+	}
+	if reason != "" {
+		bc.warnf("Page %d (%s) is empty: %s; results may be truncated", pageNum, pageURL, reason)
+		bc.pages[len(bc.pages)-1].Error = reason
+	}
+	return nil
+}
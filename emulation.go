@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/devices"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// defaultDevice is what rod emulates in every tab unless told otherwise:
+// a 1280x800 laptop screen with a desktop Chrome user agent and "en"
+var defaultDevice = devices.LaptopWithMDPIScreen.Landscape()
+
+// browserEmulation is how the browser presents itself to a blog. Some
+// blogs list a different number of posts per page at different widths, or
+// redirect by Accept-Language. Zero fields keep the defaults of
+// defaultDevice and the host's timezone.
+type browserEmulation struct {
+	Width             int     `json:"width,omitempty"`  // Viewport in CSS pixels
+	Height            int     `json:"height,omitempty"` // Viewport in CSS pixels
+	DeviceScaleFactor float64 `json:"device_scale_factor,omitempty"`
+	Timezone          string  `json:"timezone,omitempty"`        // IANA name like "Europe/Berlin"
+	AcceptLanguage    string  `json:"accept_language,omitempty"` // Like "de-DE,de;q=0.9,en;q=0.8"
+}
+
+// or fills the fields e leaves unset from fallback. The viewport's width
+// and height go together.
+func (e browserEmulation) or(fallback browserEmulation) browserEmulation {
+	if e.Width == 0 && e.Height == 0 {
+		e.Width, e.Height = fallback.Width, fallback.Height
+	}
+	if e.DeviceScaleFactor == 0 {
+		e.DeviceScaleFactor = fallback.DeviceScaleFactor
+	}
+	if e.Timezone == "" {
+		e.Timezone = fallback.Timezone
+	}
+	if e.AcceptLanguage == "" {
+		e.AcceptLanguage = fallback.AcceptLanguage
+	}
+	return e
+}
+
+// validate checks the settings Chrome would otherwise reject on every tab
+func (e browserEmulation) validate() error {
+	if e.Width < 0 || e.Height < 0 || (e.Width == 0) != (e.Height == 0) {
+		return fmt.Errorf("viewport %dx%d needs a positive width and height", e.Width, e.Height)
+	}
+	if e.DeviceScaleFactor < 0 {
+		return fmt.Errorf("device scale factor %g is negative", e.DeviceScaleFactor)
+	}
+	if e.Timezone != "" {
+		if _, err := time.LoadLocation(e.Timezone); err != nil {
+			return fmt.Errorf("unknown timezone %q", e.Timezone)
+		}
+	}
+	return nil
+}
+
+// parseViewport reads a viewport given as WIDTHxHEIGHT, like "1920x1080"
+func parseViewport(s string) (int, int, error) {
+	width, height, ok := strings.Cut(strings.ToLower(s), "x")
+	w, werr := strconv.Atoi(width)
+	h, herr := strconv.Atoi(height)
+	if !ok || werr != nil || herr != nil || w <= 0 || h <= 0 {
+		return 0, 0, fmt.Errorf("%q is not a viewport like 1920x1080", s)
+	}
+	return w, h, nil
+}
+
+// emulation returns the blog's emulation settings: its site profile's,
+// field by field over opts.Emulation
+func (bc *BlogCrawler) emulation() browserEmulation {
+	return bc.site.Emulation.or(bc.opts.Emulation)
+}
+
+// emulate applies the blog's emulation settings to a new tab
+func (bc *BlogCrawler) emulate(tab *rod.Page) error {
+	e := bc.emulation()
+	if e.Width > 0 || e.DeviceScaleFactor > 0 {
+		metrics := defaultDevice.MetricsEmulation()
+		if e.Width > 0 {
+			metrics.Width, metrics.Height = e.Width, e.Height
+		}
+		if e.DeviceScaleFactor > 0 {
+			metrics.DeviceScaleFactor = e.DeviceScaleFactor
+		}
+		if err := tab.SetViewport(metrics); err != nil {
+			return fmt.Errorf("failed to set the viewport: %w", err)
+		}
+	}
+	if e.Timezone != "" {
+		if err := (proto.EmulationSetTimezoneOverride{TimezoneID: e.Timezone}).Call(tab); err != nil {
+			return fmt.Errorf("failed to set the timezone: %w", err)
+		}
+	}
+	if e.AcceptLanguage != "" {
+		// Sets navigator.languages as well as the header
+		userAgent := defaultDevice.UserAgentEmulation()
+		userAgent.AcceptLanguage = e.AcceptLanguage
+		if err := tab.SetUserAgent(userAgent); err != nil {
+			return fmt.Errorf("failed to set Accept-Language: %w", err)
+		}
+	}
+	return nil
+}
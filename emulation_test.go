@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestBrowserEmulation(t *testing.T) {
+	if width, height, err := parseViewport("1920X1080"); err != nil || width != 1920 || height != 1080 {
+		t.Errorf("parseViewport(1920X1080) = %d, %d, %v", width, height, err)
+	}
+	for _, bad := range []string{"1920", "1920x", "0x600", "wide x tall"} {
+		if _, _, err := parseViewport(bad); err == nil {
+			t.Errorf("parseViewport(%q) accepted it", bad)
+		}
+	}
+
+	flags := browserEmulation{Width: 1280, Height: 800, Timezone: "America/New_York", AcceptLanguage: "en-US"}
+	profile := browserEmulation{Width: 390, Height: 844, DeviceScaleFactor: 3, AcceptLanguage: "de-DE"}
+	want := browserEmulation{Width: 390, Height: 844, DeviceScaleFactor: 3, Timezone: "America/New_York", AcceptLanguage: "de-DE"}
+	if got := profile.or(flags); got != want {
+		t.Errorf("profile over flags = %+v, want %+v", got, want)
+	}
+
+	for _, bad := range []browserEmulation{{Width: 800}, {DeviceScaleFactor: -1}, {Timezone: "Mars/Olympus_Mons"}} {
+		if bad.validate() == nil {
+			t.Errorf("%+v validated", bad)
+		}
+	}
+	if err := want.validate(); err != nil {
+		t.Errorf("%+v didn't validate: %v", want, err)
+	}
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// Variables so tests can point them at a local server
+var (
+	hnSearchEndpoint      = "https://hn.algolia.com/api/v1/search"
+	devToArticlesEndpoint = "https://dev.to/api/articles/"
+)
+
+// Engagement is how readers responded to a post, as far as its platform or
+// Hacker News shows it
+type Engagement struct {
+	Claps      int    `json:"claps,omitempty"`     // Medium
+	Reactions  int    `json:"reactions,omitempty"` // dev.to, and likes declared in structured data
+	Comments   int    `json:"comments,omitempty"`  // Medium responses, dev.to comments, structured data
+	HNURL      string `json:"hn_url,omitempty"`    // The post's Hacker News discussion with the most points
+	HNPoints   int    `json:"hn_points,omitempty"`
+	HNComments int    `json:"hn_comments,omitempty"`
+}
+
+// score sums up the engagement for --sort popularity. Every clap, reaction,
+// comment and point counts the same.
+func (e *Engagement) score() int {
+	if e == nil {
+		return 0
+	}
+	return e.Claps + e.Reactions + e.Comments + e.HNPoints + e.HNComments
+}
+
+// engagementJS reads Medium's clap and response counts and the pages'
+// structured data, which recordEngagement makes sense of
+const engagementJS = `() => {
+	const text = (selector) => {
+		const node = document.querySelector(selector);
+		return node ? (node.innerText || node.textContent || '').trim() : '';
+	};
+	return {
+		claps: text('.pw-multi-vote-count'),
+		responses: text('.pw-responses-count'),
+		structured: Array.from(document.querySelectorAll('script[type="application/ld+json"]'), s => s.textContent),
+	};
+}`
+
+// recordEngagement reads the engagement the loaded post page shows
+func (bc *BlogCrawler) recordEngagement(ctx context.Context, post *Post) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := bc.page.Eval(ctx, engagementJS)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate engagement script: %w", err)
+	}
+	var structured []string
+	for _, s := range res.Get("structured").Arr() {
+		structured = append(structured, s.Str())
+	}
+	post.Engagement = pageEngagement(res.Get("claps").Str(), res.Get("responses").Str(), structured)
+	return nil
+}
+
+// documentEngagement mirrors engagementJS and recordEngagement on a parsed
+// page
+func documentEngagement(doc *html.Node) *Engagement {
+	text := func(selector string) string {
+		if node := cascadia.Query(doc, cascadia.MustCompile(selector)); node != nil {
+			return strings.TrimSpace(nodeText(node))
+		}
+		return ""
+	}
+	var structured []string
+	for _, script := range cascadia.QueryAll(doc, cascadia.MustCompile(`script[type="application/ld+json"]`)) {
+		if script.FirstChild != nil {
+			structured = append(structured, script.FirstChild.Data)
+		}
+	}
+	return pageEngagement(text(".pw-multi-vote-count"), text(".pw-responses-count"), structured)
+}
+
+// pageEngagement puts together the engagement of a post page from Medium's
+// clap and response counts and the schema.org commentCount and
+// interactionStatistic of its structured data. It returns nil when the
+// page shows none.
+func pageEngagement(claps, responses string, structured []string) *Engagement {
+	e := &Engagement{Claps: parseCount(claps), Comments: parseCount(responses)}
+	for _, source := range structured {
+		var data any
+		if json.Unmarshal([]byte(source), &data) == nil {
+			structuredEngagement(data, e)
+		}
+	}
+	if *e == (Engagement{}) {
+		return nil
+	}
+	return e
+}
+
+// structuredEngagement adds the counts found anywhere in a JSON-LD document
+// to e. Of several counts of the same kind the largest is kept: a page
+// often declares its article both on its own and inside a @graph.
+func structuredEngagement(data any, e *Engagement) {
+	switch v := data.(type) {
+	case []any:
+		for _, item := range v {
+			structuredEngagement(item, e)
+		}
+	case map[string]any:
+		if count, ok := jsonCount(v["commentCount"]); ok {
+			e.Comments = max(e.Comments, count)
+		}
+		if interaction, ok := v["interactionType"]; ok {
+			count, _ := jsonCount(v["userInteractionCount"])
+			kind := fmt.Sprint(interaction)
+			if m, ok := interaction.(map[string]any); ok {
+				kind = fmt.Sprint(m["@type"])
+			}
+			switch {
+			case strings.HasSuffix(kind, "LikeAction"):
+				e.Reactions = max(e.Reactions, count)
+			case strings.HasSuffix(kind, "CommentAction"):
+				e.Comments = max(e.Comments, count)
+			}
+		}
+		for key, value := range v {
+			if key != "commentCount" && key != "userInteractionCount" {
+				structuredEngagement(value, e)
+			}
+		}
+	}
+}
+
+// jsonCount reads a count given as a JSON number or string
+func jsonCount(value any) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int(v), true
+	case string:
+		return parseCount(v), v != ""
+	}
+	return 0, false
+}
+
+// countPattern matches a count as pages show it: 12, 1,234, 1.2K or 3M
+var countPattern = regexp.MustCompile(`(\d[\d,]*(?:\.\d+)?)\s*([KkMm])?`)
+
+// parseCount reads the first count in text, 0 when there is none
+func parseCount(text string) int {
+	match := countPattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0
+	}
+	n, err := strconv.ParseFloat(strings.ReplaceAll(match[1], ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	switch strings.ToLower(match[2]) {
+	case "k":
+		n *= 1000
+	case "m":
+		n *= 1000000
+	}
+	return int(n)
+}
+
+// getJSON requests an API of another site and decodes its JSON answer
+// into v. It returns false without an error when the API doesn't know the
+// resource.
+func (bc *BlogCrawler) getJSON(ctx context.Context, requestURL string, v any) (bool, error) {
+	if err := bc.budget.request(); err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := bc.http.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode != http.StatusOK:
+		return false, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return false, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return true, nil
+}
+
+// devToEngagement asks the dev.to API for the reactions and comments of a
+// dev.to post, nil for posts on other hosts
+func (bc *BlogCrawler) devToEngagement(ctx context.Context, postURL string) (*Engagement, error) {
+	parsedURL, err := url.Parse(postURL)
+	if err != nil || strings.TrimPrefix(parsedURL.Hostname(), "www.") != "dev.to" {
+		return nil, nil
+	}
+	var article struct {
+		Reactions int `json:"public_reactions_count"`
+		Comments  int `json:"comments_count"`
+	}
+	found, err := bc.getJSON(ctx, devToArticlesEndpoint+strings.Trim(parsedURL.Path, "/"), &article)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &Engagement{Reactions: article.Reactions, Comments: article.Comments}, nil
+}
+
+// hnDiscussion finds the Hacker News story with the most points that
+// submitted postURL, nil when it was never submitted
+func (bc *BlogCrawler) hnDiscussion(ctx context.Context, postURL string) (*Engagement, error) {
+	params := url.Values{
+		"query":                        {postURL},
+		"restrictSearchableAttributes": {"url"},
+		"tags":                         {"story"},
+	}
+	var search struct {
+		Hits []struct {
+			ID          string `json:"objectID"`
+			URL         string `json:"url"`
+			Points      int    `json:"points"`
+			NumComments int    `json:"num_comments"`
+		} `json:"hits"`
+	}
+	if _, err := bc.getJSON(ctx, hnSearchEndpoint+"?"+params.Encode(), &search); err != nil {
+		return nil, err
+	}
+
+	// The search matches words of the URL; only the post itself counts
+	var best *Engagement
+	for _, hit := range search.Hits {
+		if sameURL(hit.URL, postURL) && (best == nil || hit.Points > best.HNPoints) {
+			best = &Engagement{
+				HNURL:      "https://news.ycombinator.com/item?id=" + hit.ID,
+				HNPoints:   hit.Points,
+				HNComments: hit.NumComments,
+			}
+		}
+	}
+	return best, nil
+}
+
+// sameURL reports whether two URLs point at the same page, whatever their
+// scheme, www. prefix or trailing slash
+func sameURL(a, b string) bool {
+	key := func(u string) string {
+		u = strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(u), "https://"), "http://")
+		return strings.TrimSuffix(strings.TrimPrefix(u, "www."), "/")
+	}
+	return key(a) == key(b)
+}
+
+// lookupEngagement adds what the dev.to API and Hacker News know of each
+// post to the engagement read from its page
+func (bc *BlogCrawler) lookupEngagement(ctx context.Context, posts []Post) {
+	for i := range posts {
+		if ctx.Err() != nil {
+			return
+		}
+		post := &posts[i]
+
+		devTo, err := bc.devToEngagement(ctx, post.URL)
+		if err != nil {
+			bc.warnf("Error reading the dev.to reactions of %s: %v", post.URL, err)
+		}
+		hn, err := bc.hnDiscussion(ctx, post.URL)
+		if err != nil {
+			bc.warnf("Error searching Hacker News for %s: %v", post.URL, err)
+		}
+		post.Engagement = mergeEngagement(post.Engagement, devTo, hn)
+	}
+}
+
+// mergeEngagement combines the engagement from several sources, keeping
+// the largest of each count. It returns nil when none has any.
+func mergeEngagement(sources ...*Engagement) *Engagement {
+	var merged *Engagement
+	for _, e := range sources {
+		if e == nil {
+			continue
+		}
+		if merged == nil {
+			merged = &Engagement{}
+		}
+		merged.Claps = max(merged.Claps, e.Claps)
+		merged.Reactions = max(merged.Reactions, e.Reactions)
+		merged.Comments = max(merged.Comments, e.Comments)
+		if e.HNURL != "" {
+			merged.HNURL, merged.HNPoints, merged.HNComments = e.HNURL, e.HNPoints, e.HNComments
+		}
+	}
+	return merged
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+func TestParseCount(t *testing.T) {
+	for text, want := range map[string]int{"": 0, "12": 12, "1,234": 1234, "1.2K": 1200, "3M": 3000000, "27 responses": 27} {
+		if got := parseCount(text); got != want {
+			t.Errorf("parseCount(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
+
+func TestDocumentEngagement(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want *Engagement
+	}{
+		{"nothing", `<article><p>Text</p></article>`, nil},
+		{"medium", `<div class="pw-multi-vote-count"><p><button>1.2K</button></p></div><span class="pw-responses-count">14</span>`,
+			&Engagement{Claps: 1200, Comments: 14}},
+		{"structured data", `<script type="application/ld+json">{"@graph": [{"@type": "BlogPosting", "commentCount": "8", "interactionStatistic": [
+			{"@type": "InteractionCounter", "interactionType": "https://schema.org/LikeAction", "userInteractionCount": 310},
+			{"@type": "InteractionCounter", "interactionType": {"@type": "CommentAction"}, "userInteractionCount": 9}]}]}</script>`,
+			&Engagement{Reactions: 310, Comments: 9}},
+	}
+	for _, tt := range tests {
+		doc, err := html.Parse(strings.NewReader(`<html><body>` + tt.page + `</body></html>`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := documentEngagement(doc); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: engagement = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLookupEngagement(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/articles/jane/scaling-our-queue-1f2e":
+			fmt.Fprint(w, `{"public_reactions_count": 42, "comments_count": 5}`)
+		case r.URL.Path == "/search" && strings.Contains(r.URL.Query().Get("query"), "scaling-our-queue"):
+			fmt.Fprint(w, `{"hits": [
+				{"objectID": "100", "url": "https://dev.to/jane/scaling-our-queue-1f2e", "points": 12, "num_comments": 3},
+				{"objectID": "200", "url": "http://dev.to/jane/scaling-our-queue-1f2e/", "points": 230, "num_comments": 88},
+				{"objectID": "300", "url": "https://dev.to/jane/scaling-our-queue-the-sequel", "points": 900, "num_comments": 400}]}`)
+		case r.URL.Path == "/search":
+			fmt.Fprint(w, `{"hits": []}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	defer func(hn, devTo string) { hnSearchEndpoint, devToArticlesEndpoint = hn, devTo }(hnSearchEndpoint, devToArticlesEndpoint)
+	hnSearchEndpoint, devToArticlesEndpoint = server.URL+"/search", server.URL+"/api/articles/"
+
+	posts := []Post{
+		{URL: "https://dev.to/jane/scaling-our-queue-1f2e", Engagement: &Engagement{Comments: 7}},
+		{URL: "https://eng.example.com/unnoticed"},
+	}
+	NewBlogCrawler("https://dev.to/jane", 5*time.Second, CrawlOptions{}).lookupEngagement(context.Background(), posts)
+
+	want := &Engagement{Reactions: 42, Comments: 7, HNURL: "https://news.ycombinator.com/item?id=200", HNPoints: 230, HNComments: 88}
+	if !reflect.DeepEqual(posts[0].Engagement, want) {
+		t.Errorf("engagement = %+v, want %+v", posts[0].Engagement, want)
+	}
+	if posts[1].Engagement != nil {
+		t.Errorf("engagement of a post nobody engaged with = %+v, want none", posts[1].Engagement)
+	}
+}
+
+func TestSortByPopularity(t *testing.T) {
+	urls := []string{"https://b.example/quiet", "https://b.example/famous", "https://b.example/liked", "https://b.example/a-quiet-one"}
+	posts := []Post{
+		{URL: "https://b.example/quiet"},
+		{URL: "https://b.example/famous", Engagement: &Engagement{HNPoints: 500, HNComments: 200}},
+		{URL: "https://b.example/liked", Engagement: &Engagement{Claps: 120}},
+		{URL: "https://b.example/a-quiet-one"},
+	}
+	(&BlogCrawler{opts: CrawlOptions{Sort: "popularity"}}).sortURLs(urls, posts)
+
+	want := []string{"https://b.example/famous", "https://b.example/liked", "https://b.example/a-quiet-one", "https://b.example/quiet"}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("URLs by popularity\n got: %q\nwant: %q", urls, want)
+	}
+	if posts[0].URL != want[0] || posts[3].URL != want[3] {
+		t.Errorf("posts not in the same order as the URLs: %s first, %s last", posts[0].URL, posts[3].URL)
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import "manual-blog-crawler/urlfilter"
+
+// excludePatterns compiles the blog's exclude patterns: the defaults,
+// changed by the site profile, then by the command line. Invalid regular
+// expressions were reported when the profile and flags were read.
+func excludePatterns(site siteProfile, opts CrawlOptions) []urlfilter.ExcludePattern {
+	sources := append([]string(nil), urlfilter.Excludes...)
+	sources = site.ExcludePatterns.Apply(sources)
+	sources = urlfilter.ExcludeRule{Add: opts.ExcludePatterns, Remove: opts.RemoveExcludePatterns}.Apply(sources)
+
+	var patterns []urlfilter.ExcludePattern
+	for _, source := range sources {
+		if pattern, err := urlfilter.CompileExclude(source); err == nil {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
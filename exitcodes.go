@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Exit codes of a crawl, so orchestration systems can react to a failure
+// without parsing the output. They are part of the command-line interface:
+// add new ones, don't renumber.
+const (
+	exitOK          = 0
+	exitError       = 1 // Bad flags or configuration, or a failure not listed here
+	exitEmpty       = 2 // No posts found, with --fail-on-empty
+	exitBrowser     = 3 // The browser couldn't be launched or connected to
+	exitNavigation  = 4 // The blog's page didn't load: timed out or couldn't connect
+	exitBlocked     = 5 // A bot wall, captcha or access-denied page instead of the blog
+	exitOutput      = 6 // The result couldn't be saved or an output failed
+	exitPartial     = 7 // Some of the --seeds blogs failed
+	exitInterrupted = 130
+)
+
+// exitCategories name the exit codes in error messages
+var exitCategories = map[int]string{
+	exitError:       "error",
+	exitEmpty:       "empty",
+	exitBrowser:     "browser-launch",
+	exitNavigation:  "navigation",
+	exitBlocked:     "blocked",
+	exitOutput:      "output",
+	exitPartial:     "partial",
+	exitInterrupted: "interrupted",
+}
+
+// codedError is an error with the exit code it ends the run with
+type codedError struct {
+	code int
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+
+// withExitCode gives err the exit code code, nil for a nil err
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: code, err: err}
+}
+
+// exitCode returns the exit code a run failing with err ends with
+func exitCode(err error) int {
+	var coded *codedError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, context.Canceled):
+		return exitInterrupted
+	case errors.As(err, &coded):
+		return coded.code
+	}
+	return exitError
+}
+
+// exitWithError prints err with its failure category to out and exits with
+// its code
+func exitWithError(out io.Writer, err error) {
+	code := exitCode(err)
+	fmt.Fprintf(out, "Error (%s): %s\n", exitCategories[code], redact(err.Error()))
+	os.Exit(code)
+}
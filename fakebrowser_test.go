@@ -0,0 +1,398 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andybalholm/cascadia"
+	"github.com/ysmood/gson"
+	"golang.org/x/net/html"
+)
+
+// errFakeCrash is what a crashed fakeBrowser answers to everything
+var errFakeCrash = errors.New("fake browser crashed")
+
+// fakeChrome is the web and the browser process behind fakeBrowser: pages
+// by URL, the URLs navigated to and how often the browser was launched.
+// Its launch method goes in BlogCrawler.newBrowser.
+type fakeChrome struct {
+	site      map[string]string // HTML by URL; other URLs load a 404 page
+	redirects map[string]string // URL -> where navigating to it ends up
+
+	mu       sync.Mutex
+	crashes  map[string]int // URL -> how many navigations to it crash the browser
+	visits   []string
+	launches int
+}
+
+// newFakeCrawler returns a crawler for baseURL whose browser is chrome
+func newFakeCrawler(baseURL string, chrome *fakeChrome, opts CrawlOptions) *BlogCrawler {
+	bc := NewBlogCrawler(baseURL, 10*time.Second, opts)
+	bc.newBrowser = chrome.launch
+	return bc
+}
+
+func (c *fakeChrome) launch(ctx context.Context) (Browser, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.launches++
+	return &fakeBrowser{chrome: c}, nil
+}
+
+// visited returns the URLs navigated to so far
+func (c *fakeChrome) visited() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.visits...)
+}
+
+// fakeBrowser is a Browser rendering fakeChrome's pages without
+// JavaScript. The scripts the crawl evaluates are answered by Go
+// versions of them in fakeScripts.
+type fakeBrowser struct {
+	chrome *fakeChrome
+
+	mu      sync.Mutex
+	crashed bool
+}
+
+func (b *fakeBrowser) alive() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.crashed {
+		return errFakeCrash
+	}
+	return nil
+}
+
+func (b *fakeBrowser) NewPage() (Page, error) {
+	if err := b.alive(); err != nil {
+		return nil, err
+	}
+	return &fakePage{browser: b}, nil
+}
+
+func (b *fakeBrowser) Ping(ctx context.Context) error {
+	return b.alive()
+}
+
+func (b *fakeBrowser) Version(ctx context.Context) (string, error) {
+	return "FakeChrome/1.0", b.alive()
+}
+
+func (b *fakeBrowser) Close(ctx context.Context) error {
+	return nil
+}
+
+// fakePage is a tab of a fakeBrowser
+type fakePage struct {
+	browser *fakeBrowser
+
+	url    *url.URL
+	source string
+	doc    *html.Node
+	status int
+
+	harvest *fakeHarvest // The link observer, nil until started and after navigating
+	sweeps  int          // postLinksJS runs
+	queries int          // Elements calls
+}
+
+// fakeHarvest stands in for the observer of harvestStartJS: what it has
+// reported, by selector and href
+type fakeHarvest struct {
+	selectors []string
+	reported  map[[2]string]bool
+}
+
+// grow replaces the page's HTML without navigating, like a feed loading
+// more posts
+func (p *fakePage) grow(source string) {
+	doc, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		panic(err)
+	}
+	p.source, p.doc = source, doc
+}
+
+func (p *fakePage) Navigate(ctx context.Context, pageURL string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := p.browser.alive(); err != nil {
+		return err
+	}
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil {
+		return err
+	}
+
+	chrome := p.browser.chrome
+	chrome.mu.Lock()
+	chrome.visits = append(chrome.visits, pageURL)
+	if target, ok := chrome.redirects[pageURL]; ok {
+		pageURL = target
+		if parsedURL, err = url.Parse(target); err != nil {
+			chrome.mu.Unlock()
+			return err
+		}
+	}
+	crash := chrome.crashes[pageURL] > 0
+	if crash {
+		chrome.crashes[pageURL]--
+	}
+	source, ok := chrome.site[pageURL]
+	chrome.mu.Unlock()
+
+	if crash {
+		p.browser.mu.Lock()
+		p.browser.crashed = true
+		p.browser.mu.Unlock()
+		return errFakeCrash
+	}
+	p.status = 200
+	if !ok {
+		p.status = 404
+		source = "<html><head><title>Not found</title></head><body><h1>Not found</h1></body></html>"
+	}
+	doc, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return err
+	}
+	p.url, p.source, p.doc, p.harvest = parsedURL, source, doc, nil
+	return nil
+}
+
+func (p *fakePage) WaitLoad(ctx context.Context) error {
+	return p.browser.alive()
+}
+
+func (p *fakePage) WaitStable(ctx context.Context, d time.Duration) error {
+	return p.browser.alive()
+}
+
+func (p *fakePage) Elements(ctx context.Context, selector string) ([]Element, error) {
+	if err := p.browser.alive(); err != nil {
+		return nil, err
+	}
+	compiled, err := cascadia.Compile(selector)
+	if err != nil {
+		return nil, err
+	}
+	p.queries++
+	var elements []Element
+	if p.doc != nil {
+		for _, node := range cascadia.QueryAll(p.doc, compiled) {
+			elements = append(elements, fakeElement{node})
+		}
+	}
+	return elements, nil
+}
+
+func (p *fakePage) Eval(ctx context.Context, js string, args ...any) (gson.JSON, error) {
+	if err := p.browser.alive(); err != nil {
+		return gson.JSON{}, err
+	}
+	script, ok := fakeScripts[js]
+	if !ok {
+		return gson.JSON{}, fmt.Errorf("fake browser can't run %.40q", js)
+	}
+	if p.doc == nil {
+		return gson.New(nil), nil
+	}
+	return gson.New(script(p, args)), nil
+}
+
+func (p *fakePage) HTML(ctx context.Context) (string, error) {
+	return p.source, p.browser.alive()
+}
+
+func (p *fakePage) URL(ctx context.Context) (string, error) {
+	if p.url == nil {
+		return "about:blank", p.browser.alive()
+	}
+	return p.url.String(), p.browser.alive()
+}
+
+func (p *fakePage) Close(ctx context.Context) error {
+	return nil
+}
+
+// links returns the resolved hrefs of the page's links matching selector,
+// with their elements
+func (p *fakePage) links(selector string) ([]string, []*html.Node) {
+	var hrefs []string
+	var nodes []*html.Node
+	for _, node := range cascadia.QueryAll(p.doc, cascadia.MustCompile(selector)) {
+		if href := attr(node, "href"); href != "" {
+			hrefs = append(hrefs, resolveHref(p.url, href))
+			nodes = append(nodes, node)
+		}
+	}
+	return hrefs, nodes
+}
+
+// anchors describes the elements matching selector like describeAnchorJS,
+// or returns nil for an invalid selector
+func (p *fakePage) anchors(selector string) any {
+	compiled, err := cascadia.Compile(selector)
+	if err != nil {
+		return nil
+	}
+	anchors := []map[string]any{}
+	for _, node := range cascadia.QueryAll(p.doc, compiled) {
+		anchors = append(anchors, describe(node))
+	}
+	return anchors
+}
+
+// describe is describeAnchorJS for an element of the fake page
+func describe(node *html.Node) map[string]any {
+	context, memberOnly := "", false
+	for around := node.Parent; around != nil; around = around.Parent {
+		if around.Type == html.ElementNode && (around.Data == "article" || around.Data == "li") {
+			context = strings.Join(strings.Fields(nodeText(around)), " ")
+			memberOnly = cascadia.Query(around, cascadia.MustCompile(mediumMemberOnlySelector)) != nil ||
+				strings.Contains(strings.ToLower(context), "member-only story")
+			break
+		}
+	}
+	return map[string]any{
+		"href":       attr(node, "href"),
+		"text":       strings.Join(strings.Fields(nodeText(node)), " "),
+		"context":    context,
+		"memberOnly": memberOnly,
+	}
+}
+
+// drain returns the anchors of the links matching the harvest's selectors
+// that it hasn't reported yet, by selector
+func (p *fakePage) drain() [][]map[string]any {
+	drained := make([][]map[string]any, len(p.harvest.selectors))
+	for i, selector := range p.harvest.selectors {
+		drained[i] = []map[string]any{}
+		for _, node := range cascadia.QueryAll(p.doc, cascadia.MustCompile(selector)) {
+			key := [2]string{selector, attr(node, "href")}
+			if !p.harvest.reported[key] {
+				p.harvest.reported[key] = true
+				drained[i] = append(drained[i], describe(node))
+			}
+		}
+	}
+	return drained
+}
+
+// linkMatching returns the first link whose text matches pattern, or ""
+func (p *fakePage) linkMatching(pattern *regexp.Regexp) string {
+	hrefs, nodes := p.links("a[href]")
+	for i, node := range nodes {
+		if pattern.MatchString(strings.TrimSpace(nodeText(node))) {
+			return hrefs[i]
+		}
+	}
+	return ""
+}
+
+// fakeElement is an element of a fakePage
+type fakeElement struct {
+	node *html.Node
+}
+
+func (e fakeElement) Attribute(name string) (*string, error) {
+	for _, a := range e.node.Attr {
+		if a.Key == name {
+			value := a.Val
+			return &value, nil
+		}
+	}
+	return nil, nil
+}
+
+var (
+	fakeNextLinkText = regexp.MustCompile(`(?i)^(next|next page|older posts|older entries)\s*[›»→]?$`)
+	fakeViewAllText  = regexp.MustCompile(`(?i)^((see|view|show|browse|read)\s+)?all\s+(posts|articles|stories|entries|blog posts)\s*[›»→]?$|^(blog |post )?archives?$`)
+)
+
+// fakeScripts are Go versions of the scripts the crawl evaluates, by their
+// source. Any other script fails.
+var fakeScripts = map[string]func(p *fakePage, args []any) any{
+	`() => true`: func(p *fakePage, args []any) any { return true },
+	pageLinksJS: func(p *fakePage, args []any) any {
+		hrefs, _ := p.links("a[href]")
+		return hrefs
+	},
+	nextLinkJS: func(p *fakePage, args []any) any {
+		for _, selector := range args[0].([]string) {
+			if hrefs, _ := p.links(selector); len(hrefs) > 0 {
+				return hrefs[0]
+			}
+		}
+		return p.linkMatching(fakeNextLinkText)
+	},
+	postLinksJS: func(p *fakePage, args []any) any {
+		p.sweeps++
+		found := make([]any, 0)
+		for _, selector := range args[0].([]string) {
+			found = append(found, p.anchors(selector))
+		}
+		return found
+	},
+	anchorsJS: func(p *fakePage, args []any) any {
+		return p.anchors(args[0].(string))
+	},
+	harvestStartJS: func(p *fakePage, args []any) any {
+		if p.harvest == nil {
+			p.harvest = &fakeHarvest{selectors: args[0].([]string), reported: make(map[[2]string]bool)}
+			p.drain() // Only links added from now on are reported
+		}
+		return true
+	},
+	harvestDrainJS: func(p *fakePage, args []any) any {
+		if p.harvest == nil {
+			return nil
+		}
+		return p.drain()
+	},
+	viewAllJS: func(p *fakePage, args []any) any {
+		return p.linkMatching(fakeViewAllText)
+	},
+	pageStateJS: func(p *fakePage, args []any) any {
+		var text bytes.Buffer
+		if body := cascadia.Query(p.doc, cascadia.MustCompile("body")); body != nil {
+			text.WriteString(nodeText(body))
+		}
+		hrefs, _ := p.links("a[href]")
+		title := ""
+		if node := cascadia.Query(p.doc, cascadia.MustCompile("title")); node != nil {
+			title = nodeText(node)
+		}
+		return map[string]any{
+			"status":     p.status,
+			"title":      title,
+			"text":       text.String(),
+			"textLength": text.Len(),
+			"links":      len(hrefs),
+		}
+	},
+	// A headless Chrome's fingerprint, as sites see it
+	fingerprintJS: func(p *fakePage, args []any) any {
+		return map[string]any{
+			"userAgent":    "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/126.0.0.0 Safari/537.36",
+			"webdriver":    false,
+			"plugins":      0,
+			"languages":    1,
+			"chrome":       true,
+			"notification": "denied",
+			"permission":   "prompt",
+			"webgl":        "ANGLE (Google, Vulkan 1.3.0 (SwiftShader Device (Subzero)), SwiftShader driver)",
+			"outerWidth":   800,
+		}
+	},
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// feedSummaryLength caps the content excerpt used as an item description
+const feedSummaryLength = 300
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate,omitempty"`
+	Description string  `xml:"description,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Link    atomLink `xml:"link"`
+	Updated string   `xml:"updated"`
+	Summary string   `xml:"summary,omitempty"`
+}
+
+// feedItem is the format-neutral view of one post used by both feed writers
+type feedItem struct {
+	title     string
+	url       string
+	summary   string
+	published time.Time // Zero when the post's date isn't known
+}
+
+// postTitleFromURL turns the last path segment into a readable title for
+// posts whose content was never fetched
+func postTitleFromURL(postURL string) string {
+	parsedURL, err := url.Parse(postURL)
+	if err != nil {
+		return postURL
+	}
+	slug := path.Base(strings.TrimSuffix(parsedURL.Path, "/"))
+	if slug == "" || slug == "/" || slug == "." {
+		return postURL
+	}
+	return strings.ReplaceAll(slug, "-", " ")
+}
+
+func feedItems(result *CrawlResult) []feedItem {
+	posts := make(map[string]Post, len(result.Posts))
+	for _, post := range result.Posts {
+		posts[post.URL] = post
+	}
+
+	items := make([]feedItem, 0, len(result.BlogURLs))
+	for _, postURL := range result.BlogURLs {
+		item := feedItem{title: postTitleFromURL(postURL), url: postURL}
+		if post, ok := posts[postURL]; ok {
+			if post.Title != "" {
+				item.title = post.Title
+			}
+			item.summary = post.Content
+			if t, ok := parsePublished(post.Published); ok {
+				item.published = t
+			}
+			if runes := []rune(item.summary); len(runes) > feedSummaryLength {
+				item.summary = strings.TrimSpace(string(runes[:feedSummaryLength])) + "…"
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// crawledAt returns the crawl time of result, falling back to now
+func crawledAt(result *CrawlResult) time.Time {
+	if t, err := time.Parse(time.RFC3339, result.CrawledAt); err == nil {
+		return t
+	}
+	return time.Now()
+}
+
+// date returns when the item was published, or crawled when that isn't
+// known, in layout
+func (item feedItem) date(crawled time.Time, layout string) string {
+	if item.published.IsZero() {
+		return crawled.Format(layout)
+	}
+	return item.published.Format(layout)
+}
+
+func writeXML(w io.Writer, value interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write XML header: %w", err)
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Errorf("failed to encode XML: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write XML: %w", err)
+	}
+	return nil
+}
+
+// writeRSS writes the result as an RSS 2.0 feed. Items are dated by their
+// post's publish date, or the crawl time for posts without one.
+func writeRSS(w io.Writer, result *CrawlResult) error {
+	crawled := crawledAt(result)
+	updated := crawled.Format(time.RFC1123Z)
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         result.BaseURL,
+			Link:          result.BaseURL,
+			Description:   fmt.Sprintf("Posts crawled from %s", result.BaseURL),
+			LastBuildDate: updated,
+		},
+	}
+	for _, item := range feedItems(result) {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       item.title,
+			Link:        item.url,
+			GUID:        rssGUID{IsPermaLink: true, Value: item.url},
+			PubDate:     item.date(crawled, time.RFC1123Z),
+			Description: item.summary,
+		})
+	}
+	return writeXML(w, feed)
+}
+
+// writeAtom writes the result as an Atom 1.0 feed, dated like writeRSS
+func writeAtom(w io.Writer, result *CrawlResult) error {
+	crawled := crawledAt(result)
+	updated := crawled.Format(time.RFC3339)
+	feed := atomFeed{
+		Title:   result.BaseURL,
+		ID:      result.BaseURL,
+		Updated: updated,
+		Link:    atomLink{Href: result.BaseURL},
+	}
+	for _, item := range feedItems(result) {
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title:   item.title,
+			ID:      item.url,
+			Link:    atomLink{Href: item.url},
+			Updated: item.date(crawled, time.RFC3339),
+			Summary: item.summary,
+		})
+	}
+	return writeXML(w, feed)
+}
+
+type opmlDocument struct {
+	XMLName xml.Name    `xml:"opml"`
+	Version string      `xml:"version,attr"`
+	Title   string      `xml:"head>title"`
+	Body    []opmlEntry `xml:"body>outline"`
+}
+
+type opmlEntry struct {
+	Type    string `xml:"type,attr"`
+	Text    string `xml:"text,attr"`
+	XMLURL  string `xml:"xmlUrl,attr"`
+	HTMLURL string `xml:"htmlUrl,attr"`
+}
+
+// updateOPML adds or refreshes the outline for result's blog in an OPML file,
+// creating the file if needed. Running the crawler once per blog against the
+// same OPML file builds a subscription list of all generated feeds.
+func updateOPML(filename string, result *CrawlResult, feedURL string) error {
+	doc := opmlDocument{Version: "2.0", Title: "Crawled blogs"}
+
+	if data, err := os.ReadFile(filename); err == nil {
+		if err := xml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", filename, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", filename, err)
+	}
+
+	entry := opmlEntry{Type: "rss", Text: result.BaseURL, XMLURL: feedURL, HTMLURL: result.BaseURL}
+	replaced := false
+	for i := range doc.Body {
+		if doc.Body[i].HTMLURL == result.BaseURL {
+			doc.Body[i] = entry
+			replaced = true
+		}
+	}
+	if !replaced {
+		doc.Body = append(doc.Body, entry)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	return writeXML(file, doc)
+}
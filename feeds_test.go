@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func feedTestResult() *CrawlResult {
+	return &CrawlResult{
+		BaseURL:   "https://blog.example.com/",
+		CrawledAt: "2024-06-01T12:00:00Z",
+		BlogURLs:  []string{"https://blog.example.com/dated-post", "https://blog.example.com/undated-post"},
+		Posts: []Post{
+			{URL: "https://blog.example.com/dated-post", Title: "A dated post", Published: "2024-03-15", Content: "Body"},
+			{URL: "https://blog.example.com/undated-post", Published: "sometime last spring"},
+		},
+	}
+}
+
+func TestWriteRSS(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeRSS(&buf, feedTestResult()); err != nil {
+		t.Fatal(err)
+	}
+	var feed rssFeed
+	if err := xml.Unmarshal(buf.Bytes(), &feed); err != nil {
+		t.Fatalf("invalid RSS: %v\n%s", err, buf.String())
+	}
+
+	want := []rssItem{
+		{Title: "A dated post", Link: "https://blog.example.com/dated-post", PubDate: "Fri, 15 Mar 2024 00:00:00 +0000", Description: "Body"},
+		{Title: "undated post", Link: "https://blog.example.com/undated-post", PubDate: "Sat, 01 Jun 2024 12:00:00 +0000"},
+	}
+	if len(feed.Channel.Items) != len(want) {
+		t.Fatalf("%d items, want %d", len(feed.Channel.Items), len(want))
+	}
+	for i, item := range feed.Channel.Items {
+		item.GUID = rssGUID{}
+		if item != want[i] {
+			t.Errorf("item %d = %+v, want %+v", i, item, want[i])
+		}
+	}
+	if feed.Channel.LastBuildDate != "Sat, 01 Jun 2024 12:00:00 +0000" {
+		t.Errorf("lastBuildDate = %q, want the crawl time", feed.Channel.LastBuildDate)
+	}
+}
+
+func TestWriteAtom(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeAtom(&buf, feedTestResult()); err != nil {
+		t.Fatal(err)
+	}
+	var feed atomFeed
+	if err := xml.Unmarshal(buf.Bytes(), &feed); err != nil {
+		t.Fatalf("invalid Atom: %v\n%s", err, buf.String())
+	}
+
+	if feed.Updated != "2024-06-01T12:00:00Z" {
+		t.Errorf("feed updated = %q, want the crawl time", feed.Updated)
+	}
+	wantUpdated := []string{"2024-03-15T00:00:00Z", "2024-06-01T12:00:00Z"}
+	if len(feed.Entries) != len(wantUpdated) {
+		t.Fatalf("%d entries, want %d", len(feed.Entries), len(wantUpdated))
+	}
+	for i, entry := range feed.Entries {
+		if entry.Updated != wantUpdated[i] {
+			t.Errorf("entry %s updated = %q, want %q", entry.ID, entry.Updated, wantUpdated[i])
+		}
+	}
+}
+
+func TestUpdateOPML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blogs.opml")
+	uber := &CrawlResult{BaseURL: "https://www.uber.com/blog/"}
+	medium := &CrawlResult{BaseURL: "https://medium.com/netflix-techblog"}
+
+	if err := updateOPML(path, uber, "https://example.com/feeds/uber.xml"); err != nil {
+		t.Fatal(err)
+	}
+	if err := updateOPML(path, medium, "https://example.com/feeds/netflix.xml"); err != nil {
+		t.Fatal(err)
+	}
+	// Running again for a blog replaces its entry
+	if err := updateOPML(path, uber, "https://example.com/feeds/uber.atom"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc opmlDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	want := []opmlEntry{
+		{Type: "rss", Text: "https://www.uber.com/blog/", XMLURL: "https://example.com/feeds/uber.atom", HTMLURL: "https://www.uber.com/blog/"},
+		{Type: "rss", Text: "https://medium.com/netflix-techblog", XMLURL: "https://example.com/feeds/netflix.xml", HTMLURL: "https://medium.com/netflix-techblog"},
+	}
+	if len(doc.Body) != len(want) {
+		t.Fatalf("OPML has %d entries, want %d: %+v", len(doc.Body), len(want), doc.Body)
+	}
+	for i := range want {
+		if doc.Body[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, doc.Body[i], want[i])
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("not xml"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := updateOPML(path, uber, "https://example.com/feeds/uber.xml"); err == nil {
+		t.Error("a corrupt OPML file was overwritten")
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// fixture describes a saved listing page. The page itself is stored next to
+// it as <name>.html, this metadata as <name>.json.
+type fixture struct {
+	BaseURL    string   `json:"base_url"`
+	PageURL    string   `json:"page_url"`
+	CapturedAt string   `json:"captured_at"`
+	BlogURLs   []string `json:"blog_urls"` // Post URLs the crawler found on the page, sorted
+}
+
+// fixtureName names the files of a saved page after its URL, query
+// included, so paginated listings don't overwrite each other. Saving the
+// same URL again (infinite scroll) keeps the fullest state.
+func fixtureName(pageURL string) string {
+	name := pageURL
+	if parsedURL, err := url.Parse(pageURL); err == nil {
+		name = parsedURL.Host + parsedURL.Path + "?" + parsedURL.RawQuery
+	}
+	return strings.Trim(unsafeFileChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// recordFixture saves the current listing page and the post URLs found on
+// it to opts.RecordFixtures
+func (bc *BlogCrawler) recordFixture(ctx context.Context, urls []string) error {
+	pageURL, err := bc.page.URL(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read page URL: %w", err)
+	}
+	content, err := bc.page.HTML(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read page HTML: %w", err)
+	}
+
+	if err := os.MkdirAll(bc.opts.RecordFixtures, 0755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+
+	meta := fixture{
+		BaseURL:    bc.baseURL,
+		PageURL:    pageURL,
+		CapturedAt: time.Now().Format(time.RFC3339),
+		BlogURLs:   append([]string(nil), urls...),
+	}
+	sort.Strings(meta.BlogURLs)
+	encoded, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+
+	base := filepath.Join(bc.opts.RecordFixtures, fixtureName(pageURL))
+	if err := os.WriteFile(base+".html", []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	if err := os.WriteFile(base+".json", append(encoded, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+// loadFixture reads a saved page and its metadata; path may name either file
+func loadFixture(path string) (fixture, *html.Node, error) {
+	base := strings.TrimSuffix(strings.TrimSuffix(path, ".html"), ".json")
+
+	var meta fixture
+	data, err := os.ReadFile(base + ".json")
+	if err != nil {
+		return meta, nil, fmt.Errorf("failed to read fixture metadata: %w", err)
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, nil, fmt.Errorf("failed to parse fixture metadata: %w", err)
+	}
+
+	file, err := os.Open(base + ".html")
+	if err != nil {
+		return meta, nil, fmt.Errorf("failed to open fixture page: %w", err)
+	}
+	defer file.Close()
+
+	doc, err := html.Parse(file)
+	if err != nil {
+		return meta, nil, fmt.Errorf("failed to parse fixture page: %w", err)
+	}
+	return meta, doc, nil
+}
+
+// documentHrefs finds links in a parsed page the way the browser does on a
+// live one
+func documentHrefs(doc *html.Node) hrefFinder {
+	return func(selector string) ([]string, error) {
+		compiled, err := cascadia.Compile(selector)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes := compiled.MatchAll(doc)
+		hrefs := make([]string, len(nodes))
+		for i, node := range nodes {
+			for _, attr := range node.Attr {
+				if attr.Key == "href" {
+					hrefs[i] = attr.Val
+					break
+				}
+			}
+		}
+		return hrefs, nil
+	}
+}
+
+// replayFixture runs a saved page through URL extraction without a browser.
+// With explain set every link's classification is printed as in --dry-run.
+func replayFixture(path string, explain bool) (fixture, []string, error) {
+	meta, doc, err := loadFixture(path)
+	if err != nil {
+		return meta, nil, err
+	}
+
+	bc := NewBlogCrawler(meta.BaseURL, 0, CrawlOptions{DryRun: explain})
+	urls, err := bc.collectPostURLs(documentHrefs(doc))
+	if err != nil {
+		return meta, nil, err
+	}
+	sort.Strings(urls)
+	return meta, urls, nil
+}
+
+// fixturePaths expands directories among paths to the fixtures they hold
+func fixturePaths(paths []string) ([]string, error) {
+	var expanded []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			expanded = append(expanded, path)
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(path, "*.html"))
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, matches...)
+	}
+	return expanded, nil
+}
+
+// runReplay implements the replay subcommand: every fixture is extracted
+// again and compared with the post URLs recorded for it
+func runReplay(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	explain := fs.Bool("explain", false, "print every link with the rule that accepted or rejected it")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . replay [--explain] <fixture.html | fixture_dir>...")
+		fmt.Println()
+		fmt.Println("Fixtures are recorded with --record-fixtures during a live crawl.")
+		fmt.Println()
+		fmt.Println("Flags:")
+		fs.PrintDefaults()
+	}
+
+	positional, _ := parseArgs(fs, args)
+	if len(positional) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	paths, err := fixturePaths(positional)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	mismatches := 0
+	for _, path := range paths {
+		fmt.Printf("%s\n", path)
+		meta, urls, err := replayFixture(path, *explain)
+		if err != nil {
+			fmt.Printf("Error replaying %s: %v\n", path, err)
+			mismatches++
+			continue
+		}
+
+		diff := diffResults(&CrawlResult{BlogURLs: meta.BlogURLs}, &CrawlResult{BlogURLs: urls})
+		fmt.Printf("  %d post URLs (%d recorded)\n", len(urls), len(meta.BlogURLs))
+		for _, postURL := range diff.Added {
+			fmt.Printf("  + %s\n", postURL)
+		}
+		for _, postURL := range diff.Removed {
+			fmt.Printf("  - %s\n", postURL)
+		}
+		if len(diff.Added) > 0 || len(diff.Removed) > 0 {
+			mismatches++
+		}
+	}
+
+	if mismatches > 0 {
+		fmt.Printf("%d of %d fixtures differ from their recording\n", mismatches, len(paths))
+		os.Exit(1)
+	}
+}
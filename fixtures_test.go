@@ -0,0 +1,129 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// TestFixtureReplay runs every saved listing page in testdata/fixtures
+// through URL extraction and expects the post URLs recorded with it
+func TestFixtureReplay(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "fixtures", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no fixtures found in testdata/fixtures")
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			meta, urls, err := replayFixture(path, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(urls, meta.BlogURLs) {
+				t.Errorf("post URLs differ from the recording\n got: %q\nwant: %q", urls, meta.BlogURLs)
+			}
+		})
+	}
+}
+
+func TestFixtureName(t *testing.T) {
+	tests := []struct {
+		pageURL string
+		want    string
+	}{
+		{"https://medium.com/netflix-techblog", "medium-com-netflix-techblog"},
+		{"https://www.uber.com/blog/engineering/", "www-uber-com-blog-engineering"},
+		{"https://example.com/blog?page=2", "example-com-blog-page-2"},
+	}
+	for _, tt := range tests {
+		if got := fixtureName(tt.pageURL); got != tt.want {
+			t.Errorf("fixtureName(%q) = %q, want %q", tt.pageURL, got, tt.want)
+		}
+	}
+}
+
+// loadBenchFixtures loads every saved listing page in testdata/fixtures
+func loadBenchFixtures(b *testing.B) []fixtureBench {
+	b.Helper()
+	paths, err := filepath.Glob(filepath.Join("testdata", "fixtures", "*.html"))
+	if err != nil || len(paths) == 0 {
+		b.Fatalf("no fixtures found in testdata/fixtures: %v", err)
+	}
+	var fixtures []fixtureBench
+	for _, path := range paths {
+		meta, doc, err := loadFixture(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		hrefs, _ := documentHrefs(doc)(fallbackSelector)
+		fixtures = append(fixtures, fixtureBench{strings.TrimSuffix(filepath.Base(path), ".html"), meta, doc, hrefs})
+	}
+	return fixtures
+}
+
+// fixtureBench is a saved listing page and all the hrefs on it
+type fixtureBench struct {
+	name  string
+	meta  fixture
+	doc   *html.Node
+	hrefs []string
+}
+
+// BenchmarkFixtureExtraction runs every post link selector over a saved
+// listing page and classifies what they find, as a sweep of a live page
+// does once the links are read
+func BenchmarkFixtureExtraction(b *testing.B) {
+	for _, f := range loadBenchFixtures(b) {
+		b.Run(f.name, func(b *testing.B) {
+			b.ReportAllocs()
+			find := documentHrefs(f.doc)
+			for i := 0; i < b.N; i++ {
+				bc := NewBlogCrawler(f.meta.BaseURL, 0, CrawlOptions{})
+				if _, err := bc.collectPostURLs(find); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkNormalizeURL(b *testing.B) {
+	for _, f := range loadBenchFixtures(b) {
+		b.Run(f.name, func(b *testing.B) {
+			bc := NewBlogCrawler(f.meta.BaseURL, 0, CrawlOptions{})
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, href := range f.hrefs {
+					bc.normalizeURL(href, true)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkClassifyURL(b *testing.B) {
+	for _, f := range loadBenchFixtures(b) {
+		b.Run(f.name, func(b *testing.B) {
+			bc := NewBlogCrawler(f.meta.BaseURL, 0, CrawlOptions{})
+			var links []string
+			for _, href := range f.hrefs {
+				if link, err := bc.normalizeURL(href, true); err == nil {
+					links = append(links, link)
+				}
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, link := range links {
+					bc.classifyURL(link)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Sizes of the synthetic blogs served by newFixtureBlog
+const (
+	fixturePagedPosts     = 12 // Over pages of fixturePageSize
+	fixturePageSize       = 4
+	fixtureScrollPosts    = 23 // Loaded fixtureScrollBatch at a time
+	fixtureScrollBatch    = 5
+	fixturePostParagraphs = 8 // Paragraphs of text per post, enough to read as an article
+)
+
+// fixturePost is a post of a synthetic blog
+type fixturePost struct {
+	Slug  string `json:"slug"`
+	Title string `json:"title"`
+}
+
+// fixturePosts returns the n posts of the blog named name, newest first
+func fixturePosts(name string, n int) []fixturePost {
+	posts := make([]fixturePost, n)
+	for i := range posts {
+		number := n - i
+		posts[i] = fixturePost{
+			Slug:  fmt.Sprintf("%s-engineering-notes-part-%d", name, number),
+			Title: fmt.Sprintf("%s engineering notes, part %d", strings.ToUpper(name[:1])+name[1:], number),
+		}
+	}
+	return posts
+}
+
+var fixtureListingTemplate = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html lang="en"><head><title>{{.Title}}</title>
+<style>article { min-height: 400px; }</style>
+</head>
+<body>
+<header><a href="/">Home</a> <a href="{{.Base}}">Blog</a> <a href="/about">About</a></header>
+<main id="posts">
+{{range .Posts}}<article><h2><a href="{{$.Base}}{{.Slug}}">{{.Title}}</a></h2><p>An excerpt.</p></article>
+{{end}}</main>
+{{if .Next}}<nav class="pagination"><a rel="next" href="{{.Next}}">Older posts</a></nav>{{end}}
+{{if .Script}}<script>{{.Script}}</script>{{end}}
+<footer><a href="/privacy">Privacy</a></footer>
+</body></html>`))
+
+var fixturePostTemplate = template.Must(template.New("post").Parse(`<!DOCTYPE html>
+<html lang="en"><head><title>{{.Title}}</title>
+<meta property="og:title" content="{{.Title}}">
+<meta property="og:type" content="article">
+<meta property="article:published_time" content="2024-05-{{.Day}}T09:00:00Z">
+</head>
+<body>
+<header><a href="/">Home</a></header>
+<article><h1>{{.Title}}</h1>
+{{range .Paragraphs}}<p>{{.}}</p>
+{{end}}{{if .Previous}}<p>Previously: <a href="{{.Previous.Slug}}">{{.Previous.Title}}</a></p>{{end}}</article>
+</body></html>`))
+
+// fixtureScrollJS loads the next batch of posts from the JSON endpoint
+// whenever the reader gets near the bottom of the feed, like the infinite
+// scroll of most hosted blogs
+const fixtureScrollJS = `
+let offset = %d, loading = false, done = false;
+window.addEventListener('scroll', async () => {
+	if (loading || done || window.innerHeight + window.scrollY < document.body.scrollHeight - 300) return;
+	loading = true;
+	const posts = await (await fetch('/scroll/more?offset=' + offset)).json();
+	done = posts.length === 0;
+	for (const post of posts) {
+		const article = document.createElement('article');
+		article.innerHTML = '<h2><a href="/scroll/' + post.slug + '">' + post.title + '</a></h2><p>An excerpt.</p>';
+		document.getElementById('posts').appendChild(article);
+	}
+	offset += posts.length;
+	loading = false;
+});
+`
+
+// newFixtureBlog serves two synthetic blogs for the end-to-end tests:
+//
+//	/paged/   a listing paginated with rel=next links at /paged/page/N/
+//	/scroll/  an infinite-scroll feed whose script fetches /scroll/more
+//
+// Their posts live at /paged/<slug> and /scroll/<slug>.
+func newFixtureBlog(t *testing.T) *httptest.Server {
+	t.Helper()
+	paged := fixturePosts("paged", fixturePagedPosts)
+	scroll := fixturePosts("scroll", fixtureScrollPosts)
+
+	mux := http.NewServeMux()
+	listing := func(w http.ResponseWriter, data map[string]any) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := fixtureListingTemplate.Execute(w, data); err != nil {
+			t.Errorf("rendering listing: %v", err)
+		}
+	}
+	post := func(w http.ResponseWriter, r *http.Request, posts []fixturePost, slug string) {
+		for i, p := range posts {
+			if p.Slug != slug {
+				continue
+			}
+			paragraphs := make([]string, fixturePostParagraphs)
+			for j := range paragraphs {
+				paragraphs[j] = fmt.Sprintf("Paragraph %d of %s. It goes on about queues, caches and the trade-offs between them for a while, so the page has a body of text.", j+1, p.Title)
+			}
+			var previous *fixturePost // The next older post, linked at the end
+			if i+1 < len(posts) {
+				previous = &posts[i+1]
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			if err := fixturePostTemplate.Execute(w, map[string]any{
+				"Title":      p.Title,
+				"Day":        fmt.Sprintf("%02d", 28-i%28),
+				"Paragraphs": paragraphs,
+				"Previous":   previous,
+			}); err != nil {
+				t.Errorf("rendering post: %v", err)
+			}
+			return
+		}
+		http.NotFound(w, r)
+	}
+
+	mux.HandleFunc("/paged/", func(w http.ResponseWriter, r *http.Request) {
+		rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/paged/"), "/")
+		pageNum := 1
+		if number, ok := strings.CutPrefix(rest, "page/"); ok {
+			n, err := strconv.Atoi(number)
+			if err != nil || n < 1 {
+				http.NotFound(w, r)
+				return
+			}
+			pageNum = n
+		} else if rest != "" {
+			post(w, r, paged, rest)
+			return
+		}
+
+		start := (pageNum - 1) * fixturePageSize
+		if start >= len(paged) {
+			http.NotFound(w, r)
+			return
+		}
+		end := min(start+fixturePageSize, len(paged))
+		next := ""
+		if end < len(paged) {
+			next = fmt.Sprintf("/paged/page/%d/", pageNum+1)
+		}
+		listing(w, map[string]any{"Title": fmt.Sprintf("Paged blog, page %d", pageNum), "Base": "/paged/", "Posts": paged[start:end], "Next": next})
+	})
+
+	mux.HandleFunc("/scroll/more", func(w http.ResponseWriter, r *http.Request) {
+		offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+		if err != nil || offset < 0 {
+			http.Error(w, "bad offset", http.StatusBadRequest)
+			return
+		}
+		batch := []fixturePost{}
+		if offset < len(scroll) {
+			batch = scroll[offset:min(offset+fixtureScrollBatch, len(scroll))]
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(batch)
+	})
+	mux.HandleFunc("/scroll/", func(w http.ResponseWriter, r *http.Request) {
+		if slug := strings.Trim(strings.TrimPrefix(r.URL.Path, "/scroll/"), "/"); slug != "" {
+			post(w, r, scroll, slug)
+			return
+		}
+		listing(w, map[string]any{
+			"Title":  "Scrolling blog",
+			"Base":   "/scroll/",
+			"Posts":  scroll[:fixtureScrollBatch],
+			"Script": template.JS(fmt.Sprintf(fixtureScrollJS, fixtureScrollBatch)),
+		})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// fixtureURLs returns the post URLs of a synthetic blog on server, sorted
+func fixtureURLs(server *httptest.Server, name string, n int) []string {
+	posts := fixturePosts(name, n)
+	urls := make([]string, len(posts))
+	for i, post := range posts {
+		urls[i] = server.URL + "/" + name + "/" + post.Slug
+	}
+	sort.Strings(urls)
+	return urls
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Frontier is the queue of listing pages a crawl following archives
+// (--depth 2 and up) has yet to visit. Pages come out shallowest first, in
+// the order they were added, and a page is only ever queued once.
+type Frontier interface {
+	// Add queues the pages found at depth, skipping those queued or seen
+	// before
+	Add(depth int, pageURLs ...string) error
+	// Seen marks pages as visited without queuing them
+	Seen(pageURLs ...string) error
+	// Next returns the next page to visit, leaving it queued until Done;
+	// ok is false when the queue is empty
+	Next() (pageURL string, depth int, ok bool, err error)
+	// Done takes pageURL off the queue, keeping the post URLs found on it
+	// where the frontier persists them
+	Done(pageURL string, urls []string) error
+	// Found returns the post URLs kept by Done, including those of a crawl
+	// that was interrupted
+	Found() ([]string, error)
+	// Len is the number of pages queued
+	Len() (int, error)
+	// Reset forgets everything, once the crawl is over
+	Reset() error
+}
+
+// FrontierStore opens the frontier of a blog's crawl
+type FrontierStore interface {
+	Frontier(baseURL string) (Frontier, error)
+}
+
+// openFrontier returns the frontier of the crawl from opts.Frontier, or a
+// new one in memory
+func (bc *BlogCrawler) openFrontier() (Frontier, error) {
+	if bc.opts.Frontier == nil {
+		return newMemoryFrontier(), nil
+	}
+	frontier, err := bc.opts.Frontier.Frontier(bc.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the frontier: %w", err)
+	}
+	return frontier, nil
+}
+
+// memoryFrontier is a Frontier lasting as long as the crawl
+type memoryFrontier struct {
+	queues map[int][]string // Pages by depth, in the order added
+	seen   map[string]bool  // By listingKey
+}
+
+func newMemoryFrontier() *memoryFrontier {
+	return &memoryFrontier{queues: make(map[int][]string), seen: make(map[string]bool)}
+}
+
+func (f *memoryFrontier) Add(depth int, pageURLs ...string) error {
+	for _, pageURL := range pageURLs {
+		if !f.seen[listingKey(pageURL)] {
+			f.seen[listingKey(pageURL)] = true
+			f.queues[depth] = append(f.queues[depth], pageURL)
+		}
+	}
+	return nil
+}
+
+func (f *memoryFrontier) Seen(pageURLs ...string) error {
+	for _, pageURL := range pageURLs {
+		f.seen[listingKey(pageURL)] = true
+	}
+	return nil
+}
+
+func (f *memoryFrontier) Next() (string, int, bool, error) {
+	depth := -1
+	for d := range f.queues {
+		if depth < 0 || d < depth {
+			depth = d
+		}
+	}
+	if depth < 0 {
+		return "", 0, false, nil
+	}
+	return f.queues[depth][0], depth, true, nil
+}
+
+func (f *memoryFrontier) Done(pageURL string, urls []string) error {
+	for depth, queue := range f.queues {
+		for i, queued := range queue {
+			if queued != pageURL {
+				continue
+			}
+			f.queues[depth] = append(queue[:i:i], queue[i+1:]...)
+			if len(f.queues[depth]) == 0 {
+				delete(f.queues, depth)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+func (f *memoryFrontier) Found() ([]string, error) {
+	return nil, nil
+}
+
+func (f *memoryFrontier) Len() (int, error) {
+	n := 0
+	for _, queue := range f.queues {
+		n += len(queue)
+	}
+	return n, nil
+}
+
+func (f *memoryFrontier) Reset() error {
+	*f = *newMemoryFrontier()
+	return nil
+}
+
+// Buckets of a blog's frontier in a BoltDB file, under a bucket named
+// after its base URL
+var (
+	frontierQueue = []byte("queue") // Big-endian depth and sequence number -> page URL
+	frontierSeen  = []byte("seen")  // listingKey of pages queued or visited
+	frontierFound = []byte("found") // Post URLs of the pages done
+)
+
+// boltFrontierStore keeps the frontiers of a run's blogs in a BoltDB file,
+// so a crawl of tens of thousands of archive pages neither holds them in
+// memory nor starts over when it's interrupted
+type boltFrontierStore struct {
+	db *bolt.DB
+}
+
+// openBoltFrontierStore opens the frontier file at path, creating it if
+// missing
+func openBoltFrontierStore(path string) (*boltFrontierStore, error) {
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("%s is in use by another crawl", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return &boltFrontierStore{db: db}, nil
+}
+
+func (s *boltFrontierStore) Frontier(baseURL string) (Frontier, error) {
+	f := &boltFrontier{db: s.db, bucket: []byte(baseURL)}
+	if err := f.init(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (s *boltFrontierStore) Close() error {
+	return s.db.Close()
+}
+
+// boltFrontier is a blog's Frontier in a boltFrontierStore
+type boltFrontier struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+func (f *boltFrontier) init() error {
+	return f.db.Update(func(tx *bolt.Tx) error {
+		blog, err := tx.CreateBucketIfNotExists(f.bucket)
+		if err != nil {
+			return err
+		}
+		for _, name := range [][]byte{frontierQueue, frontierSeen, frontierFound} {
+			if _, err := blog.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// update runs fn on the blog's buckets in a read-write transaction
+func (f *boltFrontier) update(fn func(queue, seen, found *bolt.Bucket) error) error {
+	return f.db.Update(func(tx *bolt.Tx) error {
+		blog := tx.Bucket(f.bucket)
+		return fn(blog.Bucket(frontierQueue), blog.Bucket(frontierSeen), blog.Bucket(frontierFound))
+	})
+}
+
+// view runs fn on the blog's buckets in a read-only transaction
+func (f *boltFrontier) view(fn func(queue, seen, found *bolt.Bucket) error) error {
+	return f.db.View(func(tx *bolt.Tx) error {
+		blog := tx.Bucket(f.bucket)
+		return fn(blog.Bucket(frontierQueue), blog.Bucket(frontierSeen), blog.Bucket(frontierFound))
+	})
+}
+
+func (f *boltFrontier) Add(depth int, pageURLs ...string) error {
+	return f.update(func(queue, seen, found *bolt.Bucket) error {
+		for _, pageURL := range pageURLs {
+			key := []byte(listingKey(pageURL))
+			if seen.Get(key) != nil {
+				continue
+			}
+			if err := seen.Put(key, []byte{1}); err != nil {
+				return err
+			}
+			sequence, err := queue.NextSequence()
+			if err != nil {
+				return err
+			}
+			position := make([]byte, 12)
+			binary.BigEndian.PutUint32(position, uint32(depth))
+			binary.BigEndian.PutUint64(position[4:], sequence)
+			if err := queue.Put(position, []byte(pageURL)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (f *boltFrontier) Seen(pageURLs ...string) error {
+	return f.update(func(queue, seen, found *bolt.Bucket) error {
+		for _, pageURL := range pageURLs {
+			if err := seen.Put([]byte(listingKey(pageURL)), []byte{1}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (f *boltFrontier) Next() (pageURL string, depth int, ok bool, err error) {
+	err = f.view(func(queue, seen, found *bolt.Bucket) error {
+		position, value := queue.Cursor().First()
+		if position == nil {
+			return nil
+		}
+		pageURL, depth, ok = string(value), int(binary.BigEndian.Uint32(position)), true
+		return nil
+	})
+	return pageURL, depth, ok, err
+}
+
+func (f *boltFrontier) Done(pageURL string, urls []string) error {
+	return f.update(func(queue, seen, found *bolt.Bucket) error {
+		for _, url := range urls {
+			if err := found.Put([]byte(url), []byte{}); err != nil {
+				return err
+			}
+		}
+		cursor := queue.Cursor()
+		for position, value := cursor.First(); position != nil; position, value = cursor.Next() {
+			if string(value) == pageURL {
+				return cursor.Delete()
+			}
+		}
+		return nil
+	})
+}
+
+func (f *boltFrontier) Found() ([]string, error) {
+	var urls []string
+	err := f.view(func(queue, seen, found *bolt.Bucket) error {
+		return found.ForEach(func(url, _ []byte) error {
+			urls = append(urls, string(url))
+			return nil
+		})
+	})
+	return urls, err
+}
+
+func (f *boltFrontier) Len() (int, error) {
+	var n int
+	err := f.view(func(queue, seen, found *bolt.Bucket) error {
+		n = queue.Stats().KeyN
+		return nil
+	})
+	return n, err
+}
+
+func (f *boltFrontier) Reset() error {
+	if err := f.db.Update(func(tx *bolt.Tx) error {
+		return tx.DeleteBucket(f.bucket)
+	}); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+		return err
+	}
+	return f.init()
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// testFrontierOrder checks that f hands out pages shallowest first, in the
+// order added, each once
+func testFrontierOrder(t *testing.T, f Frontier) {
+	t.Helper()
+	if err := f.Seen(fakeBlogURL); err != nil {
+		t.Fatal(err)
+	}
+	f.Add(3, fakeBlogURL+"2023/01/")
+	f.Add(2, fakeBlogURL+"2023/", fakeBlogURL+"2024/", fakeBlogURL+"2023", fakeBlogURL)
+
+	var order []string
+	for {
+		pageURL, depth, ok, err := f.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			break
+		}
+		order = append(order, pageURL)
+		if err := f.Done(pageURL, nil); err != nil {
+			t.Fatal(err)
+		}
+		if pageURL == fakeBlogURL+"2023/" && depth != 2 {
+			t.Errorf("depth of %s = %d, want 2", pageURL, depth)
+		}
+	}
+	want := []string{fakeBlogURL + "2023/", fakeBlogURL + "2024/", fakeBlogURL + "2023/01/"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("order\n got: %q\nwant: %q", order, want)
+	}
+}
+
+func TestMemoryFrontier(t *testing.T) {
+	testFrontierOrder(t, newMemoryFrontier())
+}
+
+func TestBoltFrontier(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "frontier.db")
+	store, err := openBoltFrontierStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := store.Frontier(fakeBlogURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testFrontierOrder(t, f)
+
+	// What's left survives reopening the file
+	f.Add(2, fakeBlogURL+"category/go/", fakeBlogURL+"category/rust/")
+	f.Done(fakeBlogURL+"category/go/", []string{fakeBlogURL + "generics-in-practice"})
+	store.Close()
+	if store, err = openBoltFrontierStore(path); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if f, err = store.Frontier(fakeBlogURL); err != nil {
+		t.Fatal(err)
+	}
+	if pageURL, _, ok, err := f.Next(); err != nil || !ok || pageURL != fakeBlogURL+"category/rust/" {
+		t.Errorf("Next() after reopening = %q, %v, %v", pageURL, ok, err)
+	}
+	if found, err := f.Found(); err != nil || !reflect.DeepEqual(found, []string{fakeBlogURL + "generics-in-practice"}) {
+		t.Errorf("Found() = %q, %v", found, err)
+	}
+
+	// Other blogs have frontiers of their own
+	other, err := store.Frontier("https://other.example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := other.Len(); err != nil || n != 0 {
+		t.Errorf("another blog has %d pages queued, %v", n, err)
+	}
+
+	if err := f.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := f.Len(); n != 0 {
+		t.Errorf("%d pages queued after Reset", n)
+	}
+	if err := f.Add(2, fakeBlogURL+"category/go/"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := f.Len(); n != 1 {
+		t.Errorf("%d pages queued after adding one to a reset frontier, want 1", n)
+	}
+}
+
+func TestCrawlResumesFrontier(t *testing.T) {
+	chrome := &fakeChrome{site: map[string]string{
+		fakeBlogURL: `<html><body><main>` +
+			`<article><a href="/scaling-the-ingest-queue">Scaling the ingest queue</a></article>` +
+			`<article><a href="/moving-search-to-rust">Moving search to Rust</a></article>` +
+			`</main><nav><a href="/category/databases/">Databases</a> <a href="/category/infra/">Infra</a></nav></body></html>`,
+		fakeBlogURL + "category/databases/": fakeListing("", "our-cache-eviction-policy"),
+		fakeBlogURL + "category/infra/":     fakeListing("", "why-we-shard-by-tenant", "testing-with-fake-clocks"),
+	}}
+
+	// An interrupted crawl got through the databases category
+	store, err := openBoltFrontierStore(filepath.Join(t.TempDir(), "frontier.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	f, err := store.Frontier(fakeBlogURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Add(2, fakeBlogURL+"category/databases/", fakeBlogURL+"category/infra/")
+	f.Done(fakeBlogURL+"category/databases/", []string{fakeBlogURL + "our-cache-eviction-policy"})
+
+	result, err := newFakeCrawler(fakeBlogURL, chrome, CrawlOptions{Depth: 2, Sort: "url", Frontier: store}).crawl(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		fakeBlogURL + "moving-search-to-rust",
+		fakeBlogURL + "our-cache-eviction-policy",
+		fakeBlogURL + "scaling-the-ingest-queue",
+		fakeBlogURL + "testing-with-fake-clocks",
+		fakeBlogURL + "why-we-shard-by-tenant",
+	}
+	if !reflect.DeepEqual(result.BlogURLs, want) {
+		t.Errorf("post URLs\n got: %q\nwant: %q", result.BlogURLs, want)
+	}
+	if slices.Contains(chrome.visits, fakeBlogURL+"category/databases/") {
+		t.Errorf("crawled the category done before the interruption again: %q", chrome.visits)
+	}
+	if !slices.Contains(chrome.visits, fakeBlogURL+"category/infra/") {
+		t.Errorf("didn't crawl the category left queued: %q", chrome.visits)
+	}
+
+	// The finished crawl leaves nothing for the next one
+	if n, _ := f.Len(); n != 0 {
+		t.Errorf("%d pages still queued", n)
+	}
+	if found, _ := f.Found(); len(found) != 0 {
+		t.Errorf("found URLs kept after the crawl: %q", found)
+	}
+}
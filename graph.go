@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// graphNode and graphEdge are the JSON form of the internal link graph
+type graphNode struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+type graphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+type linkGraph struct {
+	BaseURL string      `json:"base_url"`
+	Nodes   []graphNode `json:"nodes"`
+	Edges   []graphEdge `json:"edges"`
+}
+
+// buildLinkGraph turns the posts' cross-links into a graph with one node per
+// discovered post. Edges only exist for posts that were visited.
+func buildLinkGraph(result *CrawlResult) linkGraph {
+	titles := make(map[string]string, len(result.Posts))
+	for _, post := range result.Posts {
+		titles[post.URL] = post.Title
+	}
+
+	graph := linkGraph{BaseURL: result.BaseURL, Nodes: []graphNode{}, Edges: []graphEdge{}}
+	for _, postURL := range result.BlogURLs {
+		title := titles[postURL]
+		if title == "" {
+			title = postTitleFromURL(postURL)
+		}
+		graph.Nodes = append(graph.Nodes, graphNode{ID: postURL, Title: title})
+	}
+	for _, post := range result.Posts {
+		for _, link := range post.Links {
+			graph.Edges = append(graph.Edges, graphEdge{Source: post.URL, Target: link})
+		}
+	}
+	return graph
+}
+
+// writeGraph writes the internal link graph as dot, graphml or graph-json
+func writeGraph(w io.Writer, result *CrawlResult, format string) error {
+	graph := buildLinkGraph(result)
+	switch format {
+	case "dot":
+		return writeGraphDOT(w, graph)
+	case "graphml":
+		return writeGraphML(w, graph)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(graph); err != nil {
+		return fmt.Errorf("failed to encode graph: %w", err)
+	}
+	return nil
+}
+
+// writeGraphDOT writes the graph in Graphviz DOT format; strconv.Quote
+// produces the escaped double-quoted IDs DOT expects
+func writeGraphDOT(w io.Writer, graph linkGraph) error {
+	if _, err := fmt.Fprintf(w, "digraph %s {\n", strconv.Quote(graph.BaseURL)); err != nil {
+		return fmt.Errorf("failed to write DOT: %w", err)
+	}
+	for _, node := range graph.Nodes {
+		if _, err := fmt.Fprintf(w, "  %s [label=%s];\n", strconv.Quote(node.ID), strconv.Quote(node.Title)); err != nil {
+			return fmt.Errorf("failed to write DOT: %w", err)
+		}
+	}
+	for _, edge := range graph.Edges {
+		if _, err := fmt.Fprintf(w, "  %s -> %s;\n", strconv.Quote(edge.Source), strconv.Quote(edge.Target)); err != nil {
+			return fmt.Errorf("failed to write DOT: %w", err)
+		}
+	}
+	if _, err := io.WriteString(w, "}\n"); err != nil {
+		return fmt.Errorf("failed to write DOT: %w", err)
+	}
+	return nil
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"http://graphml.graphdrawing.org/xmlns graphml"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// writeGraphML writes the graph as GraphML, with each post's title as a node
+// attribute so tools like Gephi or yEd can label nodes
+func writeGraphML(w io.Writer, graph linkGraph) error {
+	doc := graphMLDocument{
+		Keys:  []graphMLKey{{ID: "title", For: "node", AttrName: "title", AttrType: "string"}},
+		Graph: graphMLGraph{ID: graph.BaseURL, EdgeDefault: "directed"},
+	}
+	for _, node := range graph.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID:   node.ID,
+			Data: []graphMLData{{Key: "title", Value: node.Title}},
+		})
+	}
+	for _, edge := range graph.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: edge.Source, Target: edge.Target})
+	}
+	return writeXML(w, doc)
+}
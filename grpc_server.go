@@ -0,0 +1,173 @@
+package main
+
+//go:generate sh -c "cd proto && buf generate"
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	blogcrawlerv1 "manual-blog-crawler/proto/blogcrawler/v1"
+)
+
+// crawlerService implements blogcrawler.v1.CrawlerService. Every call runs
+// its own crawler, and therefore its own browser, so calls are independent.
+type crawlerService struct {
+	blogcrawlerv1.UnimplementedCrawlerServiceServer
+
+	jobs    *jobBoard     // Shows the calls on the web dashboard, nil without one
+	running chan struct{} // One slot per crawl allowed at once, shared with the dashboard; nil for no limit
+}
+
+func (s *crawlerService) Crawl(req *blogcrawlerv1.CrawlRequest, stream grpc.ServerStreamingServer[blogcrawlerv1.CrawlResponse]) error {
+	parsedURL, err := url.Parse(req.GetBaseUrl())
+	if err != nil || parsedURL.Scheme == "" || parsedURL.Host == "" {
+		return status.Errorf(codes.InvalidArgument, "invalid base_url %q", req.GetBaseUrl())
+	}
+
+	timeout := 30 * time.Second
+	if req.GetTimeoutSeconds() > 0 {
+		timeout = time.Duration(req.GetTimeoutSeconds()) * time.Second
+	}
+	if s.running != nil {
+		select {
+		case s.running <- struct{}{}:
+			defer func() { <-s.running }()
+		default:
+			return status.Error(codes.ResourceExhausted, errTooManyCrawls.Error())
+		}
+	}
+
+	// The stream's context ends when the client cancels or disconnects, and
+	// the dashboard can cancel the crawl too
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	job := s.jobs.start(req.GetBaseUrl(), "grpc", cancel)
+
+	// Progress is reported synchronously from the crawl, which runs on this
+	// handler's goroutine, so sending from the callback is safe.
+	var sendErr error
+	opts := CrawlOptions{
+		FetchContent: req.GetFetchContent(),
+		OnProgress: func(event ProgressEvent) {
+			s.jobs.progress(job, event)
+			if sendErr != nil {
+				return
+			}
+			sendErr = stream.Send(&blogcrawlerv1.CrawlResponse{
+				Event: &blogcrawlerv1.CrawlResponse_Progress{
+					Progress: &blogcrawlerv1.Progress{
+						Kind:      event.Kind,
+						Step:      int32(event.Step),
+						Url:       event.URL,
+						UrlsFound: int32(event.URLsFound),
+						TotalUrls: int32(event.TotalURLs),
+					},
+				},
+			})
+		},
+	}
+
+	result, err := NewBlogCrawler(req.GetBaseUrl(), timeout, opts).crawl(ctx)
+	s.jobs.finish(job, result, err)
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		return status.Errorf(codes.Internal, "crawl failed: %v", err)
+	}
+	if sendErr != nil {
+		return sendErr
+	}
+
+	return stream.Send(&blogcrawlerv1.CrawlResponse{
+		Event: &blogcrawlerv1.CrawlResponse_Result{Result: crawlResultToProto(result)},
+	})
+}
+
+func crawlResultToProto(result *CrawlResult) *blogcrawlerv1.CrawlResult {
+	posts := make([]*blogcrawlerv1.Post, 0, len(result.Posts))
+	for _, post := range result.Posts {
+		posts = append(posts, &blogcrawlerv1.Post{
+			Url:         post.URL,
+			Title:       post.Title,
+			ContentHash: post.ContentHash,
+		})
+	}
+
+	return &blogcrawlerv1.CrawlResult{
+		BaseUrl:    result.BaseURL,
+		BlogUrls:   result.BlogURLs,
+		TotalCount: int32(result.TotalCount),
+		CrawledAt:  result.CrawledAt,
+		Posts:      posts,
+	}
+}
+
+// runGRPCServer implements the serve-grpc subcommand
+func runGRPCServer(args []string) {
+	fs := flag.NewFlagSet("serve-grpc", flag.ExitOnError)
+	listenAddr := fs.String("listen", ":50051", "address to listen on (this machine only; give a host like 0.0.0.0:50051 to serve others)")
+	httpAddr := fs.String("http", "", "also serve a web dashboard of the crawls on this address, like :8080 (this machine only; give a host like 0.0.0.0:8080 to serve others)")
+	dataDir := fs.String("data", "", "with --http, keep every result in this directory, for the dashboard's history")
+	maxCrawls := fs.Int("max-crawls", 2, "run at most this many crawls at once, from gRPC calls and the dashboard (0 for no limit)")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . serve-grpc [--listen :50051] [--http :8080 [--data DIR]]")
+		fmt.Println()
+		fmt.Println("Flags:")
+		fs.PrintDefaults()
+	}
+	parseArgs(fs, args)
+	if *dataDir != "" && *httpAddr == "" {
+		fmt.Println("--data needs --http")
+		os.Exit(1)
+	}
+
+	listener, err := net.Listen("tcp", loopbackAddr(*listenAddr))
+	if err != nil {
+		fmt.Printf("Error listening on %s: %v\n", *listenAddr, err)
+		os.Exit(1)
+	}
+
+	service := &crawlerService{running: crawlSlots(*maxCrawls)}
+	if *httpAddr != "" {
+		jobs, err := newJobBoard(*dataDir)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		jobs.running = service.running // Dashboard crawls count against the same --max-crawls
+		service.jobs = jobs
+
+		httpListener, err := net.Listen("tcp", loopbackAddr(*httpAddr))
+		if err != nil {
+			fmt.Printf("Error listening on %s: %v\n", *httpAddr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Web dashboard on http://%s/\n", httpListener.Addr())
+		go func() {
+			if err := http.Serve(httpListener, jobs.handler()); err != nil {
+				fmt.Printf("Error serving the dashboard: %v\n", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	server := grpc.NewServer()
+	blogcrawlerv1.RegisterCrawlerServiceServer(server, service)
+
+	fmt.Printf("gRPC crawler service listening on %s\n", listener.Addr())
+	if err := server.Serve(listener); err != nil {
+		fmt.Printf("Error serving gRPC: %v\n", err)
+		os.Exit(1)
+	}
+}
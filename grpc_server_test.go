@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	blogcrawlerv1 "manual-blog-crawler/proto/blogcrawler/v1"
+)
+
+// refusedStream is the stream of a call turned down before it streams
+// anything; using it panics
+type refusedStream struct {
+	grpc.ServerStreamingServer[blogcrawlerv1.CrawlResponse]
+}
+
+func TestCrawlerServiceRefusesExcessCrawls(t *testing.T) {
+	service := &crawlerService{running: crawlSlots(1)}
+	service.running <- struct{}{} // A crawl is already running
+
+	err := service.Crawl(&blogcrawlerv1.CrawlRequest{BaseUrl: "https://blog.example.com/"}, refusedStream{})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Crawl beyond --max-crawls = %v, want ResourceExhausted", err)
+	}
+	if len(service.running) != 1 {
+		t.Errorf("refused call changed the running crawls to %d", len(service.running))
+	}
+
+	if crawlSlots(0) != nil {
+		t.Error("--max-crawls 0 should mean no limit")
+	}
+}
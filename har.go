@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// HAR 1.2 document, limited to the fields the crawler can fill in.
+// See http://www.softwareishard.com/blog/har-12-spec/
+type harDocument struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"` // Milliseconds from request to the last byte
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Error           string      `json:"_error,omitempty"` // Why the request failed, if it did
+
+	started proto.MonotonicTime
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	Cookies     []harNameValue `json:"cookies"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []harNameValue `json:"headers"`
+	Cookies     []harNameValue `json:"cookies"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harRecorder collects the network traffic of a crawl's tabs. Bodies are
+// kept for documents only, which is what differs when a site treats the
+// crawler differently; scripts and images would make the file huge.
+type harRecorder struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	pending map[proto.NetworkRequestID]*harEntry
+	entries []*harEntry
+}
+
+func newHARRecorder(ctx context.Context) *harRecorder {
+	ctx, cancel := context.WithCancel(ctx)
+	return &harRecorder{ctx: ctx, cancel: cancel, pending: make(map[proto.NetworkRequestID]*harEntry)}
+}
+
+// watch records the traffic of page until the crawl ends
+func (hr *harRecorder) watch(page *rod.Page) {
+	wait := page.Context(hr.ctx).EachEvent(
+		func(e *proto.NetworkRequestWillBeSent) { hr.requestSent(e) },
+		func(e *proto.NetworkResponseReceived) { hr.responseReceived(e) },
+		func(e *proto.NetworkLoadingFinished) { hr.loadingFinished(page, e) },
+		func(e *proto.NetworkLoadingFailed) { hr.loadingFailed(e) },
+	)
+	go wait()
+}
+
+func (hr *harRecorder) requestSent(e *proto.NetworkRequestWillBeSent) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	// A redirect reuses the request ID: the hop so far ends with the
+	// redirect response and a new entry starts
+	if previous, ok := hr.pending[e.RequestID]; ok && e.RedirectResponse != nil {
+		previous.Response = harResponseFrom(e.RedirectResponse)
+		previous.Response.RedirectURL = e.Request.URL
+		previous.Time = (e.Timestamp - previous.started).Duration().Seconds() * 1000
+		previous.Timings.Wait = previous.Time
+		delete(hr.pending, e.RequestID)
+	}
+
+	entry := &harEntry{
+		StartedDateTime: e.WallTime.Time().UTC().Format("2006-01-02T15:04:05.000Z"),
+		Request: harRequest{
+			Method:      e.Request.Method,
+			URL:         e.Request.URL,
+			HTTPVersion: "HTTP/1.1",
+			Headers:     harHeaders(e.Request.Headers),
+			QueryString: harQueryString(e.Request.URL),
+			Cookies:     []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(e.Request.PostData),
+		},
+		Response: harResponse{Headers: []harNameValue{}, Cookies: []harNameValue{}, HeadersSize: -1, BodySize: -1},
+		started:  e.Timestamp,
+	}
+	if e.Request.HasPostData {
+		entry.Request.PostData = &harPostData{Text: e.Request.PostData}
+		for _, header := range entry.Request.Headers {
+			if strings.EqualFold(header.Name, "Content-Type") {
+				entry.Request.PostData.MimeType = header.Value
+			}
+		}
+	}
+	hr.pending[e.RequestID] = entry
+	hr.entries = append(hr.entries, entry)
+}
+
+func (hr *harRecorder) responseReceived(e *proto.NetworkResponseReceived) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	entry, ok := hr.pending[e.RequestID]
+	if !ok {
+		return
+	}
+	entry.Response = harResponseFrom(e.Response)
+	entry.ServerIPAddress = e.Response.RemoteIPAddress
+	entry.Timings.Wait = (e.Timestamp - entry.started).Duration().Seconds() * 1000
+}
+
+func (hr *harRecorder) loadingFinished(page *rod.Page, e *proto.NetworkLoadingFinished) {
+	hr.mu.Lock()
+	entry, ok := hr.pending[e.RequestID]
+	delete(hr.pending, e.RequestID)
+	hr.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	// Fetch the body outside the lock; it's a round trip to the browser
+	var body *proto.NetworkGetResponseBodyResult
+	if strings.Contains(entry.Response.Content.MimeType, "html") {
+		body, _ = proto.NetworkGetResponseBody{RequestID: e.RequestID}.Call(page.Context(hr.ctx))
+	}
+
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+	entry.Time = (e.Timestamp - entry.started).Duration().Seconds() * 1000
+	entry.Timings.Receive = entry.Time - entry.Timings.Wait
+	entry.Response.BodySize = int(e.EncodedDataLength)
+	entry.Response.Content.Size = int(e.EncodedDataLength)
+	if body != nil {
+		entry.Response.Content.Text = body.Body
+		entry.Response.Content.Size = len(body.Body)
+		if body.Base64Encoded {
+			entry.Response.Content.Encoding = "base64"
+		}
+	}
+}
+
+func (hr *harRecorder) loadingFailed(e *proto.NetworkLoadingFailed) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	entry, ok := hr.pending[e.RequestID]
+	if !ok {
+		return
+	}
+	delete(hr.pending, e.RequestID)
+	entry.Time = (e.Timestamp - entry.started).Duration().Seconds() * 1000
+	entry.Error = e.ErrorText
+	if e.BlockedReason != "" {
+		entry.Error += " (blocked: " + string(e.BlockedReason) + ")"
+	}
+}
+
+// save stops recording and writes everything seen so far to path
+func (hr *harRecorder) save(path string) error {
+	hr.cancel()
+
+	hr.mu.Lock()
+	doc := harDocument{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "manual-blog-crawler", Version: "1.0"},
+		Entries: make([]harEntry, 0, len(hr.entries)),
+	}}
+	for _, entry := range hr.entries {
+		doc.Log.Entries = append(doc.Log.Entries, *entry)
+	}
+	hr.mu.Unlock()
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode HAR: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write HAR file: %w", err)
+	}
+	return nil
+}
+
+func harResponseFrom(response *proto.NetworkResponse) harResponse {
+	httpVersion := strings.ToUpper(response.Protocol)
+	if httpVersion == "" {
+		httpVersion = "HTTP/1.1"
+	}
+	return harResponse{
+		Status:      response.Status,
+		StatusText:  response.StatusText,
+		HTTPVersion: httpVersion,
+		Headers:     harHeaders(response.Headers),
+		Cookies:     []harNameValue{},
+		Content:     harContent{MimeType: response.MIMEType},
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+}
+
+// harHeaders converts CDP headers, sorted by name for stable output
+func harHeaders(headers proto.NetworkHeaders) []harNameValue {
+	pairs := make([]harNameValue, 0, len(headers))
+	for name, value := range headers {
+		pairs = append(pairs, harNameValue{Name: name, Value: value.String()})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+func harQueryString(rawURL string) []harNameValue {
+	pairs := []harNameValue{}
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return pairs
+	}
+	for name, values := range parsedURL.Query() {
+		for _, value := range values {
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+// saveHAR writes the crawl's recorded traffic to opts.HAR. It runs even
+// when the crawl fails, since that's when the recording is most useful.
+func (bc *BlogCrawler) saveHAR() {
+	if err := bc.har.save(bc.opts.HAR); err != nil {
+		bc.printf("Warning: %v\n", err)
+		return
+	}
+	bc.printf("Network traffic saved to: %s\n", bc.opts.HAR)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// harvestStartJS installs a MutationObserver that buffers the anchor of
+// every element matching one of the selectors as it is added to the page,
+// or as its href changes, by selector. It does nothing when one is
+// installed.
+const harvestStartJS = `(selectors) => {
+	if (window.__blogCrawlerHarvest) return true;
+	const describe = ` + describeAnchorJS + `;
+	const buffer = selectors.map(() => []);
+	const take = (element, i) => buffer[i].push(describe(element));
+	const visit = (node, descendants) => {
+		if (node.nodeType !== Node.ELEMENT_NODE) return;
+		selectors.forEach((selector, i) => {
+			try {
+				if (node.matches(selector)) take(node, i);
+				if (descendants) node.querySelectorAll(selector).forEach((element) => take(element, i));
+			} catch (e) {
+				// Selectors the browser doesn't support match nothing
+			}
+		});
+	};
+	const observer = new MutationObserver((mutations) => {
+		for (const mutation of mutations) {
+			if (mutation.type === 'attributes') {
+				visit(mutation.target, false);
+			} else {
+				mutation.addedNodes.forEach((node) => visit(node, true));
+			}
+		}
+	});
+	observer.observe(document.documentElement, {childList: true, subtree: true, attributes: true, attributeFilter: ['href']});
+	window.__blogCrawlerHarvest = {buffer, observer};
+	return true;
+}`
+
+// harvestDrainJS returns the anchors buffered since the last drain, by
+// selector, and empties the buffer; null when no observer is installed,
+// as after a reload
+const harvestDrainJS = `() => {
+	const harvest = window.__blogCrawlerHarvest;
+	return harvest ? harvest.buffer.map((hrefs) => hrefs.splice(0)) : null;
+}`
+
+// harvesting reports whether an infinite scroll can take the post links
+// from the observer instead of sweeping the page. Site scripts extracting
+// URLs and fixture recording need the whole page every time.
+func (bc *BlogCrawler) harvesting() bool {
+	return !bc.site.script.has(hookExtractURLs) && bc.opts.RecordFixtures == ""
+}
+
+// startHarvest installs the observer on the loaded page
+func (bc *BlogCrawler) startHarvest(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	res, err := bc.page.Eval(ctx, harvestStartJS, bc.postLinkSelectors())
+	return err == nil && res.Bool()
+}
+
+// harvestBlogURLs returns the post URLs among the links added to the page
+// since the last call. ok is false when the observer is gone.
+func (bc *BlogCrawler) harvestBlogURLs(ctx context.Context) (urls []string, ok bool) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	res, err := bc.page.Eval(ctx, harvestDrainJS)
+	if err != nil || res.Nil() {
+		return nil, false
+	}
+
+	drained := res.Arr()
+	selectors := bc.postLinkSelectors()
+	bc.linkAnchors = make(map[string]anchor)
+	defer func() { bc.linkAnchors = nil }()
+	urls, err = bc.collectPostURLs(func(selector string) ([]string, error) {
+		for i, candidate := range selectors {
+			if candidate != selector || i >= len(drained) {
+				continue
+			}
+			anchors := parseAnchors(drained[i])
+			bc.keepAnchors(anchors)
+			return anchorHrefs(anchors), nil
+		}
+		return nil, nil
+	})
+	return urls, err == nil
+}
+
+// scrollURLs returns the post URLs on the feed after a scroll. The first
+// scroll sweeps the whole page and installs the observer; later ones only
+// look at the links added since, so extraction doesn't slow down as the
+// page grows. A scroll the observer saw nothing new in is confirmed with
+// a full sweep before it counts as idle, as is one after the observer was
+// lost.
+func (bc *BlogCrawler) scrollURLs(ctx context.Context, urlSet map[string]bool) ([]string, error) {
+	if bc.harvestActive {
+		urls, ok := bc.harvestBlogURLs(ctx)
+		if ok {
+			for _, url := range urls {
+				if !urlSet[url] {
+					return urls, nil
+				}
+			}
+		}
+	}
+
+	// Installed first, so links added during the sweep aren't missed
+	bc.harvestActive = bc.harvesting() && bc.startHarvest(ctx)
+	return bc.extractBlogURLs(ctx)
+}
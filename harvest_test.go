@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestScrollURLsHarvest(t *testing.T) {
+	ctx := context.Background()
+	chrome := &fakeChrome{site: map[string]string{
+		fakeBlogURL: fakeListing("", "scaling-the-ingest-queue", "moving-search-to-rust"),
+	}}
+	bc := newFakeCrawler(fakeBlogURL, chrome, CrawlOptions{})
+	if err := bc.initializeBrowser(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := bc.navigateToPage(ctx); err != nil {
+		t.Fatal(err)
+	}
+	page := bc.page.(*fakePage)
+	urlSet := make(map[string]bool)
+	scroll := func() []string {
+		t.Helper()
+		urls, err := bc.scrollURLs(ctx, urlSet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, url := range urls {
+			urlSet[url] = true
+		}
+		sort.Strings(urls)
+		return urls
+	}
+
+	// The first scroll sweeps the page and starts the observer
+	if urls := scroll(); len(urls) != 2 || !bc.harvestActive {
+		t.Fatalf("first scroll found %q, observer started: %v", urls, bc.harvestActive)
+	}
+
+	// Later ones only take the links added since
+	page.grow(fakeListing("", "scaling-the-ingest-queue", "moving-search-to-rust", "our-cache-eviction-policy", "a-year-of-feature-flags"))
+	sweeps := page.sweeps
+	want := []string{fakeBlogURL + "a-year-of-feature-flags", fakeBlogURL + "our-cache-eviction-policy"}
+	if urls := scroll(); !reflect.DeepEqual(urls, want) {
+		t.Errorf("after growing\n got: %q\nwant: %q", urls, want)
+	}
+	if page.sweeps != sweeps {
+		t.Errorf("swept the page %d times although the observer had the new links", page.sweeps-sweeps)
+	}
+
+	// Nothing new is confirmed with a sweep
+	if urls := scroll(); len(urls) != 4 || page.sweeps == sweeps {
+		t.Errorf("idle scroll found %q with %d sweeps, want a sweep finding all 4", urls, page.sweeps-sweeps)
+	}
+
+	// So is a page that lost its observer
+	page.harvest = nil
+	page.grow(fakeListing("", "why-we-shard-by-tenant"))
+	if urls := scroll(); !reflect.DeepEqual(urls, []string{fakeBlogURL + "why-we-shard-by-tenant"}) || page.harvest == nil {
+		t.Errorf("after losing the observer found %q, observer restarted: %v", urls, page.harvest != nil)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// fetchModes are the values --fetch-mode accepts
+var fetchModes = []string{"browser", "hybrid"}
+
+// minArticleText is the amount of main-content text below which a post
+// fetched over plain HTTP is taken to be a skeleton that needs JavaScript
+const minArticleText = 500
+
+// maxPostBytes limits the HTML read from a post fetched over plain HTTP
+const maxPostBytes = 10 << 20
+
+// fetchPostHTTP fetches a post with net/http and extracts what the browser
+// passes would: response details, content and links. It returns false when
+// the page has to go through the browser after all, because the request
+// failed or the HTML is an empty shell that scripts fill in.
+func (bc *BlogCrawler) fetchPostHTTP(ctx context.Context, post *Post, postURLs map[string]bool) (bool, string) {
+	if err := bc.throttle(ctx, post.URL); err != nil {
+		return false, err.Error()
+	}
+
+	resp, body, elapsed, err := bc.getPost(ctx, post.URL)
+	if err != nil {
+		return false, err.Error()
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, resp.Status
+	}
+	if !strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		return false, "not HTML: " + resp.Header.Get("Content-Type")
+	}
+
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return false, err.Error()
+	}
+	pageURL := resp.Request.URL // After redirects, for resolving links
+
+	content := documentContent(doc, pageURL)
+	if len(normalizeContent(content.text)) < minArticleText {
+		return false, "page needs JavaScript"
+	}
+
+	bc.loads++
+	bc.loadTime += elapsed
+	post.Status = resp.StatusCode
+	post.ContentLength = len(body)
+	post.ResponseMS = int(elapsed.Milliseconds())
+
+	if bc.opts.FetchContent {
+		post.Title = content.title
+		post.Published = content.published
+		post.Category = content.category
+		post.Authors = parseByline(content.byline)
+		post.Content = normalizeContent(content.text)
+		post.ContentHash = contentHash(post.Content)
+		post.References = bc.references(content.links)
+		post.Repositories = repositories(post.References)
+		bc.markPaywalled(post, content.paywall)
+		bc.scriptPost(post, string(body))
+	}
+	if bc.opts.Engagement {
+		post.Engagement = documentEngagement(doc)
+	}
+
+	var links []string
+	for _, href := range documentLinks(doc, pageURL) {
+		if normalizedURL, err := bc.normalizeURL(href, true); err == nil {
+			links = append(links, normalizedURL)
+		}
+	}
+	bc.setPostLinks(post, links, postURLs)
+	return true, ""
+}
+
+// getPost requests a post page, backing off from rate-limit responses as
+// they ask, and returns the final response with its body and timing
+func (bc *BlogCrawler) getPost(ctx context.Context, postURL string) (*http.Response, []byte, time.Duration, error) {
+	for attempt := 1; ; attempt++ {
+		if err := bc.budget.request(); err != nil {
+			return nil, nil, 0, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, postURL, nil)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; manual-blog-crawler)")
+		req.Header.Set("Accept", "text/html,application/xhtml+xml")
+		if lang := bc.emulation().AcceptLanguage; lang != "" {
+			req.Header.Set("Accept-Language", lang)
+		}
+
+		start := time.Now()
+		resp, err := bc.http.Do(req)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxPostBytes))
+		resp.Body.Close()
+		elapsed := time.Since(start)
+		bc.budget.transferred(int64(len(body)))
+		bc.traffic.fetched(postURL, int64(len(body)))
+		if err != nil {
+			return nil, nil, 0, err
+		}
+
+		if !isRateLimited(resp.StatusCode) || attempt > rateLimitRetries {
+			return resp, body, elapsed, nil
+		}
+		if err := bc.backOff(ctx, postURL, resp.StatusCode, resp.Header.Get("Retry-After"), attempt); err != nil {
+			return nil, nil, 0, err
+		}
+	}
+}
+
+// documentExtract is what extractContentJS returns, read from parsed HTML
+type documentExtract struct {
+	title     string
+	published string
+	category  string
+	byline    string
+	text      string
+	links     []contentLink
+	paywall   string // See documentPaywall
+}
+
+// documentContent mirrors extractContentJS on a parsed page, so posts get
+// the same fields, and mostly the same content hash, with either fetch mode
+func documentContent(doc *html.Node, pageURL *url.URL) documentExtract {
+	var extract documentExtract
+
+	title := metaContent(doc, `meta[property="og:title"]`)
+	if title == "" {
+		if h1 := cascadia.Query(doc, cascadia.MustCompile("h1")); h1 != nil {
+			title = nodeText(h1)
+		}
+	}
+	if title == "" {
+		if node := cascadia.Query(doc, cascadia.MustCompile("title")); node != nil {
+			title = nodeText(node)
+		}
+	}
+	extract.title = strings.TrimSpace(title)
+
+	container := contentContainer(doc)
+	extract.text = nodeText(container)
+	for _, a := range cascadia.QueryAll(container, cascadia.MustCompile("a[href]")) {
+		extract.links = append(extract.links, contentLink{href: resolveHref(pageURL, attr(a, "href")), text: strings.TrimSpace(nodeText(a))})
+	}
+
+	extract.published = metaContent(doc, `meta[property="article:published_time"]`)
+	if extract.published == "" {
+		for _, selector := range []string{"article time[datetime]", "time[datetime]"} {
+			if node := cascadia.Query(doc, cascadia.MustCompile(selector)); node != nil {
+				extract.published = attr(node, "datetime")
+				break
+			}
+		}
+	}
+	extract.category = metaContent(doc, `meta[property="article:section"]`)
+
+	extract.byline = metaContent(doc, `meta[name="author"]`)
+	for _, selector := range bylineSelectors {
+		if extract.byline != "" {
+			break
+		}
+		if node := cascadia.Query(doc, cascadia.MustCompile(selector)); node != nil {
+			extract.byline = attr(node, "content")
+			if extract.byline == "" {
+				extract.byline = nodeText(node)
+			}
+		}
+	}
+	extract.paywall = documentPaywall(doc)
+	return extract
+}
+
+// contentContainer returns the node holding a parsed page's main content,
+// picked like extractContentJS picks it
+func contentContainer(doc *html.Node) *html.Node {
+	for _, selector := range []string{"article", "main", `[role="main"]`, "body"} {
+		if node := cascadia.Query(doc, cascadia.MustCompile(selector)); node != nil {
+			return node
+		}
+	}
+	return doc
+}
+
+// documentLinks returns the resolved href of every link on a parsed page,
+// like pageLinksJS
+func documentLinks(doc *html.Node, pageURL *url.URL) []string {
+	var links []string
+	for _, a := range cascadia.QueryAll(doc, cascadia.MustCompile("a[href]")) {
+		links = append(links, resolveHref(pageURL, attr(a, "href")))
+	}
+	return links
+}
+
+func metaContent(doc *html.Node, selector string) string {
+	if node := cascadia.Query(doc, cascadia.MustCompile(selector)); node != nil {
+		return attr(node, "content")
+	}
+	return ""
+}
+
+func attr(node *html.Node, key string) string {
+	for _, a := range node.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func resolveHref(pageURL *url.URL, href string) string {
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return href
+	}
+	return pageURL.ResolveReference(ref).String()
+}
+
+// nodeText returns the text under node without scripts and styles, words
+// separated by whitespace
+func nodeText(node *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+			b.WriteString(" ")
+		case n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style" || n.Data == "noscript" || n.Data == "template"):
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+	return b.String()
+}
+
+// fetchPostHTTPOrBrowser is the hybrid mode's entry point: plain HTTP
+// first, the browser when that doesn't give a usable page
+func (bc *BlogCrawler) fetchPostHTTPOrBrowser(ctx context.Context, post *Post, postURLs map[string]bool) bool {
+	ok, reason := bc.fetchPostHTTP(ctx, post, postURLs)
+	if !ok && ctx.Err() == nil {
+		bc.printf("  %s: %s, loading it in the browser\n", post.URL, reason)
+	}
+	return ok
+}
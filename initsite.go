@@ -0,0 +1,474 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// wizardProfile is the part of a site profile init-site fills in, written
+// without the empty fields siteProfile would add
+type wizardProfile struct {
+	Match             string   `json:"match"`
+	Strategy          string   `json:"strategy,omitempty"`
+	PostLinkSelectors []string `json:"post_link_selectors,omitempty"`
+	NextLinkSelectors []string `json:"next_link_selectors,omitempty"`
+	PostPattern       string   `json:"post_pattern,omitempty"`
+}
+
+// pickedElement is what the user clicked while pickerJS was armed
+type pickedElement struct {
+	Skipped    bool                `json:"skipped"`
+	Href       string              `json:"href"`
+	Text       string              `json:"text"`
+	Candidates []selectorCandidate `json:"candidates"`
+}
+
+// selectorCandidate is a selector matching the picked element, with what
+// else it matches on the page
+type selectorCandidate struct {
+	Selector string   `json:"selector"`
+	Count    int      `json:"count"` // Elements matched
+	Hrefs    []string `json:"hrefs"` // Links of the matched elements
+}
+
+// pickerJS arms the element picker: a banner with the prompt, an outline
+// following the mouse and a capturing click handler that, instead of
+// following the click, describes the clicked element in
+// window.__blogcrawlerPick with the selectors that match it. Selectors are
+// built from the element's and its ancestors' stable attributes and class
+// names; class names with long digit runs are usually generated and left
+// out.
+const pickerJS = `(prompt, skippable) => {
+	window.__blogcrawlerPick = null;
+	document.getElementById('__blogcrawler_picker')?.remove();
+
+	const bar = document.createElement('div');
+	bar.id = '__blogcrawler_picker';
+	bar.style.cssText = 'position:fixed;top:0;left:0;right:0;z-index:2147483647;padding:12px;background:#1a73e8;color:#fff;font:16px sans-serif;';
+	bar.textContent = prompt;
+	if (skippable) {
+		const skip = document.createElement('button');
+		skip.textContent = 'There is none';
+		skip.style.marginLeft = '16px';
+		skip.addEventListener('click', (e) => {
+			e.preventDefault();
+			e.stopPropagation();
+			done({skipped: true});
+		});
+		bar.appendChild(skip);
+	}
+	document.documentElement.appendChild(bar);
+
+	const target = (e) => e.target.closest('a, button, [role="button"]') || e.target;
+	const tokens = (el) => {
+		const tag = el.tagName.toLowerCase();
+		const out = [];
+		for (const attr of ['rel', 'aria-label', 'data-testid', 'itemprop']) {
+			const value = el.getAttribute(attr);
+			if (value) out.push(tag + '[' + attr + '=' + JSON.stringify(value) + ']');
+		}
+		const classes = [...el.classList].filter((c) => /^[a-z][\w-]*$/i.test(c) && !/\d{3}/.test(c) && c.length < 40);
+		for (const c of classes) out.push(tag + '.' + CSS.escape(c));
+		if (classes.length > 1) out.push(tag + classes.map((c) => '.' + CSS.escape(c)).join(''));
+		if (el.id && !/\d{3}/.test(el.id)) out.push('#' + CSS.escape(el.id));
+		return out;
+	};
+	const describe = (el) => {
+		const leaf = el.tagName.toLowerCase() === 'a' ? 'a[href]' : el.tagName.toLowerCase();
+		const leaves = [leaf, ...tokens(el)];
+		const selectors = new Set(leaves);
+		let ancestor = el.parentElement;
+		for (let depth = 0; ancestor && ancestor !== document.body && depth < 5; depth++, ancestor = ancestor.parentElement) {
+			const scopes = tokens(ancestor);
+			if (['article', 'h1', 'h2', 'h3', 'h4', 'li', 'main', 'nav'].includes(ancestor.tagName.toLowerCase())) {
+				scopes.push(ancestor.tagName.toLowerCase());
+			}
+			for (const scope of scopes) {
+				for (const l of leaves) selectors.add(scope + ' ' + l);
+			}
+		}
+		const candidates = [];
+		for (const selector of selectors) {
+			let matched;
+			try {
+				matched = [...document.querySelectorAll(selector)];
+			} catch (e) {
+				continue;
+			}
+			if (!matched.includes(el)) continue;
+			const hrefs = matched.map((m) => m.href || m.closest('a')?.href || '').filter((h) => h);
+			candidates.push({selector, count: matched.length, hrefs});
+		}
+		return {href: el.href || el.closest('a')?.href || '', text: el.textContent.trim().slice(0, 100), candidates};
+	};
+
+	let outlined = null;
+	const over = (e) => {
+		if (bar.contains(e.target)) return;
+		if (outlined) outlined.style.outline = '';
+		outlined = target(e);
+		outlined.style.outline = '3px solid #1a73e8';
+	};
+	const click = (e) => {
+		if (bar.contains(e.target)) return;
+		e.preventDefault();
+		e.stopPropagation();
+		if (outlined) outlined.style.outline = '';
+		done(describe(target(e)));
+	};
+	const done = (pick) => {
+		if (outlined) outlined.style.outline = '';
+		document.removeEventListener('mouseover', over, true);
+		document.removeEventListener('click', click, true);
+		bar.remove();
+		window.__blogcrawlerPick = pick;
+	};
+	document.addEventListener('mouseover', over, true);
+	document.addEventListener('click', click, true);
+}`
+
+// pickStateJS reports whether the picker is still armed on the current
+// page, which it isn't after a navigation, and what was picked
+const pickStateJS = `() => ({armed: '__blogcrawlerPick' in window, pick: window.__blogcrawlerPick || null})`
+
+func runInitSite(args []string) {
+	fs := flag.NewFlagSet("init-site", flag.ExitOnError)
+	sitesFile := fs.String("sites", "", "add the profile to this --sites file, replacing one with the same match, instead of printing it")
+	wait := fs.Duration("wait", 10*time.Minute, "how long to wait for the clicks")
+	proxy := fs.String("proxy", "", "send the browser's traffic through this proxy")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . init-site [--sites FILE] <blog URL>")
+		fmt.Println()
+		fmt.Println("Opens the blog in a browser window, asks you to click a post link and the")
+		fmt.Println("next page control, and writes a site profile with the selectors, the")
+		fmt.Println("pagination strategy and the post URL pattern found from them.")
+		fmt.Println()
+		fmt.Println("Flags:")
+		fs.PrintDefaults()
+	}
+
+	positional, _ := parseArgs(fs, args)
+	if len(positional) != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	baseURL := positional[0]
+	if *proxy != "" {
+		if err := validateProxy(*proxy); err != nil {
+			fmt.Printf("Invalid --proxy: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *wait)
+	defer cancel()
+
+	profile, err := initSite(ctx, baseURL, CrawlOptions{Headful: true, Proxy: *proxy})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *sitesFile == "" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(profile)
+		return
+	}
+	if err := saveWizardProfile(*sitesFile, profile); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Site profile for %s saved to %s\n", profile.Match, *sitesFile)
+}
+
+// initSite runs the wizard for the blog at baseURL in a visible browser
+func initSite(ctx context.Context, baseURL string, opts CrawlOptions) (*wizardProfile, error) {
+	match, err := siteMatch(baseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	browser, launcher, err := launchBrowser(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer launcher.Kill()
+	defer browser.Close()
+
+	tab, err := browser.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a tab: %w", err)
+	}
+	page := rodPage{page: tab}
+	if err := page.Navigate(ctx, baseURL); err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", baseURL, err)
+	}
+	page.WaitLoad(ctx)
+
+	profile := &wizardProfile{Match: match}
+
+	fmt.Println("Click a link to one of the blog's posts in the browser window.")
+	post, err := pickElement(ctx, page, "Click a link to one of the blog's posts", false)
+	if err != nil {
+		return nil, err
+	}
+	if post.Href == "" {
+		return nil, fmt.Errorf("the element clicked (%q) isn't a link", post.Text)
+	}
+	selector, pattern := choosePostSelector(post.Href, post.Candidates)
+	if selector != "" {
+		profile.PostLinkSelectors = []string{selector}
+	}
+	profile.PostPattern = pattern
+	fmt.Printf("  Post links: %s\n  Post URLs:  %s\n", selector, pattern)
+
+	fmt.Println("Click the link to the next page of posts (\"Next\", \"Older posts\", \"Load more\"), or \"There is none\".")
+	next, err := pickElement(ctx, page, "Click the link or button to the next page of posts", true)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case next.Skipped:
+		profile.Strategy = "scroll"
+		fmt.Println("  No next page control: the listing is scrolled")
+	case next.Href == "" || strings.HasPrefix(next.Href, "javascript:") || strings.HasSuffix(next.Href, "#"):
+		// A "Load more" button appends to the same page, like infinite scroll
+		profile.Strategy = "scroll"
+		fmt.Printf("  %q loads more posts in place: the listing is scrolled\n", next.Text)
+	default:
+		profile.Strategy = "next-link"
+		if selector := chooseNextSelector(next.Candidates); selector != "" {
+			profile.NextLinkSelectors = []string{selector}
+		}
+		fmt.Printf("  Next page: %s\n", strings.Join(profile.NextLinkSelectors, ", "))
+	}
+	return profile, nil
+}
+
+// pickElement arms the picker on page and waits for the user's click,
+// arming it again whenever the page navigates away
+func pickElement(ctx context.Context, page Page, prompt string, skippable bool) (*pickedElement, error) {
+	ticker := time.NewTicker(300 * time.Millisecond)
+	defer ticker.Stop()
+
+	armed := false
+	for {
+		if !armed {
+			if _, err := page.Eval(ctx, pickerJS, prompt, skippable); err != nil {
+				return nil, fmt.Errorf("failed to set up the picker: %w", err)
+			}
+			armed = true
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("gave up waiting for a click")
+			}
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		res, err := page.Eval(ctx, pickStateJS)
+		if err != nil {
+			armed = false // Mid-navigation; try again on the new page
+			continue
+		}
+		var state struct {
+			Armed bool           `json:"armed"`
+			Pick  *pickedElement `json:"pick"`
+		}
+		if err := res.Unmarshal(&state); err != nil {
+			return nil, fmt.Errorf("failed to read the picked element: %w", err)
+		}
+		if state.Pick != nil {
+			return state.Pick, nil
+		}
+		armed = state.Armed
+	}
+}
+
+// choosePostSelector picks the candidate selector that best finds post
+// links like example, and returns it with the pattern post paths follow.
+// A candidate scores one point for each distinct link following the
+// pattern and loses two for each other link, so it gets the posts without
+// the navigation around them; shorter selectors win ties.
+func choosePostSelector(example string, candidates []selectorCandidate) (string, string) {
+	exampleURL, err := url.Parse(example)
+	if err != nil {
+		return "", ""
+	}
+
+	var links []string
+	for _, candidate := range candidates {
+		links = append(links, candidate.Hrefs...)
+	}
+	pattern := postURLPattern(example, links)
+	compiled := regexp.MustCompile(pattern)
+
+	best, bestScore := "", 0
+	for _, candidate := range candidates {
+		score := 0
+		seen := make(map[string]bool)
+		for _, href := range candidate.Hrefs {
+			link, err := url.Parse(href)
+			if err != nil || seen[link.Path] {
+				continue
+			}
+			seen[link.Path] = true
+			if link.Host == exampleURL.Host && compiled.MatchString(link.Path) {
+				score++
+			} else {
+				score -= 2
+			}
+		}
+		if best == "" || score > bestScore || score == bestScore && len(candidate.Selector) < len(best) {
+			best, bestScore = candidate.Selector, score
+		}
+	}
+	return best, pattern
+}
+
+// postURLPattern returns a regular expression for the paths of posts like
+// example. Its last segment, the slug, and numeric segments like dates
+// become wildcards; other segments stay literal unless links at the same
+// depth on the same host show at least three values there, like category
+// names.
+func postURLPattern(example string, links []string) string {
+	exampleURL, err := url.Parse(example)
+	if err != nil {
+		return ""
+	}
+	trailingSlash := strings.HasSuffix(exampleURL.Path, "/")
+	segments := strings.Split(strings.Trim(exampleURL.Path, "/"), "/")
+
+	values := make([]map[string]bool, len(segments))
+	for i := range values {
+		values[i] = make(map[string]bool)
+	}
+	for _, link := range append(links, example) {
+		linkURL, err := url.Parse(link)
+		if err != nil || linkURL.Host != exampleURL.Host {
+			continue
+		}
+		linkSegments := strings.Split(strings.Trim(linkURL.Path, "/"), "/")
+		if len(linkSegments) != len(segments) {
+			continue
+		}
+		for i, segment := range linkSegments {
+			values[i][segment] = true
+		}
+	}
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for i, segment := range segments {
+		pattern.WriteString("/")
+		switch {
+		case i < len(segments)-1 && digitsPattern.MatchString(segment):
+			pattern.WriteString(`\d+`)
+		case i == len(segments)-1 || len(values[i]) >= 3:
+			pattern.WriteString("[^/]+")
+		default:
+			pattern.WriteString(regexp.QuoteMeta(segment))
+		}
+	}
+	if trailingSlash {
+		pattern.WriteString("/?")
+	}
+	pattern.WriteString("$")
+	return pattern.String()
+}
+
+var digitsPattern = regexp.MustCompile(`^\d+$`)
+
+// chooseNextSelector picks the shortest candidate matching only the
+// clicked control, since the crawl follows the first element a next link
+// selector matches
+func chooseNextSelector(candidates []selectorCandidate) string {
+	unique := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		if candidate.Count == 1 {
+			unique = append(unique, candidate.Selector)
+		}
+	}
+	sort.SliceStable(unique, func(i, j int) bool { return len(unique[i]) < len(unique[j]) })
+	if len(unique) == 0 {
+		return ""
+	}
+	return unique[0]
+}
+
+// siteMatch returns the match of a site profile for the blog at baseURL:
+// its host without www, followed by its path
+func siteMatch(baseURL string) (string, error) {
+	parsedURL, err := url.Parse(baseURL)
+	if err != nil || parsedURL.Host == "" {
+		return "", fmt.Errorf("%q is not a blog URL", baseURL)
+	}
+	match := strings.TrimPrefix(strings.ToLower(parsedURL.Hostname()), "www.")
+	if path := strings.Trim(parsedURL.Path, "/"); path != "" {
+		match += "/" + path
+	}
+	return match, nil
+}
+
+// saveWizardProfile adds profile to the --sites file at path, replacing a
+// profile with the same match and leaving the others as they are. A
+// missing file is created.
+func saveWizardProfile(path string, profile *wizardProfile) error {
+	var doc struct {
+		Sites []json.RawMessage `json:"sites"`
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("failed to parse site profiles %s: %w", path, err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("failed to read site profiles: %w", err)
+	}
+
+	encoded, err := json.Marshal(profile)
+	if err != nil {
+		return err
+	}
+	replaced := false
+	for i, raw := range doc.Sites {
+		var existing struct {
+			Match string `json:"match"`
+		}
+		if json.Unmarshal(raw, &existing) == nil && existing.Match == profile.Match {
+			doc.Sites[i] = encoded
+			replaced = true
+		}
+	}
+	if !replaced {
+		doc.Sites = append(doc.Sites, encoded)
+	}
+
+	data, err = json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write site profiles: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPostURLPattern(t *testing.T) {
+	tests := []struct {
+		example string
+		links   []string
+		want    string
+	}{
+		{"https://example.com/blog/my-post/", nil, `^/blog/[^/]+/?$`},
+		{"https://example.com/2023/05/my-post", nil, `^/\d+/\d+/[^/]+$`},
+		{"https://example.com/blog/engineering/my-post", []string{
+			"https://example.com/blog/data/other-post",
+			"https://example.com/blog/culture/third-post",
+			"https://other.example/blog/x/y",
+		}, `^/blog/[^/]+/[^/]+$`},
+		{"https://example.com/blog/engineering/my-post", []string{
+			"https://example.com/blog/engineering/other-post",
+		}, `^/blog/engineering/[^/]+$`},
+	}
+	for _, tt := range tests {
+		if got := postURLPattern(tt.example, tt.links); got != tt.want {
+			t.Errorf("postURLPattern(%q) = %q, want %q", tt.example, got, tt.want)
+		}
+	}
+}
+
+func TestChooseSelectors(t *testing.T) {
+	posts := []string{"https://example.com/blog/one", "https://example.com/blog/two", "https://example.com/blog/three"}
+	candidates := []selectorCandidate{
+		{Selector: "a[href]", Count: 6, Hrefs: append([]string{"https://example.com/about", "https://example.com/", "https://twitter.com/example"}, posts...)},
+		{Selector: "article h2 a[href]", Count: 3, Hrefs: posts},
+		{Selector: "h2 a[href]", Count: 3, Hrefs: posts},
+		{Selector: "a.title", Count: 1, Hrefs: posts[:1]},
+	}
+	selector, pattern := choosePostSelector(posts[0], candidates)
+	if selector != "h2 a[href]" || pattern != `^/blog/[^/]+$` {
+		t.Errorf("choosePostSelector = %q, %q, want the shortest selector of only posts", selector, pattern)
+	}
+
+	next := []selectorCandidate{
+		{Selector: "a[href]", Count: 12},
+		{Selector: ".pagination a.next-page", Count: 1},
+		{Selector: "a.next-page", Count: 1},
+	}
+	if got := chooseNextSelector(next); got != "a.next-page" {
+		t.Errorf("chooseNextSelector = %q, want the shortest selector of only the control", got)
+	}
+}
+
+func TestSaveWizardProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sites.json")
+	existing := `{"sites": [{"match": "example.com/blog", "query_params": {"keep": ["id"]}}, {"match": "other.example"}]}`
+	if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, profile := range []*wizardProfile{
+		{Match: "other.example", Strategy: "next-link", NextLinkSelectors: []string{"a.older"}},
+		{Match: "new.example", Strategy: "scroll", PostPattern: `^/p/[^/]+$`},
+	} {
+		if err := saveWizardProfile(path, profile); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	profiles, err := loadSiteProfiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var matches []string
+	for _, profile := range profiles {
+		matches = append(matches, profile.Match)
+	}
+	if want := []string{"example.com/blog", "other.example", "new.example"}; !reflect.DeepEqual(matches, want) {
+		t.Fatalf("profiles = %q, want %q", matches, want)
+	}
+	if !reflect.DeepEqual(profiles[0].QueryParams.Keep, []string{"id"}) {
+		t.Errorf("untouched profile lost its query_params: %+v", profiles[0])
+	}
+	if profiles[1].Strategy != "next-link" || !reflect.DeepEqual(profiles[1].NextLinkSelectors, []string{"a.older"}) {
+		t.Errorf("replaced profile = %+v, want the wizard's", profiles[1])
+	}
+	if profiles[2].postPattern == nil {
+		t.Errorf("new profile's post_pattern wasn't compiled")
+	}
+
+	data, _ := os.ReadFile(path)
+	if !json.Valid(data) {
+		t.Errorf("sites file isn't valid JSON:\n%s", data)
+	}
+}
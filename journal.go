@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// journalEvent is one line of a crawl journal
+type journalEvent struct {
+	Time      string `json:"time"` // RFC 3339 with milliseconds
+	BaseURL   string `json:"base_url"`
+	Event     string `json:"event"`                // "start", "page", "post", "accepted", "rejected", "error" or "finish"
+	URL       string `json:"url,omitempty"`        // Page visited or link decided on
+	PageURL   string `json:"page_url,omitempty"`   // accepted: listing page the link was on
+	Source    string `json:"source,omitempty"`     // accepted: see Discovery
+	Step      int    `json:"step,omitempty"`       // page and accepted: listing step, from 1
+	Selector  string `json:"selector,omitempty"`   // accepted: link selector that matched it
+	Reason    string `json:"reason,omitempty"`     // rejected: rule; error, page and finish: what went wrong
+	URLsFound int    `json:"urls_found,omitempty"` // page: post URLs found on it; finish: in the result
+}
+
+// crawlJournal appends every step of the crawls of a run to a JSONL file as
+// it happens, so there's a record of what the crawler saw and decided even
+// when a crawl dies or its result can't be written. It is shared by the
+// blogs of a run.
+type crawlJournal struct {
+	path string
+
+	mu     sync.Mutex
+	file   *os.File
+	failed bool // A write failed and was reported
+}
+
+// openJournal opens the journal at path for appending, creating it if
+// missing
+func openJournal(path string) (*crawlJournal, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return &crawlJournal{path: path, file: file}, nil
+}
+
+// record appends event to the journal. Every event is written right away:
+// the journal is for when the process doesn't get to finish. Only the
+// first failure to write is returned, to be reported once; it doesn't stop
+// the crawl.
+func (j *crawlJournal) record(event journalEvent) error {
+	if j == nil {
+		return nil
+	}
+	event.Time = time.Now().Format("2006-01-02T15:04:05.000Z07:00")
+	line, err := json.Marshal(event)
+	if err != nil {
+		return nil
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, err := j.file.Write(append(line, '\n')); err != nil && !j.failed {
+		j.failed = true
+		return fmt.Errorf("failed to write journal %s: %w", j.path, err)
+	}
+	return nil
+}
+
+func (j *crawlJournal) Close() error {
+	return j.file.Close()
+}
+
+// journal records an event of the blog's crawl in opts.Journal
+func (bc *BlogCrawler) journal(event journalEvent) {
+	event.BaseURL = bc.baseURL
+	if err := bc.opts.Journal.record(event); err != nil {
+		bc.printf("Warning: %v\n", err)
+	}
+}
+
+// finishJournal records how the crawl ended
+func (bc *BlogCrawler) finishJournal(result *CrawlResult, err error) {
+	event := journalEvent{Event: "finish"}
+	if result != nil {
+		event.URLsFound = result.TotalCount
+	}
+	if err != nil {
+		event.Reason = redact(err.Error())
+	}
+	bc.journal(event)
+}
+
+// readJournal reads the events of a journal. A last line cut short by a
+// crash is skipped.
+func readJournal(r io.Reader) ([]journalEvent, error) {
+	var events []journalEvent
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var invalid error
+	for line := 1; scanner.Scan(); line++ {
+		if invalid != nil {
+			return nil, invalid
+		}
+		var event journalEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			invalid = fmt.Errorf("line %d: %w", line, err)
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
+
+// journalBlogs returns the blogs a journal has crawls of, in the order they
+// were first started
+func journalBlogs(events []journalEvent) []string {
+	var blogs []string
+	seen := make(map[string]bool)
+	for _, event := range events {
+		if event.Event == "start" && !seen[event.BaseURL] {
+			seen[event.BaseURL] = true
+			blogs = append(blogs, event.BaseURL)
+		}
+	}
+	return blogs
+}
+
+// rebuildResult puts together the result of the last crawl of baseURL in a
+// journal: the post URLs accepted and not rejected later, where they were
+// found, the listing pages and the errors. What only the end of a crawl
+// adds, like posts' content and stats, can't be rebuilt.
+func rebuildResult(events []journalEvent, baseURL string) (*CrawlResult, error) {
+	start := -1
+	for i, event := range events {
+		if event.BaseURL == baseURL && event.Event == "start" {
+			start = i
+		}
+	}
+	if start < 0 {
+		return nil, fmt.Errorf("the journal has no crawl of %s", baseURL)
+	}
+
+	result := &CrawlResult{SchemaVersion: resultSchemaVersion, BaseURL: baseURL, CrawledAt: events[start].Time}
+	discovered := make(map[string]Discovery)
+	for _, event := range events[start:] {
+		if event.BaseURL != baseURL {
+			continue
+		}
+		switch event.Event {
+		case "page":
+			result.Pages = append(result.Pages, PageStat{Number: event.Step, URL: event.URL, URLsFound: event.URLsFound, Error: event.Reason})
+		case "accepted":
+			if _, ok := discovered[event.URL]; !ok {
+				discovered[event.URL] = Discovery{
+					URL:      event.URL,
+					Source:   event.Source,
+					Step:     event.Step,
+					PageURL:  event.PageURL,
+					Selector: event.Selector,
+					order:    len(discovered),
+				}
+			}
+		case "rejected":
+			// Classifying pages drops URLs that were accepted first
+			delete(discovered, event.URL)
+		case "error":
+			result.Errors = append(result.Errors, event.Reason)
+		}
+		result.CrawledAt = event.Time
+	}
+
+	for url := range discovered {
+		result.BlogURLs = append(result.BlogURLs, url)
+	}
+	sort.Strings(result.BlogURLs)
+	for _, url := range result.BlogURLs {
+		result.Discovery = append(result.Discovery, discovered[url])
+	}
+	result.TotalCount = len(result.BlogURLs)
+	return result, nil
+}
+
+// runRebuild implements the rebuild subcommand
+func runRebuild(args []string) {
+	fs := flag.NewFlagSet("rebuild", flag.ExitOnError)
+	blog := fs.String("blog", "", "blog to rebuild the result of, when the journal has several")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . rebuild [--blog URL] <journal.jsonl> [output_file.json]")
+		fmt.Println()
+		fmt.Println("Rebuilds the result of a blog's last crawl in a --journal file: its post")
+		fmt.Println("URLs, where they were found, the listing pages and the errors. The result")
+		fmt.Println("goes to stdout unless an output file is given.")
+		fmt.Println()
+		fmt.Println("Flags:")
+		fs.PrintDefaults()
+	}
+	paths, _ := parseArgs(fs, args)
+	if len(paths) < 1 || len(paths) > 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	file, err := os.Open(paths[0])
+	if err != nil {
+		fmt.Printf("Error opening journal: %v\n", err)
+		os.Exit(1)
+	}
+	events, err := readJournal(file)
+	file.Close()
+	if err != nil {
+		fmt.Printf("Error reading journal %s: %v\n", paths[0], err)
+		os.Exit(1)
+	}
+
+	baseURL := *blog
+	if baseURL == "" {
+		blogs := journalBlogs(events)
+		if len(blogs) != 1 {
+			fmt.Printf("The journal has crawls of %d blogs; pick one with --blog:\n", len(blogs))
+			for _, blog := range blogs {
+				fmt.Printf("  %s\n", blog)
+			}
+			os.Exit(1)
+		}
+		baseURL = blogs[0]
+	}
+	result, err := rebuildResult(events, baseURL)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	out := os.Stdout
+	if len(paths) == 2 {
+		if out, err = os.Create(paths[1]); err != nil {
+			fmt.Printf("Error creating output file: %v\n", err)
+			os.Exit(1)
+		}
+		defer out.Close()
+	}
+	if err := writeJSON(out, result); err != nil {
+		fmt.Printf("Error writing result: %v\n", err)
+		os.Exit(1)
+	}
+	if len(paths) == 2 {
+		fmt.Printf("Rebuilt %d URLs of %s into %s\n", result.TotalCount, baseURL, paths[1])
+	}
+}
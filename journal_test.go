@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFakeCrawlJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	journal, err := openJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := newFakeCrawler(fakeBlogURL, fakePagedBlog(), CrawlOptions{Sort: "url", Journal: journal}).crawl(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal.Close()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	events, err := readJournal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]int)
+	for _, event := range events {
+		if event.BaseURL != fakeBlogURL || event.Time == "" {
+			t.Errorf("event %+v lacks the blog or the time", event)
+		}
+		counts[event.Event]++
+	}
+	if first, last := events[0], events[len(events)-1]; first.Event != "start" || last.Event != "finish" || last.URLsFound != len(fakePagedURLs) {
+		t.Errorf("journal runs from %+v to %+v, want start to finish with %d URLs", first, last, len(fakePagedURLs))
+	}
+	if counts["page"] != 3 || counts["accepted"] != len(fakePagedURLs) || counts["rejected"] == 0 {
+		t.Errorf("event counts %v, want 3 pages, %d accepted and the rejected links", counts, len(fakePagedURLs))
+	}
+
+	rebuilt, err := rebuildResult(events, fakeBlogURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(rebuilt.BlogURLs, result.BlogURLs) {
+		t.Errorf("rebuilt URLs\n got: %q\nwant: %q", rebuilt.BlogURLs, result.BlogURLs)
+	}
+	if !reflect.DeepEqual(pageURLs(rebuilt), pageURLs(result)) {
+		t.Errorf("rebuilt pages %q, want %q", pageURLs(rebuilt), pageURLs(result))
+	}
+}
+
+func TestReadJournal(t *testing.T) {
+	start := `{"time":"2024-05-01T10:00:00.000Z","base_url":"https://a.example/","event":"start"}` + "\n"
+	accepted := `{"time":"2024-05-01T10:00:01.000Z","base_url":"https://a.example/","event":"accepted","url":"https://a.example/post"}` + "\n"
+
+	// A crash can leave the last line unfinished
+	events, err := readJournal(strings.NewReader(start + accepted + `{"time":"2024-05-01T10:00:02`))
+	if err != nil || len(events) != 2 {
+		t.Errorf("journal with a cut last line: %d events, %v; want 2", len(events), err)
+	}
+	if _, err := readJournal(strings.NewReader(start + "garbage\n" + accepted)); err == nil {
+		t.Error("garbage within the journal was accepted")
+	}
+
+	// Later crawls of the blog replace earlier ones, and a URL rejected
+	// after being accepted is dropped
+	restart := strings.Replace(start, "10:00:00", "11:00:00", 1)
+	later := `{"time":"2024-05-01T11:00:01.000Z","base_url":"https://a.example/","event":"accepted","url":"https://a.example/other"}` + "\n"
+	dropped := `{"time":"2024-05-01T11:00:02.000Z","base_url":"https://a.example/","event":"accepted","url":"https://a.example/topics"}` + "\n" +
+		`{"time":"2024-05-01T11:00:03.000Z","base_url":"https://a.example/","event":"rejected","url":"https://a.example/topics","reason":"page classifier: listing"}` + "\n"
+	events, err = readJournal(strings.NewReader(start + accepted + restart + later + dropped))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := rebuildResult(events, "https://a.example/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"https://a.example/other"}; !reflect.DeepEqual(result.BlogURLs, want) {
+		t.Errorf("rebuilt %q, want %q", result.BlogURLs, want)
+	}
+	if _, err := rebuildResult(events, "https://b.example/"); err == nil {
+		t.Error("rebuilt a blog the journal has no crawl of")
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// kafkaSink publishes one message per discovered post to a Kafka topic
+type kafkaSink struct {
+	writer *kafka.Writer
+	keyBy  string
+}
+
+// newKafkaSink creates a producer for topic. keyBy selects the message key
+// ("url", "host" or "none") and balancer the partitioning strategy ("hash",
+// "roundrobin" or "leastbytes"); keyed messages with the hash balancer keep
+// every post of a blog, or every version of a post, on one partition.
+func newKafkaSink(brokers []string, topic, keyBy, balancer string) (*kafkaSink, error) {
+	if len(brokers) == 0 || topic == "" {
+		return nil, fmt.Errorf("kafka sink needs at least one broker and a topic")
+	}
+
+	switch keyBy {
+	case "url", "host", "none":
+	default:
+		return nil, fmt.Errorf("unknown kafka key %q (use url, host or none)", keyBy)
+	}
+
+	var kafkaBalancer kafka.Balancer
+	switch balancer {
+	case "hash":
+		kafkaBalancer = &kafka.Hash{}
+	case "roundrobin":
+		kafkaBalancer = &kafka.RoundRobin{}
+	case "leastbytes":
+		kafkaBalancer = &kafka.LeastBytes{}
+	default:
+		return nil, fmt.Errorf("unknown kafka partitioner %q (use hash, roundrobin or leastbytes)", balancer)
+	}
+
+	return &kafkaSink{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Topic:        topic,
+			Balancer:     kafkaBalancer,
+			RequiredAcks: kafka.RequireAll,
+		},
+		keyBy: keyBy,
+	}, nil
+}
+
+func (ks *kafkaSink) messageKey(postURL string) []byte {
+	switch ks.keyBy {
+	case "url":
+		return []byte(postURL)
+	case "host":
+		if parsedURL, err := url.Parse(postURL); err == nil {
+			return []byte(strings.ToLower(parsedURL.Host))
+		}
+		return []byte(postURL)
+	}
+	return nil
+}
+
+func (ks *kafkaSink) Write(ctx context.Context, result *CrawlResult) error {
+	records := postRecords(result)
+	messages := make([]kafka.Message, 0, len(records))
+	for _, record := range records {
+		value, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", record.URL, err)
+		}
+		messages = append(messages, kafka.Message{
+			Key:   ks.messageKey(record.URL),
+			Value: value,
+		})
+	}
+
+	if err := ks.writer.WriteMessages(ctx, messages...); err != nil {
+		return fmt.Errorf("failed to publish to kafka: %w", err)
+	}
+	return nil
+}
+
+func (ks *kafkaSink) Close() error {
+	return ks.writer.Close()
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"manual-blog-crawler/urlfilter"
+)
+
+// linkedInPageLimit is the safety limit of listing pages per category
+const linkedInPageLimit = 50
+
+// crawlLinkedIn paginates a LinkedIn blog. A category is crawled on its own;
+// for a section's front page every category linked from it is crawled, so
+// new categories are picked up without changes here.
+func (bc *BlogCrawler) crawlLinkedIn(ctx context.Context, urlSet map[string]bool) error {
+	section, category, _ := urlfilter.LinkedInBlog(bc.baseURL)
+
+	baseURLParsed, err := url.Parse(bc.baseURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse base URL: %w", err)
+	}
+	baseURLParsed.RawQuery = ""
+	baseURLParsed.Fragment = ""
+
+	listings := []string{strings.TrimSuffix(baseURLParsed.String(), "/")}
+	if category == "" {
+		if categories := bc.linkedInCategories(ctx, section); len(categories) > 0 {
+			bc.printf("Found %d categories in /blog/%s: %s\n", len(categories), section, strings.Join(categories, ", "))
+			listings = listings[:0]
+			for _, name := range categories {
+				categoryURL := *baseURLParsed
+				categoryURL.Path = "/blog/" + section + "/" + name
+				listings = append(listings, categoryURL.String())
+			}
+		}
+	}
+
+	// LinkedIn pagination pattern: page0 is a fixed parameter name, value is the page number
+	// Page 1: no query param (or ?page0=1)
+	// Page 2: ?page0=2
+	// Page 3: ?page0=3
+	// etc.
+	bc.printf("Using LinkedIn pagination pattern: page0=<page_number> (sequential: 1, 2, 3, ...)\n")
+	for _, listing := range listings {
+		if ctx.Err() != nil {
+			break
+		}
+		bc.paginateLinkedIn(ctx, listing, urlSet)
+	}
+	return nil
+}
+
+// linkedInCategories reads the categories of a section from the links on
+// the current page: /blog/<section>/<category> with nothing after it
+func (bc *BlogCrawler) linkedInCategories(ctx context.Context, section string) []string {
+	linksCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	anchors, err := bc.anchors(linksCtx, fmt.Sprintf(`a[href*="/blog/%s/"]`, section))
+	if err != nil {
+		return nil
+	}
+
+	var categories []string
+	seen := make(map[string]bool)
+	for _, a := range anchors {
+		linkURL, err := bc.normalizeURL(a.Href, false)
+		if err != nil {
+			continue
+		}
+		linkSection, category, ok := urlfilter.LinkedInBlog(linkURL)
+		if !ok || linkSection != section || category == "" || seen[category] {
+			continue
+		}
+		if parsedURL, err := url.Parse(linkURL); err != nil || strings.Count(strings.Trim(parsedURL.Path, "/"), "/") != 2 {
+			continue
+		}
+		seen[category] = true
+		categories = append(categories, category)
+	}
+	return categories
+}
+
+// paginateLinkedIn crawls listing?page0=N for N = 1, 2, ... until a page
+// yields nothing new
+func (bc *BlogCrawler) paginateLinkedIn(ctx context.Context, listing string, urlSet map[string]bool) {
+	consecutiveEmptyPages := 0
+	maxConsecutiveEmpty := bc.opts.maxEmptyPages()
+	pageNum := 1
+	maxPage := 0 // Read from the first page's pagination, if it has any
+
+	for ctx.Err() == nil {
+		var pageURL string
+		if pageNum == 1 {
+			pageURL = listing // First page: no query param
+		} else {
+			pageURL = fmt.Sprintf("%s?page0=%d", listing, pageNum)
+		}
+
+		bc.printf("Crawling page %s: %s\n", pageLabel(pageNum, maxPage), pageURL)
+
+		bc.setSource("page", pageNum, pageURL)
+		urls, err := bc.crawlListingPage(ctx, pageNum, pageURL)
+		bc.recordPage(pageNum, pageURL, len(urls), err)
+		if pageNum == 1 && err == nil {
+			bc.checkAdapter(ctx, pageURL, len(urls), linkedInPagesCheck)
+			if found, err := bc.getMaxPageNumber(ctx); err == nil {
+				maxPage = found
+			}
+		}
+		if err != nil {
+			bc.warnf("Error crawling page %d: %v", pageNum, err)
+			consecutiveEmptyPages++
+			if consecutiveEmptyPages >= maxConsecutiveEmpty {
+				bc.printf("Stopping: Error on page %d\n", pageNum)
+				break
+			}
+			pageNum++
+			continue
+		}
+
+		// An empty page may be the end of the listing or a failed or
+		// blocked load; the latter is retried before giving up
+		if len(urls) == 0 {
+			urls = bc.retryEmptyPage(ctx, pageNum, pageURL)
+		}
+
+		if len(urls) == 0 {
+			consecutiveEmptyPages++
+			if consecutiveEmptyPages >= maxConsecutiveEmpty {
+				bc.printf("Stopping: No blog posts found on page %d\n", pageNum)
+				break
+			}
+		} else {
+			consecutiveEmptyPages = 0
+			previousCount := len(urlSet)
+			for _, url := range urls {
+				urlSet[url] = true
+			}
+			bc.printf("  Found %d blog URLs on page %s (total: %d unique URLs)\n", len(urls), pageLabel(pageNum, maxPage), len(urlSet))
+			bc.reportProgress(ProgressEvent{Kind: "page", Step: pageNum, Steps: maxPage, URL: pageURL, URLsFound: len(urls), TotalURLs: len(urlSet)})
+
+			// If no new URLs were added, we might have reached the end
+			if len(urlSet) == previousCount {
+				consecutiveEmptyPages++
+				if consecutiveEmptyPages >= maxConsecutiveEmpty {
+					bc.printf("Stopping: No new URLs found on page %d\n", pageNum)
+					break
+				}
+			}
+		}
+
+		if maxPage > 0 && pageNum >= maxPage {
+			bc.printf("Reached the last page (%d). Stopping.\n", maxPage)
+			break
+		}
+		// Safety limit
+		if pageNum >= linkedInPageLimit {
+			bc.printf("Reached safety limit of %d pages. Stopping.\n", linkedInPageLimit)
+			break
+		}
+
+		pageNum++
+		if err := sleepContext(ctx, 1*time.Second); err != nil {
+			break
+		}
+	}
+}
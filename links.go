@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// pageLinksJS returns the resolved href of every link on the page in a
+// single round trip
+const pageLinksJS = `
+	() => {
+		return Array.from(document.querySelectorAll('a[href]'), a => a.href);
+	}
+`
+
+// pageLinks returns every link on the currently loaded page, normalized the
+// same way as discovered post URLs
+func (bc *BlogCrawler) pageLinks(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := bc.page.Eval(ctx, pageLinksJS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read links: %w", err)
+	}
+
+	var links []string
+	for _, href := range res.Arr() {
+		normalizedURL, err := bc.normalizeURL(href.Str(), true)
+		if err != nil {
+			continue
+		}
+		links = append(links, normalizedURL)
+	}
+	return links, nil
+}
+
+// recordPostLinks sets post.Links to the other posts of the blog that the
+// currently loaded post links to
+func (bc *BlogCrawler) recordPostLinks(ctx context.Context, post *Post, postURLs map[string]bool) error {
+	links, err := bc.pageLinks(ctx)
+	if err != nil {
+		return err
+	}
+	bc.setPostLinks(post, links, postURLs)
+	return nil
+}
+
+// setPostLinks sets post.Links to the posts among links, which are the
+// normalized links of the post's page
+func (bc *BlogCrawler) setPostLinks(post *Post, links []string, postURLs map[string]bool) {
+	seen := make(map[string]bool)
+	for _, link := range links {
+		link = bc.canonicalURL(link)
+		if link == post.URL || !postURLs[link] || seen[link] {
+			continue
+		}
+		seen[link] = true
+		post.Links = append(post.Links, link)
+	}
+}
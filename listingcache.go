@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// listingCache remembers what the listing pages of earlier crawls yielded,
+// by page URL, so pages that haven't changed since aren't rendered again.
+// It is shared by the blogs of a run and saved after each crawl.
+type listingCache struct {
+	path string
+
+	mu    sync.Mutex
+	Pages map[string]*cachedListing `json:"pages"`
+}
+
+// cachedListing is a listing page as a crawl last saw it
+type cachedListing struct {
+	ETag         string   `json:"etag,omitempty"`
+	LastModified string   `json:"last_modified,omitempty"`
+	Hash         string   `json:"hash"`           // Of the HTML as served
+	URLs         []string `json:"urls"`           // Post URLs found on the page
+	Next         string   `json:"next,omitempty"` // Its next link, see findNextLink
+	Checked      string   `json:"checked"`        // When the page was last loaded or revalidated
+}
+
+// servedListing is a listing page fetched over plain HTTP, to tell whether
+// it changed and to cache it after the browser rendered it
+type servedListing struct {
+	pageURL      string
+	etag         string
+	lastModified string
+	hash         string
+	links        map[string]bool // Normalized URLs of the links in the HTML
+}
+
+// loadListingCache reads the cache at path, or starts an empty one if
+// there is no file yet
+func loadListingCache(path string) (*listingCache, error) {
+	cache := &listingCache{path: path, Pages: make(map[string]*cachedListing)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, cache); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if cache.Pages == nil {
+		cache.Pages = make(map[string]*cachedListing)
+	}
+	return cache, nil
+}
+
+func (c *listingCache) get(pageURL string) *cachedListing {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.Pages[listingKey(pageURL)]; ok {
+		copied := *entry
+		return &copied
+	}
+	return nil
+}
+
+func (c *listingCache) put(pageURL string, entry *cachedListing) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Pages[listingKey(pageURL)] = entry
+}
+
+// save writes the cache back to its file, by way of a temporary file so
+// an interrupted write doesn't lose it. A nil cache saves nothing.
+func (c *listingCache) save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	data, err := json.MarshalIndent(c, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save listing cache: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save listing cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to save listing cache: %w", err)
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+// listingFromCache returns the post URLs of the listing page pageURL when
+// the cache has the page and the site says it hasn't changed: a 304 to a
+// conditional request, or the same HTML as last time. The first page is
+// always rendered, since that's where new posts show up and what the
+// adapters check. On a miss, the page's HTML is kept for cacheListing.
+func (bc *BlogCrawler) listingFromCache(ctx context.Context, pageNum int, pageURL string) ([]string, bool) {
+	bc.cachedPage, bc.served = nil, nil
+	if bc.opts.ListingCache == nil || pageNum <= 1 {
+		return nil, false
+	}
+	entry := bc.opts.ListingCache.get(pageURL)
+	served, unchanged, err := bc.revalidateListing(ctx, pageURL, entry)
+	if err != nil {
+		bc.printf("  Could not check the cached page: %v\n", err)
+		return nil, false
+	}
+	if !unchanged {
+		bc.served = served
+		return nil, false
+	}
+
+	// Cached URLs go through today's rules, which may have changed since
+	var urls []string
+	for _, postURL := range entry.URLs {
+		if ok, _ := bc.classifyURL(postURL); ok {
+			urls = append(urls, postURL)
+		}
+	}
+	if len(urls) == 0 {
+		return nil, false
+	}
+	for _, postURL := range urls {
+		bc.recordDiscovery(postURL, "")
+	}
+	if served != nil {
+		entry.ETag, entry.LastModified = served.etag, served.lastModified
+	}
+	lastChecked := entry.Checked
+	entry.Checked = time.Now().Format(time.RFC3339)
+	bc.opts.ListingCache.put(pageURL, entry)
+	bc.cachedPage = entry
+	bc.listingCacheHits++
+	bc.printf("  Unchanged since %s, using the %d cached URLs\n", lastChecked[:min(10, len(lastChecked))], len(urls))
+	return urls, true
+}
+
+// revalidateListing fetches pageURL over plain HTTP, conditionally when
+// entry has validators, and reports whether it is unchanged from entry.
+// The fetched page is returned unless the server answered 304.
+func (bc *BlogCrawler) revalidateListing(ctx context.Context, pageURL string, entry *cachedListing) (*servedListing, bool, error) {
+	if err := bc.throttle(ctx, pageURL); err != nil {
+		return nil, false, err
+	}
+	if err := bc.budget.request(); err != nil {
+		return nil, false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; manual-blog-crawler)")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	if lang := bc.emulation().AcceptLanguage; lang != "" {
+		req.Header.Set("Accept-Language", lang)
+	}
+	if entry != nil && entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+	if entry != nil && entry.LastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.LastModified)
+	}
+
+	resp, err := bc.http.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		bc.traffic.fetched(pageURL, 0)
+		return nil, true, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPostBytes))
+	bc.budget.transferred(int64(len(body)))
+	bc.traffic.fetched(pageURL, int64(len(body)))
+	if err != nil {
+		return nil, false, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, nil
+	}
+
+	served := &servedListing{
+		pageURL:      pageURL,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		hash:         contentHash(string(body)),
+		links:        make(map[string]bool),
+	}
+	if doc, err := html.Parse(bytes.NewReader(body)); err == nil {
+		for _, href := range documentLinks(doc, resp.Request.URL) {
+			if normalizedURL, err := bc.normalizeURL(href, true); err == nil {
+				served.links[bc.canonicalURL(normalizedURL)] = true
+			}
+		}
+	}
+	return served, entry != nil && served.hash == entry.Hash, nil
+}
+
+// cacheListing stores what the listing page pageURL yielded after the
+// browser rendered it. Pages whose posts only appear once scripts ran
+// aren't cached: their HTML stays the same while the posts change.
+func (bc *BlogCrawler) cacheListing(pageURL string, urls []string, next string) {
+	served := bc.served
+	bc.served = nil
+	if served == nil || served.pageURL != pageURL || len(urls) == 0 {
+		return
+	}
+	for _, postURL := range urls {
+		if !served.links[postURL] {
+			return
+		}
+	}
+	bc.opts.ListingCache.put(pageURL, &cachedListing{
+		ETag:         served.etag,
+		LastModified: served.lastModified,
+		Hash:         served.hash,
+		URLs:         urls,
+		Next:         next,
+		Checked:      time.Now().Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// roundTripFunc answers a client's requests without a network
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestFakeCrawlListingCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "listings.json")
+	chrome := fakePagedBlog()
+
+	// The blog's server: page 2 has an ETag, page 3 no validators at all
+	var mu sync.Mutex
+	var notModified []string
+	server := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		resp := &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Request: req}
+		body := chrome.site[req.URL.String()]
+		if strings.HasSuffix(req.URL.Path, "/page/2/") {
+			resp.Header.Set("ETag", `"v1"`)
+			if req.Header.Get("If-None-Match") == `"v1"` {
+				mu.Lock()
+				notModified = append(notModified, req.URL.String())
+				mu.Unlock()
+				resp.StatusCode, body = http.StatusNotModified, ""
+			}
+		}
+		resp.Body = io.NopCloser(strings.NewReader(body))
+		return resp, nil
+	})
+
+	var output bytes.Buffer
+	crawl := func(want []string) (*CrawlResult, []string) {
+		t.Helper()
+		cache, err := loadListingCache(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chrome.visits = nil
+		output.Reset()
+		bc := newFakeCrawler(fakeBlogURL, chrome, CrawlOptions{Sort: "url", ListingCache: cache, Output: &output})
+		bc.http = &http.Client{Transport: server}
+		result, err := bc.crawl(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(result.BlogURLs, want) {
+			t.Errorf("post URLs\n got: %q\nwant: %q", result.BlogURLs, want)
+		}
+		return result, chrome.visited()
+	}
+
+	first, _ := crawl(fakePagedURLs)
+	if first.Stats.ListingCacheHits != 0 {
+		t.Errorf("first crawl took %d pages from the empty cache", first.Stats.ListingCacheHits)
+	}
+
+	// The pages were cached some time ago
+	cache, err := loadListingCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range cache.Pages {
+		entry.Checked = "2024-01-02T15:04:05Z"
+	}
+	if err := cache.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	second, visited := crawl(fakePagedURLs)
+	if second.Stats.ListingCacheHits != 2 {
+		t.Errorf("second crawl took %d pages from the cache, want 2", second.Stats.ListingCacheHits)
+	}
+	if got := strings.Count(output.String(), "Unchanged since 2024-01-02,"); got != 2 {
+		t.Errorf("%d pages reported unchanged since they were cached, want 2:\n%s", got, output.String())
+	}
+	for _, pageURL := range visited {
+		if pageURL != fakeBlogURL {
+			t.Errorf("second crawl rendered %s, want only the first page", pageURL)
+		}
+	}
+	if want := []string{fakeBlogURL + "page/2/"}; !reflect.DeepEqual(notModified, want) {
+		t.Errorf("304s for %q, want %q", notModified, want)
+	}
+
+	// A changed page is rendered again
+	chrome.site[fakeBlogURL+"page/3/"] = fakeListing("", "why-we-shard-by-tenant", "testing-with-fake-clocks", "a-new-post")
+	third, visited := crawl(append([]string{fakeBlogURL + "a-new-post"}, fakePagedURLs...))
+	if third.Stats.ListingCacheHits != 1 || !contains(visited, fakeBlogURL+"page/3/") {
+		t.Errorf("%d pages from the cache, rendered %q; want page 2 cached and page 3 rendered", third.Stats.ListingCacheHits, visited)
+	}
+}
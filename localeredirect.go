@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// localeSegmentPattern matches a path segment naming a language or
+// country: "en", "en-US", "en_us", "us"
+var localeSegmentPattern = regexp.MustCompile(`^(?i)[a-z]{2}([-_][a-z]{2,4})?$`)
+
+// Redirect is one hop of a redirect chain
+type Redirect struct {
+	URL    string `json:"url"`
+	Status int    `json:"status,omitempty"` // HTTP status of the redirect; 0 for one made by the page's scripts
+}
+
+// LocaleRedirect records a listing URL that was redirected to the home
+// page of a locale, and the localized listing crawled instead
+type LocaleRedirect struct {
+	Requested string     `json:"requested"`
+	Chain     []Redirect `json:"chain"`              // Where the request went, ending at the locale's home page
+	Locale    string     `json:"locale"`             // Path prefix of the locale, like "/en-US"
+	Followed  string     `json:"followed,omitempty"` // The listing under the locale, "" if it redirected too
+}
+
+// localePrefix returns the leading locale segments of path, like "/en-US"
+// or "/us/en", and the rest of the path
+func localePrefix(path string) (string, string) {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	n := 0
+	for n < len(segments) && n < 2 && localeSegmentPattern.MatchString(segments[n]) {
+		n++
+	}
+	if n == 0 {
+		return "", path
+	}
+	return "/" + strings.Join(segments[:n], "/"), "/" + strings.Join(segments[n:], "/")
+}
+
+// localeLanding returns the locale prefix when loading requested ended on
+// the home page of a locale of the same site although it asked for a page
+// deeper down, "" otherwise
+func localeLanding(requested, final string) string {
+	requestedURL, err := url.Parse(requested)
+	if err != nil {
+		return ""
+	}
+	finalURL, err := url.Parse(final)
+	if err != nil || !sameSite(requestedURL, finalURL) {
+		return ""
+	}
+	prefix, rest := localePrefix(finalURL.Path)
+	if prefix == "" || strings.Trim(rest, "/") != "" {
+		return ""
+	}
+	if _, requestedRest := localePrefix(requestedURL.Path); strings.Trim(requestedRest, "/") == "" {
+		return "" // The home page was asked for
+	}
+	return prefix
+}
+
+// sameSite reports whether a and b are on the same host, ignoring www.
+func sameSite(a, b *url.URL) bool {
+	return strings.TrimPrefix(strings.ToLower(a.Hostname()), "www.") == strings.TrimPrefix(strings.ToLower(b.Hostname()), "www.")
+}
+
+// localized returns pageURL under the blog's locale once a redirect taught
+// it one (see followLocaleRedirect), pageURL itself otherwise
+func (bc *BlogCrawler) localized(pageURL string) string {
+	if bc.locale == "" {
+		return pageURL
+	}
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil {
+		return pageURL
+	}
+	baseURL, err := url.Parse(bc.baseURL)
+	if err != nil || !sameSite(parsedURL, baseURL) {
+		return pageURL
+	}
+	if prefix, _ := localePrefix(parsedURL.Path); prefix != "" {
+		return pageURL
+	}
+	parsedURL.Path = bc.locale + parsedURL.Path
+	return parsedURL.String()
+}
+
+// withoutLocale returns urlStr with the blog's locale prefix removed from
+// its path, so localized links are classified like the blog's own
+func (bc *BlogCrawler) withoutLocale(urlStr string) string {
+	if bc.locale == "" {
+		return urlStr
+	}
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil || !strings.HasPrefix(parsedURL.Path, bc.locale+"/") {
+		return urlStr
+	}
+	parsedURL.Path = strings.TrimPrefix(parsedURL.Path, bc.locale)
+	return parsedURL.String()
+}
+
+// localeRedirect checks where loading requested ended up. When it landed
+// on a locale's home page, it records the redirect, keeps the locale for
+// the pages after it and returns the listing under the locale to load
+// instead. It fails when requested was already localized, rather than
+// letting the crawl read the home page.
+func (bc *BlogCrawler) localeRedirect(ctx context.Context, requested string, chain []Redirect) (string, error) {
+	final, err := bc.page.URL(ctx)
+	if err != nil {
+		return "", nil
+	}
+	prefix := localeLanding(requested, final)
+	if prefix == "" {
+		return "", nil
+	}
+	if len(chain) == 0 || chain[len(chain)-1].URL != final {
+		chain = append(chain, Redirect{URL: final})
+	}
+	redirect := LocaleRedirect{Requested: requested, Chain: chain, Locale: prefix}
+	if requestedPrefix, _ := localePrefix(urlPath(requested)); requestedPrefix != "" {
+		bc.localeRedirects = append(bc.localeRedirects, redirect)
+		return "", fmt.Errorf("%s redirects to the home page %s", requested, final)
+	}
+
+	bc.locale = prefix
+	redirect.Followed = bc.localized(requested)
+	bc.localeRedirects = append(bc.localeRedirects, redirect)
+	bc.printf("  %s redirected to the home page %s; loading %s instead\n", requested, final, redirect.Followed)
+	return redirect.Followed, nil
+}
+
+// urlPath returns the path of pageURL, "" if it doesn't parse
+func urlPath(pageURL string) string {
+	parsedURL, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	return parsedURL.Path
+}
+
+// redirectChain watches the page for the redirects of its next main
+// document load. The returned function yields the hops so far; pages
+// other than Chrome's don't report them.
+func (bc *BlogCrawler) redirectChain(ctx context.Context) func() []Redirect {
+	page := rodPageOf(bc.page)
+	if page == nil {
+		return func() []Redirect { return nil }
+	}
+	hops := make(chan Redirect, 20)
+	wait := page.Context(ctx).EachEvent(func(e *proto.NetworkRequestWillBeSent) {
+		if e.Type != proto.NetworkResourceTypeDocument || e.FrameID != page.FrameID || e.RedirectResponse == nil {
+			return
+		}
+		select {
+		case hops <- Redirect{URL: e.Request.URL, Status: e.RedirectResponse.Status}:
+		default:
+		}
+	})
+	go wait()
+
+	return func() []Redirect {
+		var chain []Redirect
+		for {
+			select {
+			case hop := <-hops:
+				chain = append(chain, hop)
+			default:
+				return chain
+			}
+		}
+	}
+}
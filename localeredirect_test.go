@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLocaleLanding(t *testing.T) {
+	tests := []struct {
+		requested, final, want string
+	}{
+		{"https://www.uber.com/blog/page/4/", "https://www.uber.com/en-US/", "/en-US"},
+		{"https://www.uber.com/blog/", "https://uber.com/us/en/", "/us/en"},
+		{"https://www.uber.com/blog/page/4/", "https://www.uber.com/en-US/blog/page/4/", ""}, // The localized listing itself
+		{"https://www.uber.com/", "https://www.uber.com/en-US/", ""},                         // The home page was asked for
+		{"https://www.uber.com/blog/", "https://www.other.com/en-US/", ""},
+		{"https://blog.example.com/page/2/", "https://blog.example.com/", ""},
+	}
+	for _, tt := range tests {
+		if got := localeLanding(tt.requested, tt.final); got != tt.want {
+			t.Errorf("localeLanding(%q, %q) = %q, want %q", tt.requested, tt.final, got, tt.want)
+		}
+	}
+}
+
+func TestLocaleRedirectFollowed(t *testing.T) {
+	baseURL := "https://blog.example.com/blog/"
+	chrome := &fakeChrome{
+		site: map[string]string{
+			"https://blog.example.com/en-us/": `<html><body><h1>Welcome</h1><a href="/en-us/rides">Rides</a></body></html>`,
+			"https://blog.example.com/en-us/blog/": `<html><body><main>` +
+				`<article><a href="/en-us/blog/scaling-the-ingest-queue/">Scaling the ingest queue</a></article>` +
+				`<article><a href="/en-us/blog/moving-search-to-rust/">Moving search to Rust</a></article>` +
+				`</main><a rel="next" href="/en-us/blog/page/2/">Older posts</a></body></html>`,
+			"https://blog.example.com/en-us/blog/page/2/": `<html><body><main>` +
+				`<article><a href="/en-us/blog/testing-with-fake-clocks/">Testing with fake clocks</a></article>` +
+				`</main></body></html>`,
+		},
+		redirects: map[string]string{
+			baseURL:                                 "https://blog.example.com/en-us/",
+			"https://blog.example.com/blog/page/2/": "https://blog.example.com/en-us/",
+		},
+	}
+	result, err := newFakeCrawler(baseURL, chrome, CrawlOptions{Sort: "url"}).crawl(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"https://blog.example.com/en-us/blog/moving-search-to-rust/",
+		"https://blog.example.com/en-us/blog/scaling-the-ingest-queue/",
+		"https://blog.example.com/en-us/blog/testing-with-fake-clocks/",
+	}
+	if !reflect.DeepEqual(result.BlogURLs, want) {
+		t.Errorf("post URLs\n got: %q\nwant: %q", result.BlogURLs, want)
+	}
+	wantRedirects := []LocaleRedirect{{
+		Requested: baseURL,
+		Chain:     []Redirect{{URL: "https://blog.example.com/en-us/"}},
+		Locale:    "/en-us",
+		Followed:  "https://blog.example.com/en-us/blog/",
+	}}
+	if !reflect.DeepEqual(result.LocaleRedirects, wantRedirects) {
+		t.Errorf("locale redirects\n got: %+v\nwant: %+v", result.LocaleRedirects, wantRedirects)
+	}
+}
+
+func TestLocaleRedirectLoop(t *testing.T) {
+	baseURL := "https://blog.example.com/en-us/blog/"
+	chrome := &fakeChrome{
+		site:      map[string]string{"https://blog.example.com/en-us/": `<html><body><a href="/en-us/blog/a-post/">A post</a></body></html>`},
+		redirects: map[string]string{baseURL: "https://blog.example.com/en-us/"},
+	}
+	_, err := newFakeCrawler(baseURL, chrome, CrawlOptions{}).crawl(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "redirects to the home page") {
+		t.Errorf("err = %v, want the crawl to refuse the home page", err)
+	}
+}
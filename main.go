@@ -3,151 +3,703 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
+	"text/template"
 	"time"
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/launcher"
+
+	"manual-blog-crawler/urlfilter"
 )
 
 type BlogCrawler struct {
-	browser *rod.Browser
-	page    *rod.Page
+	browser Browser
+	page    Page
 	baseURL string
 	timeout time.Duration
+	opts    CrawlOptions
+	site    siteProfile // Settings for this blog, see siteProfileFor
+	pages   []PageStat
+	errors  []string
+
+	// URL patterns of pages that aren't posts, see excludePatterns
+	excludes []urlfilter.ExcludePattern
+
+	// Category page patterns, and the paths linked from the navigation
+	// for their nav: conditions (see learnNavigation)
+	categories []urlfilter.CategoryPattern
+	navPaths   map[string]bool
+
+	// Listing-like pages seen while crawling, followed when
+	// opts.listingDepth() > 1, and the depth of the page being crawled
+	// (see crawlArchives)
+	frontier     Frontier
+	listingLevel int
+
+	// Browser supervision: the launcher is kept to kill a hung browser
+	// process, and restarts counts relaunches (see restartBrowser)
+	launcher *launcher.Launcher
+	restarts int
+
+	// Starts the browser in place of launching Chrome, for the tests' fake
+	newBrowser func(ctx context.Context) (Browser, error)
+
+	// Product and version of the browser, for the result's run info
+	browserVersion string
+
+	// Navigations in the current tab, for opts.RecyclePages
+	navigations int
+
+	// Links already printed by explain in --dry-run mode
+	explained map[string]bool
+
+	// Links already passed to the site script's on_link_found
+	linksFound map[string]bool
+
+	// Per-selector hit rates, and the post URLs already credited to a
+	// selector (see recordSelector)
+	selectorStats []SelectorStat
+	attributed    map[string]bool
+
+	// Anchors of the listing page being swept, by href, for the text of
+	// the links posts are discovered by (see recordLinkText)
+	linkAnchors map[string]anchor
+
+	// Posts whose listing card marks them member-only, see recordMemberOnly
+	memberOnly map[string]bool
+
+	// Network recording for opts.HAR
+	har *harRecorder
+
+	// First URL seen for every URL key, see canonicalURL
+	seenURLs map[string]string
+
+	// Where every post URL was first found (see recordDiscovery), and the
+	// listing step currently being extracted
+	discovered map[string]Discovery
+	source     discoverySource
+
+	// Counters for the result's stats block
+	loads      int
+	loadTime   time.Duration
+	scrolls    int
+	scrollWait time.Duration
+	rejected   map[string]bool
+	rejections map[string]int
+
+	// Infinite scroll: where the feed was left, for the result, and whether
+	// new links are taken from the observer (see scrollURLs)
+	offset        *FeedOffset
+	harvestActive bool
+
+	// Set on the copies visiting posts in parallel, see newPostWorker
+	postWorker bool
+
+	// Client for posts fetched without the browser, see fetchPostHTTP
+	http *http.Client
+
+	// Rate-limit responses backed off from, for the stats
+	rateLimits []RateLimit
+
+	// What the crawl has spent of its budget, nil without one
+	budget *budgetTracker
+
+	// Requests and bytes of the crawl, for the stats
+	traffic *trafficCounter
+
+	// XHR and fetch requests in flight, for waitAfterScroll
+	network *networkActivity
+
+	// Full listing linked from the front page, see followViewAll
+	listingURL string
+
+	// Posts sharing a title, for the stats (see findDuplicateTitles)
+	duplicates []DuplicateTitle
+
+	// Failed sanity checks of the built-in adapters (see checkAdapter)
+	staleAdapters []StaleAdapter
+
+	// The listing page last taken from opts.ListingCache, nil when the
+	// page in the browser is the current one; the HTML of the page last
+	// checked against the cache; and the pages taken from it
+	cachedPage       *cachedListing
+	served           *servedListing
+	listingCacheHits int
+
+	// Path prefix of the locale the blog redirected to, like "/en-US", and
+	// the redirects to locale home pages met (see localeRedirect)
+	locale          string
+	localeRedirects []LocaleRedirect
+}
+
+// CrawlOptions controls the optional passes that run after URL discovery
+type CrawlOptions struct {
+	Screenshot     bool          // Capture a full-page PNG of every post
+	PDF            bool          // Print every post to PDF
+	DownloadImages bool          // Save the main content of every post as HTML with its images, see archivePost
+	CaptureDir     string        // Directory the captures are written to
+	DiagnosticsDir string        // Where a crawl finding no posts writes its diagnostics, "" for nowhere
+	WaybackSave    bool          // Submit every post to the Save Page Now API
+	WaybackLookup  bool          // Annotate posts with their latest existing snapshot
+	WaybackDelay   time.Duration // Minimum delay between Wayback Machine requests
+	FetchContent   bool          // Extract title, text and a content hash from every post
+	Engagement     bool          // Record claps, reactions, comments and Hacker News discussions of every post
+	Depth          int           // Listing levels to crawl; 2 also follows archive and category pages
+
+	// Also add the post URLs the Wayback Machine archived under the blog,
+	// looking at up to WaybackDiscoverLimit of them (0 for the default)
+	WaybackDiscover      bool
+	WaybackDiscoverLimit int
+
+	// Per-post passes: tabs visiting posts at the same time, how many of
+	// them may load pages from one host, and retries of failed loads
+	PostWorkers         int
+	PostHostConcurrency int
+	PostRetries         int
+	// "browser" (default) or "hybrid": fetch posts over plain HTTP and only
+	// load those that need JavaScript in the browser
+	FetchMode string
+
+	// Remove posts whose title another post under a shorter URL has,
+	// instead of only flagging them
+	CollapseDuplicates bool
+
+	// Also harvest posts from author and tag pages, for blogs whose front
+	// page only shows a selection of posts
+	ExpandAuthorsTags bool
+
+	// Browser resource limits for long crawls
+	BrowserMemoryMB      int  // JavaScript heap limit per renderer process
+	DisableDevShm        bool // Use /tmp instead of /dev/shm for shared memory
+	RendererProcessLimit int  // Maximum number of renderer processes
+	RecyclePages         int  // Replace the tab with a fresh one every N navigations
+	PruneEvery           int  // Infinite scroll: remove harvested post cards every N scrolls
+
+	// Limits on the requests, bandwidth and time spent on each blog, for
+	// all phases together; a site profile's budget overrides them
+	Budget crawlBudget
+
+	// Viewport, timezone and Accept-Language of every tab; a site
+	// profile's emulation overrides them
+	Emulation browserEmulation
+
+	// Paginated crawls: empty or failed pages in a row that end the crawl
+	// (0 means defaultMaxEmptyPages)
+	MaxEmptyPages int
+
+	// Infinite scroll stop conditions. Zero values mean the defaults for
+	// the idle limit and delay and disable the others.
+	ScrollIdleLimit  int           // Scrolls without new posts (or growth, with StopOnHeight) before stopping
+	ScrollDelay      time.Duration // Wait after each scroll when nothing loads; loading settling ends it sooner
+	FixedScrollDelay bool          // Always wait ScrollDelay after a scroll, as loading can't be watched
+	MaxScrolls       int
+	ScrollTimeout    time.Duration // Time spent scrolling
+	MaxURLs          int           // Stop once this many post URLs are found
+	StopBefore       time.Time     // Stop once the feed shows posts published before this
+	StopOnHeight     bool          // Measure progress by page height instead of new posts
+
+	// Batch crawls: a browser shared between concurrent crawls, each of
+	// which runs in its own incognito context, and a limiter spacing out
+	// page loads per host across all of them
+	SharedBrowser *rod.Browser
+	HostLimiter   *hostLimiter
+
+	// Only classify the links of the listing pages: print every decision
+	// and skip the per-post passes and all output
+	DryRun bool
+
+	// Save every listing page with the post URLs found on it to this
+	// directory, for the replay subcommand and the fixture tests
+	RecordFixtures string
+
+	// Record the crawl's network traffic to this HAR file
+	HAR string
+
+	// Don't move from the front page to a full listing it links to
+	NoViewAll bool
+
+	// Also ask a search engine for the blog's pages, nil to skip
+	SeedSearch *SeedSearch
+
+	// How listing pages are walked: "auto" (default), "scroll",
+	// "archive-months", "next-link", "tabs" or "search", see crawlStrategies
+	Strategy string
+	// Search results to page through with the search strategy: a URL with
+	// {page} or {page0} for the page number, overriding the site profile's
+	SearchURL string
+
+	// Order of the result's URLs and posts: "date" (default), "url",
+	// "discovery" or "popularity"
+	Sort string
+
+	// Site profiles from --sites, tried before the built-in ones, and the
+	// command line's query parameter rule, which overrides the profile's
+	Sites           []siteProfile
+	KeepQueryParams []string
+	DropQueryParams []string
+	// Exclude patterns to add and remove, after the profile's
+	ExcludePatterns       []string
+	RemoveExcludePatterns []string
+	// Category page patterns, instead of the profile's
+	CategoryPages []string
+	// Load the post URLs that could be listings and drop those that are,
+	// see classifyAmbiguous
+	ClassifyPages bool
+	// Leave out the posts a Medium listing marks as member-only
+	ExcludeMemberOnly bool
+
+	// Result of an earlier crawl to continue: its URLs are kept and an
+	// infinite scroll starts at its feed offset
+	Resume *CrawlResult
+
+	// Regions to crawl the blog from, merging the results; none for a
+	// single crawl (see crawlRegions)
+	Regions []crawlRegion
+
+	// Listing pages of earlier runs, to skip rendering those that haven't
+	// changed; nil for no cache (see listingFromCache)
+	ListingCache *listingCache
+
+	// Where archive pages to follow are queued; nil queues them in memory
+	// (see crawlArchives)
+	Frontier FrontierStore
+
+	// Journal every page visited, link decided on and error is appended
+	// to as the crawl goes; nil for none
+	Journal *crawlJournal
+
+	// Named browser profile (or user data directory) whose cookies, cache
+	// and storage persist between crawls; empty crawls in a throwaway one
+	Profile string
+
+	// Proxy the browser and the crawl's own HTTP fetches go through, like
+	// http://host:3128 or socks5://host:1080
+	Proxy string
+
+	// Show the browser window instead of running headless
+	Headful bool
+
+	// OnProgress, when set, is called after every listing page, scroll
+	// iteration and post visit so callers can follow the crawl as it runs
+	OnProgress func(ProgressEvent)
+
+	// Where the crawl's progress is printed, os.Stdout when nil
+	Output io.Writer
+
+	// Flags set for this run by name, from the command line, the config
+	// file or the environment, recorded in the result's run info
+	Flags map[string]string
 }
 
+// ProgressEvent describes one completed step of a crawl
+type ProgressEvent struct {
+	Kind      string // "page", "scroll", "archive", "tab", "search" or "post"
+	Step      int    // Listing page number, scroll iteration, archive page, tab, search page or post index, from 1
+	Steps     int    // Number of steps when known in advance (listing pages, posts), 0 otherwise
+	URL       string // Page that was processed
+	URLsFound int    // Blog URLs found by this step
+	TotalURLs int    // Unique blog URLs found so far
+}
+
+// needsPosts reports whether any per-post pass is enabled
+func (o CrawlOptions) needsPosts() bool {
+	if o.DryRun {
+		return false
+	}
+	return o.visitsPosts() || o.WaybackSave || o.WaybackLookup
+}
+
+// listingDepth is the number of listing levels to crawl. Author and tag
+// expansion needs at least the second level.
+func (o CrawlOptions) listingDepth() int {
+	if o.ExpandAuthorsTags && o.Depth < 2 {
+		return 2
+	}
+	return o.Depth
+}
+
+// output returns where the crawl's progress is printed
+func (o CrawlOptions) output() io.Writer {
+	if o.Output == nil {
+		return os.Stdout
+	}
+	return o.Output
+}
+
+// visitsPosts reports whether the browser has to load each post page
+func (o CrawlOptions) visitsPosts() bool {
+	return o.Screenshot || o.PDF || o.DownloadImages || o.FetchContent || o.Engagement
+}
+
+// CrawlResult is the result document. Its JSON form is versioned by
+// SchemaVersion (see resultSchemaVersion) and described by the schema
+// subcommand.
 type CrawlResult struct {
-	BaseURL    string   `json:"base_url"`
-	BlogURLs   []string `json:"blog_urls"`
-	TotalCount int      `json:"total_count"`
-	CrawledAt  string   `json:"crawled_at"`
+	SchemaVersion   int                 `json:"schema_version"` // Absent (0) in results written before versioning
+	BaseURL         string              `json:"base_url"`
+	BlogURLs        []string            `json:"blog_urls"`
+	TotalCount      int                 `json:"total_count"`
+	CrawledAt       string              `json:"crawled_at"` // RFC 3339
+	Posts           []Post              `json:"posts,omitempty"`
+	New             []string            `json:"new,omitempty"`     // Incremental mode: URLs missing from the previous run
+	Changed         []string            `json:"changed,omitempty"` // Incremental mode: URLs whose content hash changed
+	Pages           []PageStat          `json:"pages,omitempty"`
+	Selectors       []SelectorStat      `json:"selectors,omitempty"`
+	Discovery       []Discovery         `json:"discovery,omitempty"`   // Where each URL was first found, in blog_urls order
+	MemberOnly      []string            `json:"member_only,omitempty"` // Medium posts whose listing card has the member-only star
+	Stats           *CrawlStats         `json:"stats,omitempty"`
+	Diagnostics     *Diagnostics        `json:"diagnostics,omitempty"`      // Why the crawl found no posts
+	LocaleRedirects []LocaleRedirect    `json:"locale_redirects,omitempty"` // Listing pages redirected to a locale's home page
+	Regions         []RegionCrawl       `json:"regions,omitempty"`          // --country: the crawl from each region
+	RegionalURLs    map[string][]string `json:"regional_urls,omitempty"`    // --country: URLs not every region found, with the regions that did
+	Run             *RunInfo            `json:"run,omitempty"`              // How the result was produced, for reproducing it
+	Offset          *FeedOffset         `json:"offset,omitempty"`           // Infinite scroll: where the crawl stopped, for --resume
+	Errors          []string            `json:"errors,omitempty"`           // Non-fatal problems hit during the crawl
+}
+
+// PageStat records the outcome of crawling one listing page
+type PageStat struct {
+	Number    int    `json:"number"`
+	URL       string `json:"url"`
+	URLsFound int    `json:"urls_found"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Post holds per-post data gathered by the optional per-post passes
+type Post struct {
+	URL              string      `json:"url"`
+	Status           int         `json:"status,omitempty"`         // HTTP status of the post page, when visited
+	ContentLength    int         `json:"content_length,omitempty"` // Bytes of HTML
+	ResponseMS       int         `json:"response_ms,omitempty"`    // From request to the last byte of the response
+	Screenshot       string      `json:"screenshot,omitempty"`
+	PDF              string      `json:"pdf,omitempty"`
+	Archive          string      `json:"archive,omitempty"` // --download-images: the post's content as HTML
+	Images           []PostImage `json:"images,omitempty"`  // --download-images: the images it shows
+	WaybackURL       string      `json:"wayback_url,omitempty"`
+	WaybackTimestamp string      `json:"wayback_timestamp,omitempty"`
+	Title            string      `json:"title,omitempty"`
+	Published        string      `json:"published,omitempty"` // As stated by the page, usually ISO 8601
+	Category         string      `json:"category,omitempty"`
+	Content          string      `json:"content,omitempty"`
+	ContentHash      string      `json:"content_hash,omitempty"`
+	Paywalled        bool        `json:"paywalled,omitempty"`     // The page has a paywall or registration wall, so Content is only what comes before it
+	Paywall          string      `json:"paywall,omitempty"`       // What gave the wall away, see documentPaywall
+	Links            []string    `json:"links,omitempty"`         // Other posts of the same blog this post links to
+	DuplicateOf      string      `json:"duplicate_of,omitempty"`  // Post with the same title under another URL
+	CrossPostOf      string      `json:"cross_post_of,omitempty"` // Post with the same content on another blog crawled before this one
+	Authors          []string    `json:"authors,omitempty"`       // Names in the byline (see parseByline)
+	Regions          []string    `json:"regions,omitempty"`       // --country: regions whose crawl found the post
+	References       []Reference `json:"references,omitempty"`
+	Repositories     []string    `json:"repositories,omitempty"` // GitHub and GitLab repositories the references point into
+	Engagement       *Engagement `json:"engagement,omitempty"`   // --engagement
 }
 
-func NewBlogCrawler(baseURL string, timeout time.Duration) *BlogCrawler {
+func NewBlogCrawler(baseURL string, timeout time.Duration, opts CrawlOptions) *BlogCrawler {
+	site := siteProfileFor(baseURL, opts.Sites)
 	return &BlogCrawler{
-		baseURL: baseURL,
-		timeout: timeout,
+		baseURL:    baseURL,
+		timeout:    timeout,
+		opts:       opts,
+		site:       site,
+		excludes:   excludePatterns(site, opts),
+		categories: categoryPatterns(site, opts),
+		http:       crawlClient(timeout, opts.Proxy),
+	}
+}
+
+// printf prints the crawl's progress to its output
+func (bc *BlogCrawler) printf(format string, args ...interface{}) {
+	fmt.Fprintf(bc.opts.output(), format, args...)
+}
+
+// warnf prints a warning and keeps it for the result's errors list
+func (bc *BlogCrawler) warnf(format string, args ...interface{}) {
+	message := redact(fmt.Sprintf(format, args...))
+	bc.printf("Warning: %s\n", message)
+	bc.errors = append(bc.errors, message)
+	bc.journal(journalEvent{Event: "error", Reason: message})
+}
+
+func (bc *BlogCrawler) recordPage(number int, pageURL string, urlsFound int, err error) {
+	stat := PageStat{Number: number, URL: pageURL, URLsFound: urlsFound}
+	if err != nil {
+		stat.Error = err.Error()
+	}
+	bc.pages = append(bc.pages, stat)
+	bc.journal(journalEvent{Event: "page", URL: pageURL, Step: number, URLsFound: urlsFound, Reason: stat.Error})
+}
+
+func (bc *BlogCrawler) reportProgress(event ProgressEvent) {
+	if event.Kind == "post" {
+		bc.journal(journalEvent{Event: "post", URL: event.URL})
+	}
+	if bc.opts.OnProgress != nil {
+		bc.opts.OnProgress(event)
 	}
 }
 
-func (bc *BlogCrawler) initializeBrowser() error {
+func (bc *BlogCrawler) initializeBrowser(ctx context.Context) error {
+	// Batch crawls share one browser; each crawl gets its own incognito
+	// context so cookies and storage stay with the blog that set them,
+	// unless a named profile was asked for to keep them
+	if bc.opts.SharedBrowser != nil {
+		if bc.opts.Profile != "" {
+			bc.browser = rodBrowser{browser: bc.opts.SharedBrowser}
+			return nil
+		}
+		browser, err := bc.opts.SharedBrowser.Incognito()
+		if err != nil {
+			return fmt.Errorf("failed to create incognito context: %w", err)
+		}
+		bc.browser = rodBrowser{browser: browser}
+		return nil
+	}
+	if bc.newBrowser != nil {
+		browser, err := bc.newBrowser(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to launch browser: %w", err)
+		}
+		bc.browser = browser
+		return nil
+	}
+
+	browser, launcher, err := launchBrowser(ctx, bc.opts)
+	if err != nil {
+		return err
+	}
+	bc.browser = rodBrowser{browser: browser}
+	bc.launcher = launcher
+	return nil
+}
+
+// chromePaths are the common Chrome/Chromium paths on macOS, tried before
+// the browser rod finds or downloads
+var chromePaths = []string{
+	"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+	"/Applications/Chromium.app/Contents/MacOS/Chromium",
+}
+
+// launchBrowser starts a headless Chrome, or a visible one with
+// opts.Headful, with the resource limits of opts
+// and connects to it
+func launchBrowser(ctx context.Context, opts CrawlOptions) (*rod.Browser, *launcher.Launcher, error) {
 	// Try to use system Chrome/Chromium if available
 	launcher := launcher.New().
-		Headless(true).
+		Context(ctx).
+		Headless(!opts.Headful).
 		Set("disable-blink-features", "AutomationControlled")
 
-	// Try common Chrome/Chromium paths on macOS
-	chromePaths := []string{
-		"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
-		"/Applications/Chromium.app/Contents/MacOS/Chromium",
-	}
-
 	for _, path := range chromePaths {
 		if _, err := os.Stat(path); err == nil {
 			launcher = launcher.Bin(path)
 			break
 		}
 	}
+	launcher = opts.applyResourceLimits(launcher)
+	if opts.Proxy != "" {
+		launcher = launcher.Proxy(opts.Proxy)
+	}
+
+	// Without a profile rod starts Chrome in a fresh temporary one
+	if opts.Profile != "" {
+		dir, err := profileDir(opts.Profile)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, nil, fmt.Errorf("failed to create profile directory: %w", err)
+		}
+		launcher = launcher.UserDataDir(dir)
+	}
 
 	browserURL, err := launcher.Launch()
 	if err != nil {
-		return fmt.Errorf("failed to launch browser: %w", err)
+		return nil, nil, fmt.Errorf("failed to launch browser: %w", err)
 	}
 
-	bc.browser = rod.New().ControlURL(browserURL)
-	if err := bc.browser.Connect(); err != nil {
-		return fmt.Errorf("failed to connect to browser: %w", err)
+	browser := rod.New().ControlURL(browserURL)
+	if err := browser.Connect(); err != nil {
+		launcher.Kill()
+		return nil, nil, fmt.Errorf("failed to connect to browser: %w", err)
 	}
 
-	return nil
+	return browser, launcher, nil
 }
 
-func (bc *BlogCrawler) navigateToPage() error {
-	// Create a new page
-	var pageErr error
-	bc.page = func() *rod.Page {
-		defer func() {
-			if r := recover(); r != nil {
-				pageErr = fmt.Errorf("failed to create page: %v", r)
-			}
-		}()
-		return bc.browser.MustPage("")
-	}()
+// openPage creates the tab the crawl runs in
+func (bc *BlogCrawler) openPage() error {
+	page, err := bc.browser.NewPage()
+	if err != nil {
+		return err
+	}
+	bc.page = page
 
-	if pageErr != nil {
-		return pageErr
+	if tab := rodPageOf(page); tab != nil {
+		if err := bc.emulate(tab); err != nil {
+			return err
+		}
+		if bc.har != nil {
+			bc.har.watch(tab)
+		}
+		bc.budget.watch(tab)
+		bc.traffic.watch(tab)
+		bc.network.watch(tab)
+	}
+	return nil
+}
+
+func (bc *BlogCrawler) navigateToPage(ctx context.Context) error {
+	if err := bc.openPage(); err != nil {
+		return err
+	}
+	if err := bc.throttle(ctx, bc.baseURL); err != nil {
+		return err
+	}
+	if err := bc.budget.request(); err != nil {
+		return err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), bc.timeout)
+	ctx, cancel := context.WithTimeout(ctx, bc.timeout)
 	defer cancel()
 
-	if err := bc.page.Context(ctx).Navigate(bc.baseURL); err != nil {
+	redirects := bc.redirectChain(ctx)
+	start := time.Now()
+	if err := bc.page.Navigate(ctx, bc.baseURL); err != nil {
 		return fmt.Errorf("failed to navigate to %s: %w", bc.baseURL, err)
 	}
 
-	if err := bc.page.Context(ctx).WaitLoad(); err != nil {
+	if err := bc.page.WaitLoad(ctx); err != nil {
 		return fmt.Errorf("failed to wait for page load: %w", err)
 	}
+	localizedURL, err := bc.localeRedirect(ctx, bc.baseURL, redirects())
+	if err != nil {
+		return err
+	}
+	if localizedURL != "" {
+		if err := bc.page.Navigate(ctx, localizedURL); err != nil {
+			return fmt.Errorf("failed to navigate to %s: %w", localizedURL, err)
+		}
+		if err := bc.page.WaitLoad(ctx); err != nil {
+			return fmt.Errorf("failed to wait for page load: %w", err)
+		}
+	}
+	bc.recordLoad(start)
+	bc.injectSiteJS(ctx, bc.baseURL)
+	bc.scriptPageLoaded(ctx, bc.baseURL)
 
 	return nil
 }
 
-func (bc *BlogCrawler) waitForContent() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+func (bc *BlogCrawler) waitForContent(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	// Wait for initial content to load
-	return bc.page.Context(ctx).WaitStable(time.Millisecond * 500)
+	return bc.page.WaitStable(ctx, time.Millisecond*500)
 }
 
+// normalizeURL resolves href against the base URL and drops its fragment.
+// Query parameters are filtered by the site's rule when keepQueryParams is
+// set (post links) and dropped entirely otherwise.
 func (bc *BlogCrawler) normalizeURL(href string, keepQueryParams bool) (string, error) {
-	// Parse base URL to get scheme and host
-	baseURLParsed, err := url.Parse(bc.baseURL)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse base URL: %w", err)
+	var query urlfilter.QueryParamRule
+	if keepQueryParams {
+		query = bc.queryParamRule()
 	}
+	return urlfilter.Normalize(bc.baseURL, href, query)
+}
 
-	// Parse the href
-	hrefParsed, err := url.Parse(href)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse href: %w", err)
+// hrefFinder returns the href attribute of every element matching a CSS
+// selector on a listing page, "" for elements without one
+type hrefFinder func(selector string) ([]string, error)
+
+// postLinksJS runs every selector in one round trip and returns the
+// anchors matching it, by selector; null for selectors the browser doesn't
+// support
+const postLinksJS = `(selectors) => {
+	const describe = ` + describeAnchorJS + `;
+	return selectors.map((selector) => {
+		try {
+			return Array.from(document.querySelectorAll(selector), describe);
+		} catch (e) {
+			return null;
+		}
+	});
+}`
+
+// postLinkAnchors returns what postLinksJS finds for the post link
+// selectors, by selector, or nil when the script failed
+func (bc *BlogCrawler) postLinkAnchors(ctx context.Context) map[string][]anchor {
+	selectors := bc.postLinkSelectors()
+	res, err := bc.page.Eval(ctx, postLinksJS, selectors)
+	if err != nil || res.Nil() {
+		return nil
 	}
-
-	// Resolve relative URLs
-	absoluteURL := baseURLParsed.ResolveReference(hrefParsed)
-
-	// Remove query parameters and fragments only if requested
-	if !keepQueryParams {
-		absoluteURL.RawQuery = ""
+	found := make(map[string][]anchor, len(selectors))
+	for i, matched := range res.Arr() {
+		if i < len(selectors) && !matched.Nil() {
+			found[selectors[i]] = parseAnchors(matched)
+		}
 	}
-	absoluteURL.Fragment = ""
-
-	return absoluteURL.String(), nil
+	return found
 }
 
-func (bc *BlogCrawler) extractBlogURLs() ([]string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// extractBlogURLs returns the post URLs on the loaded listing page. All
+// post link selectors are run in a single script; only the selectors it
+// couldn't run, and the navigation's, take a round trip of their own.
+func (bc *BlogCrawler) extractBlogURLs(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	// Try multiple selectors to catch different blog layouts
-	// Priority: Uber-specific first, then generic
-	selectors := []string{
-		`a[data-baseweb="card"][href]`,         // Uber blog posts (specific)
-		"article a[href]",                      // Links in articles
-		"h2 a[href]",                           // Links in h2 headings
-		"h3 a[href]",                           // Links in h3 headings
-		"[data-testid='post-preview-title'] a", // Medium specific
-		".post-title a",                        // Generic post title
-		".blog-post a",                         // Generic blog post
-		"a[href]",                              // All links (fallback)
+	found := bc.postLinkAnchors(ctx)
+	bc.linkAnchors = make(map[string]anchor)
+	defer func() { bc.linkAnchors = nil }()
+	urls, err := bc.collectPostURLs(func(selector string) ([]string, error) {
+		anchors, ok := found[selector]
+		if !ok {
+			var err error
+			if anchors, err = bc.anchors(ctx, selector); err != nil {
+				return nil, err
+			}
+		}
+		bc.keepAnchors(anchors)
+		return anchorHrefs(anchors), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if bc.site.script.has(hookExtractURLs) {
+		urls = bc.scriptURLs(ctx, urls)
 	}
 
+	if bc.opts.RecordFixtures != "" {
+		if err := bc.recordFixture(ctx, urls); err != nil {
+			bc.warnf("Could not record fixture: %v", err)
+		}
+	}
+
+	return urls, nil
+}
+
+// collectPostURLs runs every post link selector through find and returns
+// the unique post URLs among the links. It doesn't touch the browser, so
+// saved fixtures go through the same code as live pages. A link an earlier
+// selector matched, as the catch-all matches them all again, only counts
+// towards the selector's matches.
+func (bc *BlogCrawler) collectPostURLs(find hrefFinder) ([]string, error) {
 	urlSet := make(map[string]bool)
+	processed := make(map[string]bool) // Hrefs classified already
 
 	// Parse base URL to get domain for filtering
 	baseURLParsed, err := url.Parse(bc.baseURL)
@@ -156,22 +708,27 @@ func (bc *BlogCrawler) extractBlogURLs() ([]string, error) {
 	}
 	baseDomain := baseURLParsed.Host
 
-	for _, selector := range selectors {
-		elements, err := bc.page.Context(ctx).Elements(selector)
+	if bc.usesNavigation() {
+		bc.learnNavigation(find)
+	}
+
+	// Try multiple selectors to catch different blog layouts
+	for i, selector := range bc.postLinkSelectors() {
+		hrefs, err := find(selector)
 		if err != nil {
 			continue // Try next selector if this one fails
 		}
 
-		for _, elem := range elements {
-			href, err := elem.Attribute("href")
-			if err != nil || href == nil {
+		var accepted []string
+		for _, href := range hrefs {
+			if href == "" || processed[href] {
 				continue
 			}
+			processed[href] = true
 
-			// For Uber blog posts, keep query parameters (like ?uclick_id=...)
-			// For other sites, strip them
-			keepQueryParams := strings.Contains(bc.baseURL, "uber.com")
-			normalizedURL, err := bc.normalizeURL(*href, keepQueryParams)
+			// Query parameters are kept as far as the site's rule says
+			// (Uber's ?uclick_id=...); for most sites they're stripped
+			normalizedURL, err := bc.normalizeURL(href, true)
 			if err != nil {
 				continue
 			}
@@ -185,11 +742,27 @@ func (bc *BlogCrawler) extractBlogURLs() ([]string, error) {
 			// Filter to only include URLs from the same domain
 			if parsedURL.Host == baseDomain || parsedURL.Host == "" {
 				// Skip non-blog URLs (like /about, /archive, etc.)
-				if bc.isBlogPostURL(normalizedURL) {
+				ok, rule := bc.classifyURL(normalizedURL)
+				bc.explain(normalizedURL, ok, rule)
+				bc.scriptLinkFound(normalizedURL, ok, rule)
+				if ok {
+					normalizedURL = bc.canonicalURL(normalizedURL)
+					bc.recordDiscovery(normalizedURL, selector)
+					if a, ok := bc.linkAnchors[href]; ok {
+						bc.recordLinkText(normalizedURL, a)
+						bc.recordMemberOnly(normalizedURL, a)
+					}
 					urlSet[normalizedURL] = true
+					accepted = append(accepted, normalizedURL)
+				} else {
+					bc.recordRejection(normalizedURL, rule)
 				}
+			} else {
+				bc.explain(normalizedURL, false, "other domain "+parsedURL.Host)
+				bc.recordRejection(normalizedURL, "other domain")
 			}
 		}
+		bc.recordSelector(i, len(hrefs), accepted)
 	}
 
 	urls := make([]string, 0, len(urlSet))
@@ -200,167 +773,44 @@ func (bc *BlogCrawler) extractBlogURLs() ([]string, error) {
 	return urls, nil
 }
 
-func (bc *BlogCrawler) isBlogPostURL(urlStr string) bool {
-	parsedURL, err := url.Parse(urlStr)
-	if err != nil {
-		return false
-	}
-
-	path := strings.ToLower(parsedURL.Path)
-	urlLower := strings.ToLower(urlStr)
-
-	// Parse base URL to get base path
-	baseURLParsed, err := url.Parse(bc.baseURL)
-	if err != nil {
-		return false
+// classifyURL decides whether urlStr is a blog post and names the rule that
+// made the decision, for --dry-run
+func (bc *BlogCrawler) classifyURL(urlStr string) (bool, string) {
+	// The site's script gets the first say
+	if accept, ok := bc.scriptFilterURL(urlStr); ok {
+		return accept, "script " + hookFilterURL
 	}
-	basePath := strings.ToLower(baseURLParsed.Path)
-
-	// For LinkedIn blog: check if it's a blog post URL pattern
-	// Pattern: /blog/engineering/<category>/<post-slug> or similar
-	if strings.Contains(bc.baseURL, "linkedin.com") {
-		// LinkedIn blog posts typically have paths like /blog/engineering/data/...
-		// Exclude pagination query params
-		if strings.Contains(path, "?page0=") {
-			return false // Pagination page
-		}
-		// Include if it matches /blog/engineering/<category>/<post-slug> pattern
-		if strings.HasPrefix(path, "/blog/engineering/") {
-			// Get the part after /blog/engineering/
-			blogPath := strings.TrimPrefix(path, "/blog/engineering/")
-			blogPath = strings.Trim(blogPath, "/")
-			parts := strings.Split(blogPath, "/")
-
-			// Exclude known category pages (data, infrastructure)
-			categories := []string{"data", "infrastructure"}
-			if len(parts) == 1 && contains(categories, parts[0]) {
-				return false // Category page
-			}
-
-			// If it has more than just the category, it's likely a blog post
-			if len(parts) > 1 {
-				return true
-			}
+	if pattern := bc.site.postPattern; pattern != nil {
+		if parsedURL, err := url.Parse(bc.withoutLocale(urlStr)); err == nil && pattern.MatchString(parsedURL.Path) {
+			return true, "site post_pattern"
 		}
-		return false
-	}
-
-	// For Uber blog: check if it's a blog post URL pattern
-	// Pattern: /blog/<post-slug>/ or /blog/<category>/<post-slug>/
-	if strings.Contains(bc.baseURL, "uber.com") {
-		// Uber blog posts follow pattern: /blog/<slug>/
-		// Exclude pagination, category pages, etc.
-		if strings.Contains(path, "/page/") {
-			return false // Pagination page
-		}
-		if strings.Contains(path, "/engineering/backend/page/") {
-			return false // Pagination page
-		}
-		// Include if it matches /blog/<something>/ pattern and is not a category
-		if strings.HasPrefix(path, "/blog/") {
-			// Get the part after /blog/
-			blogPath := strings.TrimPrefix(path, "/blog/")
-			blogPath = strings.Trim(blogPath, "/")
-			parts := strings.Split(blogPath, "/")
-
-			// Exclude known category pages
-			categories := []string{"engineering", "advertising", "earn", "ride", "eat", "merchants",
-				"business", "freight", "health", "higher-education", "transit", "careers",
-				"community-support", "research"}
-			if len(parts) == 1 && contains(categories, parts[0]) {
-				return false // Category page
-			}
-
-			// If it has a slug (not just a category), it's likely a blog post
-			if len(parts) > 0 && parts[0] != "" {
-				// Check if it's a category with subcategory (like /blog/engineering/backend/)
-				if len(parts) == 2 && parts[0] == "engineering" {
-					// This is a category listing page, not a post
-					return false
-				}
-				// Otherwise, it's likely a blog post
-				return true
-			}
-		}
-		return false
-	}
-
-	// Filter out common non-blog URLs for other sites
-	excludePatterns := []string{
-		"/about",
-		"/archive",
-		"/tag/",
-		"/search",
-		"/@",
-		"/latest",
-		"/membership",
-		"/settings",
-		"/me/",
-		"/?source=",
-		"/page/", // Pagination pages
-		"/category/",
-		"/categories/",
-		"/author/",
-		"/authors/",
-		"/feed",
-		"/rss",
-		"/sitemap",
-		"/contact",
-		"/privacy",
-		"/terms",
-		"/careers",
-	}
-
-	for _, pattern := range excludePatterns {
-		if strings.Contains(urlLower, pattern) {
-			// Some patterns like "/p/" might be blog posts, so check more carefully
-			if pattern == "/p/" && strings.Count(path, "/") >= 4 {
-				// Likely a blog post: /username/post-title-123456
-				continue
-			}
-			return false
-		}
-	}
-
-	// Get relative path
-	relativePath := strings.TrimPrefix(path, basePath)
-	relativePath = strings.Trim(relativePath, "/")
-
-	// Exclude if it's just the base path or empty
-	if relativePath == "" || relativePath == "/" {
-		return false
+		return false, "not matching site post_pattern"
 	}
+	return urlfilter.Classify(bc.withoutLocale(urlStr), bc.urlRules())
+}
 
-	// Exclude language codes and pagination in path
-	pathParts := strings.Split(relativePath, "/")
-	for _, part := range pathParts {
-		// Skip language codes (en-US, es-US, etc.)
-		if strings.Contains(part, "-us") || (strings.Contains(part, "-") && len(part) <= 6) {
-			continue
-		}
-		// Skip pagination
-		if part == "page" {
-			return false
-		}
+// urlRules is what the crawl's links are classified by
+func (bc *BlogCrawler) urlRules() urlfilter.Rules {
+	return urlfilter.Rules{
+		BaseURL:    bc.baseURL,
+		Excludes:   bc.excludes,
+		Categories: bc.categories,
+		NavPaths:   bc.navPaths,
 	}
+}
 
-	// Include URLs that look like blog posts
-	// Should have at least one meaningful path segment after the base
-	if len(pathParts) > 0 && pathParts[0] != "" {
-		// Check if it contains typical blog post indicators
-		if strings.Contains(path, "/blog/") ||
-			strings.Contains(path, "/post/") ||
-			strings.Contains(path, "/article/") ||
-			(len(pathParts) >= 2 && pathParts[0] == "blog") {
-			return true
-		}
-		// For other sites: if it's a direct path under base, it's likely a post
-		if strings.HasPrefix(path, basePath) && len(pathParts) >= 1 {
-			return true
-		}
+// sleepContext waits for d, returning early with ctx's error if it is
+// cancelled first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
-
-	return false
 }
 
 // Helper function to check if a string is in a slice
@@ -373,28 +823,30 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
-func (bc *BlogCrawler) scrollToBottom() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (bc *BlogCrawler) scrollToBottom(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	// Use a more robust scrolling method
-	_, err := bc.page.Context(ctx).Eval(`
-		(function() {
+	_, err := bc.page.Eval(ctx, `
+		() => {
 			window.scrollTo({
 				top: document.body.scrollHeight || document.documentElement.scrollHeight,
 				behavior: 'smooth'
 			});
-		})()
+		}
 	`)
 	return err
 }
 
-func (bc *BlogCrawler) getMaxPageNumber() (int, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// getMaxPageNumber reads the number of listing pages from the pagination
+// controls of the current page
+func (bc *BlogCrawler) getMaxPageNumber(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	// First, try to find pagination select dropdown (Uber uses this)
-	selectElements, err := bc.page.Context(ctx).Elements(`[data-baseweb="select"] div[value]`)
+	selectElements, err := bc.page.Elements(ctx, uberPageSelectSelector)
 	if err == nil && len(selectElements) > 0 {
 		maxPage := 0
 		for _, elem := range selectElements {
@@ -411,18 +863,30 @@ func (bc *BlogCrawler) getMaxPageNumber() (int, error) {
 		}
 	}
 
-	// Alternative: Look for pagination links and find the highest page number
-	elements, err := bc.page.Context(ctx).Elements(`a[href*="/page/"]`)
+	// LinkedIn: pagination links carry the page number in page0=
+	anchors, err := bc.anchors(ctx, linkedInPageSelector)
 	if err == nil {
 		maxPage := 0
-		for _, elem := range elements {
-			href, err := elem.Attribute("href")
-			if err != nil || href == nil {
-				continue
+		for _, a := range anchors {
+			if parsedHref, err := url.Parse(a.Href); err == nil {
+				if pageNum, err := strconv.Atoi(parsedHref.Query().Get("page0")); err == nil && pageNum > maxPage {
+					maxPage = pageNum
+				}
 			}
+		}
+		if maxPage > 0 {
+			return maxPage, nil
+		}
+	}
+
+	// Alternative: Look for pagination links and find the highest page number
+	anchors, err = bc.anchors(ctx, `a[href*="/page/"]`)
+	if err == nil {
+		maxPage := 0
+		for _, a := range anchors {
 			// Extract page number from href like "/blog/engineering/backend/page/3/"
-			if strings.Contains(*href, "/page/") {
-				parts := strings.Split(*href, "/page/")
+			if strings.Contains(a.Href, "/page/") {
+				parts := strings.Split(a.Href, "/page/")
 				if len(parts) > 1 {
 					pagePart := strings.Trim(parts[1], "/")
 					pageNumStr := strings.Split(pagePart, "/")[0]
@@ -441,8 +905,8 @@ func (bc *BlogCrawler) getMaxPageNumber() (int, error) {
 
 	// Try to get text content and look for "Page X of Y"
 	// Look for the pagination text element directly
-	paginationText, err := bc.page.Context(ctx).Eval(`
-		(function() {
+	paginationText, err := bc.page.Eval(ctx, `
+		() => {
 			// Look for element containing "Page X of Y" text
 			const allElements = document.querySelectorAll('*');
 			for (let el of allElements) {
@@ -453,10 +917,10 @@ func (bc *BlogCrawler) getMaxPageNumber() (int, error) {
 				}
 			}
 			return 0;
-		})()
+		}
 	`)
 	if err == nil {
-		maxPageStr := fmt.Sprintf("%v", paginationText.Value)
+		maxPageStr := fmt.Sprintf("%v", paginationText)
 		if maxPage, err := strconv.Atoi(maxPageStr); err == nil && maxPage > 0 {
 			return maxPage, nil
 		}
@@ -465,160 +929,197 @@ func (bc *BlogCrawler) getMaxPageNumber() (int, error) {
 	return 0, fmt.Errorf("could not determine max page number")
 }
 
-func (bc *BlogCrawler) crawlSinglePage(pageURL string) ([]string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), bc.timeout)
+// pageLabel formats a page number for progress output: "12/37" when the
+// number of pages is known, "12" otherwise
+func pageLabel(pageNum, maxPage int) string {
+	if maxPage > 0 {
+		return fmt.Sprintf("%d/%d", pageNum, maxPage)
+	}
+	return fmt.Sprint(pageNum)
+}
+
+// loadPage navigates the shared page to pageURL and waits for it to settle.
+// If the browser crashed or hung, it is restarted and the page loaded again.
+func (bc *BlogCrawler) loadPage(ctx context.Context, pageURL string) error {
+	bc.cachedPage = nil
+	err := bc.navigate(ctx, pageURL)
+	if err == nil || ctx.Err() != nil || bc.browserResponsive(ctx) {
+		return err
+	}
+
+	if restartErr := bc.restartBrowser(ctx, err); restartErr != nil {
+		return fmt.Errorf("%w (browser restart failed: %v)", err, restartErr)
+	}
+	return bc.navigate(ctx, pageURL)
+}
+
+// navigate loads pageURL in the current page and waits for it to settle.
+// Rate-limit responses are waited out as they ask and tried again.
+func (bc *BlogCrawler) navigate(ctx context.Context, pageURL string) error {
+	for attempt := 1; ; attempt++ {
+		status, header, err := bc.navigateOnce(ctx, pageURL)
+		if err != nil || !isRateLimited(status) {
+			return err
+		}
+		if attempt > rateLimitRetries {
+			return fmt.Errorf("%s is still rate limited (HTTP %d)", pageURL, status)
+		}
+		if err := bc.backOff(ctx, pageURL, status, header, attempt); err != nil {
+			return err
+		}
+	}
+}
+
+// navigateOnce loads pageURL and returns the status and Retry-After header
+// of the document. Once the blog redirected to a locale, pageURL is loaded
+// under it.
+func (bc *BlogCrawler) navigateOnce(ctx context.Context, pageURL string) (int, string, error) {
+	pageURL = bc.localized(pageURL)
+	if err := bc.recyclePageIfDue(); err != nil {
+		return 0, "", fmt.Errorf("failed to recycle page: %w", err)
+	}
+	if err := bc.throttle(ctx, pageURL); err != nil {
+		return 0, "", err
+	}
+	if err := bc.budget.request(); err != nil {
+		return 0, "", err
+	}
+
+	loadCtx, cancel := context.WithTimeout(ctx, bc.timeout)
 	defer cancel()
 
 	// Navigate to the page
-	if err := bc.page.Context(ctx).Navigate(pageURL); err != nil {
-		return nil, fmt.Errorf("failed to navigate to %s: %w", pageURL, err)
+	response := bc.documentResponse(loadCtx)
+	redirects := bc.redirectChain(loadCtx)
+	start := time.Now()
+	if err := bc.page.Navigate(loadCtx, pageURL); err != nil {
+		return 0, "", fmt.Errorf("failed to navigate to %s: %w", pageURL, err)
 	}
 
-	if err := bc.page.Context(ctx).WaitLoad(); err != nil {
-		return nil, fmt.Errorf("failed to wait for page load: %w", err)
+	if err := bc.page.WaitLoad(loadCtx); err != nil {
+		return 0, "", fmt.Errorf("failed to wait for page load: %w", err)
+	}
+	bc.recordLoad(start)
+	status, header := response()
+	if isRateLimited(status) {
+		return status, header, nil
+	}
+	if localizedURL, err := bc.localeRedirect(ctx, pageURL, redirects()); err != nil {
+		return status, header, err
+	} else if localizedURL != "" {
+		return bc.navigateOnce(ctx, localizedURL)
 	}
 
 	// Wait for content to load
-	if err := bc.waitForContent(); err != nil {
-		fmt.Printf("Warning: Timeout waiting for content on %s: %v\n", pageURL, err)
+	if err := bc.waitForContent(ctx); err != nil {
+		bc.warnf("Timeout waiting for content on %s: %v", pageURL, err)
 	}
+	bc.injectSiteJS(ctx, pageURL)
+	bc.scriptPageLoaded(ctx, pageURL)
 
-	// Extract blog URLs from this page
-	return bc.extractBlogURLs()
+	return status, header, nil
 }
 
-func (bc *BlogCrawler) crawl() (*CrawlResult, error) {
-	fmt.Printf("Initializing browser...\n")
-	if err := bc.initializeBrowser(); err != nil {
+func (bc *BlogCrawler) crawlSinglePage(ctx context.Context, pageURL string) ([]string, error) {
+	if err := bc.loadPage(ctx, pageURL); err != nil {
 		return nil, err
 	}
-	defer bc.browser.Close()
 
-	fmt.Printf("Navigating to %s...\n", bc.baseURL)
-	if err := bc.navigateToPage(); err != nil {
-		return nil, err
+	if bc.opts.listingDepth() > 1 {
+		bc.collectListingURLs(ctx)
 	}
 
-	fmt.Printf("Waiting for content to load...\n")
-	if err := bc.waitForContent(); err != nil {
-		fmt.Printf("Warning: Timeout waiting for initial content: %v\n", err)
+	// Extract blog URLs from this page
+	urls, err := bc.extractBlogURLs(ctx)
+	if len(urls) > 0 || ctx.Err() != nil || bc.browserResponsive(ctx) {
+		return urls, err
 	}
 
-	// Check if this is a paginated blog (like Uber or LinkedIn)
-	isUberBlog := strings.Contains(bc.baseURL, "uber.com")
-	isLinkedInBlog := strings.Contains(bc.baseURL, "linkedin.com/blog")
-	urlSet := make(map[string]bool)
-
-	if isLinkedInBlog && (strings.Contains(bc.baseURL, "/blog/engineering/data") || strings.Contains(bc.baseURL, "/blog/engineering/infrastructure")) {
-		// LinkedIn blog with pagination - extract actual pagination links from the page
-		fmt.Printf("Detected LinkedIn blog with pagination. Extracting pagination pattern...\n")
+	// An empty page from a dead browser would end pagination early, so
+	// restart and crawl the same page number again
+	if restartErr := bc.restartBrowser(ctx, fmt.Errorf("no response while reading %s", pageURL)); restartErr != nil {
+		return urls, err
+	}
+	if err := bc.loadPage(ctx, pageURL); err != nil {
+		return nil, err
+	}
+	if bc.opts.listingDepth() > 1 {
+		bc.collectListingURLs(ctx)
+	}
+	return bc.extractBlogURLs(ctx)
+}
 
-		// Extract base URL without query params
-		baseURLParsed, err := url.Parse(bc.baseURL)
+// crawl discovers the blog's posts and runs the enabled per-post passes.
+// Cancelling ctx stops the crawl at the next page, scroll or post.
+func (bc *BlogCrawler) crawl(ctx context.Context) (result *CrawlResult, err error) {
+	started := time.Now()
+	bc.journal(journalEvent{Event: "start"})
+	defer func() { bc.finishJournal(result, err) }()
+
+	// Running out of budget ends ctx too, but the crawl then keeps what it
+	// found; only cancelling parent fails it
+	parent := ctx
+	ctx, stopBudget := bc.startBudget(parent)
+	defer stopBudget()
+	bc.traffic = newTrafficCounter(ctx)
+	bc.network = newNetworkActivity(ctx)
+	if bc.opts.listingDepth() > 1 {
+		frontier, err := bc.openFrontier()
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse base URL: %w", err)
-		}
-		baseURLParsed.RawQuery = ""
-		basePath := baseURLParsed.String()
-
-		// Try to extract pagination links from the current page to understand the pattern
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		paginationLinks := make(map[int]string) // page number -> URL
-
-		// Look for pagination links with page0 parameter
-		elements, err := bc.page.Context(ctx).Elements(`a[href*="page0="]`)
-		if err == nil {
-			for _, elem := range elements {
-				href, err := elem.Attribute("href")
-				if err != nil || href == nil {
-					continue
-				}
-				// Parse the href to extract page0 value
-				parsedHref, err := url.Parse(*href)
-				if err == nil {
-					page0Value := parsedHref.Query().Get("page0")
-					if page0Value != "" {
-						if pageNum, err := strconv.Atoi(page0Value); err == nil {
-							// Resolve relative URL
-							absoluteURL := baseURLParsed.ResolveReference(parsedHref)
-							absoluteURL.RawQuery = fmt.Sprintf("page0=%s", page0Value)
-							paginationLinks[pageNum] = absoluteURL.String()
-						}
-					}
-				}
-			}
+			return nil, err
 		}
-		cancel()
-
-		// LinkedIn pagination pattern: page0 is a fixed parameter name, value is the page number
-		// Page 1: no query param (or ?page0=1)
-		// Page 2: ?page0=2
-		// Page 3: ?page0=3
-		// etc.
-		// We'll try both: start with no param for page 1, then use sequential page numbers
-		fmt.Printf("Using LinkedIn pagination pattern: page0=<page_number> (sequential: 1, 2, 3, ...)\n")
-
-		consecutiveEmptyPages := 0
-		maxConsecutiveEmpty := 1 // Stop on first empty page
-		pageNum := 1
+		bc.frontier = frontier
+	}
 
-		for {
-			var pageURL string
-			if pageNum == 1 {
-				pageURL = basePath // First page: no query param
-			} else {
-				pageURL = fmt.Sprintf("%s?page0=%d", basePath, pageNum)
-			}
+	bc.printf("Initializing browser...\n")
+	if err := bc.initializeBrowser(ctx); err != nil {
+		return nil, withExitCode(exitBrowser, err)
+	}
+	// bc.browser changes if the browser has to be restarted mid-crawl
+	defer func() { bc.closeBrowser(context.Background()) }()
+	bc.readBrowserVersion(ctx)
 
-			fmt.Printf("Crawling page %d: %s\n", pageNum, pageURL)
+	if bc.opts.HAR != "" {
+		bc.har = newHARRecorder(ctx)
+		defer bc.saveHAR()
+	}
 
-			urls, err := bc.crawlSinglePage(pageURL)
-			if err != nil {
-				fmt.Printf("Warning: Error crawling page %d: %v\n", pageNum, err)
-				consecutiveEmptyPages++
-				if consecutiveEmptyPages >= maxConsecutiveEmpty {
-					fmt.Printf("Stopping: Error on page %d\n", pageNum)
-					break
-				}
-				continue
-			}
+	bc.printf("Navigating to %s...\n", bc.baseURL)
+	if err := bc.navigateToPage(ctx); err != nil {
+		if parent.Err() != nil {
+			return nil, fmt.Errorf("crawl cancelled: %w", parent.Err())
+		}
+		return nil, withExitCode(exitNavigation, err)
+	}
 
-			if len(urls) == 0 {
-				consecutiveEmptyPages++
-				if consecutiveEmptyPages >= maxConsecutiveEmpty {
-					fmt.Printf("Stopping: No blog posts found on page %d\n", pageNum)
-					break
-				}
-			} else {
-				consecutiveEmptyPages = 0
-				previousCount := len(urlSet)
-				for _, url := range urls {
-					urlSet[url] = true
-				}
-				fmt.Printf("  Found %d blog URLs on page %d (total: %d unique URLs)\n", len(urls), pageNum, len(urlSet))
+	bc.printf("Waiting for content to load...\n")
+	if err := bc.waitForContent(ctx); err != nil {
+		bc.warnf("Timeout waiting for initial content: %v", err)
+	}
 
-				// If no new URLs were added, we might have reached the end
-				if len(urlSet) == previousCount {
-					consecutiveEmptyPages++
-					if consecutiveEmptyPages >= maxConsecutiveEmpty {
-						fmt.Printf("Stopping: No new URLs found on page %d\n", pageNum)
-						break
-					}
-				}
-			}
+	// Check if this is a paginated blog (like Uber or LinkedIn)
+	isUberBlog := strings.Contains(bc.baseURL, "uber.com")
+	_, _, isLinkedInBlog := urlfilter.LinkedInBlog(bc.baseURL)
+	urlSet := make(map[string]bool)
+	if bc.opts.Resume != nil {
+		for _, url := range bc.opts.Resume.BlogURLs {
+			urlSet[url] = true
+		}
+	}
 
-			// Safety limit: don't go beyond 50 pages
-			if pageNum >= 50 {
-				fmt.Printf("Reached safety limit of 50 pages. Stopping.\n")
-				break
-			}
+	strategy := bc.strategy()
+	if !bc.opts.NoViewAll && !isLinkedInBlog && !isUberBlog && (strategy == "auto" || strategy == "scroll" || strategy == "next-link") {
+		bc.followViewAll(ctx)
+	}
 
-			pageNum++
-			time.Sleep(1 * time.Second)
+	if strategy == "auto" && isLinkedInBlog {
+		bc.printf("Detected LinkedIn blog with pagination...\n")
+		if err := bc.crawlLinkedIn(ctx, urlSet); err != nil {
+			return nil, err
 		}
-	} else if isUberBlog && strings.Contains(bc.baseURL, "/blog/engineering/backend") {
+	} else if strategy == "auto" && isUberBlog && strings.Contains(bc.baseURL, "/blog/engineering/backend") {
 		// Uber blog with pagination - simple increment approach
-		fmt.Printf("Detected Uber blog with pagination. Crawling all pages...\n")
+		bc.printf("Detected Uber blog with pagination. Crawling all pages...\n")
 
 		// Extract base path without page number
 		basePath := strings.TrimSuffix(bc.baseURL, "/")
@@ -630,9 +1131,18 @@ func (bc *BlogCrawler) crawl() (*CrawlResult, error) {
 
 		pageNum := 1
 		consecutiveEmptyPages := 0
-		maxConsecutiveEmpty := 1 // Stop on first empty page
+		maxConsecutiveEmpty := bc.opts.maxEmptyPages()
 
-		for {
+		// A known page count bounds the loop and the progress output;
+		// without it the empty-page heuristic and a safety limit end it
+		maxPage, err := bc.getMaxPageNumber(ctx)
+		if err != nil {
+			maxPage = 0
+		} else {
+			bc.printf("Blog has %d pages\n", maxPage)
+		}
+
+		for ctx.Err() == nil {
 			var pageURL string
 			if pageNum == 1 {
 				pageURL = basePath + "/"
@@ -640,24 +1150,35 @@ func (bc *BlogCrawler) crawl() (*CrawlResult, error) {
 				pageURL = fmt.Sprintf("%s/page/%d/", basePath, pageNum)
 			}
 
-			fmt.Printf("Crawling page %d: %s\n", pageNum, pageURL)
+			bc.printf("Crawling page %s: %s\n", pageLabel(pageNum, maxPage), pageURL)
 
-			urls, err := bc.crawlSinglePage(pageURL)
+			bc.setSource("page", pageNum, pageURL)
+			urls, err := bc.crawlListingPage(ctx, pageNum, pageURL)
+			bc.recordPage(pageNum, pageURL, len(urls), err)
+			if pageNum == 1 && err == nil {
+				bc.checkAdapter(ctx, pageURL, len(urls), uberCardsCheck, uberPaginationCheck)
+			}
 			if err != nil {
-				fmt.Printf("Warning: Error crawling page %d: %v\n", pageNum, err)
+				bc.warnf("Error crawling page %d: %v", pageNum, err)
 				consecutiveEmptyPages++
 				if consecutiveEmptyPages >= maxConsecutiveEmpty {
-					fmt.Printf("Stopping: Error on page %d\n", pageNum)
+					bc.printf("Stopping: Error on page %d\n", pageNum)
 					break
 				}
 				pageNum++
 				continue
 			}
 
+			// An empty page may be the end of the listing or a failed or
+			// blocked load; the latter is retried before giving up
+			if len(urls) == 0 {
+				urls = bc.retryEmptyPage(ctx, pageNum, pageURL)
+			}
+
 			if len(urls) == 0 {
 				consecutiveEmptyPages++
 				if consecutiveEmptyPages >= maxConsecutiveEmpty {
-					fmt.Printf("Stopping: No blog posts found on page %d\n", pageNum)
+					bc.printf("Stopping: No blog posts found on page %d\n", pageNum)
 					break
 				}
 			} else {
@@ -666,40 +1187,65 @@ func (bc *BlogCrawler) crawl() (*CrawlResult, error) {
 				for _, url := range urls {
 					urlSet[url] = true
 				}
-				fmt.Printf("  Found %d blog URLs on page %d (total: %d unique URLs)\n", len(urls), pageNum, len(urlSet))
+				bc.printf("  Found %d blog URLs on page %s (total: %d unique URLs)\n", len(urls), pageLabel(pageNum, maxPage), len(urlSet))
+				bc.reportProgress(ProgressEvent{Kind: "page", Step: pageNum, Steps: maxPage, URL: pageURL, URLsFound: len(urls), TotalURLs: len(urlSet)})
 
 				// If no new URLs were added, we might have reached the end
 				if len(urlSet) == previousCount {
 					consecutiveEmptyPages++
 					if consecutiveEmptyPages >= maxConsecutiveEmpty {
-						fmt.Printf("Stopping: No new URLs found on page %d\n", pageNum)
+						bc.printf("Stopping: No new URLs found on page %d\n", pageNum)
 						break
 					}
 				}
 			}
 
+			if maxPage > 0 && pageNum >= maxPage {
+				bc.printf("Reached the last page (%d). Stopping.\n", maxPage)
+				break
+			}
 			// Safety limit: don't go beyond 20 pages
-			if pageNum >= 20 {
-				fmt.Printf("Reached safety limit of 20 pages. Stopping.\n")
+			if maxPage == 0 && pageNum >= 20 {
+				bc.printf("Reached safety limit of 20 pages. Stopping.\n")
 				break
 			}
 
 			pageNum++
-			time.Sleep(1 * time.Second)
+			if err := sleepContext(ctx, 1*time.Second); err != nil {
+				break
+			}
 		}
+	} else if strategy == "archive-months" {
+		bc.printf("Detected Medium publication. Crawling its archive month by month...\n")
+		bc.crawlMediumArchive(ctx, urlSet)
+	} else if strategy == "search" {
+		bc.printf("Enumerating posts through the blog's search...\n")
+		bc.crawlSearch(ctx, urlSet)
+	} else if strategy == "tabs" {
+		bc.printf("Clicking through the listing's tabs...\n")
+		bc.crawlTabs(ctx, urlSet)
+	} else if strategy == "next-link" || (strategy == "auto" && bc.hasNextLink(ctx)) {
+		bc.printf("Following next-page links...\n")
+		bc.crawlNextLinks(ctx, urlSet)
 	} else {
 		// Original behavior: scroll and extract (for Medium and other blogs)
-		fmt.Printf("Starting to crawl blog URLs (infinite scroll mode)...\n")
+		bc.printf("Starting to crawl blog URLs (infinite scroll mode)...\n")
 
-		noNewContentCount := 0
-		maxNoNewContentIterations := 3
-		scrollDelay := 2 * time.Second
+		stop := newScrollStop(bc.opts)
+		scrollIteration := 0
+		resumed := false
+		if bc.opts.Resume != nil && bc.opts.Resume.Offset != nil {
+			scrollIteration, resumed = bc.resumeFeed(ctx, bc.opts.Resume.Offset, urlSet)
+		}
+		for ctx.Err() == nil && !resumed {
+			scrollIteration++
+			bc.scrolls = scrollIteration
+			bc.setSource("scroll", scrollIteration, bc.listing())
 
-		for {
 			// Extract current URLs
-			currentURLs, err := bc.extractBlogURLs()
+			currentURLs, err := bc.scrollURLs(ctx, urlSet)
 			if err != nil {
-				fmt.Printf("Warning: Error extracting URLs: %v\n", err)
+				bc.warnf("Error extracting URLs: %v", err)
 			} else {
 				previousCount := len(urlSet)
 				for _, url := range currentURLs {
@@ -707,53 +1253,174 @@ func (bc *BlogCrawler) crawl() (*CrawlResult, error) {
 				}
 				newCount := len(urlSet)
 
-				fmt.Printf("Found %d unique blog URLs so far...\n", newCount)
+				bc.printf("Found %d unique blog URLs so far...\n", newCount)
+				bc.reportProgress(ProgressEvent{Kind: "scroll", Step: scrollIteration, URL: bc.listing(), URLsFound: len(currentURLs), TotalURLs: newCount})
 
-				if newCount == previousCount {
-					noNewContentCount++
-					if noNewContentCount >= maxNoNewContentIterations {
-						fmt.Printf("No new content detected after %d scrolls. Stopping.\n", maxNoNewContentIterations)
-						break
+				if reason := stop.check(ctx, bc, scrollIteration, previousCount, newCount); reason != "" {
+					bc.printf("%s Stopping.\n", reason)
+					break
+				}
+
+				if bc.opts.PruneEvery > 0 && scrollIteration%bc.opts.PruneEvery == 0 {
+					if removed, err := bc.pruneHarvestedDOM(ctx, urlSet); err != nil {
+						bc.warnf("Error pruning feed DOM: %v", err)
+					} else if removed > 0 {
+						bc.printf("Pruned %d harvested posts from the page\n", removed)
 					}
-				} else {
-					noNewContentCount = 0
 				}
 			}
 
 			// Scroll down
-			if err := bc.scrollToBottom(); err != nil {
-				fmt.Printf("Warning: Error scrolling: %v\n", err)
+			if err := bc.scrollToBottom(ctx); err != nil {
+				bc.warnf("Error scrolling: %v", err)
+				if ctx.Err() == nil && !bc.browserResponsive(ctx) {
+					if err := bc.recoverScroll(ctx, err, scrollIteration); err != nil {
+						bc.warnf("Giving up on infinite scroll: %v", err)
+						break
+					}
+				}
 			}
 
 			// Wait for new content to load
-			time.Sleep(scrollDelay)
+			if err := bc.waitAfterScroll(ctx); err != nil {
+				break
+			}
+		}
 
-			// Small delay to allow content to load
-			time.Sleep(500 * time.Millisecond)
+		// The whole feed lives on one page
+		bc.recordPage(1, bc.listing(), len(urlSet), nil)
+		if !resumed {
+			// Read even when the budget stopped the scroll, for --resume
+			bc.offset = bc.feedOffset(parent, scrollIteration)
+		}
+		if bc.opts.listingDepth() > 1 {
+			bc.collectListingURLs(ctx)
 		}
 	}
 
+	if bc.opts.listingDepth() > 1 {
+		bc.crawlArchives(ctx, urlSet)
+	}
+
+	if bc.opts.SeedSearch != nil {
+		bc.seedFromSearch(ctx, urlSet)
+	}
+	if bc.opts.WaybackDiscover {
+		bc.discoverFromWayback(ctx, urlSet)
+	}
+	bc.checkCategoryCandidates(ctx, urlSet)
+	if bc.opts.ClassifyPages {
+		bc.classifyAmbiguous(ctx, urlSet)
+	}
+	if bc.opts.ExcludeMemberOnly {
+		bc.dropMemberOnly(urlSet)
+	}
+
+	if err := parent.Err(); err != nil {
+		return nil, fmt.Errorf("crawl cancelled: %w", err)
+	}
+
 	urls := make([]string, 0, len(urlSet))
 	for url := range urlSet {
 		urls = append(urls, url)
 	}
+	var diagnostics *Diagnostics
+	if len(urls) == 0 {
+		diagnostics = bc.diagnoseEmpty(ctx)
+		if diagnostics.Verdict == "blocked" {
+			return nil, withExitCode(exitBlocked, fmt.Errorf("no posts found, %s is %s", bc.baseURL, diagnostics.Reason))
+		}
+	}
+
+	var posts []Post
+	if bc.opts.needsPosts() {
+		posts = make([]Post, len(urls))
+		for i, url := range urls {
+			posts[i] = Post{URL: url}
+		}
+	}
+
+	if bc.opts.visitsPosts() {
+		bc.printf("Visiting %d posts...\n", len(posts))
+		bc.visitPosts(ctx, posts)
+		urls, posts = bc.findDuplicateTitles(urls, posts)
+	}
+
+	if bc.opts.Engagement {
+		bc.printf("Looking up the engagement of %d posts...\n", len(posts))
+		bc.lookupEngagement(ctx, posts)
+	}
+
+	if bc.opts.WaybackSave || bc.opts.WaybackLookup {
+		bc.printf("Archiving %d posts with the Wayback Machine...\n", len(posts))
+		bc.archivePosts(ctx, posts)
+	}
+
+	if err := parent.Err(); err != nil {
+		return nil, fmt.Errorf("crawl cancelled: %w", err)
+	}
+
+	if reason := bc.budget.exhausted(); reason != "" {
+		bc.warnf("Stopped early, %s; keeping what was found so far", reason)
+	}
+	if err := bc.opts.ListingCache.save(); err != nil {
+		bc.warnf("Error saving the listing cache: %v", err)
+	}
+
+	bc.sortURLs(urls, posts)
+	urls, posts = bc.scriptResult(urls, posts)
 
 	return &CrawlResult{
-		BaseURL:    bc.baseURL,
-		BlogURLs:   urls,
-		TotalCount: len(urls),
-		CrawledAt:  time.Now().Format(time.RFC3339),
+		SchemaVersion:   resultSchemaVersion,
+		BaseURL:         bc.baseURL,
+		BlogURLs:        urls,
+		TotalCount:      len(urls),
+		CrawledAt:       time.Now().Format(time.RFC3339),
+		Posts:           posts,
+		Pages:           bc.pages,
+		Selectors:       bc.selectorStats,
+		Discovery:       bc.discoveries(urls),
+		MemberOnly:      bc.memberOnlyURLs(urls),
+		Stats:           bc.stats(started, urls, posts),
+		Diagnostics:     diagnostics,
+		LocaleRedirects: bc.localeRedirects,
+		Run:             bc.runInfo(strategy),
+		Offset:          bc.offset,
+		Errors:          bc.errors,
 	}, nil
 }
 
-func (bc *BlogCrawler) saveToJSON(result *CrawlResult, filename string) error {
-	file, err := os.Create(filename)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+// writeResult encodes result to w in the given output format
+func writeResult(w io.Writer, result *CrawlResult, format string) error {
+	switch format {
+	case "rss":
+		return writeRSS(w, result)
+	case "atom":
+		return writeAtom(w, result)
+	case "html":
+		return writeHTML(w, result)
+	case "ndjson":
+		return writeNDJSON(w, result)
+	case "dot", "graphml", "graph-json":
+		return writeGraph(w, result, format)
 	}
-	defer file.Close()
+	return writeJSON(w, result)
+}
 
-	encoder := json.NewEncoder(file)
+// writeNDJSON writes one JSON post record per line, which streams and
+// appends well for large archives
+func writeNDJSON(w io.Writer, result *CrawlResult) error {
+	encoder := json.NewEncoder(w)
+	for _, record := range postRecords(result) {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", record.URL, err)
+		}
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, result *CrawlResult) error {
+	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
 
 	if err := encoder.Encode(result); err != nil {
@@ -763,40 +1430,756 @@ func (bc *BlogCrawler) saveToJSON(result *CrawlResult, filename string) error {
 	return nil
 }
 
+// flagOrEnv returns value, falling back to the environment variable env so
+// secrets don't have to appear on the command line, and then to the
+// secrets file
+func flagOrEnv(value, env string) string {
+	if value != "" {
+		return value
+	}
+	value, _ = lookupSecret(env)
+	return value
+}
+
+// parseArgs parses flags from args while allowing them to appear before or
+// after the positional arguments, and returns the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run main.go <base_url> [output_file.json]")
-		fmt.Println("Example: go run main.go https://medium.com/netflix-techblog")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			runDiff(os.Args[2:])
+			return
+		case "serve-grpc":
+			runGRPCServer(os.Args[2:])
+			return
+		case "worker":
+			runWorker(os.Args[2:])
+			return
+		case "coordinate":
+			runCoordinator(os.Args[2:])
+			return
+		case "seeds":
+			runSeeds(os.Args[2:])
+			return
+		case "search":
+			runSearch(os.Args[2:])
+			return
+		case "replay":
+			runReplay(os.Args[2:])
+			return
+		case "schema":
+			runSchema(os.Args[2:])
+			return
+		case "init-site":
+			runInitSite(os.Args[2:])
+			return
+		case "report":
+			runReport(os.Args[2:])
+			return
+		case "rebuild":
+			runRebuild(os.Args[2:])
+			return
+		case "doctor":
+			runDoctor(os.Args[2:])
+			return
+		}
+	}
+
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	configFile := fs.String("config", "", "YAML file with default settings (default ~/.config/blogcrawler/config.yaml if it exists)")
+	secretsFile := fs.String("secrets", "", "file of NAME=value secrets like TELEGRAM_BOT_TOKEN, readable only by you (defaults to $BLOGCRAWLER_SECRETS, then ~/.config/blogcrawler/secrets if it exists)")
+	timeout := fs.Duration("timeout", 30*time.Second, "page load timeout")
+	proxy := fs.String("proxy", "", "send the browser's and the crawler's HTTP traffic through this proxy, like http://host:3128 or socks5://host:1080")
+	screenshot := fs.Bool("screenshot", false, "capture a full-page PNG screenshot of each post")
+	pdf := fs.Bool("pdf", false, "capture a PDF of each post")
+	downloadImages := fs.Bool("download-images", false, "save the content of each post as HTML with its images downloaded next to it")
+	waybackSave := fs.Bool("wayback-save", false, "submit each post to the Internet Archive's Save Page Now API")
+	waybackLookup := fs.Bool("wayback-lookup", false, "annotate each post with its latest Wayback Machine snapshot")
+	waybackDiscover := fs.Bool("wayback-discover", false, "also add the post URLs the Wayback Machine archived under the blog, including unpublished ones")
+	waybackDiscoverLimit := fs.Int("wayback-discover-limit", defaultWaybackDiscoverLimit, "with --wayback-discover, look at no more than this many archived URLs")
+	waybackDelay := fs.Duration("wayback-delay", 5*time.Second, "minimum delay between Wayback Machine requests")
+	fetchContent := fs.Bool("fetch-content", false, "visit each post and record its title, text and content hash")
+	engagement := fs.Bool("engagement", false, "visit each post and record its claps, reactions and comments, and look up its Hacker News discussion")
+	postWorkers := fs.Int("post-workers", 1, "visit this many posts at the same time, each in its own tab")
+	postHostConcurrency := fs.Int("post-host-concurrency", 2, "with --post-workers, at most this many concurrent page loads per host (0 for no limit)")
+	fetchMode := fs.String("fetch-mode", "browser", "how per-post passes load posts: browser, or hybrid (plain HTTP, falling back to the browser for pages that need JavaScript)")
+	postRetries := fs.Int("post-retries", 2, "retry a post page that fails to load this many times")
+	depth := fs.Int("depth", 1, "listing depth; 2 also follows archive, year and category pages linked from the blog")
+	browserMemory := fs.Int("browser-memory-mb", 0, "limit the JavaScript heap of each Chrome renderer to this many MB")
+	disableDevShm := fs.Bool("disable-dev-shm-usage", false, "don't use /dev/shm in Chrome (for containers with a small /dev/shm)")
+	rendererLimit := fs.Int("renderer-process-limit", 0, "maximum number of Chrome renderer processes")
+	pruneEvery := fs.Int("prune-dom-every", 0, "infinite scroll: remove already-harvested posts from the page every N scrolls")
+	scrollIdle := fs.Int("scroll-idle-limit", defaultScrollIdleLimit, "infinite scroll: stop after this many scrolls without new posts")
+	scrollDelay := fs.Duration("scroll-delay", defaultScrollDelay, "infinite scroll: wait this long after a scroll that loads nothing; one that loads posts moves on once loading settles, or after 5 times this")
+	fixedScrollDelay := fs.Bool("fixed-scroll-delay", false, "infinite scroll: always wait --scroll-delay after each scroll, without watching the feed load")
+	maxScrolls := fs.Int("max-scrolls", 0, "infinite scroll: stop after this many scrolls (0 for no limit)")
+	scrollTimeout := fs.Duration("scroll-timeout", 0, "infinite scroll: stop after scrolling this long (0 for no limit)")
+	maxURLs := fs.Int("max-urls", 0, "infinite scroll: stop once this many post URLs are found (0 for no limit)")
+	stopBefore := fs.String("stop-before", "", "infinite scroll: stop once the feed shows posts published before this date (YYYY-MM-DD)")
+	stopOnHeight := fs.Bool("stop-on-height", false, "infinite scroll: count a scroll as idle when the page stops growing rather than when no new posts appear")
+	budgetRequests := fs.Int("budget-requests", 0, "stop crawling a blog after this many page loads and HTTP fetches, keeping what was found (0 for no limit)")
+	budgetMB := fs.Int("budget-mb", 0, "stop crawling a blog after it transferred this many MB (0 for no limit)")
+	budgetDuration := fs.Duration("budget-duration", 0, "stop crawling a blog after this long, post passes included (0 for no limit)")
+	viewport := fs.String("viewport", "", "browser viewport as WIDTHxHEIGHT in CSS pixels, like 1920x1080 (default 1280x800)")
+	deviceScaleFactor := fs.Float64("device-scale-factor", 0, "browser device pixel ratio, like 2 for a retina screen (default 1)")
+	timezone := fs.String("timezone", "", "browser timezone as an IANA name, like Europe/Berlin (default the host's)")
+	country := fs.String("country", "", "crawl from these comma-separated countries, like US,DE, with their language, timezone and, through {country} in --proxy, exit IP; results are merged and tagged by region")
+	locale := fs.String("locale", "", "language to crawl in, like de-DE; with --country for every country, alone as one region")
+	acceptLanguage := fs.String("accept-language", "", "Accept-Language of the browser and the crawl's HTTP fetches, like \"de-DE,de;q=0.9\" (default en)")
+	maxEmptyPages := fs.Int("max-empty-pages", defaultMaxEmptyPages, "paginated blogs: stop after this many empty or failed pages in a row")
+	recyclePages := fs.Int("recycle-pages", 0, "replace the browser tab with a fresh one every N page loads")
+	noViewAll := fs.Bool("no-view-all", false, "crawl the given page even when it links to a full listing (\"See all posts\", \"Archive\")")
+	collapseDuplicates := fs.Bool("collapse-duplicates", false, "with --fetch-content, drop posts whose title a post under a shorter URL has instead of flagging them")
+	expandAuthorsTags := fs.Bool("expand-authors-tags", false, "also harvest posts from author and tag pages linked from the blog")
+	resumeFile := fs.String("resume", "", "continue from this earlier result: keep its URLs and start an infinite scroll where it stopped")
+	previousFile := fs.String("previous", "", "previous result file; report new and changed posts relative to it")
+	listingCacheFile := fs.String("listing-cache", "", "remember listing pages in this JSON file and skip rendering those unchanged since the last run (created if missing)")
+	journalFile := fs.String("journal", "", "append every page visited, post URL accepted or rejected and error to this JSONL file as the crawl goes (created if missing)")
+	frontierFile := fs.String("frontier", "", "with --depth 2 and up, queue archive pages in this BoltDB file instead of memory, so an interrupted crawl resumes where it stopped (created if missing)")
+	kafkaBrokers := fs.String("kafka-brokers", "", "comma-separated Kafka brokers; publish each post to --kafka-topic")
+	kafkaTopic := fs.String("kafka-topic", "blog-posts", "Kafka topic to publish posts to")
+	kafkaKey := fs.String("kafka-key", "url", "Kafka message key: url, host or none")
+	kafkaPartitioner := fs.String("kafka-partitioner", "hash", "Kafka partitioner: hash, roundrobin or leastbytes")
+	postgresDSN := fs.String("postgres-dsn", "", "PostgreSQL connection string; upsert posts into --postgres-table")
+	postgresTable := fs.String("postgres-table", "blog_posts", "PostgreSQL table to upsert posts into")
+	watchInterval := fs.Duration("watch", 0, "re-crawl at this interval and notify about new posts (e.g. 1h)")
+	tui := fs.Bool("tui", false, "with --watch, show a dashboard of the watched blogs with keys to re-crawl one now")
+	telegramChatID := fs.String("telegram-chat-id", "", "Telegram chat or channel to notify about new posts in watch mode")
+	telegramToken := fs.String("telegram-token", "", "Telegram bot token (defaults to $TELEGRAM_BOT_TOKEN)")
+	pocket := fs.Bool("pocket", false, "add new posts to Pocket")
+	pocketConsumerKey := fs.String("pocket-consumer-key", "", "Pocket consumer key (defaults to $POCKET_CONSUMER_KEY)")
+	pocketAccessToken := fs.String("pocket-access-token", "", "Pocket access token (defaults to $POCKET_ACCESS_TOKEN)")
+	readwise := fs.Bool("readwise", false, "save new posts to Readwise Reader")
+	readwiseToken := fs.String("readwise-token", "", "Readwise access token (defaults to $READWISE_TOKEN)")
+	instapaper := fs.Bool("instapaper", false, "add new posts to Instapaper")
+	instapaperUsername := fs.String("instapaper-username", "", "Instapaper username or email (defaults to $INSTAPAPER_USERNAME)")
+	instapaperPassword := fs.String("instapaper-password", "", "Instapaper password (defaults to $INSTAPAPER_PASSWORD)")
+	format := fs.String("format", "json", "output format: json, ndjson, rss, atom, html, or a link graph as dot, graphml or graph-json")
+	compress := fs.String("compress", "none", "compress file outputs: none, gzip or zstd")
+	var outputs outputSpecs
+	fs.Var(&outputs, "output", "additional output as TYPE:TARGET, repeatable (json, ndjson, rss, atom, html, dot, graphml, graph-json, template, chunks, sqlite, bleve, webhook, kafka, postgres)")
+	stdoutMode := fs.Bool("stdout", false, "write the result document to stdout and all logs to stderr")
+	templateFile := fs.String("template", "", "render the result through this Go text/template file instead of --format")
+	opmlFile := fs.String("opml", "", "add this blog's feed to an OPML file (created if missing)")
+	failOnEmpty := fs.Bool("fail-on-empty", false, "exit with code 2 when the crawl finds no posts (the empty result is still written)")
+	authorsFile := fs.String("authors", "", "with --fetch-content, add the posts' authors to this JSON index of authors across blogs (created if missing)")
+	indexDir := fs.String("index", "", "add posts to a Bleve full-text index in this directory (query it with the search subcommand)")
+	chunksFile := fs.String("chunks", "", "write post content as JSONL chunks for embedding pipelines to this file")
+	chunkSize := fs.Int("chunk-size", 200, "words per chunk")
+	chunkOverlap := fs.Int("chunk-overlap", 40, "words repeated from the previous chunk")
+	embeddingURL := fs.String("embedding-url", "", "OpenAI-compatible embeddings endpoint; adds an embedding to every chunk")
+	embeddingModel := fs.String("embedding-model", "text-embedding-3-small", "model name sent to --embedding-url")
+	embeddingKey := fs.String("embedding-key", "", "API key for --embedding-url (defaults to $EMBEDDING_API_KEY)")
+	strategy := fs.String("strategy", "auto", "how to walk the blog's listing: auto, scroll (infinite scroll the feed), archive-months (Medium publications) next-link (follow \"Next\" links), tabs (click through client-side tabs) or search (page through --search-url)")
+	seedSearch := fs.String("seed-search", "", "also ask this search engine for site:<blog> to find posts the listing no longer links to: "+strings.Join(seedSearchEngines, ", "))
+	seedSearchKey := fs.String("seed-search-key", "", "API key for --seed-search (defaults to $SEARCH_API_KEY)")
+	seedSearchCX := fs.String("seed-search-cx", "", "Programmable Search Engine ID for --seed-search google (defaults to $GOOGLE_CSE_ID)")
+	seedSearchLimit := fs.Int("seed-search-limit", 0, "search results to ask --seed-search for (0 for as many as the API gives: 100 for google, 200 for brave)")
+	searchURL := fs.String("search-url", "", "blog search results to page through, with {page} or {page0} for the page number, e.g. https://example.com/search?q=&page={page}")
+	sortOrder := fs.String("sort", "date", "order of URLs and posts in the result: date (newest first, falling back to URL), url, discovery or popularity (needs --engagement)")
+	sitesFile := fs.String("sites", "", "JSON file with per-site settings such as which query parameters to keep")
+	keepQueryParams := fs.String("keep-query-params", "", "comma-separated query parameters (or globs, * for all) to keep in post URLs, overriding the site profile")
+	excludePatternsFlag := fs.String("exclude-patterns", "", "comma-separated URL patterns of pages that aren't posts, added to the defaults (re: for a regular expression on the path)")
+	excludeMemberOnly := fs.Bool("exclude-member-only", false, "leave out the posts a Medium listing marks as member-only (they're listed under member_only otherwise)")
+	classifyPages := fs.Bool("classify-pages", false, "load post URLs that could be category or topic pages (a single word like /blog/engineering) and drop those that read like listings")
+	categoryPages := fs.String("category-pages", "", "comma-separated category page patterns, instead of the site profile's: globs on the path like /blog/*, nav:/blog/* for links also in the navigation, grid:/blog/*/* for pages listing posts")
+	removeExcludePatterns := fs.String("remove-exclude-patterns", "", "comma-separated default exclude patterns to drop, like /careers")
+	dropQueryParams := fs.String("drop-query-params", "", "comma-separated query parameters (or globs like utm_*) to drop from post URLs, overriding the site profile")
+	harFile := fs.String("har", "", "record all network traffic of the crawl to this HAR file")
+	recordFixtures := fs.String("record-fixtures", "", "save every listing page and the post URLs found on it to this directory, for the replay subcommand")
+	dryRun := fs.Bool("dry-run", false, "crawl the listing pages and print every link with the rule that accepted or rejected it, without writing output")
+	profile := fs.String("profile", "", "reuse this named browser profile (or user data directory) so logins and cookies persist between crawls")
+	parallel := fs.Int("parallel", 1, "with --seeds, crawl this many blogs at the same time in one shared browser")
+	hostDelay := fs.Duration("host-delay", 0, "minimum delay between page loads on the same host, across parallel crawls")
+	seedsSource := fs.String("seeds", "", "crawl every blog listed on this aggregator page or OPML file; the positional argument becomes the output directory")
+	feedURL := fs.String("feed-url", "", "public URL of the generated feed, used in the OPML entry (defaults to the output path)")
+	cpuProfile := fs.String("cpuprofile", "", "write a pprof CPU profile of the crawl to this file")
+	memProfile := fs.String("memprofile", "", "write a pprof heap profile to this file when the crawl ends")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . [flags] <base_url> [output_file.json]")
+		fmt.Println("       go run . [flags] --seeds <aggregator_url | feeds.opml> [output_dir]")
+		fmt.Println("       go run . diff [--json] <old.json> <new.json>")
+		fmt.Println("       go run . serve-grpc [--listen :50051]")
+		fmt.Println("       go run . worker [--queue URL] [--requests NAME] [--results NAME]")
+		fmt.Println("       go run . coordinate --out DIR [--queue URL] [--seeds SOURCE] [blog URL]...")
+		fmt.Println("       go run . seeds [--queue URL] <aggregator_url | feeds.opml>")
+		fmt.Println("       go run . search [--index DIR] <query>")
+		fmt.Println("       go run . replay [--explain] <fixture.html | fixture_dir>...")
+		fmt.Println("       go run . schema")
+		fmt.Println("       go run . init-site [--sites FILE] <blog URL>")
+		fmt.Println("       go run . report --db FILE [--months N] [--blog URL] [--json]")
+		fmt.Println("       go run . rebuild [--blog URL] <journal.jsonl> [output_file.json]")
+		fmt.Println("       go run . doctor [--proxy URL] [--profile NAME] [blog URL]")
+		fmt.Println("Example: go run . https://medium.com/netflix-techblog")
+		fmt.Println()
+		fmt.Println("Flags:")
+		fs.PrintDefaults()
+	}
+
+	args, _ := parseArgs(fs, os.Args[1:])
+	// Secrets the config sets aren't warned about, so the command line's
+	// are noted before it's applied
+	secretArgs := commandLineSecrets(fs)
+	if _, err := loadSecrets(secretsPath(*secretsFile)); err != nil {
+		fmt.Printf("Error loading secrets: %v\n", err)
+		os.Exit(1)
+	}
+	config, err := applyConfig(fs, *configFile)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
 		os.Exit(1)
 	}
 
-	baseURL := os.Args[1]
+	// Machine mode: stdout is kept for the result document and everything
+	// else that would print there (progress, warnings) goes to stderr
+	var console io.Writer = os.Stdout
+	var resultOutput io.Writer
+	if *stdoutMode {
+		console, resultOutput = os.Stderr, os.Stdout
+	}
+	if config.Path != "" {
+		fmt.Fprintf(console, "Using config %s\n", config.Path)
+	}
+	warnSecretFlags(console, secretArgs)
+	for name := range secretFlags {
+		trackSecret(fs.Lookup(name).Value.String())
+	}
+	flags := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		flags[f.Name] = redactFlag(f.Name, f.Value.String())
+	})
+	if len(args) < 1 && *seedsSource == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *seedsSource != "" && (*previousFile != "" || *resumeFile != "") {
+		fmt.Fprintln(console, "--seeds cannot be combined with --previous or --resume")
+		os.Exit(1)
+	}
+	if *tui && (*watchInterval <= 0 || *stdoutMode) {
+		fmt.Fprintln(console, "--tui needs --watch and cannot be combined with --stdout")
+		os.Exit(1)
+	}
+	if *authorsFile != "" && !*fetchContent {
+		fmt.Fprintln(console, "--authors needs --fetch-content")
+		os.Exit(1)
+	}
+	if *dryRun && *watchInterval > 0 {
+		fmt.Fprintln(console, "--dry-run cannot be combined with --watch")
+		os.Exit(1)
+	}
+	if !contains(crawlStrategies, *strategy) {
+		fmt.Fprintf(console, "Unknown --strategy %q (use %s)\n", *strategy, strings.Join(crawlStrategies, ", "))
+		os.Exit(1)
+	}
+	if *seedSearch != "" {
+		if !contains(seedSearchEngines, *seedSearch) {
+			fmt.Fprintf(console, "Unknown --seed-search %q (use %s)\n", *seedSearch, strings.Join(seedSearchEngines, ", "))
+			os.Exit(1)
+		}
+		if flagOrEnv(*seedSearchKey, "SEARCH_API_KEY") == "" {
+			fmt.Fprintln(console, "--seed-search needs --seed-search-key or $SEARCH_API_KEY")
+			os.Exit(1)
+		}
+		if *seedSearch == "google" && flagOrEnv(*seedSearchCX, "GOOGLE_CSE_ID") == "" {
+			fmt.Fprintln(console, "--seed-search google needs --seed-search-cx or $GOOGLE_CSE_ID")
+			os.Exit(1)
+		}
+	}
+	if *searchURL != "" && !validSearchURL(*searchURL) {
+		fmt.Fprintf(console, "Invalid --search-url %q (needs {page} or {page0} for the page number)\n", *searchURL)
+		os.Exit(1)
+	}
+	if !contains(fetchModes, *fetchMode) {
+		fmt.Fprintf(console, "Unknown --fetch-mode %q (use %s)\n", *fetchMode, strings.Join(fetchModes, ", "))
+		os.Exit(1)
+	}
+	if !contains(sortOrders, *sortOrder) {
+		fmt.Fprintf(console, "Unknown --sort %q (use %s)\n", *sortOrder, strings.Join(sortOrders, ", "))
+		os.Exit(1)
+	}
+	if *sortOrder == "popularity" && !*engagement {
+		fmt.Fprintln(console, "--sort popularity needs --engagement")
+		os.Exit(1)
+	}
+	if *timeout <= 0 {
+		fmt.Fprintf(console, "Invalid --timeout %v (must be positive)\n", *timeout)
+		os.Exit(1)
+	}
+	if *proxy != "" {
+		if err := validateProxy(regionProxy(*proxy, "us")); err != nil {
+			fmt.Fprintf(console, "Invalid --proxy: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	regions, err := parseRegions(*country, *locale)
+	if err != nil {
+		fmt.Fprintf(console, "Invalid --country or --locale: %v\n", err)
+		os.Exit(1)
+	}
+	if strings.Contains(*proxy, countryPlaceholder) && len(regions) == 0 {
+		fmt.Fprintf(console, "--proxy has %s but no --country or --locale to fill it in\n", countryPlaceholder)
+		os.Exit(1)
+	}
+	for _, region := range regions {
+		if region.Country == "" && strings.Contains(*proxy, countryPlaceholder) {
+			fmt.Fprintf(console, "--locale %s has no country for the %s in --proxy; add --country\n", region.Locale, countryPlaceholder)
+			os.Exit(1)
+		}
+	}
+	if *parallel > 1 && *seedsSource == "" {
+		fmt.Fprintln(console, "--parallel needs several blogs to crawl; use it with --seeds")
+		os.Exit(1)
+	}
+
+	switch *format {
+	case "json", "ndjson", "rss", "atom", "html", "dot", "graphml", "graph-json":
+	default:
+		fmt.Fprintf(console, "Unknown output format %q (use json, ndjson, rss, atom, html, dot, graphml or graph-json)\n", *format)
+		os.Exit(1)
+	}
+	if _, err := compressWriter(io.Discard, *compress); err != nil {
+		fmt.Fprintf(console, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var outputTemplate *template.Template
+	if *templateFile != "" {
+		tmpl, err := loadOutputTemplate(*templateFile)
+		if err != nil {
+			fmt.Fprintf(console, "Error loading template: %v\n", err)
+			os.Exit(1)
+		}
+		outputTemplate = tmpl
+	}
+
 	outputFile := "blog_urls.json"
-	if len(os.Args) >= 3 {
-		outputFile = os.Args[2]
+	switch *format {
+	case "rss", "atom":
+		outputFile = "blog_urls.xml"
+	case "html":
+		outputFile = "blog_urls.html"
+	case "ndjson":
+		outputFile = "blog_urls.ndjson"
+	case "dot":
+		outputFile = "blog_urls.dot"
+	case "graphml":
+		outputFile = "blog_urls.graphml"
+	case "graph-json":
+		outputFile = "blog_urls.graph.json"
+	}
+	if outputTemplate != nil {
+		outputFile = "blog_urls.txt"
 	}
 
-	// 30 second timeout for initial page load
-	timeout := 30 * time.Second
+	var baseURL string
+	outputDir := "."
+	if *seedsSource != "" {
+		if len(args) >= 1 {
+			outputDir = args[0]
+		}
+	} else {
+		baseURL = args[0]
+		if len(args) >= 2 {
+			outputFile = args[1]
+		}
+	}
 
-	crawler := NewBlogCrawler(baseURL, timeout)
+	// An output path with placeholders is expanded for every crawl; with
+	// --seeds it stands for the output directory
+	var outputPath string
+	if *seedsSource != "" && isOutputTemplate(outputDir) {
+		outputPath, outputDir = outputDir, "."
+	} else if *seedsSource == "" && isOutputTemplate(outputFile) {
+		outputPath = outputFile
+	}
+	if outputPath != "" {
+		if err := validateOutputTemplate(outputPath, *seedsSource != ""); err != nil {
+			fmt.Fprintf(console, "Invalid output path: %v\n", err)
+			os.Exit(1)
+		}
+	}
 
-	fmt.Printf("Starting blog crawler for: %s\n", baseURL)
-	fmt.Printf("Timeout set to: %v\n", timeout)
+	// With --tui the crawls print to the dashboard instead of the terminal
+	var dashboardOutput *dashboardLog
+	output := console
+	if *tui {
+		dashboardOutput = newDashboardLog()
+		output = dashboardOutput
+	}
 
-	result, err := crawler.crawl()
-	if err != nil {
-		fmt.Printf("Error during crawling: %v\n", err)
+	// Captures and diagnostics live next to the JSON output: results.json ->
+	// results_captures/ and results_diagnostics/
+	opts := CrawlOptions{
+		Output:         output,
+		Screenshot:     *screenshot,
+		PDF:            *pdf,
+		DownloadImages: *downloadImages,
+		CaptureDir:     strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + "_captures",
+		DiagnosticsDir: strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + "_diagnostics",
+		WaybackSave:    *waybackSave,
+		WaybackLookup:  *waybackLookup,
+		WaybackDelay:   *waybackDelay,
+		FetchContent:   *fetchContent,
+		Engagement:     *engagement,
+		Depth:          *depth,
+
+		WaybackDiscover:      *waybackDiscover,
+		WaybackDiscoverLimit: *waybackDiscoverLimit,
+
+		PostWorkers:         *postWorkers,
+		PostHostConcurrency: *postHostConcurrency,
+		PostRetries:         *postRetries,
+		FetchMode:           *fetchMode,
+
+		ExpandAuthorsTags:  *expandAuthorsTags,
+		CollapseDuplicates: *collapseDuplicates,
+
+		BrowserMemoryMB:      *browserMemory,
+		DisableDevShm:        *disableDevShm,
+		RendererProcessLimit: *rendererLimit,
+		RecyclePages:         *recyclePages,
+		PruneEvery:           *pruneEvery,
+		MaxEmptyPages:        *maxEmptyPages,
+		ScrollIdleLimit:      *scrollIdle,
+		ScrollDelay:          *scrollDelay,
+		MaxScrolls:           *maxScrolls,
+		ScrollTimeout:        *scrollTimeout,
+		MaxURLs:              *maxURLs,
+		StopOnHeight:         *stopOnHeight,
+		FixedScrollDelay:     *fixedScrollDelay,
+		Profile:              *profile,
+		Proxy:                *proxy,
+		DryRun:               *dryRun,
+		RecordFixtures:       *recordFixtures,
+		HAR:                  *harFile,
+		NoViewAll:            *noViewAll,
+		Strategy:             *strategy,
+		SearchURL:            *searchURL,
+		Sort:                 *sortOrder,
+		ClassifyPages:        *classifyPages,
+		ExcludeMemberOnly:    *excludeMemberOnly,
+		Flags:                flags,
+		Budget: crawlBudget{
+			MaxRequests: *budgetRequests,
+			MaxMB:       *budgetMB,
+			MaxDuration: jsonDuration(*budgetDuration),
+		},
+		Emulation: browserEmulation{
+			DeviceScaleFactor: *deviceScaleFactor,
+			Timezone:          *timezone,
+			AcceptLanguage:    *acceptLanguage,
+		},
+	}
+	if *viewport != "" {
+		width, height, err := parseViewport(*viewport)
+		if err != nil {
+			fmt.Fprintf(console, "Invalid --viewport: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Emulation.Width, opts.Emulation.Height = width, height
+	}
+	opts.Regions = regions
+	if err := opts.Emulation.validate(); err != nil {
+		fmt.Fprintf(console, "Invalid browser emulation: %v\n", err)
 		os.Exit(1)
 	}
+	if *seedSearch != "" {
+		opts.SeedSearch = &SeedSearch{
+			Engine: *seedSearch,
+			Key:    flagOrEnv(*seedSearchKey, "SEARCH_API_KEY"),
+			CX:     flagOrEnv(*seedSearchCX, "GOOGLE_CSE_ID"),
+			Limit:  *seedSearchLimit,
+		}
+	}
+	if *resumeFile != "" {
+		resume, err := loadResult(*resumeFile)
+		if err != nil {
+			fmt.Fprintf(console, "Error loading --resume result: %v\n", err)
+			os.Exit(1)
+		}
+		if resume.BaseURL != baseURL {
+			fmt.Fprintf(console, "--resume result is for %s, not %s\n", resume.BaseURL, baseURL)
+			os.Exit(1)
+		}
+		opts.Resume = resume
+	}
+	if *listingCacheFile != "" {
+		cache, err := loadListingCache(*listingCacheFile)
+		if err != nil {
+			fmt.Fprintf(console, "Error loading --listing-cache: %v\n", err)
+			os.Exit(1)
+		}
+		opts.ListingCache = cache
+	}
+	if *frontierFile != "" {
+		store, err := openBoltFrontierStore(*frontierFile)
+		if err != nil {
+			fmt.Fprintf(console, "Error opening --frontier: %v\n", err)
+			os.Exit(1)
+		}
+		defer store.Close()
+		opts.Frontier = store
+	}
+	if *journalFile != "" {
+		journal, err := openJournal(*journalFile)
+		if err != nil {
+			fmt.Fprintf(console, "Error opening --journal: %v\n", err)
+			os.Exit(1)
+		}
+		defer journal.Close()
+		opts.Journal = journal
+	}
+	if *stopBefore != "" {
+		date, err := time.Parse("2006-01-02", *stopBefore)
+		if err != nil {
+			fmt.Fprintf(console, "Invalid --stop-before %q (use YYYY-MM-DD)\n", *stopBefore)
+			os.Exit(1)
+		}
+		opts.StopBefore = date
+	}
+	if *keepQueryParams != "" {
+		opts.KeepQueryParams = strings.Split(*keepQueryParams, ",")
+	}
+	if *dropQueryParams != "" {
+		opts.DropQueryParams = strings.Split(*dropQueryParams, ",")
+	}
+	if *excludePatternsFlag != "" {
+		opts.ExcludePatterns = strings.Split(*excludePatternsFlag, ",")
+		if err := urlfilter.ValidateExcludes(opts.ExcludePatterns); err != nil {
+			fmt.Fprintf(console, "Invalid --exclude-patterns: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *removeExcludePatterns != "" {
+		opts.RemoveExcludePatterns = strings.Split(*removeExcludePatterns, ",")
+	}
+	if *categoryPages != "" {
+		opts.CategoryPages = strings.Split(*categoryPages, ",")
+		if err := urlfilter.ValidateCategories(opts.CategoryPages); err != nil {
+			fmt.Fprintf(console, "Invalid --category-pages: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *sitesFile != "" {
+		sites, err := loadSiteProfiles(*sitesFile)
+		if err != nil {
+			fmt.Fprintf(console, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Sites = sites
+	}
+	opts.Sites = append(opts.Sites, config.Sites...)
+	if err := loadSiteScripts(opts.Sites, console); err != nil {
+		fmt.Fprintf(console, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *hostDelay > 0 {
+		opts.HostLimiter = newHostLimiter(*hostDelay)
+	}
+
+	// Sinks and notifiers are set up before crawling so configuration
+	// errors fail fast
+	var sinks []resultSink
+	if *kafkaBrokers != "" {
+		sink, err := newKafkaSink(strings.Split(*kafkaBrokers, ","), *kafkaTopic, *kafkaKey, *kafkaPartitioner)
+		if err != nil {
+			fmt.Fprintf(console, "Error configuring Kafka sink: %v\n", err)
+			os.Exit(1)
+		}
+		sinks = append(sinks, sink)
+	}
+	if *postgresDSN != "" {
+		sink, err := newPostgresSink(*postgresDSN, *postgresTable)
+		if err != nil {
+			fmt.Fprintf(console, "Error configuring PostgreSQL sink: %s\n", redact(err.Error()))
+			os.Exit(1)
+		}
+		sinks = append(sinks, sink)
+	}
+	if *indexDir != "" {
+		if !*fetchContent {
+			fmt.Fprintln(console, "Note: without --fetch-content only titles derived from URLs are indexed")
+		}
+		sink, err := newBleveSink(*indexDir, output)
+		if err != nil {
+			fmt.Fprintf(console, "Error configuring search index: %v\n", err)
+			os.Exit(1)
+		}
+		sinks = append(sinks, sink)
+	}
+	defaults := sinkDefaults{
+		kafkaKey:         *kafkaKey,
+		kafkaPartitioner: *kafkaPartitioner,
+		postgresTable:    *postgresTable,
+		compress:         *compress,
+		chunkSize:        *chunkSize,
+		chunkOverlap:     *chunkOverlap,
+		output:           output,
+	}
+	if *chunkSize <= 0 || *chunkOverlap < 0 || *chunkOverlap >= *chunkSize {
+		fmt.Fprintln(console, "Error: --chunk-size must be positive and larger than --chunk-overlap")
+		os.Exit(1)
+	}
+	if *embeddingURL != "" {
+		defaults.embedder = newEmbeddingClient(*embeddingURL, *embeddingModel, flagOrEnv(*embeddingKey, "EMBEDDING_API_KEY"))
+	}
+	if *chunksFile != "" {
+		outputs = append(outputs, "chunks:"+*chunksFile)
+	}
+	for _, spec := range outputs {
+		sink, err := parseOutputSpec(spec, defaults)
+		if err != nil {
+			fmt.Fprintf(console, "Error configuring output: %s\n", redact(err.Error()))
+			os.Exit(1)
+		}
+		sinks = append(sinks, sink)
+	}
+	defer func() {
+		for _, sink := range sinks {
+			sink.Close()
+		}
+	}()
+
+	var notifiers []notifier
+	if *telegramChatID != "" {
+		token := flagOrEnv(*telegramToken, "TELEGRAM_BOT_TOKEN")
+		if token == "" {
+			fmt.Fprintln(console, "Error configuring Telegram notifier: set --telegram-token or TELEGRAM_BOT_TOKEN")
+			os.Exit(1)
+		}
+		notifiers = append(notifiers, newTelegramNotifier(token, *telegramChatID))
+	}
+	if *pocket {
+		consumerKey := flagOrEnv(*pocketConsumerKey, "POCKET_CONSUMER_KEY")
+		accessToken := flagOrEnv(*pocketAccessToken, "POCKET_ACCESS_TOKEN")
+		if consumerKey == "" || accessToken == "" {
+			fmt.Fprintln(console, "Error configuring Pocket: set --pocket-consumer-key and --pocket-access-token or POCKET_CONSUMER_KEY and POCKET_ACCESS_TOKEN")
+			os.Exit(1)
+		}
+		notifiers = append(notifiers, newPocketNotifier(consumerKey, accessToken, output))
+	}
+	if *readwise {
+		token := flagOrEnv(*readwiseToken, "READWISE_TOKEN")
+		if token == "" {
+			fmt.Fprintln(console, "Error configuring Readwise: set --readwise-token or READWISE_TOKEN")
+			os.Exit(1)
+		}
+		notifiers = append(notifiers, newReadwiseNotifier(token, output))
+	}
+	if *instapaper {
+		username := flagOrEnv(*instapaperUsername, "INSTAPAPER_USERNAME")
+		if username == "" {
+			fmt.Fprintln(console, "Error configuring Instapaper: set --instapaper-username or INSTAPAPER_USERNAME")
+			os.Exit(1)
+		}
+		notifiers = append(notifiers, newInstapaperNotifier(username, flagOrEnv(*instapaperPassword, "INSTAPAPER_PASSWORD"), output))
+	}
+
+	var previous *CrawlResult
+	if *previousFile != "" {
+		loaded, err := loadResult(*previousFile)
+		if os.IsNotExist(err) {
+			fmt.Fprintf(console, "Previous result %s not found, treating all posts as new\n", *previousFile)
+			loaded = &CrawlResult{}
+		} else if err != nil {
+			fmt.Fprintf(console, "Error loading previous result: %v\n", err)
+			os.Exit(1)
+		}
+		previous = loaded
+	}
+
+	run := &crawlRun{
+		baseURL:     baseURL,
+		outputFile:  outputFile,
+		outputPath:  outputPath,
+		runStarted:  time.Now(),
+		format:      *format,
+		template:    outputTemplate,
+		stdout:      resultOutput,
+		compress:    *compress,
+		opmlFile:    *opmlFile,
+		feedURL:     *feedURL,
+		authorsFile: *authorsFile,
+		timeout:     *timeout,
+		opts:        opts,
+		sinks:       sinks,
+		notifiers:   notifiers,
+		parallel:    *parallel,
+		failOnEmpty: *failOnEmpty,
+	}
 
-	fmt.Printf("\nCrawling completed!\n")
-	fmt.Printf("Total blog URLs found: %d\n", result.TotalCount)
+	// Interrupting stops the crawl (or watch loop) cleanly instead of
+	// killing the browser mid-page
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	if err := crawler.saveToJSON(result, outputFile); err != nil {
-		fmt.Printf("Error saving to JSON: %v\n", err)
+	prof, err := startProfiling(*cpuProfile, *memProfile, console)
+	if err != nil {
+		fmt.Fprintf(console, "Error: %v\n", err)
 		os.Exit(1)
 	}
+	defer prof.stop()
+
+	if *seedsSource != "" {
+		seeds, err := loadSeeds(ctx, *seedsSource, run.timeout, console)
+		if err != nil {
+			fmt.Fprintf(console, "Error loading seeds: %v\n", err)
+			prof.stop()
+			os.Exit(1)
+		}
+		fmt.Fprintf(console, "Found %d blogs in %s\n", len(seeds), *seedsSource)
 
-	fmt.Printf("Results saved to: %s\n", outputFile)
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			fmt.Fprintf(console, "Error creating output directory: %v\n", err)
+			prof.stop()
+			os.Exit(1)
+		}
+		if *watchInterval > 0 {
+			dashboard := startDashboard(dashboardOutput, seeds, *watchInterval, stop)
+			run.watchSeeds(ctx, seeds, outputDir, *watchInterval, dashboard)
+			dashboard.close()
+			return
+		}
+		if err := run.crawlSeeds(ctx, seeds, outputDir); err != nil {
+			for _, sink := range sinks {
+				sink.Close()
+			}
+			prof.stop()
+			exitWithError(console, err)
+		}
+		return
+	}
+
+	if *watchInterval > 0 {
+		if dashboard := startDashboard(dashboardOutput, []string{baseURL}, *watchInterval, stop); dashboard != nil {
+			run.status = dashboard.blogs[0]
+			defer dashboard.close()
+		}
+		run.watch(ctx, previous, *watchInterval)
+		return
+	}
+
+	if _, err := run.once(ctx, previous); err != nil {
+		for _, sink := range sinks {
+			sink.Close()
+		}
+		prof.stop()
+		exitWithError(console, err)
+	}
 }
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// emptyMonthLimit is the number of consecutive archive months without
+// posts after which a publication is assumed to have no older ones
+const emptyMonthLimit = 12
+
+// mediumYearPattern matches the year pages linked from a Medium
+// publication's archive
+var mediumYearPattern = regexp.MustCompile(`/archive/(\d{4})/?$`)
+
+// mediumArchiveRoot returns the archive page of a Medium publication, or ""
+// when the blog isn't hosted on Medium
+func mediumArchiveRoot(baseURL string) string {
+	parsedURL, err := url.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(parsedURL.Hostname())
+	if host != "medium.com" && !strings.HasSuffix(host, ".medium.com") {
+		return ""
+	}
+	// medium.com/<publication> needs the publication in the path
+	publication := strings.Trim(parsedURL.Path, "/")
+	if host == "medium.com" && publication == "" {
+		return ""
+	}
+	if publication != "" {
+		publication = "/" + strings.SplitN(publication, "/", 2)[0]
+	}
+	return fmt.Sprintf("%s://%s%s/archive", parsedURL.Scheme, parsedURL.Host, publication)
+}
+
+// mediumArchiveURL returns the archive page of a Medium publication for the
+// month of t, or "" when the blog isn't hosted on Medium
+func mediumArchiveURL(baseURL string, t time.Time) string {
+	root := mediumArchiveRoot(baseURL)
+	if root == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/%04d/%02d", root, t.Year(), int(t.Month()))
+}
+
+// crawlMediumArchive enumerates a Medium publication's archive months from
+// the current one back to January of the first year the archive lists.
+// Unlike the feed, which only loads more posts while scrolled, this reaches
+// every post and needs a page load per month rather than per batch.
+func (bc *BlogCrawler) crawlMediumArchive(ctx context.Context, urlSet map[string]bool) {
+	root := mediumArchiveRoot(bc.baseURL)
+	first, err := bc.mediumFirstYear(ctx, root)
+	if err != nil {
+		bc.warnf("Could not read the years of %s, stopping after %d empty months instead: %v", root, emptyMonthLimit, err)
+		bc.crawlArchiveMonths(ctx, urlSet, time.Now(), time.Time{})
+		return
+	}
+
+	bc.printf("Crawling archive months back to %d...\n", first)
+	bc.crawlArchiveMonths(ctx, urlSet, time.Now(), time.Date(first, time.January, 1, 0, 0, 0, 0, time.UTC))
+}
+
+// mediumFirstYear reads the earliest year linked from the archive page
+func (bc *BlogCrawler) mediumFirstYear(ctx context.Context, root string) (int, error) {
+	if err := bc.loadPage(ctx, root); err != nil {
+		return 0, err
+	}
+
+	linksCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	anchors, err := bc.anchors(linksCtx, mediumYearSelector)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find year links: %w", err)
+	}
+
+	first := 0
+	for _, a := range anchors {
+		match := mediumYearPattern.FindStringSubmatch(a.Href)
+		if match == nil {
+			continue
+		}
+		if year, err := strconv.Atoi(match[1]); err == nil && (first == 0 || year < first) {
+			first = year
+		}
+	}
+	if first == 0 {
+		if bc.emptyPageReason(ctx) == "" {
+			bc.adapterStale(mediumYearLinksCheck, root)
+		}
+		return 0, fmt.Errorf("no year links found")
+	}
+	return first, nil
+}
+
+// crawlArchiveMonths crawls a Medium publication's archive months from the
+// month of from backwards. It ends at the month of until, or, when until is
+// zero, after emptyMonthLimit months in a row without posts.
+func (bc *BlogCrawler) crawlArchiveMonths(ctx context.Context, urlSet map[string]bool, from, until time.Time) {
+	month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	last := time.Date(until.Year(), until.Month(), 1, 0, 0, 0, 0, time.UTC)
+	pageNum := len(bc.pages)
+	step := 0
+	emptyMonths := 0
+
+	for ctx.Err() == nil {
+		if !until.IsZero() && month.Before(last) {
+			break
+		}
+		if until.IsZero() && emptyMonths >= emptyMonthLimit {
+			bc.printf("No posts in %d months. Stopping.\n", emptyMonthLimit)
+			break
+		}
+
+		pageURL := mediumArchiveURL(bc.baseURL, month)
+		step++
+		pageNum++
+		bc.printf("Crawling archive month %s: %s\n", month.Format("2006-01"), pageURL)
+
+		bc.setSource("archive", step, pageURL)
+		urls, err := bc.crawlSinglePage(ctx, pageURL)
+		bc.recordPage(pageNum, pageURL, len(urls), err)
+		if err != nil {
+			bc.warnf("Error crawling archive month %s: %v", month.Format("2006-01"), err)
+		}
+		if len(urls) == 0 {
+			emptyMonths++
+		} else {
+			emptyMonths = 0
+		}
+
+		for _, url := range urls {
+			urlSet[url] = true
+		}
+		bc.printf("  Found %d blog URLs (total: %d unique URLs)\n", len(urls), len(urlSet))
+		bc.reportProgress(ProgressEvent{Kind: "archive", Step: step, URL: pageURL, URLsFound: len(urls), TotalURLs: len(urlSet)})
+
+		month = month.AddDate(0, -1, 0)
+		if err := sleepContext(ctx, 1*time.Second); err != nil {
+			break
+		}
+	}
+}
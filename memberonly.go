@@ -0,0 +1,45 @@
+package main
+
+import (
+	"sort"
+)
+
+// recordMemberOnly remembers postURL as member-only when the card of the
+// link it was found by has Medium's star
+func (bc *BlogCrawler) recordMemberOnly(postURL string, a anchor) {
+	if !a.MemberOnly {
+		return
+	}
+	if bc.memberOnly == nil {
+		bc.memberOnly = make(map[string]bool)
+	}
+	bc.memberOnly[postURL] = true
+}
+
+// dropMemberOnly takes the member-only posts out of urlSet for
+// --exclude-member-only, counting them as rejected
+func (bc *BlogCrawler) dropMemberOnly(urlSet map[string]bool) {
+	dropped := 0
+	for postURL := range bc.memberOnly {
+		if urlSet[postURL] {
+			delete(urlSet, postURL)
+			bc.recordRejection(postURL, "member-only")
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		bc.printf("Leaving out %d member-only posts\n", dropped)
+	}
+}
+
+// memberOnlyURLs returns the member-only posts among urls, sorted
+func (bc *BlogCrawler) memberOnlyURLs(urls []string) []string {
+	var memberOnly []string
+	for _, postURL := range urls {
+		if bc.memberOnly[postURL] {
+			memberOnly = append(memberOnly, postURL)
+		}
+	}
+	sort.Strings(memberOnly)
+	return memberOnly
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// nextLinkPageLimit is the safety limit of pages followed by next links
+const nextLinkPageLimit = 200
+
+// nextLinkSelectors find a listing's link to its next page, most reliable
+// first: the rel=next hint, accessible labels, then the class names of
+// common blog themes
+var nextLinkSelectors = []string{
+	`link[rel="next"]`,
+	`a[rel~="next"]`,
+	`a[aria-label="Next page"]`,
+	`a[aria-label="Next"]`,
+	`a.next`,
+	`a.next-page`,
+	`.pagination a.next`,
+	`.pagination-next a`,
+	`.nav-previous a`, // WordPress: "Older posts"
+	`a.older-posts`,
+}
+
+// nextLinkJS returns the absolute URL of the first element matching one of
+// the selectors, or of a link reading "Next" or "Older posts"
+const nextLinkJS = `(selectors) => {
+	for (const selector of selectors) {
+		const el = document.querySelector(selector);
+		if (el && el.href) return el.href;
+	}
+	const texts = /^(next|next page|older posts|older entries)\s*[›»→]?$/i;
+	for (const a of document.querySelectorAll('a[href]')) {
+		if (texts.test(a.textContent.trim())) return a.href;
+	}
+	return '';
+}`
+
+// findNextLink returns the current page's absolute link to the next
+// listing page, or "" if there is none. For a page taken from the listing
+// cache that's the link it had when cached.
+func (bc *BlogCrawler) findNextLink(ctx context.Context) (string, error) {
+	if bc.cachedPage != nil {
+		return bc.cachedPage.Next, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := bc.page.Eval(ctx, nextLinkJS, bc.nextLinkSelectors())
+	if err != nil {
+		return "", fmt.Errorf("failed to look for a next link: %w", err)
+	}
+	// Kept as is: unlike post links, the query often is the pagination
+	return res.Str(), nil
+}
+
+// crawlNextLinks crawls the listing page by page, following each page's
+// next link until there is none. Unlike guessing URL patterns like
+// /page/N/, this works with whatever scheme the blog uses.
+func (bc *BlogCrawler) crawlNextLinks(ctx context.Context, urlSet map[string]bool) {
+	visited := make(map[string]bool)
+	pageURL := bc.listing()
+
+	for pageNum := 1; ctx.Err() == nil; pageNum++ {
+		visited[urlKey(pageURL)] = true
+		bc.printf("Crawling page %d: %s\n", pageNum, pageURL)
+
+		bc.setSource("page", pageNum, pageURL)
+		urls, cached := bc.listingFromCache(ctx, pageNum, pageURL)
+		var err error
+		if !cached {
+			urls, err = bc.crawlSinglePage(ctx, pageURL)
+		}
+		bc.recordPage(pageNum, pageURL, len(urls), err)
+		if err != nil {
+			bc.warnf("Error crawling page %d: %v", pageNum, err)
+			bc.printf("Stopping: Error on page %d\n", pageNum)
+			return
+		}
+
+		for _, url := range urls {
+			urlSet[url] = true
+		}
+		bc.printf("  Found %d blog URLs on page %d (total: %d unique URLs)\n", len(urls), pageNum, len(urlSet))
+		bc.reportProgress(ProgressEvent{Kind: "page", Step: pageNum, URL: pageURL, URLsFound: len(urls), TotalURLs: len(urlSet)})
+
+		next, err := bc.findNextLink(ctx)
+		if err == nil {
+			bc.cacheListing(pageURL, urls, next)
+		}
+		switch {
+		case err != nil:
+			bc.warnf("Error on page %d: %v", pageNum, err)
+			return
+		case next == "":
+			bc.printf("Stopping: No next link on page %d\n", pageNum)
+			return
+		case visited[urlKey(next)]:
+			bc.printf("Stopping: Next link on page %d leads back to %s\n", pageNum, next)
+			return
+		case pageNum >= nextLinkPageLimit:
+			bc.printf("Reached safety limit of %d pages. Stopping.\n", nextLinkPageLimit)
+			return
+		}
+
+		pageURL = next
+		if err := sleepContext(ctx, 1*time.Second); err != nil {
+			return
+		}
+	}
+}
+
+// hasNextLink reports whether the current page links to a next page
+func (bc *BlogCrawler) hasNextLink(ctx context.Context) bool {
+	next, err := bc.findNextLink(ctx)
+	return err == nil && next != "" && urlKey(next) != urlKey(bc.listing())
+}
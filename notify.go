@@ -0,0 +1,20 @@
+package main
+
+import "context"
+
+// notifier announces the new posts of a result (result.New) somewhere people
+// will see them. It is only called when there is at least one new post.
+type notifier interface {
+	Notify(ctx context.Context, result *CrawlResult) error
+}
+
+// newPostTitles maps new post URLs to their titles when content was fetched
+func newPostTitles(result *CrawlResult) map[string]string {
+	titles := make(map[string]string)
+	for _, post := range result.Posts {
+		if post.Title != "" {
+			titles[post.URL] = post.Title
+		}
+	}
+	return titles
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// sortOrders are the values --sort accepts
+var sortOrders = []string{"date", "url", "discovery", "popularity"}
+
+// publishedLayouts are the formats publish dates are commonly stated in
+var publishedLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+}
+
+// parsePublished reads a post's publish date in any of publishedLayouts
+func parsePublished(value string) (time.Time, bool) {
+	for _, layout := range publishedLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// sortURLs orders the result's URLs and posts by opts.Sort, so repeated
+// crawls produce the same output. "date" puts the newest posts first and
+// undated ones after them by URL; without any dates it is the same as
+// "url". "discovery" keeps the order in which the crawl found the posts.
+// "popularity" puts the posts with the most engagement first.
+func (bc *BlogCrawler) sortURLs(urls []string, posts []Post) {
+	published := make(map[string]time.Time)
+	popularity := make(map[string]int)
+	for _, post := range posts {
+		if t, ok := parsePublished(post.Published); ok {
+			published[post.URL] = t
+		}
+		popularity[post.URL] = post.Engagement.score()
+	}
+
+	less := func(a, b string) bool {
+		switch bc.opts.Sort {
+		case "discovery":
+			foundA, okA := bc.discovered[a]
+			foundB, okB := bc.discovered[b]
+			if okA != okB {
+				return okA
+			}
+			if foundA.order != foundB.order {
+				return foundA.order < foundB.order
+			}
+		case "popularity":
+			if popularity[a] != popularity[b] {
+				return popularity[a] > popularity[b]
+			}
+		case "url":
+		default:
+			dateA, okA := published[a]
+			dateB, okB := published[b]
+			if okA != okB {
+				return okA
+			}
+			if !dateA.Equal(dateB) {
+				return dateA.After(dateB)
+			}
+		}
+		return a < b
+	}
+
+	sort.SliceStable(urls, func(i, j int) bool { return less(urls[i], urls[j]) })
+	sort.SliceStable(posts, func(i, j int) bool { return less(posts[i].URL, posts[j].URL) })
+}
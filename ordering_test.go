@@ -0,0 +1,71 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParsePublished(t *testing.T) {
+	want := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	for _, value := range []string{"2024-03-15", "2024-03-15T00:00:00Z", "March 15, 2024", "Mar 15, 2024", "15 March 2024"} {
+		if got, ok := parsePublished(value); !ok || !got.Equal(want) {
+			t.Errorf("parsePublished(%q) = %v, %v; want %v", value, got, ok, want)
+		}
+	}
+	for _, value := range []string{"", "last spring", "15/03/2024"} {
+		if _, ok := parsePublished(value); ok {
+			t.Errorf("parsePublished(%q) succeeded, want no date", value)
+		}
+	}
+}
+
+func TestSortURLs(t *testing.T) {
+	const (
+		a = "https://blog.example.com/a-undated"
+		b = "https://blog.example.com/b-march"
+		c = "https://blog.example.com/c-january"
+		d = "https://blog.example.com/d-unparseable"
+		e = "https://blog.example.com/e-march-too"
+	)
+	posts := []Post{
+		{URL: a, Engagement: &Engagement{Claps: 5}},
+		{URL: b, Published: "2024-03-01T09:00:00Z", Engagement: &Engagement{Claps: 50}},
+		{URL: c, Published: "January 10, 2024"},
+		{URL: d, Published: "sometime", Engagement: &Engagement{Comments: 5}},
+		{URL: e, Published: "2024-03-01T09:00:00Z", Engagement: &Engagement{Claps: 100}},
+	}
+	found := []string{d, e, a, c} // b was never recorded as discovered
+
+	tests := []struct {
+		sort string
+		want []string
+	}{
+		// Newest first, the same date by URL, then undated posts by URL
+		{"date", []string{b, e, c, a, d}},
+		{"", []string{b, e, c, a, d}},
+		{"url", []string{a, b, c, d, e}},
+		// Discovery order, then posts without a discovery by URL
+		{"discovery", []string{d, e, a, c, b}},
+		// Most engagement first, ties by URL
+		{"popularity", []string{e, b, a, d, c}},
+	}
+	for _, tt := range tests {
+		bc := NewBlogCrawler("https://blog.example.com/", time.Second, CrawlOptions{Sort: tt.sort})
+		for _, postURL := range found {
+			bc.recordDiscovery(postURL, "")
+		}
+		urls := []string{c, e, a, d, b}
+		sorted := append([]Post(nil), posts...)
+		bc.sortURLs(urls, sorted)
+
+		if !reflect.DeepEqual(urls, tt.want) {
+			t.Errorf("--sort %q: URLs %q, want %q", tt.sort, urls, tt.want)
+		}
+		for i := range sorted {
+			if sorted[i].URL != tt.want[i] {
+				t.Errorf("--sort %q: post %d is %s, want %s", tt.sort, i, sorted[i].URL, tt.want[i])
+			}
+		}
+	}
+}
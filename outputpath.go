@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// outputPlaceholder matches the placeholders of an output path template
+var outputPlaceholder = regexp.MustCompile(`\{([a-z]*)\}`)
+
+// outputPlaceholders are what an output path template can contain:
+//
+//	{host}  the blog's host name, like medium.com
+//	{name}  the blog's host and path, like medium-com-netflix-techblog
+//	{date}  the day the crawl started, like 2024-05-01
+//	{time}  the time the crawl started, like 143005
+//	{run}   when the run started, the same for every blog and watch cycle, like 20240501-143005
+var outputPlaceholders = map[string]bool{"host": true, "name": true, "date": true, "time": true, "run": true}
+
+// isOutputTemplate reports whether an output path has placeholders
+func isOutputTemplate(path string) bool {
+	return outputPlaceholder.MatchString(path)
+}
+
+// validateOutputTemplate rejects placeholders that don't exist. A template
+// shared by several blogs has to tell them apart with {host} or {name}.
+func validateOutputTemplate(pattern string, manyBlogs bool) error {
+	for _, match := range outputPlaceholder.FindAllStringSubmatch(pattern, -1) {
+		if _, ok := outputPlaceholders[match[1]]; !ok {
+			return fmt.Errorf("unknown placeholder %s in %q (use {host}, {name}, {date}, {time} or {run})", match[0], pattern)
+		}
+	}
+	if manyBlogs && !strings.Contains(pattern, "{host}") && !strings.Contains(pattern, "{name}") {
+		return fmt.Errorf("%q would write every blog to the same file; add {host} or {name}", pattern)
+	}
+	return nil
+}
+
+// expandOutputPath fills in an output path template for a crawl of baseURL
+// starting at started, in a run that started at run
+func expandOutputPath(pattern, baseURL string, started, run time.Time) string {
+	host := seedOutputName(baseURL)
+	if parsedURL, err := url.Parse(baseURL); err == nil && parsedURL.Hostname() != "" {
+		host = strings.ReplaceAll(strings.ToLower(parsedURL.Hostname()), ":", "-")
+	}
+	values := map[string]string{
+		"host": host,
+		"name": seedOutputName(baseURL),
+		"date": started.Format("2006-01-02"),
+		"time": started.Format("150405"),
+		"run":  run.Format("20060102-150405"),
+	}
+	return filepath.FromSlash(outputPlaceholder.ReplaceAllStringFunc(pattern, func(placeholder string) string {
+		return values[strings.Trim(placeholder, "{}")]
+	}))
+}
+
+// setOutputFile points the run's result and everything written next to it
+// at path: captures, diagnostics and, for seed blogs, the HAR file
+func (r *crawlRun) setOutputFile(path string) {
+	ext := filepath.Ext(path)
+	r.outputFile = path
+	r.opts.CaptureDir = strings.TrimSuffix(path, ext) + "_captures"
+	r.opts.DiagnosticsDir = strings.TrimSuffix(path, ext) + "_diagnostics"
+	if r.opts.HAR != "" && r.browser != nil {
+		r.opts.HAR = strings.TrimSuffix(path, ext) + ".har"
+	}
+}
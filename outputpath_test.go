@@ -0,0 +1,63 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExpandOutputPath(t *testing.T) {
+	started := time.Date(2024, 5, 1, 14, 30, 5, 0, time.UTC)
+	run := time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC)
+	tests := []struct {
+		pattern string
+		baseURL string
+		want    string
+	}{
+		{"results/{host}/{date}.json", "https://medium.com/netflix-techblog", "results/medium.com/2024-05-01.json"},
+		{"results/{name}-{date}T{time}.json", "https://medium.com/netflix-techblog", "results/medium-com-netflix-techblog-2024-05-01T143005.json"},
+		{"runs/{run}/{host}.json", "https://WWW.Uber.com:8443/blog/", "runs/20240501-140000/www.uber.com.json"},
+		{"plain.json", "https://medium.com/", "plain.json"},
+	}
+	for _, tt := range tests {
+		if got := expandOutputPath(tt.pattern, tt.baseURL, started, run); got != filepath.FromSlash(tt.want) {
+			t.Errorf("expandOutputPath(%q, %q) = %q, want %q", tt.pattern, tt.baseURL, got, tt.want)
+		}
+	}
+}
+
+func TestValidateOutputTemplate(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		manyBlogs bool
+		ok        bool
+	}{
+		{"results/{host}/{date}.json", true, true},
+		{"results/{name}.json", true, true},
+		{"results/{date}.json", false, true},
+		{"results/{date}.json", true, false}, // Every blog would overwrite the last
+		{"results/{blog}.json", false, false},
+	}
+	for _, tt := range tests {
+		if err := validateOutputTemplate(tt.pattern, tt.manyBlogs); (err == nil) != tt.ok {
+			t.Errorf("validateOutputTemplate(%q, %v) = %v, want ok %v", tt.pattern, tt.manyBlogs, err, tt.ok)
+		}
+	}
+}
+
+func TestForSeedOutput(t *testing.T) {
+	base := crawlRun{outputFile: "blog_urls.json", opts: CrawlOptions{HAR: "crawl.har"}}
+	run := base.forSeed("https://medium.com/netflix-techblog", "out", &seedBrowser{})
+	if want := filepath.Join("out", "medium-com-netflix-techblog.json"); run.outputFile != want {
+		t.Errorf("seed output %q, want %q", run.outputFile, want)
+	}
+	if want := filepath.Join("out", "medium-com-netflix-techblog.har"); run.opts.HAR != want {
+		t.Errorf("seed HAR %q, want %q", run.opts.HAR, want)
+	}
+
+	// A template is left for every crawl to expand
+	base.outputPath = "results/{host}/{date}.json"
+	if run := base.forSeed("https://medium.com/netflix-techblog", ".", &seedBrowser{}); run.outputFile != base.outputFile || run.outputPath != base.outputPath {
+		t.Errorf("seed of a template writes to %q, template %q", run.outputFile, run.outputPath)
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// Page kinds told apart by pageFeatures.kind
+const (
+	pageArticle = "article"
+	pageListing = "listing"
+)
+
+// maxClassifiedPages limits the pages classifyAmbiguous loads per crawl
+const maxClassifiedPages = 100
+
+// ambiguousRules are the URL rules that accept a link on its path alone,
+// which category and topic pages pass as easily as posts
+var ambiguousRules = []string{"blog, post or article path", "path under base URL"}
+
+// paginationLinkPattern matches the links of numbered listing pages
+var paginationLinkPattern = regexp.MustCompile(`/page/\d+/?$|[?&](page|paged|p)=\d+`)
+
+// pageFeatures are the DOM features telling a post from a listing
+type pageFeatures struct {
+	PostLinks   int  // Distinct post links outside header, nav, footer and aside
+	Articles    int  // <article> elements
+	ArticleText int  // Characters of main-content text, when there's at most one <article>
+	Pagination  bool // A next-page link or links to numbered pages
+	PostMeta    bool // og:type article, a publish date or BlogPosting JSON-LD
+}
+
+// kind weighs the features against each other and returns pageArticle,
+// pageListing, or "" when they don't tell
+func (f pageFeatures) kind() string {
+	listing, article := 0, 0
+	if f.PostLinks >= postGridMinPosts {
+		listing += 2
+	}
+	if f.Articles >= 3 {
+		listing++
+	}
+	if f.Pagination {
+		listing++
+	}
+	if f.Articles <= 1 && f.ArticleText >= minArticleText {
+		article += 2
+	}
+	if f.PostMeta {
+		article++
+	}
+
+	switch {
+	case listing > article:
+		return pageListing
+	case article > listing:
+		return pageArticle
+	}
+	return ""
+}
+
+// readPageFeatures reads the features of a parsed page. Post links are
+// links the URL rules accept.
+func (bc *BlogCrawler) readPageFeatures(doc *html.Node, pageURL *url.URL) pageFeatures {
+	var features pageFeatures
+
+	chrome := cascadia.QueryAll(doc, cascadia.MustCompile(`header, nav, footer, aside, [role="navigation"]`))
+	inChrome := func(node *html.Node) bool {
+		for n := node.Parent; n != nil; n = n.Parent {
+			for _, c := range chrome {
+				if n == c {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	posts := make(map[string]bool)
+	for _, a := range cascadia.QueryAll(doc, cascadia.MustCompile("a[href]")) {
+		if inChrome(a) {
+			continue
+		}
+		normalizedURL, err := bc.normalizeURL(resolveHref(pageURL, attr(a, "href")), true)
+		if err != nil || urlKey(normalizedURL) == urlKey(pageURL.String()) {
+			continue
+		}
+		if ok, _ := bc.classifyURL(normalizedURL); ok {
+			posts[urlKey(normalizedURL)] = true
+		}
+	}
+	features.PostLinks = len(posts)
+
+	features.Articles = len(cascadia.QueryAll(doc, cascadia.MustCompile("article")))
+	if features.Articles <= 1 {
+		features.ArticleText = len(normalizeContent(documentContent(doc, pageURL).text))
+	}
+
+	for _, selector := range bc.nextLinkSelectors() {
+		if cascadia.Query(doc, cascadia.MustCompile(selector)) != nil {
+			features.Pagination = true
+			break
+		}
+	}
+	if !features.Pagination {
+		for _, link := range documentLinks(doc, pageURL) {
+			if paginationLinkPattern.MatchString(link) {
+				features.Pagination = true
+				break
+			}
+		}
+	}
+
+	features.PostMeta = metaContent(doc, `meta[property="og:type"]`) == "article" ||
+		metaContent(doc, `meta[property="article:published_time"]`) != ""
+	for _, script := range cascadia.QueryAll(doc, cascadia.MustCompile(`script[type="application/ld+json"]`)) {
+		if text := nodeText(script); strings.Contains(text, `"BlogPosting"`) || strings.Contains(text, `"Article"`) {
+			features.PostMeta = true
+		}
+	}
+	return features
+}
+
+// pageDocument fetches and parses pageURL over plain HTTP, or loads it in
+// the browser when the HTML is an empty shell that scripts fill in
+func (bc *BlogCrawler) pageDocument(ctx context.Context, pageURL string) (*html.Node, *url.URL, error) {
+	if err := bc.throttle(ctx, pageURL); err != nil {
+		return nil, nil, err
+	}
+	resp, body, _, err := bc.getPost(ctx, pageURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", pageURL, err)
+	}
+	if len(normalizeContent(documentContent(doc, resp.Request.URL).text)) >= minArticleText || bc.page == nil {
+		return doc, resp.Request.URL, nil
+	}
+
+	if err := bc.loadPage(ctx, pageURL); err != nil {
+		return nil, nil, err
+	}
+	rendered, err := bc.page.HTML(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", pageURL, err)
+	}
+	doc, err = html.Parse(strings.NewReader(rendered))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", pageURL, err)
+	}
+	return doc, resp.Request.URL, nil
+}
+
+// pageFeaturesOf loads pageURL and reads its features
+func (bc *BlogCrawler) pageFeaturesOf(ctx context.Context, pageURL string) (pageFeatures, error) {
+	doc, finalURL, err := bc.pageDocument(ctx, pageURL)
+	if err != nil {
+		return pageFeatures{}, err
+	}
+	return bc.readPageFeatures(doc, finalURL), nil
+}
+
+// ambiguous reports whether postURL could as well be a listing: the URL
+// rules accepted it on its path alone and its last segment is a single
+// word, like /blog/engineering, where post slugs are several
+func (bc *BlogCrawler) ambiguous(postURL string) bool {
+	if _, ok := bc.categoryCandidate(postURL); ok {
+		return false // Checked by checkCategoryCandidates
+	}
+	if ok, rule := bc.classifyURL(postURL); !ok || !contains(ambiguousRules, rule) {
+		return false
+	}
+	parsedURL, err := url.Parse(postURL)
+	if err != nil {
+		return false
+	}
+	segments := strings.Split(strings.Trim(parsedURL.Path, "/"), "/")
+	last := segments[len(segments)-1]
+	return !strings.ContainsAny(last, "-_.0123456789") && len(last) <= 30
+}
+
+// classifyAmbiguous loads the post URLs the URL rules can't tell from
+// listings and removes those whose page reads like a listing: many post
+// cards, pagination and no article body. Up to maxClassifiedPages are
+// loaded; pages that can't be loaded or don't tell stay.
+func (bc *BlogCrawler) classifyAmbiguous(ctx context.Context, urlSet map[string]bool) {
+	var urls []string
+	for postURL := range urlSet {
+		if bc.ambiguous(postURL) {
+			urls = append(urls, postURL)
+		}
+	}
+	if len(urls) == 0 {
+		return
+	}
+	sort.Strings(urls)
+	if len(urls) > maxClassifiedPages {
+		bc.warnf("%d post URLs could be listings, classifying the first %d", len(urls), maxClassifiedPages)
+		urls = urls[:maxClassifiedPages]
+	}
+
+	bc.printf("Loading %d post URLs that could be listings...\n", len(urls))
+	removed := 0
+	for _, postURL := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+		features, err := bc.pageFeaturesOf(ctx, postURL)
+		if err != nil {
+			bc.warnf("Error classifying %s: %v", postURL, err)
+			continue
+		}
+		if features.kind() != pageListing {
+			continue
+		}
+		bc.printf("  %s reads like a listing (%d post links, pagination: %t), dropping it\n", postURL, features.PostLinks, features.Pagination)
+		delete(urlSet, postURL)
+		bc.recordRejection(postURL, "page classifier: listing")
+		removed++
+	}
+	bc.printf("Dropped %d listing pages (total: %d unique URLs)\n", removed, len(urlSet))
+}
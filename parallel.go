@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hostLimiter spaces out page loads to the same host, so parallel crawls of
+// blogs on one platform don't add up to more traffic than a single crawl
+type hostLimiter struct {
+	delay time.Duration
+
+	mu   sync.Mutex
+	next map[string]time.Time // Earliest time the host may be loaded again
+}
+
+func newHostLimiter(delay time.Duration) *hostLimiter {
+	return &hostLimiter{delay: delay, next: make(map[string]time.Time)}
+}
+
+// wait blocks until pageURL's host may be loaded, reserving the following
+// slot for the caller
+func (hl *hostLimiter) wait(ctx context.Context, pageURL string) error {
+	parsed, err := url.Parse(pageURL)
+	if err != nil || parsed.Host == "" {
+		return nil
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+
+	hl.mu.Lock()
+	now := time.Now()
+	slot := hl.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	hl.next[host] = slot.Add(hl.delay)
+	hl.mu.Unlock()
+
+	if wait := slot.Sub(now); wait > 0 {
+		return sleepContext(ctx, wait)
+	}
+	return nil
+}
+
+// throttle waits for the host limiter, if any, before pageURL is loaded
+func (bc *BlogCrawler) throttle(ctx context.Context, pageURL string) error {
+	if bc.opts.HostLimiter == nil {
+		return nil
+	}
+	return bc.opts.HostLimiter.wait(ctx, pageURL)
+}
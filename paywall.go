@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// paywallSelectors find the overlays and gates publishers put in front of
+// the rest of a post; extractContentJS has the same list
+var paywallSelectors = []string{`[class*="paywall"]`, `[id*="paywall"]`, `[data-testid*="paywall"]`, `[class*="regwall"]`, `[class*="registration-wall"]`, ".tp-modal"}
+
+// paywallText is what walls say, lowercase. It is also a JavaScript regular
+// expression in extractContentJS, so no slashes.
+const paywallText = `(subscribe|sign up|sign in|log in|register) to (continue|keep) reading|create a free account to (continue|keep) reading|this (post|story|article) is for paid subscribers|available to medium members only|you('|’)ve reached your (free )?article limit`
+
+// paywallMarkup is structured data declaring that the page isn't free to
+// read, as publishers tell search engines about their paywalls
+const paywallMarkup = `"isAccessibleForFree"\s*:\s*"?false`
+
+var (
+	paywallTextPattern   = regexp.MustCompile(paywallText)
+	paywallMarkupPattern = regexp.MustCompile(`(?i)` + paywallMarkup)
+)
+
+// documentPaywall mirrors the paywall checks of extractContentJS on a parsed
+// page: it returns what gives away a wall cutting the post short, or ""
+func documentPaywall(doc *html.Node) string {
+	for _, script := range cascadia.QueryAll(doc, cascadia.MustCompile(`script[type="application/ld+json"]`)) {
+		if script.FirstChild != nil && paywallMarkupPattern.MatchString(script.FirstChild.Data) {
+			return "isAccessibleForFree: false"
+		}
+	}
+	for _, selector := range paywallSelectors {
+		if cascadia.Query(doc, cascadia.MustCompile(selector)) != nil {
+			return selector
+		}
+	}
+	if phrase := paywallTextPattern.FindString(strings.ToLower(normalizeContent(nodeText(doc)))); phrase != "" {
+		return fmt.Sprintf("%q", phrase)
+	}
+	return ""
+}
+
+// markPaywalled flags a post whose page has a paywall or registration wall,
+// as told by reason. What was readable before the wall stays its content,
+// so a search index still gets the introduction, but it is no longer
+// mistaken for the whole post.
+func (bc *BlogCrawler) markPaywalled(post *Post, reason string) {
+	if reason == "" {
+		return
+	}
+	post.Paywalled = true
+	post.Paywall = reason
+	bc.printf("  %s is behind a paywall (%s), keeping the %d characters before it\n", post.URL, reason, len(post.Content))
+}
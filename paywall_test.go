@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func TestDocumentPaywall(t *testing.T) {
+	intro := `<h1>Scaling our queue</h1><p>Last year our ingest queue fell over twice.</p>`
+	tests := []struct {
+		name string
+		page string
+		want string
+	}{
+		{"free post", `<article>` + intro + `<p>Here is how we fixed it.</p></article><footer><a href="/subscribe">Subscribe</a></footer>`, ""},
+		{"structured data",
+			`<script type="application/ld+json">{"@type": "NewsArticle", "isAccessibleForFree": "False"}</script><article>` + intro + `</article>`,
+			"isAccessibleForFree: false"},
+		{"substack", `<article>` + intro + `<div class="paywall"><h2>Keep reading with a 7-day free trial</h2></div></article>`, `[class*="paywall"]`},
+		{"piano", `<article>` + intro + `</article><div class="tp-modal"></div>`, ".tp-modal"},
+		{"registration wall text", `<article>` + intro + `<p>Create a free account to continue reading.</p></article>`, `"create a free account to continue reading"`},
+		{"medium", `<article>` + intro + `<p>The author made this story available to Medium members only.</p></article>`, `"available to medium members only"`},
+	}
+	for _, tt := range tests {
+		doc, err := html.Parse(strings.NewReader(`<html><body>` + tt.page + `</body></html>`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := documentPaywall(doc); got != tt.want {
+			t.Errorf("%s: paywall = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// postgresSink upserts every discovered post into a table keyed by URL,
+// keeping the first time a post was seen and bumping last_seen on each run
+type postgresSink struct {
+	db    *sql.DB
+	table string // Already quoted
+}
+
+// quoteTableName quotes a possibly schema-qualified table name
+func quoteTableName(table string) string {
+	parts := strings.Split(table, ".")
+	for i, part := range parts {
+		parts[i] = pq.QuoteIdentifier(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+func newPostgresSink(dsn, table string) (*postgresSink, error) {
+	// --output postgres:DSN isn't a secret flag, and errors may quote the
+	// password alone
+	trackDSN(dsn)
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+
+	sink := &postgresSink{db: db, table: quoteTableName(table)}
+	_, err = db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		url        TEXT PRIMARY KEY,
+		base_url   TEXT NOT NULL,
+		first_seen TIMESTAMPTZ NOT NULL,
+		last_seen  TIMESTAMPTZ NOT NULL,
+		metadata   JSONB
+	)`, sink.table))
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create table %s: %w", table, err)
+	}
+
+	return sink, nil
+}
+
+func (ps *postgresSink) Write(ctx context.Context, result *CrawlResult) error {
+	seenAt, err := time.Parse(time.RFC3339, result.CrawledAt)
+	if err != nil {
+		seenAt = time.Now()
+	}
+
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Metadata from an earlier run is kept when this run collected none
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`
+		INSERT INTO %[1]s (url, base_url, first_seen, last_seen, metadata)
+		VALUES ($1, $2, $3, $3, $4)
+		ON CONFLICT (url) DO UPDATE SET
+			base_url  = EXCLUDED.base_url,
+			last_seen = GREATEST(%[1]s.last_seen, EXCLUDED.last_seen),
+			metadata  = COALESCE(EXCLUDED.metadata, %[1]s.metadata)`, ps.table))
+	if err != nil {
+		return fmt.Errorf("failed to prepare upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, record := range postRecords(result) {
+		metadata, err := postMetadata(record)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.ExecContext(ctx, record.URL, record.BaseURL, seenAt, metadata); err != nil {
+			return fmt.Errorf("failed to upsert %s: %w", record.URL, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+// postMetadata returns the metadata column of record: its post as JSON, or
+// NULL when the crawl collected nothing about it. A nil []byte would be sent
+// as an empty string, which isn't valid JSONB.
+func postMetadata(record PostRecord) (any, error) {
+	if record.Post == nil {
+		return nil, nil
+	}
+	metadata, err := json.Marshal(record.Post)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode metadata for %s: %w", record.URL, err)
+	}
+	return string(metadata), nil
+}
+
+func (ps *postgresSink) Close() error {
+	return ps.db.Close()
+}
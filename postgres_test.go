@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestPostMetadata(t *testing.T) {
+	// A crawl without per-post data must write NULL, so the upsert keeps
+	// the metadata of an earlier run
+	metadata, err := postMetadata(PostRecord{URL: "https://blog.example.com/a"})
+	if err != nil || metadata != nil {
+		t.Errorf("metadata without a post = %#v, %v; want nil", metadata, err)
+	}
+
+	metadata, err = postMetadata(PostRecord{URL: "https://blog.example.com/a", Post: &Post{URL: "https://blog.example.com/a", Title: "A"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, ok := metadata.(string); !ok || s == "" || s[0] != '{' {
+		t.Errorf("metadata with a post = %#v, want a JSON object", metadata)
+	}
+}
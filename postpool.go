@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// hostSlots caps the number of concurrent page loads per host
+type hostSlots struct {
+	limit int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func newHostSlots(limit int) *hostSlots {
+	return &hostSlots{limit: limit, slots: make(map[string]chan struct{})}
+}
+
+// acquire waits for a free slot on pageURL's host and returns the function
+// releasing it
+func (hs *hostSlots) acquire(ctx context.Context, pageURL string) (func(), error) {
+	if hs.limit <= 0 {
+		return func() {}, nil
+	}
+	host := pageURL
+	if parsed, err := url.Parse(pageURL); err == nil {
+		host = strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	}
+
+	hs.mu.Lock()
+	slot, ok := hs.slots[host]
+	if !ok {
+		slot = make(chan struct{}, hs.limit)
+		hs.slots[host] = slot
+	}
+	hs.mu.Unlock()
+
+	select {
+	case slot <- struct{}{}:
+		return func() { <-slot }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// newPostWorker returns a copy of the crawler with its own tab in the same
+// browser, for visiting posts alongside other workers. Its warnings and
+// counters are merged back by mergePostWorker.
+func (bc *BlogCrawler) newPostWorker() (*BlogCrawler, error) {
+	worker := *bc
+	worker.postWorker = true
+	worker.errors = nil
+	worker.loads = 0
+	worker.loadTime = 0
+	worker.rateLimits = nil
+	worker.navigations = 0
+	// Post URLs are all in there already; link variants found on the post
+	// pages are only remembered per worker
+	worker.seenURLs = maps.Clone(bc.seenURLs)
+	if err := worker.openPage(); err != nil {
+		return nil, err
+	}
+	return &worker, nil
+}
+
+func (bc *BlogCrawler) mergePostWorker(worker *BlogCrawler) {
+	bc.errors = append(bc.errors, worker.errors...)
+	bc.loads += worker.loads
+	bc.loadTime += worker.loadTime
+	bc.rateLimits = append(bc.rateLimits, worker.rateLimits...)
+}
+
+// visitPostsParallel visits the posts with opts.PostWorkers tabs at a time,
+// no more than opts.PostHostConcurrency of them on the same host
+func (bc *BlogCrawler) visitPostsParallel(ctx context.Context, posts []Post, postURLs map[string]bool, capturing bool) {
+	var workers []*BlogCrawler
+	for len(workers) < min(bc.opts.PostWorkers, len(posts)) {
+		worker, err := bc.newPostWorker()
+		if err != nil {
+			bc.warnf("Could not open a tab for post worker %d: %v", len(workers)+1, err)
+			break
+		}
+		workers = append(workers, worker)
+	}
+	if len(workers) == 0 {
+		bc.printf("Visiting posts one at a time instead\n")
+		bc.opts.PostWorkers = 1
+		bc.visitPosts(ctx, posts)
+		return
+	}
+	bc.printf("Visiting posts with %d tabs...\n", len(workers))
+
+	slots := newHostSlots(bc.opts.PostHostConcurrency)
+	jobs := make(chan int)
+	var mu sync.Mutex // Serializes progress output and callbacks
+	done := 0
+
+	var wg sync.WaitGroup
+	for _, worker := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				post := &posts[i]
+				release, err := slots.acquire(ctx, post.URL)
+				if err != nil {
+					continue
+				}
+				worker.visitPost(ctx, post, postURLs, capturing)
+				release()
+
+				mu.Lock()
+				done++
+				bc.printf("Visited post %d/%d: %s\n", done, len(posts), post.URL)
+				bc.reportProgress(ProgressEvent{Kind: "post", Step: done, Steps: len(posts), URL: post.URL, TotalURLs: len(posts)})
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range posts {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, worker := range workers {
+		if err := worker.page.Close(context.Background()); err != nil {
+			bc.warnf("Error closing post worker tab: %v", err)
+		}
+		bc.mergePostWorker(worker)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// navigationTimingJS reads the status, size and timing of the current
+// page's document from the Navigation Timing API
+const navigationTimingJS = `() => {
+	const nav = performance.getEntriesByType('navigation')[0];
+	if (!nav) return null;
+	return {
+		status: nav.responseStatus || 0,
+		size: nav.decodedBodySize || 0,
+		ms: Math.round(nav.responseEnd - nav.requestStart),
+	};
+}`
+
+// visitPosts loads every post page once and runs the enabled page-level
+// passes on it. Failures are reported per post and never abort the crawl.
+// With opts.PostWorkers above 1 the posts are spread over that many tabs.
+func (bc *BlogCrawler) visitPosts(ctx context.Context, posts []Post) {
+	capturing := bc.opts.Screenshot || bc.opts.PDF || bc.opts.DownloadImages
+	if capturing {
+		if err := os.MkdirAll(bc.opts.CaptureDir, 0755); err != nil {
+			bc.warnf("Could not create capture directory: %v", err)
+		}
+	}
+
+	postURLs := make(map[string]bool, len(posts))
+	for _, post := range posts {
+		postURLs[post.URL] = true
+	}
+
+	if bc.opts.PostWorkers > 1 && len(posts) > 1 {
+		bc.visitPostsParallel(ctx, posts, postURLs, capturing)
+		return
+	}
+
+	for i := range posts {
+		if ctx.Err() != nil {
+			return
+		}
+		post := &posts[i]
+		bc.printf("Visiting post %d/%d: %s\n", i+1, len(posts), post.URL)
+		bc.visitPost(ctx, post, postURLs, capturing)
+		bc.reportProgress(ProgressEvent{Kind: "post", Step: i + 1, Steps: len(posts), URL: post.URL, TotalURLs: len(posts)})
+	}
+}
+
+// visitPost loads one post page and runs the enabled passes on it
+func (bc *BlogCrawler) visitPost(ctx context.Context, post *Post, postURLs map[string]bool, capturing bool) {
+	// Captures need the rendered page; everything else can often do
+	// without the browser
+	if bc.opts.FetchMode == "hybrid" && !capturing && bc.fetchPostHTTPOrBrowser(ctx, post, postURLs) {
+		return
+	}
+
+	if err := bc.loadPost(ctx, post.URL); err != nil {
+		bc.warnf("Error loading %s: %v", post.URL, err)
+		return
+	}
+	bc.recordResponse(ctx, post)
+
+	if bc.opts.FetchContent {
+		if err := bc.extractContent(ctx, post); err != nil {
+			bc.warnf("Error extracting content from %s: %v", post.URL, err)
+		}
+	}
+
+	if bc.opts.Engagement {
+		if err := bc.recordEngagement(ctx, post); err != nil {
+			bc.warnf("Error reading the engagement of %s: %v", post.URL, err)
+		}
+	}
+
+	if err := bc.recordPostLinks(ctx, post, postURLs); err != nil {
+		bc.warnf("Error reading links of %s: %v", post.URL, err)
+	}
+
+	if bc.opts.Screenshot || bc.opts.PDF {
+		if err := bc.capturePost(ctx, post); err != nil {
+			bc.warnf("Error capturing %s: %v", post.URL, err)
+		}
+	}
+
+	if bc.opts.DownloadImages {
+		if err := bc.archivePost(ctx, post); err != nil {
+			bc.warnf("Error archiving %s: %v", post.URL, err)
+		}
+	}
+}
+
+// loadPost loads a post page, retrying up to opts.PostRetries times with a
+// growing pause. Post workers share the browser with each other, so only
+// the serial pass restarts it when it dies (see loadPage).
+func (bc *BlogCrawler) loadPost(ctx context.Context, postURL string) error {
+	load := bc.loadPage
+	if bc.postWorker {
+		load = bc.navigate
+	}
+
+	err := load(ctx, postURL)
+	for attempt := 1; err != nil && attempt <= bc.opts.PostRetries && ctx.Err() == nil; attempt++ {
+		bc.printf("  Error loading %s: %v. Retrying (%d/%d)...\n", postURL, err, attempt, bc.opts.PostRetries)
+		if sleepErr := sleepContext(ctx, time.Duration(attempt)*2*time.Second); sleepErr != nil {
+			return err
+		}
+		err = load(ctx, postURL)
+	}
+	return err
+}
+
+// recordResponse notes the HTTP status, content length and response time
+// of the post's page, so paywalled or broken posts can be filtered out
+func (bc *BlogCrawler) recordResponse(ctx context.Context, post *Post) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := bc.page.Eval(ctx, navigationTimingJS)
+	if err != nil || res.Nil() {
+		return
+	}
+	post.Status = res.Get("status").Int()
+	post.ContentLength = res.Get("size").Int()
+	post.ResponseMS = res.Get("ms").Int()
+}
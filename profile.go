@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// profileNamePattern is what a --profile name may look like; anything else
+// must be a directory path
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// profileDir resolves a --profile value to a Chrome user data directory.
+// Plain names live in the user's config directory, so a session logged in
+// once is found again by every later crawl; paths are used as they are.
+func profileDir(profile string) (string, error) {
+	if filepath.IsAbs(profile) || filepath.Base(profile) != profile {
+		return profile, nil
+	}
+	if !profileNamePattern.MatchString(profile) || profile == "." || profile == ".." {
+		return "", fmt.Errorf("invalid profile name %q", profile)
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find config directory: %w", err)
+	}
+	return filepath.Join(configDir, "manual-blog-crawler", "profiles", profile), nil
+}
+
+// closeBrowser releases what the crawl holds of the browser: the whole
+// browser when it launched its own, its incognito context when sharing one,
+// and only its tab when sharing a named profile
+func (bc *BlogCrawler) closeBrowser(ctx context.Context) {
+	if bc.opts.SharedBrowser != nil && bc.opts.Profile != "" {
+		if bc.page != nil {
+			bc.page.Close(ctx)
+		}
+		return
+	}
+	bc.browser.Close(ctx)
+}
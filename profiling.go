@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+)
+
+// profiler writes the pprof profiles of a crawl asked for by --cpuprofile
+// and --memprofile, for `go tool pprof`
+type profiler struct {
+	cpu     *os.File
+	memPath string
+	out     io.Writer // Where errors writing the profiles are reported
+	once    sync.Once
+}
+
+// startProfiling starts the CPU profile when cpuPath is set. The heap
+// profile is written to memPath by stop, which reports its errors to out.
+func startProfiling(cpuPath, memPath string, out io.Writer) (*profiler, error) {
+	p := &profiler{memPath: memPath, out: out}
+	if cpuPath == "" {
+		return p, nil
+	}
+	file, err := os.Create(cpuPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CPU profile: %w", err)
+	}
+	if err := pprof.StartCPUProfile(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+	}
+	p.cpu = file
+	return p, nil
+}
+
+// stop ends the CPU profile and writes the heap profile. Only the first
+// call does anything, so it can be both deferred and called before exiting.
+func (p *profiler) stop() {
+	if p == nil {
+		return
+	}
+	p.once.Do(func() {
+		if p.cpu != nil {
+			pprof.StopCPUProfile()
+			if err := p.cpu.Close(); err != nil {
+				fmt.Fprintf(p.out, "Warning: Error writing CPU profile: %v\n", err)
+			}
+		}
+		if p.memPath != "" {
+			if err := writeHeapProfile(p.memPath); err != nil {
+				fmt.Fprintf(p.out, "Warning: Error writing heap profile: %v\n", err)
+			}
+		}
+	})
+}
+
+func writeHeapProfile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	runtime.GC() // Up-to-date statistics of what is still in use
+	err = pprof.WriteHeapProfile(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,483 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: blogcrawler/v1/crawler.proto
+
+package blogcrawlerv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CrawlRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Blog listing URL to crawl.
+	BaseUrl string `protobuf:"bytes,1,opt,name=base_url,json=baseUrl,proto3" json:"base_url,omitempty"`
+	// Page load timeout; defaults to 30 seconds when zero.
+	TimeoutSeconds int32 `protobuf:"varint,2,opt,name=timeout_seconds,json=timeoutSeconds,proto3" json:"timeout_seconds,omitempty"`
+	// Visit each post and record its title and content hash.
+	FetchContent  bool `protobuf:"varint,3,opt,name=fetch_content,json=fetchContent,proto3" json:"fetch_content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CrawlRequest) Reset() {
+	*x = CrawlRequest{}
+	mi := &file_blogcrawler_v1_crawler_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CrawlRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CrawlRequest) ProtoMessage() {}
+
+func (x *CrawlRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_blogcrawler_v1_crawler_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CrawlRequest.ProtoReflect.Descriptor instead.
+func (*CrawlRequest) Descriptor() ([]byte, []int) {
+	return file_blogcrawler_v1_crawler_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CrawlRequest) GetBaseUrl() string {
+	if x != nil {
+		return x.BaseUrl
+	}
+	return ""
+}
+
+func (x *CrawlRequest) GetTimeoutSeconds() int32 {
+	if x != nil {
+		return x.TimeoutSeconds
+	}
+	return 0
+}
+
+func (x *CrawlRequest) GetFetchContent() bool {
+	if x != nil {
+		return x.FetchContent
+	}
+	return false
+}
+
+type CrawlResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*CrawlResponse_Progress
+	//	*CrawlResponse_Result
+	Event         isCrawlResponse_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CrawlResponse) Reset() {
+	*x = CrawlResponse{}
+	mi := &file_blogcrawler_v1_crawler_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CrawlResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CrawlResponse) ProtoMessage() {}
+
+func (x *CrawlResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_blogcrawler_v1_crawler_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CrawlResponse.ProtoReflect.Descriptor instead.
+func (*CrawlResponse) Descriptor() ([]byte, []int) {
+	return file_blogcrawler_v1_crawler_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CrawlResponse) GetEvent() isCrawlResponse_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *CrawlResponse) GetProgress() *Progress {
+	if x != nil {
+		if x, ok := x.Event.(*CrawlResponse_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *CrawlResponse) GetResult() *CrawlResult {
+	if x != nil {
+		if x, ok := x.Event.(*CrawlResponse_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isCrawlResponse_Event interface {
+	isCrawlResponse_Event()
+}
+
+type CrawlResponse_Progress struct {
+	Progress *Progress `protobuf:"bytes,1,opt,name=progress,proto3,oneof"`
+}
+
+type CrawlResponse_Result struct {
+	Result *CrawlResult `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+func (*CrawlResponse_Progress) isCrawlResponse_Event() {}
+
+func (*CrawlResponse_Result) isCrawlResponse_Event() {}
+
+// Progress reports one step of the crawl.
+type Progress struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// One of "page" (listing page crawled), "scroll" (infinite scroll
+	// iteration) or "post" (post page visited).
+	Kind string `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	// Listing page number, scroll iteration or post index, starting at 1.
+	Step int32 `protobuf:"varint,2,opt,name=step,proto3" json:"step,omitempty"`
+	// URL of the page that was processed.
+	Url string `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
+	// Blog URLs found by this step.
+	UrlsFound int32 `protobuf:"varint,4,opt,name=urls_found,json=urlsFound,proto3" json:"urls_found,omitempty"`
+	// Unique blog URLs found so far.
+	TotalUrls     int32 `protobuf:"varint,5,opt,name=total_urls,json=totalUrls,proto3" json:"total_urls,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Progress) Reset() {
+	*x = Progress{}
+	mi := &file_blogcrawler_v1_crawler_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Progress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Progress) ProtoMessage() {}
+
+func (x *Progress) ProtoReflect() protoreflect.Message {
+	mi := &file_blogcrawler_v1_crawler_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Progress.ProtoReflect.Descriptor instead.
+func (*Progress) Descriptor() ([]byte, []int) {
+	return file_blogcrawler_v1_crawler_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Progress) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Progress) GetStep() int32 {
+	if x != nil {
+		return x.Step
+	}
+	return 0
+}
+
+func (x *Progress) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Progress) GetUrlsFound() int32 {
+	if x != nil {
+		return x.UrlsFound
+	}
+	return 0
+}
+
+func (x *Progress) GetTotalUrls() int32 {
+	if x != nil {
+		return x.TotalUrls
+	}
+	return 0
+}
+
+type Post struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Url           string                 `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	ContentHash   string                 `protobuf:"bytes,3,opt,name=content_hash,json=contentHash,proto3" json:"content_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Post) Reset() {
+	*x = Post{}
+	mi := &file_blogcrawler_v1_crawler_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Post) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Post) ProtoMessage() {}
+
+func (x *Post) ProtoReflect() protoreflect.Message {
+	mi := &file_blogcrawler_v1_crawler_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Post.ProtoReflect.Descriptor instead.
+func (*Post) Descriptor() ([]byte, []int) {
+	return file_blogcrawler_v1_crawler_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Post) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Post) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Post) GetContentHash() string {
+	if x != nil {
+		return x.ContentHash
+	}
+	return ""
+}
+
+type CrawlResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BaseUrl       string                 `protobuf:"bytes,1,opt,name=base_url,json=baseUrl,proto3" json:"base_url,omitempty"`
+	BlogUrls      []string               `protobuf:"bytes,2,rep,name=blog_urls,json=blogUrls,proto3" json:"blog_urls,omitempty"`
+	TotalCount    int32                  `protobuf:"varint,3,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	CrawledAt     string                 `protobuf:"bytes,4,opt,name=crawled_at,json=crawledAt,proto3" json:"crawled_at,omitempty"`
+	Posts         []*Post                `protobuf:"bytes,5,rep,name=posts,proto3" json:"posts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CrawlResult) Reset() {
+	*x = CrawlResult{}
+	mi := &file_blogcrawler_v1_crawler_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CrawlResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CrawlResult) ProtoMessage() {}
+
+func (x *CrawlResult) ProtoReflect() protoreflect.Message {
+	mi := &file_blogcrawler_v1_crawler_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CrawlResult.ProtoReflect.Descriptor instead.
+func (*CrawlResult) Descriptor() ([]byte, []int) {
+	return file_blogcrawler_v1_crawler_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *CrawlResult) GetBaseUrl() string {
+	if x != nil {
+		return x.BaseUrl
+	}
+	return ""
+}
+
+func (x *CrawlResult) GetBlogUrls() []string {
+	if x != nil {
+		return x.BlogUrls
+	}
+	return nil
+}
+
+func (x *CrawlResult) GetTotalCount() int32 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+func (x *CrawlResult) GetCrawledAt() string {
+	if x != nil {
+		return x.CrawledAt
+	}
+	return ""
+}
+
+func (x *CrawlResult) GetPosts() []*Post {
+	if x != nil {
+		return x.Posts
+	}
+	return nil
+}
+
+var File_blogcrawler_v1_crawler_proto protoreflect.FileDescriptor
+
+const file_blogcrawler_v1_crawler_proto_rawDesc = "" +
+	"\n" +
+	"\x1cblogcrawler/v1/crawler.proto\x12\x0eblogcrawler.v1\"w\n" +
+	"\fCrawlRequest\x12\x19\n" +
+	"\bbase_url\x18\x01 \x01(\tR\abaseUrl\x12'\n" +
+	"\x0ftimeout_seconds\x18\x02 \x01(\x05R\x0etimeoutSeconds\x12#\n" +
+	"\rfetch_content\x18\x03 \x01(\bR\ffetchContent\"\x87\x01\n" +
+	"\rCrawlResponse\x126\n" +
+	"\bprogress\x18\x01 \x01(\v2\x18.blogcrawler.v1.ProgressH\x00R\bprogress\x125\n" +
+	"\x06result\x18\x02 \x01(\v2\x1b.blogcrawler.v1.CrawlResultH\x00R\x06resultB\a\n" +
+	"\x05event\"\x82\x01\n" +
+	"\bProgress\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x12\n" +
+	"\x04step\x18\x02 \x01(\x05R\x04step\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\x12\x1d\n" +
+	"\n" +
+	"urls_found\x18\x04 \x01(\x05R\turlsFound\x12\x1d\n" +
+	"\n" +
+	"total_urls\x18\x05 \x01(\x05R\ttotalUrls\"Q\n" +
+	"\x04Post\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12!\n" +
+	"\fcontent_hash\x18\x03 \x01(\tR\vcontentHash\"\xb1\x01\n" +
+	"\vCrawlResult\x12\x19\n" +
+	"\bbase_url\x18\x01 \x01(\tR\abaseUrl\x12\x1b\n" +
+	"\tblog_urls\x18\x02 \x03(\tR\bblogUrls\x12\x1f\n" +
+	"\vtotal_count\x18\x03 \x01(\x05R\n" +
+	"totalCount\x12\x1d\n" +
+	"\n" +
+	"crawled_at\x18\x04 \x01(\tR\tcrawledAt\x12*\n" +
+	"\x05posts\x18\x05 \x03(\v2\x14.blogcrawler.v1.PostR\x05posts2X\n" +
+	"\x0eCrawlerService\x12F\n" +
+	"\x05Crawl\x12\x1c.blogcrawler.v1.CrawlRequest\x1a\x1d.blogcrawler.v1.CrawlResponse0\x01B8Z6manual-blog-crawler/proto/blogcrawler/v1;blogcrawlerv1b\x06proto3"
+
+var (
+	file_blogcrawler_v1_crawler_proto_rawDescOnce sync.Once
+	file_blogcrawler_v1_crawler_proto_rawDescData []byte
+)
+
+func file_blogcrawler_v1_crawler_proto_rawDescGZIP() []byte {
+	file_blogcrawler_v1_crawler_proto_rawDescOnce.Do(func() {
+		file_blogcrawler_v1_crawler_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_blogcrawler_v1_crawler_proto_rawDesc), len(file_blogcrawler_v1_crawler_proto_rawDesc)))
+	})
+	return file_blogcrawler_v1_crawler_proto_rawDescData
+}
+
+var file_blogcrawler_v1_crawler_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_blogcrawler_v1_crawler_proto_goTypes = []any{
+	(*CrawlRequest)(nil),  // 0: blogcrawler.v1.CrawlRequest
+	(*CrawlResponse)(nil), // 1: blogcrawler.v1.CrawlResponse
+	(*Progress)(nil),      // 2: blogcrawler.v1.Progress
+	(*Post)(nil),          // 3: blogcrawler.v1.Post
+	(*CrawlResult)(nil),   // 4: blogcrawler.v1.CrawlResult
+}
+var file_blogcrawler_v1_crawler_proto_depIdxs = []int32{
+	2, // 0: blogcrawler.v1.CrawlResponse.progress:type_name -> blogcrawler.v1.Progress
+	4, // 1: blogcrawler.v1.CrawlResponse.result:type_name -> blogcrawler.v1.CrawlResult
+	3, // 2: blogcrawler.v1.CrawlResult.posts:type_name -> blogcrawler.v1.Post
+	0, // 3: blogcrawler.v1.CrawlerService.Crawl:input_type -> blogcrawler.v1.CrawlRequest
+	1, // 4: blogcrawler.v1.CrawlerService.Crawl:output_type -> blogcrawler.v1.CrawlResponse
+	4, // [4:5] is the sub-list for method output_type
+	3, // [3:4] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_blogcrawler_v1_crawler_proto_init() }
+func file_blogcrawler_v1_crawler_proto_init() {
+	if File_blogcrawler_v1_crawler_proto != nil {
+		return
+	}
+	file_blogcrawler_v1_crawler_proto_msgTypes[1].OneofWrappers = []any{
+		(*CrawlResponse_Progress)(nil),
+		(*CrawlResponse_Result)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_blogcrawler_v1_crawler_proto_rawDesc), len(file_blogcrawler_v1_crawler_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_blogcrawler_v1_crawler_proto_goTypes,
+		DependencyIndexes: file_blogcrawler_v1_crawler_proto_depIdxs,
+		MessageInfos:      file_blogcrawler_v1_crawler_proto_msgTypes,
+	}.Build()
+	File_blogcrawler_v1_crawler_proto = out.File
+	file_blogcrawler_v1_crawler_proto_goTypes = nil
+	file_blogcrawler_v1_crawler_proto_depIdxs = nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// proxySchemes are the proxy kinds both Chrome and net/http support
+var proxySchemes = []string{"http", "https", "socks5"}
+
+// validateProxy checks a --proxy URL. Chrome doesn't take credentials in
+// the proxy URL, so those are refused rather than silently ignored.
+func validateProxy(proxy string) error {
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return err
+	}
+	if !contains(proxySchemes, proxyURL.Scheme) || proxyURL.Host == "" {
+		return fmt.Errorf("%q is not a proxy URL like http://host:3128 or socks5://host:1080", proxy)
+	}
+	if proxyURL.User != nil {
+		return fmt.Errorf("credentials in the proxy URL aren't supported by Chrome")
+	}
+	return nil
+}
+
+// crawlClient returns the client for the crawl's own HTTP fetches, sent
+// through proxy when set and otherwise through $HTTPS_PROXY as usual
+func crawlClient(timeout time.Duration, proxy string) *http.Client {
+	client := &http.Client{Timeout: timeout}
+	if proxy == "" {
+		return client
+	}
+	proxyURL, err := url.Parse(proxy)
+	if err != nil {
+		return client // Refused by validateProxy before getting here
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxyURL)
+	client.Transport = transport
+	return client
+}
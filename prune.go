@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// pruneKeepCards is how many of the most recent post cards stay in the DOM,
+// so the feed's own infinite-scroll trigger still has something to observe
+const pruneKeepCards = 10
+
+// pruneDOMJS removes the cards of posts that were already harvested, except
+// the last few, and returns how many it removed
+const pruneDOMJS = `
+	(urls, keep) => {
+		const harvested = new Set(urls);
+		const cards = [];
+		for (const a of document.querySelectorAll('a[href]')) {
+			const url = new URL(a.href, location.href);
+			url.hash = '';
+			const withQuery = url.toString();
+			url.search = '';
+			if (!harvested.has(withQuery) && !harvested.has(url.toString())) {
+				continue;
+			}
+			const card = a.closest('article, li, [data-testid="post-preview"], [role="article"]') || a.parentElement;
+			if (card && card !== document.body && !cards.includes(card)) {
+				cards.push(card);
+			}
+		}
+		const prune = cards.slice(0, Math.max(0, cards.length - keep));
+		prune.forEach(card => card.remove());
+		return prune.length;
+	}
+`
+
+// pruneHarvestedDOM drops already-harvested post cards from an infinite-scroll
+// feed so its DOM, and Chrome's memory, stay bounded on very long feeds
+func (bc *BlogCrawler) pruneHarvestedDOM(ctx context.Context, urlSet map[string]bool) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	urls := make([]string, 0, len(urlSet))
+	for url := range urlSet {
+		urls = append(urls, url)
+	}
+
+	res, err := bc.page.Eval(ctx, pruneDOMJS, urls, pruneKeepCards)
+	if err != nil {
+		return 0, err
+	}
+	return res.Int(), nil
+}
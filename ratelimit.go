@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Rate limit handling: how often a rate-limited page is tried again, and
+// how long to wait when the response doesn't say
+const (
+	rateLimitRetries  = 3
+	defaultRetryAfter = 30 * time.Second
+	maxRetryAfter     = 5 * time.Minute
+)
+
+// RateLimit records a rate-limit response the crawl backed off from
+type RateLimit struct {
+	URL        string  `json:"url"`
+	Status     int     `json:"status"`      // 429 or 503
+	RetryAfter float64 `json:"retry_after"` // Seconds waited before trying again
+	At         string  `json:"at"`          // RFC 3339
+}
+
+// isRateLimited reports whether an HTTP status asks the client to slow down
+func isRateLimited(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// retryAfter reads a Retry-After header, in seconds or as an HTTP date.
+// Without one, the wait doubles with every attempt from defaultRetryAfter.
+func retryAfter(header string, attempt int) time.Duration {
+	header = strings.TrimSpace(header)
+	wait := defaultRetryAfter << (attempt - 1)
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		wait = time.Duration(seconds) * time.Second
+	} else if date, err := http.ParseTime(header); err == nil {
+		wait = time.Until(date)
+	}
+	return max(0, min(wait, maxRetryAfter))
+}
+
+// backOff records a rate-limit response for pageURL and waits as long as
+// it asks before the next attempt
+func (bc *BlogCrawler) backOff(ctx context.Context, pageURL string, status int, header string, attempt int) error {
+	wait := retryAfter(header, attempt)
+	bc.rateLimits = append(bc.rateLimits, RateLimit{
+		URL:        pageURL,
+		Status:     status,
+		RetryAfter: wait.Seconds(),
+		At:         time.Now().Format(time.RFC3339),
+	})
+	bc.printf("  %s: HTTP %d, backing off for %v (attempt %d/%d)\n", pageURL, status, wait, attempt, rateLimitRetries)
+	return sleepContext(ctx, wait)
+}
+
+// documentResponse watches the page for the response to its next main
+// document load. The returned function yields its status and Retry-After
+// header once the load is over, or 0 if none arrived.
+func (bc *BlogCrawler) documentResponse(ctx context.Context) func() (int, string) {
+	type response struct {
+		status     int
+		retryAfter string
+	}
+	responses := make(chan response, 1)
+	page := rodPageOf(bc.page)
+	if page == nil {
+		return func() (int, string) { return 0, "" }
+	}
+	wait := page.Context(ctx).EachEvent(func(e *proto.NetworkResponseReceived) bool {
+		if e.Type != proto.NetworkResourceTypeDocument || e.FrameID != page.FrameID {
+			return false
+		}
+		var header string
+		for name, value := range e.Response.Headers {
+			if strings.EqualFold(name, "Retry-After") {
+				header = value.String()
+			}
+		}
+		responses <- response{status: e.Response.Status, retryAfter: header}
+		return true
+	})
+	go wait()
+
+	return func() (int, string) {
+		select {
+		case r := <-responses:
+			return r.status, r.retryAfter
+		default:
+			return 0, ""
+		}
+	}
+}
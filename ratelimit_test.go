@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header  string
+		attempt int
+		want    time.Duration
+	}{
+		{"120", 1, 2 * time.Minute},
+		{" 7 ", 3, 7 * time.Second},
+		{"0", 1, 0},
+		{"", 1, defaultRetryAfter},
+		{"", 2, 2 * defaultRetryAfter},
+		{"soon", 1, defaultRetryAfter}, // Invalid: the default
+		{"-5", 1, defaultRetryAfter},
+		{"86400", 1, maxRetryAfter},
+		{"", 10, maxRetryAfter},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 1, 0}, // Already passed
+		{time.Now().Add(24 * time.Hour).UTC().Format(http.TimeFormat), 1, maxRetryAfter},
+	}
+	for _, tt := range tests {
+		if got := retryAfter(tt.header, tt.attempt); got != tt.want {
+			t.Errorf("retryAfter(%q, %d) = %v, want %v", tt.header, tt.attempt, got, tt.want)
+		}
+	}
+
+	// An HTTP date a little ahead is waited for until then
+	header := time.Now().Add(90 * time.Second).UTC().Format(http.TimeFormat)
+	if got := retryAfter(header, 1); got < 80*time.Second || got > 90*time.Second {
+		t.Errorf("retryAfter(%q) = %v, want about 90s", header, got)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	pocketAddEndpoint     = "https://getpocket.com/v3/add"
+	readwiseSaveEndpoint  = "https://readwise.io/api/v3/save/"
+	instapaperAddEndpoint = "https://www.instapaper.com/api/add"
+)
+
+// readLaterSaver saves a single post to a read-later service
+type readLaterSaver func(ctx context.Context, postURL, title string) error
+
+// saveNewPosts saves every new post of result with save. One failing post
+// doesn't stop the others; the error reports how many failed. What was
+// saved is reported to out.
+func saveNewPosts(ctx context.Context, out io.Writer, service string, result *CrawlResult, save readLaterSaver) error {
+	titles := newPostTitles(result)
+
+	failed := 0
+	var lastErr error
+	for _, postURL := range result.New {
+		if err := save(ctx, postURL, titles[postURL]); err != nil {
+			fmt.Fprintf(out, "Warning: Error saving %s to %s: %s\n", postURL, service, redact(err.Error()))
+			failed++
+			lastErr = err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d posts could not be saved to %s: %w", failed, len(result.New), service, lastErr)
+	}
+	fmt.Fprintf(out, "Saved %d new posts to %s\n", len(result.New), service)
+	return nil
+}
+
+// postReadLater sends one request and checks for a 2xx answer. Credentials
+// travel in headers or bodies, so errors never include them.
+func postReadLater(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// pocketNotifier adds new posts to a Pocket list
+type pocketNotifier struct {
+	http        *http.Client
+	consumerKey string
+	accessToken string
+	out         io.Writer
+}
+
+func newPocketNotifier(consumerKey, accessToken string, out io.Writer) *pocketNotifier {
+	return &pocketNotifier{
+		http:        &http.Client{Timeout: 30 * time.Second},
+		consumerKey: consumerKey,
+		accessToken: accessToken,
+		out:         out,
+	}
+}
+
+func (pn *pocketNotifier) Notify(ctx context.Context, result *CrawlResult) error {
+	return saveNewPosts(ctx, pn.out, "Pocket", result, func(ctx context.Context, postURL, title string) error {
+		body, err := json.Marshal(map[string]string{
+			"url":          postURL,
+			"title":        title,
+			"consumer_key": pn.consumerKey,
+			"access_token": pn.accessToken,
+		})
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, pocketAddEndpoint, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+		req.Header.Set("X-Accept", "application/json")
+		return postReadLater(pn.http, req)
+	})
+}
+
+// readwiseNotifier saves new posts to Readwise Reader
+type readwiseNotifier struct {
+	http  *http.Client
+	token string
+	out   io.Writer
+}
+
+func newReadwiseNotifier(token string, out io.Writer) *readwiseNotifier {
+	return &readwiseNotifier{
+		http:  &http.Client{Timeout: 30 * time.Second},
+		token: token,
+		out:   out,
+	}
+}
+
+func (rn *readwiseNotifier) Notify(ctx context.Context, result *CrawlResult) error {
+	return saveNewPosts(ctx, rn.out, "Readwise Reader", result, func(ctx context.Context, postURL, title string) error {
+		payload := map[string]interface{}{"url": postURL, "location": "new"}
+		if title != "" {
+			payload["title"] = title
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, readwiseSaveEndpoint, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Token "+rn.token)
+		return postReadLater(rn.http, req)
+	})
+}
+
+// instapaperNotifier adds new posts through Instapaper's simple API
+type instapaperNotifier struct {
+	http     *http.Client
+	username string
+	password string
+	out      io.Writer
+}
+
+func newInstapaperNotifier(username, password string, out io.Writer) *instapaperNotifier {
+	return &instapaperNotifier{
+		http:     &http.Client{Timeout: 30 * time.Second},
+		username: username,
+		password: password,
+		out:      out,
+	}
+}
+
+func (in *instapaperNotifier) Notify(ctx context.Context, result *CrawlResult) error {
+	return saveNewPosts(ctx, in.out, "Instapaper", result, func(ctx context.Context, postURL, title string) error {
+		form := url.Values{"url": {postURL}}
+		if title != "" {
+			form.Set("title", title)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, instapaperAddEndpoint, strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(in.username, in.password)
+		return postReadLater(in.http, req)
+	})
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+
+	"manual-blog-crawler/urlfilter"
+)
+
+// Reference is an outbound link found in a post's content
+type Reference struct {
+	URL  string `json:"url"`
+	Text string `json:"text,omitempty"` // Link text as shown in the post
+	Kind string `json:"kind"`           // "code", "paper", "blog" or "other"
+}
+
+// contentLink is a raw link read from a post's main content
+type contentLink struct {
+	href string
+	text string
+}
+
+var (
+	codeHosts  = []string{"github.com", "gitlab.com", "bitbucket.org", "codeberg.org", "sourcehut.org", "sr.ht"}
+	paperHosts = []string{"arxiv.org", "doi.org", "dl.acm.org", "ieeexplore.ieee.org", "usenix.org",
+		"openreview.net", "proceedings.neurips.cc", "papers.nips.cc", "semanticscholar.org", "research.google",
+		"vldb.org", "aclanthology.org", "springer.com", "sciencedirect.com"}
+	blogHosts = []string{"medium.com", "substack.com", "dev.to", "hashnode.dev", "wordpress.com", "blogspot.com"}
+)
+
+// referenceKind classifies an outbound link by where it points
+func referenceKind(parsedURL *url.URL) string {
+	host := strings.TrimPrefix(strings.ToLower(parsedURL.Host), "www.")
+	path := strings.ToLower(parsedURL.Path)
+
+	switch {
+	case urlfilter.HostMatches(host, codeHosts):
+		return "code"
+	case urlfilter.HostMatches(host, paperHosts) || strings.HasSuffix(path, ".pdf"):
+		return "paper"
+	case urlfilter.HostMatches(host, blogHosts) ||
+		strings.HasPrefix(host, "blog.") || strings.HasPrefix(host, "engineering.") ||
+		strings.Contains(path, "/blog/") || strings.HasPrefix(path, "/blog"):
+		return "blog"
+	}
+	return "other"
+}
+
+// githubPages are the first path segments of github.com pages that aren't
+// anybody's repositories
+var githubPages = []string{"about", "apps", "collections", "contact", "customer-stories", "enterprise", "events",
+	"explore", "features", "login", "marketplace", "new", "notifications", "orgs", "organizations", "pricing",
+	"pulls", "issues", "search", "security", "settings", "signup", "site", "sponsors", "team", "topics", "trending", "users"}
+
+// gitlabPages are the same for gitlab.com
+var gitlabPages = []string{"dashboard", "explore", "help", "groups", "projects", "search", "users"}
+
+// gitlabProjectPages are the pages of a GitLab project that older links
+// have right after its path, without the "-" segment
+var gitlabProjectPages = []string{"blob", "tree", "raw", "commit", "commits", "issues", "merge_requests", "pipelines", "releases", "tags", "wikis"}
+
+// repository returns the repository a GitHub or GitLab link points into,
+// like https://github.com/Netflix/zuul for a link to a file, issue or
+// release of it, or "" for links to anything else
+func repository(parsedURL *url.URL) string {
+	host := strings.TrimPrefix(strings.ToLower(parsedURL.Host), "www.")
+	segments := strings.FieldsFunc(parsedURL.Path, func(r rune) bool { return r == '/' })
+
+	switch host {
+	case "github.com", "raw.githubusercontent.com":
+		if len(segments) < 2 || (host == "github.com" && contains(githubPages, strings.ToLower(segments[0]))) {
+			return ""
+		}
+		return "https://github.com/" + segments[0] + "/" + strings.TrimSuffix(segments[1], ".git")
+	case "gitlab.com":
+		// Projects can be nested in groups; their own pages follow a "-"
+		for i, segment := range segments {
+			if segment == "-" || (i >= 2 && contains(gitlabProjectPages, segment)) {
+				segments = segments[:i]
+				break
+			}
+		}
+		if len(segments) < 2 || contains(gitlabPages, strings.ToLower(segments[0])) {
+			return ""
+		}
+		segments[len(segments)-1] = strings.TrimSuffix(segments[len(segments)-1], ".git")
+		return "https://gitlab.com/" + strings.Join(segments, "/")
+	}
+	return ""
+}
+
+// repositories lists the GitHub and GitLab repositories a post's
+// references point into, once each in order of appearance. Both hosts
+// ignore case, so links differing only in case are the same repository.
+func repositories(refs []Reference) []string {
+	seen := make(map[string]bool)
+	var repos []string
+	for _, ref := range refs {
+		parsedURL, err := url.Parse(ref.URL)
+		if err != nil {
+			continue
+		}
+		if repo := repository(parsedURL); repo != "" && !seen[strings.ToLower(repo)] {
+			seen[strings.ToLower(repo)] = true
+			repos = append(repos, repo)
+		}
+	}
+	return repos
+}
+
+// references keeps the links of a post that leave the blog's own site,
+// classified and deduplicated in order of appearance
+func (bc *BlogCrawler) references(links []contentLink) []Reference {
+	baseURLParsed, err := url.Parse(bc.baseURL)
+	if err != nil {
+		return nil
+	}
+	baseHost := strings.TrimPrefix(strings.ToLower(baseURLParsed.Host), "www.")
+
+	seen := make(map[string]bool)
+	var refs []Reference
+	for _, link := range links {
+		parsedURL, err := url.Parse(link.href)
+		if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+			continue
+		}
+		if strings.TrimPrefix(strings.ToLower(parsedURL.Host), "www.") == baseHost {
+			continue
+		}
+
+		parsedURL.Fragment = ""
+		refURL := parsedURL.String()
+		if seen[refURL] {
+			continue
+		}
+		seen[refURL] = true
+
+		refs = append(refs, Reference{URL: refURL, Text: link.text, Kind: referenceKind(parsedURL)})
+	}
+	return refs
+}
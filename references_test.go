@@ -0,0 +1,38 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRepositories(t *testing.T) {
+	var refs []Reference
+	for _, link := range []string{
+		"https://github.com/Netflix/zuul",
+		"https://github.com/netflix/zuul/blob/master/README.md",
+		"https://github.com/Netflix/zuul/issues/1204",
+		"https://www.github.com/apache/kafka.git",
+		"https://raw.githubusercontent.com/golang/go/master/LICENSE",
+		"https://github.com/Netflix",
+		"https://github.com/features/actions",
+		"https://github.com/orgs/netflix/repositories",
+		"https://gitlab.com/gitlab-org/gitlab-runner/-/merge_requests/4200",
+		"https://gitlab.com/inkscape/inbox/issues/2315",
+		"https://gitlab.com/explore/projects",
+		"https://netflix.github.io/zuul/",
+		"https://arxiv.org/abs/1706.03762",
+	} {
+		refs = append(refs, Reference{URL: link})
+	}
+
+	want := []string{
+		"https://github.com/Netflix/zuul",
+		"https://github.com/apache/kafka",
+		"https://github.com/golang/go",
+		"https://gitlab.com/gitlab-org/gitlab-runner",
+		"https://gitlab.com/inkscape/inbox",
+	}
+	if got := repositories(refs); !reflect.DeepEqual(got, want) {
+		t.Errorf("repositories\n got: %q\nwant: %q", got, want)
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// countryPlaceholder in --proxy is replaced by the lowercase country code
+// of the region crawled, for proxy providers that pick the exit country by
+// host name
+const countryPlaceholder = "{country}"
+
+// countryDefaults are the locale and timezone a region gets from its
+// country alone
+var countryDefaults = map[string]struct{ locale, timezone string }{
+	"AR": {"es-AR", "America/Argentina/Buenos_Aires"},
+	"AT": {"de-AT", "Europe/Vienna"},
+	"AU": {"en-AU", "Australia/Sydney"},
+	"BE": {"nl-BE", "Europe/Brussels"},
+	"BR": {"pt-BR", "America/Sao_Paulo"},
+	"CA": {"en-CA", "America/Toronto"},
+	"CH": {"de-CH", "Europe/Zurich"},
+	"CN": {"zh-CN", "Asia/Shanghai"},
+	"CZ": {"cs-CZ", "Europe/Prague"},
+	"DE": {"de-DE", "Europe/Berlin"},
+	"DK": {"da-DK", "Europe/Copenhagen"},
+	"ES": {"es-ES", "Europe/Madrid"},
+	"FI": {"fi-FI", "Europe/Helsinki"},
+	"FR": {"fr-FR", "Europe/Paris"},
+	"GB": {"en-GB", "Europe/London"},
+	"HK": {"zh-HK", "Asia/Hong_Kong"},
+	"ID": {"id-ID", "Asia/Jakarta"},
+	"IE": {"en-IE", "Europe/Dublin"},
+	"IL": {"he-IL", "Asia/Jerusalem"},
+	"IN": {"en-IN", "Asia/Kolkata"},
+	"IT": {"it-IT", "Europe/Rome"},
+	"JP": {"ja-JP", "Asia/Tokyo"},
+	"KR": {"ko-KR", "Asia/Seoul"},
+	"MX": {"es-MX", "America/Mexico_City"},
+	"NL": {"nl-NL", "Europe/Amsterdam"},
+	"NO": {"nb-NO", "Europe/Oslo"},
+	"NZ": {"en-NZ", "Pacific/Auckland"},
+	"PL": {"pl-PL", "Europe/Warsaw"},
+	"PT": {"pt-PT", "Europe/Lisbon"},
+	"SE": {"sv-SE", "Europe/Stockholm"},
+	"SG": {"en-SG", "Asia/Singapore"},
+	"TR": {"tr-TR", "Europe/Istanbul"},
+	"TW": {"zh-TW", "Asia/Taipei"},
+	"UA": {"uk-UA", "Europe/Kiev"},
+	"US": {"en-US", "America/New_York"},
+	"ZA": {"en-ZA", "Africa/Johannesburg"},
+}
+
+var (
+	countryPattern = regexp.MustCompile(`^[A-Za-z]{2}$`)
+	localePattern  = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+)
+
+// crawlRegion is where a blog is crawled from, see --country and --locale
+type crawlRegion struct {
+	Country  string // ISO 3166-1 alpha-2 code, upper case; "" for a locale alone
+	Locale   string // BCP 47 tag like "de-DE"
+	Timezone string // IANA name, "" when the country isn't known
+}
+
+// name is what the region's posts are tagged with
+func (r crawlRegion) name() string {
+	if r.Country != "" {
+		return r.Country
+	}
+	return r.Locale
+}
+
+// parseRegions reads --country, a comma-separated list of country codes,
+// and --locale. The locale goes to every country; without it each
+// country gets its own from countryDefaults. A locale alone is one
+// region, in the country of its region subtag if it has one.
+func parseRegions(countries, locale string) ([]crawlRegion, error) {
+	if locale != "" && !localePattern.MatchString(locale) {
+		return nil, fmt.Errorf("%q is not a locale like de-DE", locale)
+	}
+	if countries == "" {
+		if locale == "" {
+			return nil, nil
+		}
+		region := crawlRegion{Locale: locale}
+		if _, subtag, ok := strings.Cut(locale, "-"); ok && countryPattern.MatchString(subtag) {
+			region.Country = strings.ToUpper(subtag)
+			region.Timezone = countryDefaults[region.Country].timezone
+		}
+		return []crawlRegion{region}, nil
+	}
+
+	var regions []crawlRegion
+	seen := make(map[string]bool)
+	for _, country := range strings.Split(countries, ",") {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if !countryPattern.MatchString(country) {
+			return nil, fmt.Errorf("%q is not a two-letter country code like DE", country)
+		}
+		if seen[country] {
+			continue
+		}
+		seen[country] = true
+
+		defaults, known := countryDefaults[country]
+		region := crawlRegion{Country: country, Locale: locale, Timezone: defaults.timezone}
+		if region.Locale == "" {
+			if !known {
+				return nil, fmt.Errorf("no default locale for country %s; give one with --locale", country)
+			}
+			region.Locale = defaults.locale
+		}
+		regions = append(regions, region)
+	}
+	return regions, nil
+}
+
+// acceptLanguage is the Accept-Language header of a locale: "de-DE" asks
+// for German from Germany, then any German
+func acceptLanguage(locale string) string {
+	language, _, ok := strings.Cut(locale, "-")
+	if !ok {
+		return locale
+	}
+	return locale + "," + language + ";q=0.9"
+}
+
+// regionProxy returns proxy with its country placeholder filled in for
+// country
+func regionProxy(proxy, country string) string {
+	return strings.ReplaceAll(proxy, countryPlaceholder, strings.ToLower(country))
+}
+
+// forRegion returns the options for crawling from region: its language
+// and timezone, unless set explicitly, and its proxy
+func (o CrawlOptions) forRegion(region crawlRegion) CrawlOptions {
+	o.Emulation = o.Emulation.or(browserEmulation{
+		Timezone:       region.Timezone,
+		AcceptLanguage: acceptLanguage(region.Locale),
+	})
+	if strings.Contains(o.Proxy, countryPlaceholder) {
+		o.Proxy = regionProxy(o.Proxy, region.Country)
+		// The shared browser can't exit through another country
+		o.SharedBrowser = nil
+	}
+	return o
+}
+
+// RegionCrawl is how the crawl from one region went
+type RegionCrawl struct {
+	Region     string `json:"region"` // Country code, or the locale without a country
+	Locale     string `json:"locale"`
+	TotalCount int    `json:"total_count"`
+	Error      string `json:"error,omitempty"`
+}
+
+// crawlRegions crawls the blog once from every region in opts.Regions and
+// merges the results
+func (r *crawlRun) crawlRegions(ctx context.Context, opts CrawlOptions) (*CrawlResult, error) {
+	var results []*CrawlResult
+	var crawls []RegionCrawl
+	var lastErr error
+	for _, region := range opts.Regions {
+		r.printf("Crawling %s from region %s (%s)...\n", r.baseURL, region.name(), region.Locale)
+		crawl := RegionCrawl{Region: region.name(), Locale: region.Locale}
+		result, err := NewBlogCrawler(r.baseURL, r.timeout, opts.forRegion(region)).crawl(ctx)
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("crawl cancelled: %w", ctx.Err())
+		}
+		if err != nil {
+			r.printf("Crawling from region %s failed: %v\n", region.name(), err)
+			crawl.Error, lastErr = redact(err.Error()), err
+		} else {
+			crawl.TotalCount = result.TotalCount
+			results = append(results, result)
+		}
+		crawls = append(crawls, crawl)
+	}
+	if len(results) == 0 {
+		return nil, lastErr
+	}
+	return mergeRegions(crawls, results), nil
+}
+
+// mergeRegions combines the results of the regions that succeeded, in
+// crawl order, into one holding every URL any of them found. URLs not all
+// of them found are listed with the regions that did; posts are tagged
+// with their regions either way. Stats and run info are the first
+// region's.
+func mergeRegions(crawls []RegionCrawl, results []*CrawlResult) *CrawlResult {
+	var succeeded []string
+	for _, crawl := range crawls {
+		if crawl.Error == "" {
+			succeeded = append(succeeded, crawl.Region)
+		}
+	}
+
+	merged := *results[0]
+	merged.BlogURLs = nil
+	merged.Posts = nil
+	merged.Pages = nil
+	merged.Discovery = nil
+	merged.MemberOnly = nil
+	merged.Errors = nil
+	regions := make(map[string][]string)
+	posts := make(map[string]int) // URL -> index in merged.Posts
+	discovered := make(map[string]bool)
+	memberOnly := make(map[string]bool)
+	for i, result := range results {
+		region := succeeded[i]
+		for _, postURL := range result.BlogURLs {
+			if _, ok := regions[postURL]; !ok {
+				merged.BlogURLs = append(merged.BlogURLs, postURL)
+			}
+			regions[postURL] = append(regions[postURL], region)
+		}
+		for _, post := range result.Posts {
+			if j, ok := posts[post.URL]; ok {
+				merged.Posts[j].Regions = append(merged.Posts[j].Regions, region)
+				continue
+			}
+			post.Regions = []string{region}
+			posts[post.URL] = len(merged.Posts)
+			merged.Posts = append(merged.Posts, post)
+		}
+		for _, discovery := range result.Discovery {
+			if !discovered[discovery.URL] {
+				discovered[discovery.URL] = true
+				merged.Discovery = append(merged.Discovery, discovery)
+			}
+		}
+		for _, postURL := range result.MemberOnly {
+			if !memberOnly[postURL] {
+				memberOnly[postURL] = true
+				merged.MemberOnly = append(merged.MemberOnly, postURL)
+			}
+		}
+		merged.Pages = append(merged.Pages, result.Pages...)
+		for _, message := range result.Errors {
+			merged.Errors = append(merged.Errors, fmt.Sprintf("[%s] %s", region, message))
+		}
+	}
+	merged.TotalCount = len(merged.BlogURLs)
+	merged.Regions = crawls
+	sort.Strings(merged.MemberOnly)
+
+	for postURL, found := range regions {
+		if len(found) < len(results) {
+			if merged.RegionalURLs == nil {
+				merged.RegionalURLs = make(map[string][]string)
+			}
+			merged.RegionalURLs[postURL] = found
+		}
+	}
+	return &merged
+}
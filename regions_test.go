@@ -0,0 +1,92 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRegions(t *testing.T) {
+	regions, err := parseRegions("us, de,US", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []crawlRegion{
+		{Country: "US", Locale: "en-US", Timezone: "America/New_York"},
+		{Country: "DE", Locale: "de-DE", Timezone: "Europe/Berlin"},
+	}
+	if !reflect.DeepEqual(regions, want) {
+		t.Errorf("regions %+v, want %+v", regions, want)
+	}
+
+	regions, err = parseRegions("", "fr-CA")
+	if err != nil || len(regions) != 1 || regions[0] != (crawlRegion{Country: "CA", Locale: "fr-CA", Timezone: "America/Toronto"}) {
+		t.Errorf("locale alone = %+v, %v; want one region in Canada", regions, err)
+	}
+	if regions, err := parseRegions("", ""); regions != nil || err != nil {
+		t.Errorf("no country or locale = %+v, %v; want no regions", regions, err)
+	}
+	for _, bad := range [][2]string{{"USA", ""}, {"XQ", ""}, {"", "not a locale"}} {
+		if _, err := parseRegions(bad[0], bad[1]); err == nil {
+			t.Errorf("parseRegions(%q, %q) accepted it", bad[0], bad[1])
+		}
+	}
+	if regions, err := parseRegions("XQ", "en"); err != nil || regions[0].Locale != "en" || regions[0].Timezone != "" {
+		t.Errorf("unknown country with a locale = %+v, %v", regions, err)
+	}
+
+	for country, defaults := range countryDefaults {
+		if _, err := time.LoadLocation(defaults.timezone); err != nil {
+			t.Errorf("timezone of %s: %v", country, err)
+		}
+	}
+	if got := acceptLanguage("de-DE"); got != "de-DE,de;q=0.9" {
+		t.Errorf("acceptLanguage(de-DE) = %q", got)
+	}
+}
+
+func TestRegionOptions(t *testing.T) {
+	opts := CrawlOptions{Proxy: "http://{country}.geo.example.com:8080", Emulation: browserEmulation{Timezone: "UTC"}}
+	regional := opts.forRegion(crawlRegion{Country: "JP", Locale: "ja-JP", Timezone: "Asia/Tokyo"})
+	if regional.Proxy != "http://jp.geo.example.com:8080" {
+		t.Errorf("proxy %q, want the country filled in", regional.Proxy)
+	}
+	if regional.Emulation.Timezone != "UTC" || regional.Emulation.AcceptLanguage != "ja-JP,ja;q=0.9" {
+		t.Errorf("emulation %+v, want the explicit timezone and the region's language", regional.Emulation)
+	}
+}
+
+func TestMergeRegions(t *testing.T) {
+	us := &CrawlResult{
+		BaseURL:  fakeBlogURL,
+		BlogURLs: []string{fakeBlogURL + "global", fakeBlogURL + "us-only"},
+		Posts:    []Post{{URL: fakeBlogURL + "global"}, {URL: fakeBlogURL + "us-only"}},
+		Errors:   []string{"slow page"},
+	}
+	de := &CrawlResult{
+		BaseURL:  fakeBlogURL,
+		BlogURLs: []string{fakeBlogURL + "de-only", fakeBlogURL + "global"},
+		Posts:    []Post{{URL: fakeBlogURL + "de-only"}, {URL: fakeBlogURL + "global"}},
+	}
+	crawls := []RegionCrawl{
+		{Region: "US", Locale: "en-US", TotalCount: 2},
+		{Region: "JP", Locale: "ja-JP", Error: "blocked"},
+		{Region: "DE", Locale: "de-DE", TotalCount: 2},
+	}
+	merged := mergeRegions(crawls, []*CrawlResult{us, de})
+
+	wantURLs := []string{fakeBlogURL + "global", fakeBlogURL + "us-only", fakeBlogURL + "de-only"}
+	if !reflect.DeepEqual(merged.BlogURLs, wantURLs) || merged.TotalCount != 3 {
+		t.Errorf("merged URLs %q (%d), want %q", merged.BlogURLs, merged.TotalCount, wantURLs)
+	}
+	wantRegional := map[string][]string{fakeBlogURL + "us-only": {"US"}, fakeBlogURL + "de-only": {"DE"}}
+	if !reflect.DeepEqual(merged.RegionalURLs, wantRegional) {
+		t.Errorf("regional URLs %v, want %v", merged.RegionalURLs, wantRegional)
+	}
+	if len(merged.Posts) != 3 || !reflect.DeepEqual(merged.Posts[0].Regions, []string{"US", "DE"}) {
+		t.Errorf("posts %+v, want 3 with the global one in US and DE", merged.Posts)
+	}
+	if !reflect.DeepEqual(merged.Errors, []string{"[US] slow page"}) || len(merged.Regions) != 3 {
+		t.Errorf("errors %q and %d regions, want the US error tagged and all 3 regions", merged.Errors, len(merged.Regions))
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+)
+
+// reportTemplate renders a self-contained HTML page: no external assets, so
+// the file can be mailed or dropped into a shared drive as-is.
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Crawl report: {{.Result.BaseURL}}</title>
+<style>
+  body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+  h1 { font-size: 1.4em; word-break: break-all; }
+  h2 { font-size: 1.15em; margin-top: 2em; }
+  table { border-collapse: collapse; width: 100%; font-size: 0.9em; }
+  th, td { border: 1px solid #ddd; padding: 6px 8px; text-align: left; vertical-align: top; }
+  th { background: #f4f4f4; cursor: pointer; user-select: none; white-space: nowrap; }
+  th.sorted-asc::after { content: " \25B2"; }
+  th.sorted-desc::after { content: " \25BC"; }
+  tr:nth-child(even) td { background: #fafafa; }
+  td.url { word-break: break-all; }
+  .summary td:first-child { font-weight: bold; width: 12em; }
+  .new { color: #0a7d22; font-weight: bold; }
+  .error { color: #b00020; }
+</style>
+</head>
+<body>
+<h1>Crawl report: <a href="{{.Result.BaseURL}}">{{.Result.BaseURL}}</a></h1>
+
+<table class="summary">
+  <tr><td>Crawled at</td><td>{{.Result.CrawledAt}}</td></tr>
+  <tr><td>Posts found</td><td>{{.Result.TotalCount}}</td></tr>
+  <tr><td>Listing pages</td><td>{{len .Result.Pages}}</td></tr>
+  {{if .Result.New}}<tr><td>New posts</td><td>{{len .Result.New}}</td></tr>{{end}}
+  {{if .Result.Changed}}<tr><td>Changed posts</td><td>{{len .Result.Changed}}</td></tr>{{end}}
+  <tr><td>Errors</td><td>{{len .Result.Errors}}</td></tr>
+</table>
+
+<h2>Posts</h2>
+<table class="sortable">
+  <thead><tr><th>Title</th><th>Published</th><th>Category</th><th>Status</th><th>URL</th></tr></thead>
+  <tbody>
+  {{range .Rows}}<tr>
+    <td>{{.Title}}</td>
+    <td>{{.Published}}</td>
+    <td>{{.Category}}</td>
+    <td>{{if .New}}<span class="new">new</span>{{else if .Changed}}changed{{end}}</td>
+    <td class="url"><a href="{{.URL}}">{{.URL}}</a></td>
+  </tr>
+  {{end}}</tbody>
+</table>
+
+{{if .Result.Pages}}<h2>Listing pages</h2>
+<table class="sortable">
+  <thead><tr><th>Page</th><th>URLs found</th><th>Error</th><th>URL</th></tr></thead>
+  <tbody>
+  {{range .Result.Pages}}<tr>
+    <td>{{.Number}}</td>
+    <td>{{.URLsFound}}</td>
+    <td class="error">{{.Error}}</td>
+    <td class="url"><a href="{{.URL}}">{{.URL}}</a></td>
+  </tr>
+  {{end}}</tbody>
+</table>{{end}}
+
+{{if .Result.Errors}}<h2>Errors</h2>
+<ul class="error">
+  {{range .Result.Errors}}<li>{{.}}</li>
+  {{end}}</ul>{{end}}
+
+<script>
+document.querySelectorAll('table.sortable').forEach(function(table) {
+  table.querySelectorAll('th').forEach(function(th, column) {
+    th.addEventListener('click', function() {
+      var ascending = !th.classList.contains('sorted-asc');
+      table.querySelectorAll('th').forEach(function(h) { h.classList.remove('sorted-asc', 'sorted-desc'); });
+      th.classList.add(ascending ? 'sorted-asc' : 'sorted-desc');
+      var body = table.tBodies[0];
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function(a, b) {
+        var x = a.cells[column].textContent.trim(), y = b.cells[column].textContent.trim();
+        var nx = parseFloat(x), ny = parseFloat(y);
+        var cmp = (!isNaN(nx) && !isNaN(ny)) ? nx - ny : x.localeCompare(y);
+        return ascending ? cmp : -cmp;
+      });
+      rows.forEach(function(row) { body.appendChild(row); });
+    });
+  });
+});
+</script>
+</body>
+</html>
+`))
+
+// reportRow is one post line of the HTML report
+type reportRow struct {
+	URL       string
+	Title     string
+	Published string
+	Category  string
+	New       bool
+	Changed   bool
+}
+
+// writeHTML writes the result as a self-contained, sortable HTML report
+func writeHTML(w io.Writer, result *CrawlResult) error {
+	posts := make(map[string]Post, len(result.Posts))
+	for _, post := range result.Posts {
+		posts[post.URL] = post
+	}
+	isNew := make(map[string]bool, len(result.New))
+	for _, url := range result.New {
+		isNew[url] = true
+	}
+	isChanged := make(map[string]bool, len(result.Changed))
+	for _, url := range result.Changed {
+		isChanged[url] = true
+	}
+
+	rows := make([]reportRow, 0, len(result.BlogURLs))
+	for _, url := range result.BlogURLs {
+		row := reportRow{URL: url, Title: postTitleFromURL(url), New: isNew[url], Changed: isChanged[url]}
+		if post, ok := posts[url]; ok {
+			if post.Title != "" {
+				row.Title = post.Title
+			}
+			row.Published = post.Published
+			row.Category = post.Category
+		}
+		rows = append(rows, row)
+	}
+
+	data := struct {
+		Result *CrawlResult
+		Rows   []reportRow
+	}{result, rows}
+	if err := reportTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("failed to render HTML report: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/go-rod/rod/lib/launcher"
+)
+
+// applyResourceLimits adds the Chrome flags that keep long crawls from
+// exhausting the host's memory
+func (o CrawlOptions) applyResourceLimits(l *launcher.Launcher) *launcher.Launcher {
+	if o.BrowserMemoryMB > 0 {
+		// Caps the JavaScript heap of every renderer
+		l = l.Set("js-flags", fmt.Sprintf("--max-old-space-size=%d", o.BrowserMemoryMB))
+	}
+	if o.DisableDevShm {
+		// Docker's default 64MB /dev/shm makes Chrome crash on large pages
+		l = l.Set("disable-dev-shm-usage")
+	}
+	if o.RendererProcessLimit > 0 {
+		l = l.Set("renderer-process-limit", strconv.Itoa(o.RendererProcessLimit))
+	}
+	return l
+}
+
+// recyclePageIfDue replaces the crawl's tab with a fresh one every
+// opts.RecyclePages navigations, releasing whatever the old tab had leaked
+func (bc *BlogCrawler) recyclePageIfDue() error {
+	if bc.opts.RecyclePages <= 0 {
+		return nil
+	}
+	bc.navigations++
+	if bc.navigations <= bc.opts.RecyclePages {
+		return nil
+	}
+
+	bc.navigations = 1
+	if err := bc.page.Close(context.Background()); err != nil {
+		bc.warnf("Error closing recycled page: %v", err)
+	}
+	return bc.openPage()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// FeedOffset is how far an infinite-scroll crawl got into the feed, so a
+// later crawl can pick up there with --resume instead of collecting
+// everything again from the top
+type FeedOffset struct {
+	Scrolls int    `json:"scrolls"`
+	Oldest  string `json:"oldest,omitempty"` // Oldest publish date shown on the feed, YYYY-MM-DD
+}
+
+// resumeFeed moves an infinite-scroll crawl to the previous crawl's offset.
+// Medium publications have archive pages per month, so the crawl continues
+// with the months from the oldest post seen backwards and needs no
+// scrolling at all (done is true). Other feeds are scrolled down to the
+// offset without extracting on the way, which is what made deep re-crawls
+// quadratic; the returned scroll count continues from there.
+func (bc *BlogCrawler) resumeFeed(ctx context.Context, offset *FeedOffset, urlSet map[string]bool) (scrolls int, done bool) {
+	if oldest, err := time.Parse("2006-01-02", offset.Oldest); err == nil && mediumArchiveURL(bc.baseURL, oldest) != "" {
+		bc.printf("Resuming at the %s archive...\n", oldest.Format("2006-01"))
+		bc.crawlArchiveMonths(ctx, urlSet, oldest, time.Time{})
+		return 0, true
+	}
+
+	bc.printf("Resuming after %d scrolls...\n", offset.Scrolls)
+	if err := bc.fastForward(ctx, offset.Scrolls, 500*time.Millisecond); err != nil {
+		bc.warnf("Error scrolling to the previous offset: %v", err)
+	}
+	return offset.Scrolls, false
+}
+
+// fastForward scrolls the feed down scrolls times without extracting URLs
+func (bc *BlogCrawler) fastForward(ctx context.Context, scrolls int, delay time.Duration) error {
+	for i := 0; i < scrolls; i++ {
+		if err := bc.scrollToBottom(ctx); err != nil {
+			return err
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// feedOffset records where an infinite-scroll crawl stopped
+func (bc *BlogCrawler) feedOffset(ctx context.Context, scrolls int) *FeedOffset {
+	offset := &FeedOffset{Scrolls: scrolls}
+	if oldest, ok := bc.oldestListedDate(ctx); ok {
+		offset.Oldest = oldest.Format("2006-01-02")
+	}
+	return offset
+}
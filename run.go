@@ -0,0 +1,415 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// crawlRun holds everything needed to crawl one blog and deliver the result
+type crawlRun struct {
+	baseURL     string
+	outputFile  string
+	outputPath  string             // Template outputFile is expanded from for every crawl, see expandOutputPath
+	runStarted  time.Time          // For {run} in outputPath
+	format      string             // json, rss, atom or html
+	template    *template.Template // Overrides format when set
+	stdout      io.Writer          // Machine mode: result goes here instead of outputFile
+	compress    string             // Compression applied to the result document
+	opmlFile    string             // OPML file to register the generated feed in
+	feedURL     string             // Public URL of the feed for the OPML entry
+	authorsFile string             // Index of authors across blogs to add the posts' authors to
+	timeout     time.Duration
+	opts        CrawlOptions
+	sinks       []resultSink
+	notifiers   []notifier
+	parallel    int  // Seed blogs crawled at the same time
+	failOnEmpty bool // A crawl finding no posts fails with exitEmpty
+
+	// Shared by the crawls of the seed blogs, nil for a single blog
+	browser *seedBrowser
+	// Watch mode with --tui: the blog's row on the dashboard, nil without
+	status *blogStatus
+	// Watching seed blogs: limits how many are crawled at the same time
+	slots chan struct{}
+	// Content hashes of the posts of every seed blog, nil for a single blog
+	crossPosts *crossPostIndex
+
+	// Held while a result is saved and delivered when crawls run in
+	// parallel; sinks and the OPML file aren't safe for concurrent use
+	deliver *sync.Mutex
+}
+
+// printf prints the run's progress to the crawl's output
+func (r *crawlRun) printf(format string, args ...interface{}) {
+	fmt.Fprintf(r.opts.output(), format, args...)
+}
+
+func (r *crawlRun) println(args ...interface{}) {
+	fmt.Fprintln(r.opts.output(), args...)
+}
+
+// once crawls the blog, compares the result with previous when given, saves
+// it and hands it to every sink and notifier
+func (r *crawlRun) once(ctx context.Context, previous *CrawlResult) (*CrawlResult, error) {
+	if r.outputPath != "" {
+		r.setOutputFile(expandOutputPath(r.outputPath, r.baseURL, time.Now(), r.runStarted))
+	}
+	opts := r.opts
+	// Regions exiting through their own proxies launch their own browsers
+	if r.browser != nil && !strings.Contains(opts.Proxy, countryPlaceholder) {
+		shared, err := r.browser.get(ctx)
+		if err != nil {
+			return nil, withExitCode(exitBrowser, err)
+		}
+		opts.SharedBrowser = shared
+	}
+	if r.status != nil {
+		opts.OnProgress = r.status.progress
+	}
+	r.printf("Starting blog crawler for: %s\n", r.baseURL)
+	r.printf("Timeout set to: %v\n", r.timeout)
+
+	var result *CrawlResult
+	var err error
+	if len(opts.Regions) > 0 {
+		result, err = r.crawlRegions(ctx, opts)
+	} else {
+		result, err = NewBlogCrawler(r.baseURL, r.timeout, opts).crawl(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("crawling failed: %w", err)
+	}
+
+	if r.deliver != nil {
+		r.deliver.Lock()
+		defer r.deliver.Unlock()
+	}
+
+	r.printf("\nCrawling completed!\n")
+	r.printf("Total blog URLs found: %d\n", result.TotalCount)
+	printSelectorStats(r.opts.output(), result.Selectors)
+	printStats(r.opts.output(), result.Stats)
+
+	if r.opts.DryRun {
+		r.println("Dry run: no results written")
+		return result, r.checkEmpty(result)
+	}
+
+	if linked := r.crossPosts.link(result); linked > 0 {
+		r.printf("Found %d posts cross-posted from other blogs (see cross_post_of)\n", linked)
+	}
+
+	if previous != nil {
+		diff := diffResults(previous, result)
+		result.New = diff.Added
+		result.Changed = diff.Modified
+		r.printf("New posts since previous run: %d\n", len(result.New))
+		r.printf("Changed posts since previous run: %d\n", len(result.Changed))
+	}
+
+	if err := r.save(result); err != nil {
+		return nil, withExitCode(exitOutput, fmt.Errorf("saving results failed: %w", err))
+	}
+
+	if r.opmlFile != "" {
+		feedURL := r.feedURL
+		if feedURL == "" {
+			feedURL = compressedPath(r.outputFile, r.compress)
+		}
+		if err := updateOPML(r.opmlFile, result, feedURL); err != nil {
+			r.printf("Warning: Error updating OPML file: %v\n", err)
+		} else {
+			r.printf("Feed registered in: %s\n", r.opmlFile)
+		}
+	}
+
+	if r.authorsFile != "" {
+		if err := updateAuthorsIndex(r.authorsFile, result); err != nil {
+			r.printf("Warning: Error updating authors index: %v\n", err)
+		} else {
+			r.printf("Authors added to: %s\n", r.authorsFile)
+		}
+	}
+
+	var sinkErr error
+	for _, sink := range r.sinks {
+		if err := sink.Write(ctx, result); err != nil {
+			r.printf("Error writing to sink: %s\n", redact(err.Error()))
+			sinkErr = withExitCode(exitOutput, fmt.Errorf("writing to sinks failed: %w", err))
+		}
+	}
+
+	notified := withoutCrossPosts(result)
+	if left := len(result.New) - len(notified.New); left > 0 {
+		r.printf("Leaving %d cross-posted posts out of notifications\n", left)
+	}
+	if len(notified.New) > 0 {
+		for _, n := range r.notifiers {
+			if err := n.Notify(ctx, notified); err != nil {
+				r.printf("Warning: Error sending notification: %s\n", redact(err.Error()))
+			}
+		}
+	}
+
+	if sinkErr != nil {
+		return result, sinkErr
+	}
+	return result, r.checkEmpty(result)
+}
+
+// checkEmpty fails a crawl that found no posts with --fail-on-empty. The
+// empty result has been written by then.
+func (r *crawlRun) checkEmpty(result *CrawlResult) error {
+	if !r.failOnEmpty || result.TotalCount > 0 {
+		return nil
+	}
+	return withExitCode(exitEmpty, fmt.Errorf("no posts found on %s", r.baseURL))
+}
+
+// save writes the result document to stdout in machine mode, otherwise to
+// the output file
+func (r *crawlRun) save(result *CrawlResult) error {
+	write := func(w io.Writer) error {
+		if r.template != nil {
+			return writeWithTemplate(w, result, r.template)
+		}
+		return writeResult(w, result, r.format)
+	}
+
+	if r.stdout != nil {
+		return writeCompressed(r.stdout, r.compress, write)
+	}
+
+	if r.outputPath != "" {
+		if err := os.MkdirAll(filepath.Dir(r.outputFile), 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	path, err := writeOutputFile(r.outputFile, r.compress, write)
+	if err != nil {
+		return err
+	}
+	r.printf("Results saved to: %s\n", path)
+	return nil
+}
+
+// crawlSeeds crawls every seed blog with the settings of r, writing each
+// result to its own file in dir. The blogs share one browser, each crawled
+// in its own incognito context, and up to r.parallel of them run at once.
+// A failing blog doesn't stop the rest.
+func (r *crawlRun) crawlSeeds(ctx context.Context, seeds []string, dir string) error {
+	workers := r.seedWorkers(len(seeds))
+	browser := &seedBrowser{opts: r.opts}
+	defer browser.close()
+
+	base := *r
+	base.crossPosts = newCrossPostIndex()
+	if workers > 1 {
+		base.deliver = &sync.Mutex{}
+		r.printf("Crawling up to %d blogs in parallel\n", workers)
+	}
+
+	var mu sync.Mutex
+	started, failed := 0, 0
+	codes := make(map[int]bool)
+	next := func() (int, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if ctx.Err() != nil || started == len(seeds) {
+			return 0, false
+		}
+		started++
+		return started - 1, true
+	}
+
+	crawlSeed := func(i int) error {
+		run := base.forSeed(seeds[i], dir, browser)
+		_, err := run.once(ctx, nil)
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i, ok := next(); ok; i, ok = next() {
+				r.printf("\n[%d/%d] %s\n", i+1, len(seeds), seeds[i])
+				if err := crawlSeed(i); err != nil {
+					r.printf("Warning: Crawling %s failed: %s\n", seeds[i], redact(err.Error()))
+					mu.Lock()
+					failed++
+					codes[exitCode(err)] = true
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("stopped after %d of %d blogs: %w", started, len(seeds), ctx.Err())
+	}
+	// Blogs that all failed the same way fail the run that way, so a
+	// browser that doesn't start is told apart from a few blocked blogs
+	if failed == len(seeds) && len(codes) == 1 {
+		for code := range codes {
+			return withExitCode(code, fmt.Errorf("all %d blogs failed", failed))
+		}
+	}
+	if failed > 0 {
+		return withExitCode(exitPartial, fmt.Errorf("%d of %d blogs failed", failed, len(seeds)))
+	}
+	return nil
+}
+
+// seedWorkers returns how many of n seed blogs are crawled at the same time
+func (r *crawlRun) seedWorkers(n int) int {
+	return max(1, min(r.parallel, n))
+}
+
+// forSeed returns the run crawling the seed blog into its own file in dir,
+// or where the output path template puts it, in browser
+func (r *crawlRun) forSeed(seed, dir string, browser *seedBrowser) crawlRun {
+	run := *r
+	run.browser = browser
+	run.baseURL = seed
+	if run.outputPath == "" {
+		run.setOutputFile(filepath.Join(dir, seedOutputName(seed)+filepath.Ext(r.outputFile)))
+	}
+	return run
+}
+
+// watch re-crawls every interval until ctx is cancelled. Each run is compared
+// with the one before it; without a previous result the first run only
+// records a baseline, so notifiers don't announce the whole back catalogue.
+// On the dashboard, asking for a re-crawl runs the next crawl right away.
+func (r *crawlRun) watch(ctx context.Context, previous *CrawlResult, interval time.Duration) {
+	var recrawl <-chan struct{}
+	if r.status != nil {
+		recrawl = r.status.recrawl
+	}
+
+	for {
+		result, err := r.watchOnce(ctx, previous)
+		if err != nil {
+			r.printf("Warning: Watch run of %s failed: %s\n", r.baseURL, redact(err.Error()))
+		}
+		if result != nil {
+			previous = result
+		}
+
+		next := time.Now().Add(interval)
+		r.status.finished(result, err, next)
+		r.printf("Next crawl of %s at %s\n", r.baseURL, next.Format(time.RFC3339))
+		select {
+		case <-ctx.Done():
+			r.printf("Watch stopped\n")
+			return
+		case <-time.After(interval):
+		case <-recrawl:
+			r.printf("Re-crawling %s now\n", r.baseURL)
+		}
+	}
+}
+
+// watchOnce is one crawl of watch, waiting its turn when seed blogs are
+// watched
+func (r *crawlRun) watchOnce(ctx context.Context, previous *CrawlResult) (*CrawlResult, error) {
+	if r.slots != nil {
+		r.status.queued()
+		select {
+		case r.slots <- struct{}{}:
+			defer func() { <-r.slots }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	r.status.crawling()
+	return r.once(ctx, previous)
+}
+
+// watchSeeds watches every seed blog as watch does, each writing to its
+// own file in dir. The blogs share one browser and up to r.parallel of
+// them are crawled at the same time. dashboard, when not nil, has a row
+// for every seed.
+func (r *crawlRun) watchSeeds(ctx context.Context, seeds []string, dir string, interval time.Duration, dashboard *dashboard) {
+	browser := &seedBrowser{opts: r.opts}
+	defer browser.close()
+
+	base := *r
+	base.slots = make(chan struct{}, r.seedWorkers(len(seeds)))
+	base.crossPosts = newCrossPostIndex()
+	base.deliver = &sync.Mutex{}
+
+	var wg sync.WaitGroup
+	for i, seed := range seeds {
+		run := base.forSeed(seed, dir, browser)
+		if dashboard != nil {
+			run.status = dashboard.blogs[i]
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run.watch(ctx, nil, interval)
+		}()
+	}
+	wg.Wait()
+}
+
+// seedBrowser is the browser shared by the crawls of crawlSeeds. It is
+// relaunched for the next blog when it crashed during an earlier one.
+type seedBrowser struct {
+	opts CrawlOptions
+
+	mu       sync.Mutex
+	browser  *rod.Browser
+	launcher *launcher.Launcher
+}
+
+// get returns the running browser, launching a new one if there is none or
+// the current one stopped answering
+func (sb *seedBrowser) get(ctx context.Context) (*rod.Browser, error) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	if sb.browser != nil {
+		checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		_, err := (proto.BrowserGetVersion{}).Call(sb.browser.Context(checkCtx))
+		cancel()
+		if err == nil {
+			return sb.browser, nil
+		}
+		fmt.Fprintf(sb.opts.output(), "Warning: Shared browser stopped responding (%v), relaunching\n", err)
+		sb.launcher.Kill()
+	}
+
+	browser, launcher, err := launchBrowser(ctx, sb.opts)
+	if err != nil {
+		sb.browser = nil
+		return nil, err
+	}
+	sb.browser, sb.launcher = browser, launcher
+	return browser, nil
+}
+
+func (sb *seedBrowser) close() {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	if sb.browser != nil {
+		sb.browser.Close()
+		sb.launcher.Kill()
+	}
+}
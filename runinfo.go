@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// RunInfo records how a result was produced: the crawler build, the
+// browser, the site profile and the settings the crawl ran with, so a
+// result can be reproduced and a surprising one traced back to its cause
+type RunInfo struct {
+	CrawlerVersion string            `json:"crawler_version"`        // Module version, or the VCS revision of a development build
+	GoVersion      string            `json:"go_version"`             // Go toolchain the crawler was built with
+	Browser        string            `json:"browser,omitempty"`      // Browser product and version, like "HeadlessChrome/126.0.6478.126"
+	SiteProfile    string            `json:"site_profile,omitempty"` // Match of the site profile applied, empty for none
+	SiteScript     string            `json:"site_script,omitempty"`  // Starlark file of the site profile
+	Strategy       string            `json:"strategy"`               // Listing strategy after resolving --strategy for the blog
+	Flags          map[string]string `json:"flags,omitempty"`        // Flags set for the run, on the command line, in the config file or the environment
+	Config         RunConfig         `json:"config"`
+}
+
+// RunConfig is the configuration a crawl effectively ran with, once the
+// site profile, the command line and the defaults were combined
+type RunConfig struct {
+	Timeout           string   `json:"timeout"` // Like "30s"
+	Proxy             string   `json:"proxy,omitempty"`
+	Depth             int      `json:"depth"`
+	FetchMode         string   `json:"fetch_mode,omitempty"`
+	MaxURLs           int      `json:"max_urls,omitempty"`
+	MaxEmptyPages     int      `json:"max_empty_pages"`
+	ScrollIdleLimit   int      `json:"scroll_idle_limit"`
+	ScrollDelay       string   `json:"scroll_delay"`
+	FixedScrollDelay  bool     `json:"fixed_scroll_delay,omitempty"`
+	PostLinkSelectors []string `json:"post_link_selectors"`
+	NextLinkSelectors []string `json:"next_link_selectors"`
+	ExcludePatterns   []string `json:"exclude_patterns"`
+	CategoryPages     []string `json:"category_pages,omitempty"`
+	KeepQueryParams   []string `json:"keep_query_params,omitempty"`
+	DropQueryParams   []string `json:"drop_query_params,omitempty"`
+	BudgetRequests    int      `json:"budget_requests,omitempty"`
+	BudgetMB          int      `json:"budget_mb,omitempty"`
+	BudgetDuration    string   `json:"budget_duration,omitempty"`
+
+	Emulation *browserEmulation `json:"emulation,omitempty"` // Only when something differs from the defaults
+}
+
+// crawlerVersion returns the version of this build of the crawler: the
+// module version when installed with go install, otherwise the VCS
+// revision it was built from, "-dirty" with uncommitted changes
+func crawlerVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Version
+	if version != "" && version != "(devel)" {
+		return version
+	}
+
+	revision, modified := "", false
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if revision == "" {
+		return "(devel)"
+	}
+	version = revision[:min(len(revision), 12)]
+	if modified {
+		version += "-dirty"
+	}
+	return version
+}
+
+// readBrowserVersion remembers the browser's version for the run info
+func (bc *BlogCrawler) readBrowserVersion(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	version, err := bc.browser.Version(ctx)
+	if err != nil {
+		bc.warnf("Could not read the browser version: %v", err)
+		return
+	}
+	bc.browserVersion = version
+}
+
+// runInfo describes this crawl for the result's run block
+func (bc *BlogCrawler) runInfo(strategy string) *RunInfo {
+	excludes := make([]string, len(bc.excludes))
+	for i, pattern := range bc.excludes {
+		excludes[i] = pattern.Source
+	}
+	var categories []string
+	for _, pattern := range bc.categories {
+		categories = append(categories, pattern.Source)
+	}
+	query := bc.queryParamRule()
+	budget := bc.site.Budget.or(bc.opts.Budget)
+
+	info := &RunInfo{
+		CrawlerVersion: crawlerVersion(),
+		GoVersion:      runtime.Version(),
+		Browser:        bc.browserVersion,
+		SiteProfile:    bc.site.Match,
+		SiteScript:     bc.site.Script,
+		Strategy:       strategy,
+		Flags:          bc.opts.Flags,
+		Config: RunConfig{
+			Timeout:           bc.timeout.String(),
+			Proxy:             bc.opts.Proxy,
+			Depth:             bc.opts.listingDepth(),
+			FetchMode:         bc.opts.FetchMode,
+			MaxURLs:           bc.opts.MaxURLs,
+			MaxEmptyPages:     bc.opts.maxEmptyPages(),
+			ScrollIdleLimit:   bc.opts.scrollIdleLimit(),
+			ScrollDelay:       bc.opts.scrollDelay().String(),
+			FixedScrollDelay:  bc.opts.FixedScrollDelay,
+			PostLinkSelectors: bc.postLinkSelectors(),
+			NextLinkSelectors: bc.nextLinkSelectors(),
+			ExcludePatterns:   excludes,
+			CategoryPages:     categories,
+			KeepQueryParams:   query.Keep,
+			DropQueryParams:   query.Drop,
+			BudgetRequests:    budget.MaxRequests,
+			BudgetMB:          budget.MaxMB,
+		},
+	}
+	if budget.MaxDuration > 0 {
+		info.Config.BudgetDuration = time.Duration(budget.MaxDuration).String()
+	}
+	if emulation := bc.emulation(); emulation != (browserEmulation{}) {
+		info.Config.Emulation = &emulation
+	}
+	return info
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// resultSchemaVersion is the version of the CrawlResult JSON format. New
+// optional fields keep the version; it goes up when a field is removed,
+// renamed or changes its meaning, so consumers can refuse what they don't
+// understand instead of misreading it.
+const resultSchemaVersion = 1
+
+// resultSchema returns the JSON Schema (draft 2020-12) of CrawlResult.
+// Fields without omitempty are required, nested structs go to $defs.
+func resultSchema() map[string]interface{} {
+	defs := make(map[string]interface{})
+	schema := structSchema(reflect.TypeOf(CrawlResult{}), defs)
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "CrawlResult"
+	schema["description"] = fmt.Sprintf("Result document of manual-blog-crawler, schema version %d", resultSchemaVersion)
+	schema["properties"].(map[string]interface{})["schema_version"] = map[string]interface{}{
+		"type":  "integer",
+		"const": resultSchemaVersion,
+	}
+	schema["$defs"] = defs
+	return schema
+}
+
+func structSchema(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = typeSchema(field.Type, defs)
+		if !strings.Contains(options, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	// Additional properties stay allowed: new optional fields don't bump
+	// the version and mustn't fail validation against an older schema
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+func typeSchema(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem(), defs)
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), defs)}
+	case reflect.Struct:
+		if _, ok := defs[t.Name()]; !ok {
+			defs[t.Name()] = nil // Placeholder against recursive types
+			defs[t.Name()] = structSchema(t, defs)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+	}
+	return map[string]interface{}{}
+}
+
+// runSchema implements the schema subcommand
+func runSchema(args []string) {
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . schema")
+		fmt.Println()
+		fmt.Printf("Prints the JSON Schema of the result document (schema version %d).\n", resultSchemaVersion)
+	}
+	parseArgs(fs, args)
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(resultSchema()); err != nil {
+		fmt.Printf("Error encoding schema: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+)
+
+// Hooks a site script may define, see siteScript
+const (
+	hookFilterURL   = "filter_url"
+	hookExtractURLs = "extract_urls"
+	hookExtractPost = "extract_post"
+
+	// Pipeline stages, see scriptPageLoaded, scriptLinkFound and scriptResult
+	hookPageLoaded = "on_page_loaded"
+	hookLinkFound  = "on_link_found"
+	hookResult     = "on_result"
+)
+
+var scriptHooks = []string{hookFilterURL, hookExtractURLs, hookExtractPost, hookPageLoaded, hookLinkFound, hookResult}
+
+// scriptStepLimit bounds the work of one hook call, so a runaway loop in a
+// script can't hang the crawl
+const scriptStepLimit = 10_000_000
+
+// siteScript is a site profile's Starlark script. It defines any of:
+//
+//	filter_url(url)       True or False to accept or reject a link, None for the built-in rules
+//	extract_urls(page)    more post URLs of a listing page (page.url, page.html, page.links)
+//	extract_post(post)    a dict overriding title, published, category or content of a post
+//	                      (post.url, post.html and the fields extracted so far)
+//	on_page_loaded(page)  called after every page load (page.url, page.html)
+//	on_link_found(link)   called once per classified link (link.url, link.accepted, link.rule)
+//	on_result(result)     None, or the post URLs the result keeps (result.base_url,
+//	                      result.urls, result.posts with url, title, published and category)
+type siteScript struct {
+	filename string
+	hooks    starlark.StringDict
+
+	mu       sync.Mutex
+	reported map[string]bool // Errors already warned about
+}
+
+// loadSiteScript runs a script file once to define its hooks, printing to out
+func loadSiteScript(filename string, out io.Writer) (*siteScript, error) {
+	thread := &starlark.Thread{Name: filename, Print: scriptPrint(out)}
+	thread.SetMaxExecutionSteps(scriptStepLimit)
+	globals, err := starlark.ExecFile(thread, filename, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load script %s: %w", filename, err)
+	}
+	// Frozen globals can be shared by hook calls from parallel post workers
+	globals.Freeze()
+
+	hooks := make(starlark.StringDict)
+	for _, name := range scriptHooks {
+		value, ok := globals[name]
+		if !ok {
+			continue
+		}
+		if _, ok := value.(starlark.Callable); !ok {
+			return nil, fmt.Errorf("%s in script %s is a %s, not a function", name, filename, value.Type())
+		}
+		hooks[name] = value
+	}
+	if len(hooks) == 0 {
+		return nil, fmt.Errorf("script %s defines none of %v", filename, scriptHooks)
+	}
+	return &siteScript{filename: filename, hooks: hooks, reported: make(map[string]bool)}, nil
+}
+
+// scriptPrint returns the print function of scripts, printing to w
+func scriptPrint(w io.Writer) func(*starlark.Thread, string) {
+	return func(thread *starlark.Thread, msg string) {
+		fmt.Fprintf(w, "  [%s] %s\n", thread.Name, msg)
+	}
+}
+
+// has reports whether the script defines the hook
+func (s *siteScript) has(hook string) bool {
+	return s != nil && s.hooks[hook] != nil
+}
+
+// call runs a hook with a fresh thread and the step limit. What the hook
+// prints goes to out.
+func (s *siteScript) call(out io.Writer, hook string, args ...starlark.Value) (starlark.Value, error) {
+	thread := &starlark.Thread{Name: hook, Print: scriptPrint(out)}
+	thread.SetMaxExecutionSteps(scriptStepLimit)
+	value, err := starlark.Call(thread, s.hooks[hook], args, nil)
+	if err != nil {
+		var evalErr *starlark.EvalError
+		if errors.As(err, &evalErr) {
+			return nil, fmt.Errorf("%s: %s", s.filename, evalErr.Backtrace())
+		}
+		return nil, fmt.Errorf("%s: %s: %w", s.filename, hook, err)
+	}
+	return value, nil
+}
+
+// warnScript reports a failed hook call, once per distinct error since a
+// broken hook usually fails the same way for every link
+func (bc *BlogCrawler) warnScript(err error) {
+	s := bc.site.script
+	s.mu.Lock()
+	first := !s.reported[err.Error()]
+	s.reported[err.Error()] = true
+	s.mu.Unlock()
+
+	if first {
+		bc.warnf("Site script failed: %v", err)
+	}
+}
+
+// scriptFilterURL asks the filter_url hook about urlStr. ok is false when
+// the hook isn't defined, fails or returns None, leaving the decision to
+// the built-in rules.
+func (bc *BlogCrawler) scriptFilterURL(urlStr string) (accept, ok bool) {
+	if !bc.site.script.has(hookFilterURL) {
+		return false, false
+	}
+	value, err := bc.site.script.call(bc.opts.output(), hookFilterURL, starlark.String(urlStr))
+	if err != nil {
+		bc.warnScript(err)
+		return false, false
+	}
+	switch value := value.(type) {
+	case starlark.Bool:
+		return bool(value), true
+	case starlark.NoneType:
+		return false, false
+	}
+	bc.warnScript(fmt.Errorf("%s must return True, False or None, not %s", hookFilterURL, value.Type()))
+	return false, false
+}
+
+// scriptURLs adds the post URLs the extract_urls hook finds on the loaded
+// listing page to urls
+func (bc *BlogCrawler) scriptURLs(ctx context.Context, urls []string) []string {
+	html, err := bc.page.HTML(ctx)
+	if err != nil {
+		bc.warnf("Could not read the page for %s: %v", hookExtractURLs, err)
+		return urls
+	}
+	links, err := bc.pageLinks(ctx)
+	if err != nil {
+		bc.warnf("Could not read the page for %s: %v", hookExtractURLs, err)
+		return urls
+	}
+	linkValues := make([]starlark.Value, len(links))
+	for i, link := range links {
+		linkValues[i] = starlark.String(link)
+	}
+
+	pageURL := bc.baseURL
+	if currentURL, err := bc.page.URL(ctx); err == nil {
+		pageURL = currentURL
+	}
+	page := starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"url":   starlark.String(pageURL),
+		"html":  starlark.String(html),
+		"links": starlark.NewList(linkValues),
+	})
+	value, err := bc.site.script.call(bc.opts.output(), hookExtractURLs, page)
+	if err != nil {
+		bc.warnScript(err)
+		return urls
+	}
+	iterable, ok := value.(starlark.Iterable)
+	if !ok || value.Type() == "string" {
+		bc.warnScript(fmt.Errorf("%s must return a list of URLs, not %s", hookExtractURLs, value.Type()))
+		return urls
+	}
+
+	seen := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		seen[url] = true
+	}
+	iter := iterable.Iterate()
+	defer iter.Done()
+	var item starlark.Value
+	for iter.Next(&item) {
+		href, ok := starlark.AsString(item)
+		if !ok {
+			bc.warnScript(fmt.Errorf("%s returned a %s instead of a URL", hookExtractURLs, item.Type()))
+			continue
+		}
+		normalizedURL, err := bc.normalizeURL(href, true)
+		if err != nil {
+			continue
+		}
+		normalizedURL = bc.canonicalURL(normalizedURL)
+		if seen[normalizedURL] {
+			continue
+		}
+		seen[normalizedURL] = true
+		bc.recordDiscovery(normalizedURL, "script "+hookExtractURLs)
+		bc.scriptLinkFound(normalizedURL, true, "script "+hookExtractURLs)
+		urls = append(urls, normalizedURL)
+	}
+	return urls
+}
+
+// scriptPost lets the extract_post hook override the fields extracted from
+// a post whose page is html
+func (bc *BlogCrawler) scriptPost(post *Post, html string) {
+	if !bc.site.script.has(hookExtractPost) {
+		return
+	}
+	fields := map[string]*string{
+		"title":     &post.Title,
+		"published": &post.Published,
+		"category":  &post.Category,
+		"content":   &post.Content,
+	}
+	args := starlark.StringDict{"url": starlark.String(post.URL), "html": starlark.String(html)}
+	for name, field := range fields {
+		args[name] = starlark.String(*field)
+	}
+
+	value, err := bc.site.script.call(bc.opts.output(), hookExtractPost, starlarkstruct.FromStringDict(starlarkstruct.Default, args))
+	if err != nil {
+		bc.warnScript(err)
+		return
+	}
+	if value == starlark.None {
+		return
+	}
+	dict, ok := value.(*starlark.Dict)
+	if !ok {
+		bc.warnScript(fmt.Errorf("%s must return a dict or None, not %s", hookExtractPost, value.Type()))
+		return
+	}
+	for _, item := range dict.Items() {
+		name, _ := starlark.AsString(item[0])
+		text, ok := starlark.AsString(item[1])
+		field := fields[name]
+		if field == nil || !ok {
+			bc.warnScript(fmt.Errorf("%s returned %s: %s, expected a string for one of title, published, category or content", hookExtractPost, item[0], item[1].Type()))
+			continue
+		}
+		*field = text
+	}
+	post.Content = normalizeContent(post.Content)
+	post.ContentHash = contentHash(post.Content)
+}
+
+// scriptPageLoaded passes the page just loaded from pageURL to the
+// on_page_loaded hook. What it returns is ignored.
+func (bc *BlogCrawler) scriptPageLoaded(ctx context.Context, pageURL string) {
+	if !bc.site.script.has(hookPageLoaded) {
+		return
+	}
+	html, err := bc.page.HTML(ctx)
+	if err != nil {
+		bc.warnf("Could not read the page for %s: %v", hookPageLoaded, err)
+		return
+	}
+	page := starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"url":  starlark.String(pageURL),
+		"html": starlark.String(html),
+	})
+	if _, err := bc.site.script.call(bc.opts.output(), hookPageLoaded, page); err != nil {
+		bc.warnScript(err)
+	}
+}
+
+// scriptLinkFound tells the on_link_found hook about a link the first time
+// it's classified, with the rule that accepted or rejected it
+func (bc *BlogCrawler) scriptLinkFound(linkURL string, accepted bool, rule string) {
+	if !bc.site.script.has(hookLinkFound) || bc.linksFound[linkURL] {
+		return
+	}
+	if bc.linksFound == nil {
+		bc.linksFound = make(map[string]bool)
+	}
+	bc.linksFound[linkURL] = true
+
+	link := starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"url":      starlark.String(linkURL),
+		"accepted": starlark.Bool(accepted),
+		"rule":     starlark.String(rule),
+	})
+	if _, err := bc.site.script.call(bc.opts.output(), hookLinkFound, link); err != nil {
+		bc.warnScript(err)
+	}
+}
+
+// scriptResult lets the on_result hook pick the posts the result keeps. The
+// order of urls and posts stays as it is.
+func (bc *BlogCrawler) scriptResult(urls []string, posts []Post) ([]string, []Post) {
+	if !bc.site.script.has(hookResult) {
+		return urls, posts
+	}
+	urlValues := make([]starlark.Value, len(urls))
+	for i, url := range urls {
+		urlValues[i] = starlark.String(url)
+	}
+	postValues := make([]starlark.Value, len(posts))
+	for i, post := range posts {
+		postValues[i] = starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+			"url":       starlark.String(post.URL),
+			"title":     starlark.String(post.Title),
+			"published": starlark.String(post.Published),
+			"category":  starlark.String(post.Category),
+		})
+	}
+	result := starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"base_url": starlark.String(bc.baseURL),
+		"urls":     starlark.NewList(urlValues),
+		"posts":    starlark.NewList(postValues),
+	})
+
+	value, err := bc.site.script.call(bc.opts.output(), hookResult, result)
+	if err != nil {
+		bc.warnScript(err)
+		return urls, posts
+	}
+	if value == starlark.None {
+		return urls, posts
+	}
+	iterable, ok := value.(starlark.Iterable)
+	if !ok || value.Type() == "string" {
+		bc.warnScript(fmt.Errorf("%s must return a list of URLs or None, not %s", hookResult, value.Type()))
+		return urls, posts
+	}
+	keep := make(map[string]bool)
+	iter := iterable.Iterate()
+	defer iter.Done()
+	var item starlark.Value
+	for iter.Next(&item) {
+		url, ok := starlark.AsString(item)
+		if !ok {
+			bc.warnScript(fmt.Errorf("%s returned a %s instead of a URL", hookResult, item.Type()))
+			return urls, posts
+		}
+		keep[url] = true
+	}
+
+	keptURLs := make([]string, 0, len(keep))
+	for _, url := range urls {
+		if keep[url] {
+			keptURLs = append(keptURLs, url)
+		}
+	}
+	var keptPosts []Post
+	if posts != nil {
+		keptPosts = make([]Post, 0, len(keep))
+		for _, post := range posts {
+			if keep[post.URL] {
+				keptPosts = append(keptPosts, post)
+			}
+		}
+	}
+	return keptURLs, keptPosts
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSiteScriptPipelineHooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "site.star")
+	script := `
+def on_page_loaded(page):
+    print("loaded", page.url, "<article>" in page.html)
+
+def on_link_found(link):
+    print("link", link.url, link.accepted)
+
+def on_result(result):
+    return [url for url in result.urls if "rust" not in url]
+`
+	if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	loaded, err := loadSiteScript(path, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := CrawlOptions{Sort: "url", Output: &out, Sites: []siteProfile{{Match: "blog.example.com", script: loaded}}}
+	result, err := newFakeCrawler(fakeBlogURL, fakePagedBlog(), opts).crawl(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	printed := out.String()
+
+	for _, page := range []string{fakeBlogURL, fakeBlogURL + "page/2/", fakeBlogURL + "page/3/"} {
+		if !strings.Contains(printed, "[on_page_loaded] loaded "+page+" True\n") {
+			t.Errorf("on_page_loaded wasn't called for %s:\n%s", page, printed)
+		}
+	}
+	if !strings.Contains(printed, "[on_link_found] link "+fakeBlogURL+"why-we-shard-by-tenant True\n") {
+		t.Errorf("on_link_found wasn't called for a post:\n%s", printed)
+	}
+	// Every listing page links home, which is reported once
+	if n := strings.Count(printed, "[on_link_found] link "+fakeBlogURL+" False\n"); n != 1 {
+		t.Errorf("on_link_found called %d times for the home link, want once:\n%s", n, printed)
+	}
+
+	var want []string
+	for _, url := range fakePagedURLs {
+		if !strings.Contains(url, "rust") {
+			want = append(want, url)
+		}
+	}
+	if !reflect.DeepEqual(result.BlogURLs, want) || result.TotalCount != len(want) {
+		t.Errorf("on_result kept %q (total %d), want %q", result.BlogURLs, result.TotalCount, want)
+	}
+}
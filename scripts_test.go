@@ -0,0 +1,34 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// functionScript matches the start of a function expression, which is what
+// rod's Eval expects: it calls the script with the given arguments
+var functionScript = regexp.MustCompile(`^(async\s*)?(\([^)]*\)|[a-zA-Z_$][\w$]*)\s*=>|^(async\s+)?function\b`)
+
+// TestPageScriptsAreFunctions guards against page scripts written as
+// immediately-invoked function expressions. Eval wraps its script in a
+// call, so an IIFE runs and then throws calling its own result: the
+// extraction it did is lost and the pass reports an error for every page.
+func TestPageScriptsAreFunctions(t *testing.T) {
+	scripts := map[string]string{
+		"anchorsJS": anchorsJS, "clickTabJS": clickTabJS, "domSizeJS": domSizeJS, "engagementJS": engagementJS,
+		"extractContentJS": extractContentJS, "findTabsJS": findTabsJS, "fingerprintJS": fingerprintJS,
+		"harvestDrainJS": harvestDrainJS, "harvestStartJS": harvestStartJS, "navigationTimingJS": navigationTimingJS,
+		"nextLinkJS": nextLinkJS, "pageLinksJS": pageLinksJS, "pageStateJS": pageStateJS, "pickStateJS": pickStateJS,
+		"pickerJS": pickerJS, "postLinksJS": postLinksJS, "pruneDOMJS": pruneDOMJS, "viewAllJS": viewAllJS,
+	}
+	for name, script := range scripts {
+		script = strings.TrimSpace(script)
+		if !functionScript.MatchString(script) {
+			t.Errorf("%s doesn't start as a function expression: %.40q", name, script)
+		}
+		if strings.HasSuffix(strings.TrimSuffix(script, ";"), ")()") {
+			t.Errorf("%s calls itself; Eval calls the script, so it must only define the function", name)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Defaults of the infinite scroll stop conditions
+const (
+	defaultScrollIdleLimit = 3
+	defaultScrollDelay     = 2 * time.Second
+)
+
+// scrollIdleLimit is the number of scrolls without progress that ends an
+// infinite scroll crawl
+func (o CrawlOptions) scrollIdleLimit() int {
+	if o.ScrollIdleLimit > 0 {
+		return o.ScrollIdleLimit
+	}
+	return defaultScrollIdleLimit
+}
+
+// scrollDelay is the wait after each scroll for new posts to load
+func (o CrawlOptions) scrollDelay() time.Duration {
+	if o.ScrollDelay > 0 {
+		return o.ScrollDelay
+	}
+	return defaultScrollDelay
+}
+
+// scrollStop keeps the state of the infinite scroll stop conditions
+// between iterations
+type scrollStop struct {
+	opts    CrawlOptions
+	started time.Time
+	idle    int
+	height  int
+}
+
+func newScrollStop(opts CrawlOptions) *scrollStop {
+	return &scrollStop{opts: opts, started: time.Now()}
+}
+
+// check is called after every scroll iteration with the number of unique
+// URLs before and after it. It returns why the crawl should stop, or "" to
+// keep scrolling.
+func (s *scrollStop) check(ctx context.Context, bc *BlogCrawler, iteration, previousCount, newCount int) string {
+	if s.opts.MaxURLs > 0 && newCount >= s.opts.MaxURLs {
+		return fmt.Sprintf("Reached %d URLs.", s.opts.MaxURLs)
+	}
+	if s.opts.MaxScrolls > 0 && iteration >= s.opts.MaxScrolls {
+		return fmt.Sprintf("Reached the limit of %d scrolls.", s.opts.MaxScrolls)
+	}
+	if s.opts.ScrollTimeout > 0 && time.Since(s.started) >= s.opts.ScrollTimeout {
+		return fmt.Sprintf("Scrolled for %v.", s.opts.ScrollTimeout)
+	}
+	if !s.opts.StopBefore.IsZero() {
+		if oldest, ok := bc.oldestListedDate(ctx); ok && oldest.Before(s.opts.StopBefore) {
+			return fmt.Sprintf("Reached posts from %s.", oldest.Format("2006-01-02"))
+		}
+	}
+
+	// Progress is new posts, or with StopOnHeight a page that still grows;
+	// slow feeds sometimes load a batch of cards before their links
+	progress := newCount > previousCount
+	if s.opts.StopOnHeight {
+		if height, err := bc.pageHeight(ctx); err == nil {
+			progress = height > s.height
+			s.height = max(s.height, height)
+		}
+	}
+	if progress {
+		s.idle = 0
+		return ""
+	}
+
+	s.idle++
+	if s.idle < s.opts.scrollIdleLimit() {
+		return ""
+	}
+	if s.opts.StopOnHeight {
+		return fmt.Sprintf("Page stopped growing after %d scrolls.", s.idle)
+	}
+	return fmt.Sprintf("No new content detected after %d scrolls.", s.idle)
+}
+
+// pageHeight returns the scroll height of the current page
+func (bc *BlogCrawler) pageHeight(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := bc.page.Eval(ctx, `() => Math.max(document.body.scrollHeight, document.documentElement.scrollHeight)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read page height: %w", err)
+	}
+	return res.Int(), nil
+}
+
+// oldestListedDate returns the oldest publish date shown on the current
+// page, read from <time datetime> elements, which feeds put on post cards
+func (bc *BlogCrawler) oldestListedDate(ctx context.Context) (time.Time, bool) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := bc.page.Eval(ctx, `() => Array.from(document.querySelectorAll('time[datetime]'), t => t.getAttribute('datetime'))`)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var oldest time.Time
+	for _, value := range res.Arr() {
+		if t, ok := parsePublished(value.Str()); ok && (oldest.IsZero() || t.Before(oldest)) {
+			oldest = t
+		}
+	}
+	return oldest, !oldest.IsZero()
+}
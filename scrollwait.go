@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Timing of the adaptive wait after a scroll (see waitAfterScroll)
+const (
+	scrollSettle     = 500 * time.Millisecond // Quiet needed once something loaded
+	scrollPoll       = 100 * time.Millisecond
+	scrollWaitFactor = 5               // The longest wait, in scroll delays
+	stalledRequest   = 5 * time.Second // Requests in flight longer are long polls, not posts loading
+)
+
+// domSizeJS counts the elements of the page, which grows as a feed loads
+const domSizeJS = `() => document.getElementsByTagName('*').length`
+
+// networkActivity follows the XHR and fetch requests of the crawl's tab,
+// which is how feeds load their next posts
+type networkActivity struct {
+	ctx context.Context
+
+	mu       sync.Mutex
+	inFlight map[proto.NetworkRequestID]time.Time // By when they started
+	last     time.Time                            // When one last started or ended
+}
+
+func newNetworkActivity(ctx context.Context) *networkActivity {
+	return &networkActivity{ctx: ctx, inFlight: make(map[proto.NetworkRequestID]time.Time)}
+}
+
+// watch follows page's requests until the crawl ends
+func (n *networkActivity) watch(page *rod.Page) {
+	if n == nil {
+		return
+	}
+	wait := page.Context(n.ctx).EachEvent(
+		func(e *proto.NetworkRequestWillBeSent) {
+			if e.Type == proto.NetworkResourceTypeXHR || e.Type == proto.NetworkResourceTypeFetch {
+				n.started(e.RequestID)
+			}
+		},
+		func(e *proto.NetworkLoadingFinished) { n.ended(e.RequestID) },
+		func(e *proto.NetworkLoadingFailed) { n.ended(e.RequestID) },
+	)
+	go wait()
+}
+
+func (n *networkActivity) started(id proto.NetworkRequestID) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.inFlight[id] = time.Now()
+	n.last = time.Now()
+}
+
+func (n *networkActivity) ended(id proto.NetworkRequestID) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.inFlight[id]; ok {
+		delete(n.inFlight, id)
+		n.last = time.Now()
+	}
+}
+
+// pending returns how many requests are in flight, leaving out stalled
+// ones, and when one last started or ended
+func (n *networkActivity) pending() (int, time.Time) {
+	if n == nil {
+		return 0, time.Time{}
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	count := 0
+	for _, started := range n.inFlight {
+		if time.Since(started) < stalledRequest {
+			count++
+		}
+	}
+	return count, n.last
+}
+
+// scrollSample is what the page is doing at one point of a scroll wait
+type scrollSample struct {
+	elements    int       // -1 when unknown
+	pending     int       // XHR and fetch requests in flight
+	lastRequest time.Time // When one last started or ended
+}
+
+// scrollWait decides when the posts a scroll asked for have loaded
+type scrollWait struct {
+	settle time.Duration // Quiet needed once something loaded
+	idle   time.Duration // Wait when nothing happens at all
+	limit  time.Duration // Longest wait
+	poll   time.Duration
+}
+
+// wait polls sample until the page settles: no request in flight and no
+// new elements for w.settle after something loaded. A scroll nothing
+// answers waits w.idle, as the feed may just be slow to react; one that
+// keeps loading waits w.limit at most. It returns how long it waited.
+func (w scrollWait) wait(ctx context.Context, sample func() scrollSample) (time.Duration, error) {
+	start := time.Now()
+	elements := sample().elements
+	quietSince, active := start, false
+	for {
+		if err := sleepContext(ctx, w.poll); err != nil {
+			return time.Since(start), err
+		}
+		now := time.Now()
+		s := sample()
+		if s.elements >= 0 && s.elements != elements {
+			elements, quietSince, active = s.elements, now, true
+		}
+		if s.lastRequest.After(start) {
+			active = true
+			if s.lastRequest.After(quietSince) {
+				quietSince = s.lastRequest
+			}
+		}
+		if s.pending > 0 {
+			quietSince, active = now, true
+		}
+
+		waited := now.Sub(start)
+		switch {
+		case waited >= w.limit:
+			return waited, nil
+		case active && now.Sub(quietSince) >= w.settle:
+			return waited, nil
+		case !active && waited >= w.idle:
+			return waited, nil
+		}
+	}
+}
+
+// waitAfterScroll waits for the posts a scroll loaded: until the feed's
+// requests are done and the page stops growing, or the scroll delay when
+// nothing loads. --fixed-scroll-delay always waits the scroll delay and a
+// little more for rendering.
+func (bc *BlogCrawler) waitAfterScroll(ctx context.Context) error {
+	if bc.opts.FixedScrollDelay {
+		return sleepContext(ctx, bc.opts.scrollDelay()+500*time.Millisecond)
+	}
+	w := scrollWait{
+		settle: scrollSettle,
+		idle:   bc.opts.scrollDelay(),
+		limit:  scrollWaitFactor * bc.opts.scrollDelay(),
+		poll:   scrollPoll,
+	}
+	waited, err := w.wait(ctx, func() scrollSample {
+		s := scrollSample{elements: -1}
+		if res, err := bc.page.Eval(ctx, domSizeJS); err == nil {
+			s.elements = res.Int()
+		}
+		s.pending, s.lastRequest = bc.network.pending()
+		return s
+	})
+	bc.scrollWait += waited
+	return err
+}
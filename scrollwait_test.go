@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestScrollWait(t *testing.T) {
+	w := scrollWait{settle: 50 * time.Millisecond, idle: 300 * time.Millisecond, limit: 600 * time.Millisecond, poll: 10 * time.Millisecond}
+
+	tests := []struct {
+		name     string
+		sample   func(elapsed time.Duration) scrollSample
+		min, max time.Duration
+	}{
+		{
+			// The feed fetches a batch and renders it within 100ms
+			name: "settles",
+			sample: func(elapsed time.Duration) scrollSample {
+				s := scrollSample{elements: 100}
+				if elapsed > 20*time.Millisecond && elapsed < 80*time.Millisecond {
+					s.pending = 1
+				}
+				if elapsed > 100*time.Millisecond {
+					s.elements = 140
+				}
+				return s
+			},
+			min: 150 * time.Millisecond,
+			max: 250 * time.Millisecond,
+		},
+		{
+			name:   "nothing loads",
+			sample: func(elapsed time.Duration) scrollSample { return scrollSample{elements: 100} },
+			min:    300 * time.Millisecond,
+			max:    400 * time.Millisecond,
+		},
+		{
+			name:   "unknown page size",
+			sample: func(elapsed time.Duration) scrollSample { return scrollSample{elements: -1} },
+			min:    300 * time.Millisecond,
+			max:    400 * time.Millisecond,
+		},
+		{
+			// A slow feed keeps a request in flight past the idle wait
+			name: "keeps loading",
+			sample: func(elapsed time.Duration) scrollSample {
+				return scrollSample{elements: 100, pending: 1}
+			},
+			min: 600 * time.Millisecond,
+			max: 700 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		start := time.Now()
+		waited, err := w.wait(context.Background(), func() scrollSample { return tt.sample(time.Since(start)) })
+		if err != nil {
+			t.Fatal(err)
+		}
+		if waited < tt.min || waited > tt.max {
+			t.Errorf("%s: waited %v, want %v to %v", tt.name, waited, tt.min, tt.max)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := w.wait(ctx, func() scrollSample { return scrollSample{elements: 1} }); err == nil {
+		t.Error("a cancelled wait returned no error")
+	}
+}
+
+func TestNetworkActivity(t *testing.T) {
+	n := newNetworkActivity(context.Background())
+	n.started("1")
+	n.started("2")
+	n.ended("1")
+	n.ended("unknown")
+	if pending, last := n.pending(); pending != 1 || time.Since(last) > time.Second {
+		t.Errorf("pending() = %d, %v; want 1 and now", pending, last)
+	}
+
+	n.inFlight["2"] = time.Now().Add(-stalledRequest)
+	if pending, _ := n.pending(); pending != 0 {
+		t.Errorf("a stalled request counts as pending")
+	}
+	if pending, last := (*networkActivity)(nil).pending(); pending != 0 || !last.IsZero() {
+		t.Errorf("nil activity pending() = %d, %v", pending, last)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/highlight/highlighter/ansi"
+)
+
+// searchDocument is what gets indexed for every post
+type searchDocument struct {
+	URL       string `json:"url"`
+	BaseURL   string `json:"base_url"`
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	Published string `json:"published"`
+	Category  string `json:"category"`
+	CrawledAt string `json:"crawled_at"`
+}
+
+// searchIndexMapping analyzes titles and content as English text and keeps
+// the identifying fields as exact keywords
+func searchIndexMapping() mapping.IndexMapping {
+	text := bleve.NewTextFieldMapping()
+	text.Analyzer = en.AnalyzerName
+
+	keyword := bleve.NewKeywordFieldMapping()
+
+	post := bleve.NewDocumentMapping()
+	post.AddFieldMappingsAt("title", text)
+	post.AddFieldMappingsAt("content", text)
+	post.AddFieldMappingsAt("category", text)
+	post.AddFieldMappingsAt("url", keyword)
+	post.AddFieldMappingsAt("base_url", keyword)
+	post.AddFieldMappingsAt("published", keyword)
+	post.AddFieldMappingsAt("crawled_at", keyword)
+
+	indexMapping := bleve.NewIndexMapping()
+	indexMapping.DefaultMapping = post
+	indexMapping.DefaultAnalyzer = en.AnalyzerName
+	return indexMapping
+}
+
+// openSearchIndex opens the Bleve index in dir, creating it if create is set
+// and it doesn't exist yet
+func openSearchIndex(dir string, create bool) (bleve.Index, error) {
+	index, err := bleve.Open(dir)
+	if errors.Is(err, bleve.ErrorIndexPathDoesNotExist) && create {
+		index, err = bleve.New(dir, searchIndexMapping())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open search index %s: %w", dir, err)
+	}
+	return index, nil
+}
+
+// bleveSink adds every post of a result to a Bleve full-text index
+type bleveSink struct {
+	index bleve.Index
+	out   io.Writer // Where indexing is reported
+}
+
+func newBleveSink(dir string, out io.Writer) (*bleveSink, error) {
+	index, err := openSearchIndex(dir, true)
+	if err != nil {
+		return nil, err
+	}
+	return &bleveSink{index: index, out: out}, nil
+}
+
+func (bs *bleveSink) Write(ctx context.Context, result *CrawlResult) error {
+	posts := make(map[string]Post, len(result.Posts))
+	for _, post := range result.Posts {
+		posts[post.URL] = post
+	}
+
+	batch := bs.index.NewBatch()
+	for _, postURL := range result.BlogURLs {
+		post, ok := posts[postURL]
+		if !ok || post.Content == "" {
+			// Without content there's little to add, and an earlier crawl
+			// with --fetch-content may already have indexed the full post
+			if existing, err := bs.index.Document(postURL); err == nil && existing != nil {
+				continue
+			}
+		}
+
+		doc := searchDocument{
+			URL:       postURL,
+			BaseURL:   result.BaseURL,
+			Title:     post.Title,
+			Content:   post.Content,
+			Published: post.Published,
+			Category:  post.Category,
+			CrawledAt: result.CrawledAt,
+		}
+		if doc.Title == "" {
+			doc.Title = postTitleFromURL(postURL)
+		}
+		if err := batch.Index(postURL, doc); err != nil {
+			return fmt.Errorf("failed to index %s: %w", postURL, err)
+		}
+	}
+
+	if err := bs.index.Batch(batch); err != nil {
+		return fmt.Errorf("failed to update search index: %w", err)
+	}
+
+	fmt.Fprintf(bs.out, "Indexed %d posts for search\n", batch.Size())
+	return nil
+}
+
+func (bs *bleveSink) Close() error {
+	return bs.index.Close()
+}
+
+// searchHit is one result of the search subcommand in --json mode
+type searchHit struct {
+	URL       string   `json:"url"`
+	Title     string   `json:"title"`
+	BaseURL   string   `json:"base_url"`
+	Published string   `json:"published,omitempty"`
+	Score     float64  `json:"score"`
+	Fragments []string `json:"fragments,omitempty"`
+}
+
+// runSearch implements the search subcommand
+func runSearch(args []string) {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	indexDir := fs.String("index", "blog_index", "Bleve index directory built with --index")
+	limit := fs.Int("limit", 10, "maximum number of results")
+	jsonOutput := fs.Bool("json", false, "print results as JSON")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . search [--index DIR] [--limit N] [--json] <query>")
+		fmt.Println()
+		fmt.Println("The query uses Bleve's query string syntax, e.g. kafka +title:streaming -base_url:\"https://example.com\"")
+		fmt.Println()
+		fmt.Println("Flags:")
+		fs.PrintDefaults()
+	}
+	positional, _ := parseArgs(fs, args)
+	if len(positional) == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	index, err := openSearchIndex(*indexDir, false)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer index.Close()
+
+	request := bleve.NewSearchRequestOptions(bleve.NewQueryStringQuery(strings.Join(positional, " ")), *limit, 0, false)
+	request.Fields = []string{"title", "base_url", "published"}
+	// Matches are marked with <mark> in JSON and with terminal colors otherwise
+	request.Highlight = bleve.NewHighlight()
+	if !*jsonOutput {
+		request.Highlight = bleve.NewHighlightWithStyle(ansi.Name)
+	}
+	request.Highlight.AddField("content")
+
+	results, err := index.Search(request)
+	if err != nil {
+		fmt.Printf("Error searching: %v\n", err)
+		os.Exit(1)
+	}
+
+	hits := make([]searchHit, 0, len(results.Hits))
+	for _, match := range results.Hits {
+		hit := searchHit{URL: match.ID, Score: match.Score, Fragments: match.Fragments["content"]}
+		hit.Title, _ = match.Fields["title"].(string)
+		hit.BaseURL, _ = match.Fields["base_url"].(string)
+		hit.Published, _ = match.Fields["published"].(string)
+		hits = append(hits, hit)
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(hits); err != nil {
+			fmt.Printf("Error encoding results: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Printf("%d matches, showing %d\n", results.Total, len(hits))
+	for i, hit := range hits {
+		fmt.Printf("\n%d. %s\n   %s\n", i+1, hit.Title, hit.URL)
+		for _, fragment := range hit.Fragments {
+			fmt.Printf("   …%s…\n", strings.Join(strings.Fields(fragment), " "))
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// searchPageLimit is the safety limit of search result pages crawled
+const searchPageLimit = 500
+
+// validSearchURL reports whether a search URL template has a page number
+// placeholder
+func validSearchURL(template string) bool {
+	return strings.Contains(template, "{page}") || strings.Contains(template, "{page0}")
+}
+
+// searchPageURL fills in a search URL template for pageNum, counted from
+// 1: {page} becomes pageNum and {page0} pageNum-1
+func searchPageURL(template string, pageNum int) string {
+	return strings.NewReplacer(
+		"{page}", strconv.Itoa(pageNum),
+		"{page0}", strconv.Itoa(pageNum-1),
+	).Replace(template)
+}
+
+// searchURL is the blog's search URL template: --search-url, or the site
+// profile's
+func (bc *BlogCrawler) searchURL() string {
+	if bc.opts.SearchURL != "" {
+		return bc.opts.SearchURL
+	}
+	return bc.site.SearchURL
+}
+
+// crawlSearch enumerates the blog's posts through its own search, for blogs
+// whose listing stops after a few pages while an empty or wildcard search
+// pages through everything. Result pages are crawled until
+// opts.maxEmptyPages() of them in a row bring no new posts.
+func (bc *BlogCrawler) crawlSearch(ctx context.Context, urlSet map[string]bool) {
+	template := bc.searchURL()
+	consecutiveEmpty := 0
+
+	for pageNum := 1; ctx.Err() == nil; pageNum++ {
+		pageURL := searchPageURL(template, pageNum)
+		bc.printf("Crawling search page %d: %s\n", pageNum, pageURL)
+
+		bc.setSource("search", pageNum, pageURL)
+		urls, err := bc.crawlListingPage(ctx, pageNum, pageURL)
+		bc.recordPage(pageNum, pageURL, len(urls), err)
+		if err != nil {
+			bc.warnf("Error crawling search page %d: %v", pageNum, err)
+		}
+
+		previousCount := len(urlSet)
+		for _, url := range urls {
+			urlSet[url] = true
+		}
+		if err == nil {
+			bc.printf("  Found %d blog URLs on search page %d (total: %d unique URLs)\n", len(urls), pageNum, len(urlSet))
+			bc.reportProgress(ProgressEvent{Kind: "search", Step: pageNum, URL: pageURL, URLsFound: len(urls), TotalURLs: len(urlSet)})
+		}
+
+		// Past the last page, searches tend to repeat it or show nothing
+		if len(urlSet) == previousCount {
+			consecutiveEmpty++
+			if consecutiveEmpty >= bc.opts.maxEmptyPages() {
+				bc.printf("Stopping: No new URLs on search page %d\n", pageNum)
+				return
+			}
+		} else {
+			consecutiveEmpty = 0
+		}
+
+		if pageNum >= searchPageLimit {
+			bc.printf("Reached safety limit of %d search pages. Stopping.\n", searchPageLimit)
+			return
+		}
+		if err := sleepContext(ctx, 1*time.Second); err != nil {
+			return
+		}
+	}
+}
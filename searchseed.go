@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	googleSearchEndpoint = "https://www.googleapis.com/customsearch/v1"
+	braveSearchEndpoint  = "https://api.search.brave.com/res/v1/web/search"
+)
+
+// seedSearchEngines are the values --seed-search accepts
+var seedSearchEngines = []string{"google", "brave"}
+
+// Results per request and the most results each API pages through
+var (
+	searchPageSizes  = map[string]int{"google": 10, "brave": 20}
+	searchMaxResults = map[string]int{"google": 100, "brave": 200}
+)
+
+// SeedSearch configures the discovery pass querying a search engine for
+// site:<blog> to find posts the listing no longer links to
+type SeedSearch struct {
+	Engine string // "google" or "brave"
+	Key    string // API key
+	CX     string // Google: the Programmable Search Engine ID
+	Limit  int    // Results to ask for, 0 for as many as the API gives
+}
+
+// siteQuery is the search query for every page under baseURL
+func siteQuery(baseURL string) string {
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return "site:" + baseURL
+	}
+	return "site:" + strings.TrimPrefix(parsed.Host, "www.") + strings.TrimSuffix(parsed.Path, "/")
+}
+
+// searchPage fetches the page-th page of results for query, counted from
+// 0, and returns their URLs. The API key is never part of the error.
+func (bc *BlogCrawler) searchPage(ctx context.Context, query string, page int) ([]string, error) {
+	if err := bc.budget.request(); err != nil {
+		return nil, err
+	}
+
+	search := *bc.opts.SeedSearch
+	var req *http.Request
+	var err error
+	switch search.Engine {
+	case "google":
+		params := url.Values{
+			"key":   {search.Key},
+			"cx":    {search.CX},
+			"q":     {query},
+			"start": {strconv.Itoa(page*searchPageSizes["google"] + 1)},
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, googleSearchEndpoint+"?"+params.Encode(), nil)
+	case "brave":
+		params := url.Values{
+			"q":      {query},
+			"count":  {strconv.Itoa(searchPageSizes["brave"])},
+			"offset": {strconv.Itoa(page)},
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, braveSearchEndpoint+"?"+params.Encode(), nil)
+		if err == nil {
+			req.Header.Set("X-Subscription-Token", search.Key)
+			req.Header.Set("Accept", "application/json")
+		}
+	default:
+		return nil, fmt.Errorf("unknown search engine %q", search.Engine)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := bc.http.Do(req)
+	if err != nil {
+		// url.Error repeats the request URL, which carries Google's key
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("search request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("search request failed: unexpected status %s", resp.Status)
+	}
+
+	var results struct {
+		Items []struct {
+			Link string `json:"link"`
+		} `json:"items"` // Google
+		Web struct {
+			Results []struct {
+				URL string `json:"url"`
+			} `json:"results"`
+		} `json:"web"` // Brave
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("failed to parse search results: %w", err)
+	}
+
+	var links []string
+	for _, item := range results.Items {
+		links = append(links, item.Link)
+	}
+	for _, result := range results.Web.Results {
+		links = append(links, result.URL)
+	}
+	return links, nil
+}
+
+// seedFromSearch asks the search engine for the blog's pages and adds
+// those that look like posts to urlSet. Search engines remember old posts
+// long after the listing stopped linking to them.
+func (bc *BlogCrawler) seedFromSearch(ctx context.Context, urlSet map[string]bool) {
+	search := *bc.opts.SeedSearch
+	baseURL, err := url.Parse(bc.baseURL)
+	if err != nil {
+		bc.warnf("Error searching %s: %v", search.Engine, err)
+		return
+	}
+	limit := searchMaxResults[search.Engine]
+	if search.Limit > 0 {
+		limit = min(search.Limit, limit)
+	}
+	query := siteQuery(bc.baseURL)
+	bc.printf("Searching %s for %s...\n", search.Engine, query)
+
+	added := 0
+	for page := 0; page*searchPageSizes[search.Engine] < limit && ctx.Err() == nil; page++ {
+		links, err := bc.searchPage(ctx, query, page)
+		if err != nil {
+			bc.warnf("Error searching %s for %s: %v", search.Engine, query, err)
+			break
+		}
+		if len(links) == 0 {
+			break
+		}
+
+		bc.setSource("search-engine", page+1, "")
+		for _, link := range links {
+			normalizedURL, err := bc.normalizeURL(link, true)
+			if err != nil {
+				continue
+			}
+			if parsed, err := url.Parse(normalizedURL); err != nil || parsed.Host != baseURL.Host {
+				continue
+			}
+			ok, rule := bc.classifyURL(normalizedURL)
+			bc.explain(normalizedURL, ok, rule)
+			bc.scriptLinkFound(normalizedURL, ok, rule)
+			if !ok {
+				continue
+			}
+			normalizedURL = bc.canonicalURL(normalizedURL)
+			bc.recordDiscovery(normalizedURL, search.Engine)
+			if !urlSet[normalizedURL] {
+				urlSet[normalizedURL] = true
+				added++
+			}
+		}
+	}
+	bc.printf("Search found %d posts the listing didn't (total: %d unique URLs)\n", added, len(urlSet))
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// redactedValue replaces secrets in logs and results
+const redactedValue = "[redacted]"
+
+// minSecretLength is the shortest value redacted, so a short one like "1"
+// doesn't blank out every digit of a log line
+const minSecretLength = 6
+
+// secretFlags are the flags holding credentials, by the environment
+// variable each falls back to ("" for none). Their values never appear in
+// the run block of a result.
+var secretFlags = map[string]string{
+	"telegram-token":      "TELEGRAM_BOT_TOKEN",
+	"pocket-consumer-key": "POCKET_CONSUMER_KEY",
+	"pocket-access-token": "POCKET_ACCESS_TOKEN",
+	"readwise-token":      "READWISE_TOKEN",
+	"instapaper-password": "INSTAPAPER_PASSWORD",
+	"embedding-key":       "EMBEDDING_API_KEY",
+	"seed-search-key":     "SEARCH_API_KEY",
+	"postgres-dsn":        "",
+}
+
+// secretStore holds the values of the secrets file and every secret the
+// run uses, which redact removes from what the crawler prints and saves
+type secretStore struct {
+	mu     sync.Mutex
+	values map[string]string // NAME -> value from the secrets file
+	known  map[string]bool   // Secret values in use
+}
+
+// secrets is the process's secret store, filled by main
+var secrets = &secretStore{}
+
+// defaultSecretsPath is where the secrets file is looked for without
+// --secrets or $BLOGCRAWLER_SECRETS: ~/.config/blogcrawler/secrets on Linux
+func defaultSecretsPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(configDir, "blogcrawler", "secrets")
+}
+
+// secretsPath returns the secrets file given with --secrets, falling back
+// to $BLOGCRAWLER_SECRETS
+func secretsPath(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return os.Getenv(configEnvPrefix + "SECRETS")
+}
+
+// loadSecrets reads the secrets file at path, the default path when empty,
+// where a missing file is fine. It returns the path read, "" for none.
+//
+// The file has NAME=value lines, like a .env file, named after the
+// environment variables they stand in for. It must not be readable by
+// other users.
+func loadSecrets(path string) (string, error) {
+	if path == "" {
+		path = defaultSecretsPath()
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return "", nil // Having no secrets file is fine
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open secrets file: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to open secrets file: %w", err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return "", fmt.Errorf("secrets file %s is accessible by other users (mode %v); run chmod 600 %s", path, info.Mode().Perm(), path)
+	}
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		name = strings.TrimSpace(name)
+		if !ok || !configVarPattern.MatchString("${"+name+"}") {
+			return "", fmt.Errorf("%s:%d: expected NAME=value", path, lineNum)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read secrets file: %w", err)
+	}
+
+	secrets.mu.Lock()
+	secrets.values = values
+	secrets.mu.Unlock()
+	return path, nil
+}
+
+// lookupSecret returns the environment variable name, falling back to the
+// secrets file. The value found is redacted from then on.
+func lookupSecret(name string) (string, bool) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		value, ok = secretsFileValue(name)
+	}
+	if ok {
+		trackSecret(value)
+	}
+	return value, ok
+}
+
+// secretsFileValue returns the value of name in the secrets file
+func secretsFileValue(name string) (string, bool) {
+	secrets.mu.Lock()
+	defer secrets.mu.Unlock()
+	value, ok := secrets.values[name]
+	return value, ok
+}
+
+// trackSecret marks value as a secret for redact
+func trackSecret(value string) {
+	if len(value) < minSecretLength {
+		return
+	}
+	secrets.mu.Lock()
+	defer secrets.mu.Unlock()
+	if secrets.known == nil {
+		secrets.known = make(map[string]bool)
+	}
+	secrets.known[value] = true
+}
+
+// trackDSN marks a PostgreSQL connection string and its password as
+// secrets, since the driver's errors can carry the password alone
+func trackDSN(dsn string) {
+	trackSecret(dsn)
+	trackSecret(dsnPassword(dsn))
+}
+
+// dsnPassword returns the password of a postgres:// URL or a key=value
+// connection string, "" when it has none
+func dsnPassword(dsn string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		parsed, err := url.Parse(dsn)
+		if err != nil || parsed.User == nil {
+			return ""
+		}
+		password, _ := parsed.User.Password()
+		return password
+	}
+
+	rest := dsn
+	for {
+		i := strings.Index(rest, "password")
+		if i < 0 {
+			return ""
+		}
+		before, after := rest[:i], strings.TrimLeft(rest[i+len("password"):], " ")
+		rest = rest[i+len("password"):]
+		if (before != "" && !strings.HasSuffix(before, " ")) || !strings.HasPrefix(after, "=") {
+			continue // Part of another key or value
+		}
+		value := strings.TrimLeft(after[1:], " ")
+		if quoted, ok := strings.CutPrefix(value, "'"); ok {
+			// Quoted values may hold spaces and \' or \\ escapes
+			var password strings.Builder
+			for i := 0; i < len(quoted) && quoted[i] != '\''; i++ {
+				if quoted[i] == '\\' && i+1 < len(quoted) {
+					i++
+				}
+				password.WriteByte(quoted[i])
+			}
+			return password.String()
+		}
+		if end := strings.IndexByte(value, ' '); end >= 0 {
+			value = value[:end]
+		}
+		return value
+	}
+}
+
+// redact replaces every secret in s with [redacted]
+func redact(s string) string {
+	secrets.mu.Lock()
+	known := make([]string, 0, len(secrets.known))
+	for value := range secrets.known {
+		known = append(known, value)
+	}
+	secrets.mu.Unlock()
+
+	// Longest first, so a secret containing another is replaced whole
+	sort.Slice(known, func(i, j int) bool { return len(known[i]) > len(known[j]) })
+	for _, value := range known {
+		s = strings.ReplaceAll(s, value, redactedValue)
+	}
+	return s
+}
+
+// redactFlag returns the value of a flag as the run block records it:
+// secret flags and PostgreSQL connection strings are replaced whole,
+// webhook URLs, which carry their token in the path, keep only their host,
+// and other values have known secrets redacted
+func redactFlag(name, value string) string {
+	if _, secret := secretFlags[name]; secret && value != "" {
+		return redactedValue
+	}
+	if name == "output" {
+		specs := strings.Split(value, ", ")
+		for i, spec := range specs {
+			if target, ok := strings.CutPrefix(spec, "webhook:"); ok {
+				specs[i] = "webhook:" + redactURL(target)
+			} else if strings.HasPrefix(spec, "postgres:") {
+				specs[i] = "postgres:" + redactedValue
+			}
+		}
+		value = strings.Join(specs, ", ")
+	}
+	return redact(value)
+}
+
+// redactURL keeps only the scheme and host of a URL
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return redactedValue
+	}
+	return parsed.Scheme + "://" + parsed.Host + "/" + redactedValue
+}
+
+// commandLineSecrets returns the secret flags set so far, which before
+// the config is applied are those given on the command line
+func commandLineSecrets(fs *flag.FlagSet) []string {
+	var names []string
+	fs.Visit(func(f *flag.Flag) {
+		if _, secret := secretFlags[f.Name]; secret {
+			names = append(names, f.Name)
+		}
+	})
+	return names
+}
+
+// warnSecretFlags warns about secrets given on the command line, where
+// they end up in the shell history and the process list
+func warnSecretFlags(out io.Writer, names []string) {
+	for _, name := range names {
+		env := secretFlags[name]
+		if env == "" {
+			env = configEnvName(name)
+		}
+		fmt.Fprintf(out, "Warning: --%s on the command line is visible to other users; set %s or put it in the secrets file instead\n", name, env)
+	}
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/redis/go-redis/v9"
+
+	"manual-blog-crawler/urlfilter"
+)
+
+// seedSkipHosts are sites aggregator pages link to that are never blogs
+// worth crawling: code hosts, social networks and the like
+var seedSkipHosts = []string{
+	"github.com", "gitlab.com", "twitter.com", "x.com", "t.co", "facebook.com",
+	"instagram.com", "youtube.com", "reddit.com", "news.ycombinator.com",
+	"wikipedia.org", "creativecommons.org",
+}
+
+// opmlOutline is one outline of an OPML document as found in the wild,
+// where feeds are often grouped into nested folders
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	XMLURL   string        `xml:"xmlUrl,attr"`
+	HTMLURL  string        `xml:"htmlUrl,attr"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+// loadSeeds extracts blog base URLs from source, which is either an OPML
+// export (local file or URL) or an aggregator page listing blogs. Loading
+// an aggregator page prints its progress to out.
+func loadSeeds(ctx context.Context, source string, timeout time.Duration, out io.Writer) ([]string, error) {
+	parsedURL, err := url.Parse(source)
+	isRemote := err == nil && (parsedURL.Scheme == "http" || parsedURL.Scheme == "https")
+
+	if !isRemote {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", source, err)
+		}
+		return seedsFromOPML(data)
+	}
+
+	lowerPath := strings.ToLower(parsedURL.Path)
+	if strings.HasSuffix(lowerPath, ".opml") || strings.HasSuffix(lowerPath, ".xml") {
+		data, err := fetchSeedList(ctx, source, timeout)
+		if err != nil {
+			return nil, err
+		}
+		return seedsFromOPML(data)
+	}
+
+	return seedsFromPage(ctx, source, timeout, out)
+}
+
+func fetchSeedList(ctx context.Context, source string, timeout time.Duration) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request for %s: %w", source, err)
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", source, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s returned %s", source, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// seedsFromOPML returns the site URL of every feed in an OPML document,
+// falling back to the feed's own site root when htmlUrl is missing
+func seedsFromOPML(data []byte) ([]string, error) {
+	var doc struct {
+		Body []opmlOutline `xml:"body>outline"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OPML: %w", err)
+	}
+
+	var seeds []string
+	var walk func(outlines []opmlOutline)
+	walk = func(outlines []opmlOutline) {
+		for _, outline := range outlines {
+			if outline.HTMLURL != "" {
+				seeds = append(seeds, outline.HTMLURL)
+			} else if feedURL, err := url.Parse(outline.XMLURL); err == nil && feedURL.Host != "" {
+				seeds = append(seeds, feedURL.Scheme+"://"+feedURL.Host+"/")
+			}
+			walk(outline.Outlines)
+		}
+	}
+	walk(doc.Body)
+
+	return dedupeSeeds(seeds), nil
+}
+
+// seedOutputName derives a file name for a seed's result from its host and
+// path, e.g. medium.com/netflix-techblog -> medium-com-netflix-techblog
+func seedOutputName(seed string) string {
+	name := seed
+	if parsedURL, err := url.Parse(seed); err == nil {
+		name = parsedURL.Host + parsedURL.Path
+	}
+	return strings.Trim(unsafeFileChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// seedsFromPage renders an aggregator page in the browser and returns the
+// external links that look like blogs
+func seedsFromPage(ctx context.Context, pageURL string, timeout time.Duration, out io.Writer) ([]string, error) {
+	bc := NewBlogCrawler(pageURL, timeout, CrawlOptions{Output: out})
+	if err := bc.initializeBrowser(ctx); err != nil {
+		return nil, err
+	}
+	defer bc.closeBrowser(context.Background())
+
+	if err := bc.navigateToPage(ctx); err != nil {
+		return nil, err
+	}
+	if err := bc.waitForContent(ctx); err != nil {
+		bc.printf("Warning: Timeout waiting for content on %s: %v\n", pageURL, err)
+	}
+
+	linksCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	anchors, err := bc.anchors(linksCtx, "a[href]")
+	if err != nil {
+		return nil, err
+	}
+
+	aggregatorURL, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base URL: %w", err)
+	}
+
+	var seeds []string
+	for _, a := range anchors {
+		normalizedURL, err := bc.normalizeURL(a.Href, false)
+		if err != nil {
+			continue
+		}
+		if isSeedURL(normalizedURL, aggregatorURL.Host) {
+			seeds = append(seeds, normalizedURL)
+		}
+	}
+
+	return dedupeSeeds(seeds), nil
+}
+
+// isSeedURL keeps external http(s) links that aren't on a known non-blog host
+func isSeedURL(link, aggregatorHost string) bool {
+	parsedURL, err := url.Parse(link)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		return false
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(parsedURL.Host), "www.")
+	if host == "" || host == strings.TrimPrefix(strings.ToLower(aggregatorHost), "www.") {
+		return false
+	}
+	return !urlfilter.HostMatches(host, seedSkipHosts)
+}
+
+// dedupeSeeds drops repeated seeds, treating URLs that differ only in a
+// trailing slash as the same blog, and keeps the original order
+func dedupeSeeds(seeds []string) []string {
+	seen := make(map[string]bool, len(seeds))
+	unique := make([]string, 0, len(seeds))
+	for _, seed := range seeds {
+		key := strings.TrimSuffix(seed, "/")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, seed)
+	}
+	return unique
+}
+
+// enqueueSeeds publishes one crawl job per seed onto the requests list or
+// subject that workers consume
+func enqueueSeeds(ctx context.Context, queueURL, requests string, seeds []string, fetchContent bool) error {
+	parsedURL, err := url.Parse(queueURL)
+	if err != nil {
+		return fmt.Errorf("failed to parse queue URL: %w", err)
+	}
+
+	var publish func(ctx context.Context, payload []byte) error
+	switch parsedURL.Scheme {
+	case "redis", "rediss":
+		options, err := redis.ParseURL(queueURL)
+		if err != nil {
+			return fmt.Errorf("failed to parse Redis URL: %w", err)
+		}
+		client := redis.NewClient(options)
+		defer client.Close()
+		publish = func(ctx context.Context, payload []byte) error {
+			return client.RPush(ctx, requests, payload).Err()
+		}
+
+	case "nats", "tls":
+		conn, err := nats.Connect(queueURL)
+		if err != nil {
+			return fmt.Errorf("failed to connect to NATS: %w", err)
+		}
+		defer conn.Close()
+		publish = func(ctx context.Context, payload []byte) error {
+			if err := conn.Publish(requests, payload); err != nil {
+				return err
+			}
+			return conn.FlushWithContext(ctx)
+		}
+
+	default:
+		return fmt.Errorf("unsupported queue scheme %q (use redis:// or nats://)", parsedURL.Scheme)
+	}
+
+	batch := time.Now().Unix()
+	for i, seed := range seeds {
+		job := CrawlJob{ID: fmt.Sprintf("seed-%d-%d", batch, i+1), BaseURL: seed, FetchContent: fetchContent}
+		payload, err := json.Marshal(job)
+		if err != nil {
+			return fmt.Errorf("failed to encode job for %s: %w", seed, err)
+		}
+		if err := publish(ctx, payload); err != nil {
+			return fmt.Errorf("failed to enqueue %s: %w", seed, err)
+		}
+	}
+	return nil
+}
+
+// runSeeds implements the seeds subcommand
+func runSeeds(args []string) {
+	fs := flag.NewFlagSet("seeds", flag.ExitOnError)
+	queueURL := fs.String("queue", "", "enqueue a crawl job per seed on this queue (redis://... or nats://...) instead of printing them")
+	requests := fs.String("requests", "blogcrawler:requests", "Redis list or NATS subject carrying crawl jobs")
+	fetchContent := fs.Bool("fetch-content", false, "ask workers to fetch each post's content")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . seeds [--queue URL] [--requests NAME] <aggregator_url | feeds.opml>")
+		fmt.Println()
+		fmt.Println("Flags:")
+		fs.PrintDefaults()
+	}
+	positional, _ := parseArgs(fs, args)
+	if len(positional) != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	seeds, err := loadSeeds(ctx, positional[0], 30*time.Second, os.Stdout)
+	if err != nil {
+		fmt.Printf("Error loading seeds: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *queueURL == "" {
+		for _, seed := range seeds {
+			fmt.Println(seed)
+		}
+		return
+	}
+
+	if err := enqueueSeeds(ctx, *queueURL, *requests, seeds, *fetchContent); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Enqueued %d crawl jobs on %s (%s)\n", len(seeds), *queueURL, *requests)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// postLinkSelectors find post links on listing pages, tried in order.
+// Priority: Uber-specific first, then generic
+var postLinkSelectors = []string{
+	uberCardSelector,                       // Uber blog posts (specific)
+	"article a[href]",                      // Links in articles
+	"h2 a[href]",                           // Links in h2 headings
+	"h3 a[href]",                           // Links in h3 headings
+	"[data-testid='post-preview-title'] a", // Medium specific
+	".post-title a",                        // Generic post title
+	".blog-post a",                         // Generic blog post
+	fallbackSelector,                       // All links (fallback)
+}
+
+const fallbackSelector = "a[href]"
+
+// SelectorStat records how well one of postLinkSelectors worked over a crawl
+type SelectorStat struct {
+	Selector string `json:"selector"`
+	Matched  int    `json:"matched"` // Elements matched, summed over all listing pages
+	URLs     int    `json:"urls"`    // Accepted post URLs no earlier selector had found
+}
+
+// recordSelector adds one selector run on a listing page to the crawl's
+// selector stats. A post URL counts for the first selector that found it.
+// index is into bc.postLinkSelectors().
+func (bc *BlogCrawler) recordSelector(index, matched int, accepted []string) {
+	if bc.selectorStats == nil {
+		selectors := bc.postLinkSelectors()
+		bc.selectorStats = make([]SelectorStat, len(selectors))
+		for i, selector := range selectors {
+			bc.selectorStats[i].Selector = selector
+		}
+		bc.attributed = make(map[string]bool)
+	}
+
+	stat := &bc.selectorStats[index]
+	stat.Matched += matched
+	for _, postURL := range accepted {
+		if !bc.attributed[postURL] {
+			bc.attributed[postURL] = true
+			stat.URLs++
+		}
+	}
+}
+
+// printSelectorStats prints the selector hit rates and points out when only
+// the catch-all fallback found posts, which usually means the site's markup
+// changed and its selectors need updating
+func printSelectorStats(w io.Writer, stats []SelectorStat) {
+	if len(stats) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\nSelector hit rates:\n")
+	specific := 0
+	for _, stat := range stats {
+		fmt.Fprintf(w, "  %-40s %6d matched %6d posts\n", stat.Selector, stat.Matched, stat.URLs)
+		if stat.Selector != fallbackSelector {
+			specific += stat.URLs
+		}
+	}
+
+	fallback := stats[len(stats)-1]
+	if specific == 0 && fallback.URLs > 0 {
+		fmt.Fprintf(w, "Note: only the fallback selector %s found posts; the selectors may be out of date for this site\n", fallbackSelector)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// resultSink receives the finished crawl result in addition to the JSON file
+type resultSink interface {
+	Write(ctx context.Context, result *CrawlResult) error
+	Close() error
+}
+
+// PostRecord is the per-post unit published by streaming and database sinks
+type PostRecord struct {
+	URL        string `json:"url"`
+	BaseURL    string `json:"base_url"`
+	CrawledAt  string `json:"crawled_at"`
+	IsNew      bool   `json:"is_new,omitempty"`
+	MemberOnly bool   `json:"member_only,omitempty"` // Medium: the listing marks the post member-only
+	Post       *Post  `json:"post,omitempty"`        // Present when a per-post pass ran
+}
+
+// postRecords flattens a result into one record per discovered URL
+func postRecords(result *CrawlResult) []PostRecord {
+	posts := make(map[string]*Post, len(result.Posts))
+	for i := range result.Posts {
+		posts[result.Posts[i].URL] = &result.Posts[i]
+	}
+	isNew := make(map[string]bool, len(result.New))
+	for _, url := range result.New {
+		isNew[url] = true
+	}
+	memberOnly := make(map[string]bool, len(result.MemberOnly))
+	for _, url := range result.MemberOnly {
+		memberOnly[url] = true
+	}
+
+	records := make([]PostRecord, 0, len(result.BlogURLs))
+	for _, url := range result.BlogURLs {
+		records = append(records, PostRecord{
+			URL:        url,
+			BaseURL:    result.BaseURL,
+			CrawledAt:  result.CrawledAt,
+			IsNew:      isNew[url],
+			MemberOnly: memberOnly[url],
+			Post:       posts[url],
+		})
+	}
+	return records
+}
+
+// fileSink writes the result document to a file in one of the output formats,
+// or through a template when one is set
+type fileSink struct {
+	path     string
+	format   string
+	template *template.Template
+	compress string
+	out      io.Writer // Where the saved file is reported
+}
+
+func (f *fileSink) Write(ctx context.Context, result *CrawlResult) error {
+	path, err := writeOutputFile(f.path, f.compress, func(w io.Writer) error {
+		if f.template != nil {
+			return writeWithTemplate(w, result, f.template)
+		}
+		return writeResult(w, result, f.format)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(f.out, "Results saved to: %s\n", path)
+	return nil
+}
+
+// writeCompressed runs write against w through the given compression
+func writeCompressed(w io.Writer, compress string, write func(io.Writer) error) error {
+	cw, err := compressWriter(w, compress)
+	if err != nil {
+		return err
+	}
+	if err := write(cw); err != nil {
+		cw.Close()
+		return err
+	}
+	return cw.Close()
+}
+
+// writeOutputFile creates path (with the compression suffix added) and fills
+// it through write, returning the path actually written
+func writeOutputFile(path, compress string, write func(io.Writer) error) (string, error) {
+	path = compressedPath(path, compress)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	if err := writeCompressed(file, compress, write); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return path, nil
+}
+
+func (f *fileSink) Close() error {
+	return nil
+}
+
+// outputSpecs collects repeated --output flags
+type outputSpecs []string
+
+func (o *outputSpecs) String() string {
+	return strings.Join(*o, ", ")
+}
+
+func (o *outputSpecs) Set(value string) error {
+	*o = append(*o, value)
+	return nil
+}
+
+// sinkDefaults carries the settings of sinks that are configured by flags
+// beyond their --output target
+type sinkDefaults struct {
+	kafkaKey         string
+	kafkaPartitioner string
+	postgresTable    string
+	compress         string
+	chunkSize        int
+	chunkOverlap     int
+	embedder         *embeddingClient // Embeds chunks when set
+	output           io.Writer        // Where sinks report what they wrote
+}
+
+// parseOutputSpec builds a sink from a TYPE:TARGET spec such as
+// json:results.json, sqlite:crawl.db or webhook:https://example.com/hook
+func parseOutputSpec(spec string, defaults sinkDefaults) (resultSink, error) {
+	kind, target, ok := strings.Cut(spec, ":")
+	if !ok || target == "" {
+		return nil, fmt.Errorf("invalid output %q (expected TYPE:TARGET)", spec)
+	}
+
+	switch kind {
+	case "json", "ndjson", "rss", "atom", "html", "dot", "graphml", "graph-json":
+		return &fileSink{path: target, format: kind, compress: defaults.compress, out: defaults.output}, nil
+
+	case "template":
+		// template:TEMPLATE_FILE=OUTPUT_FILE
+		templateFile, outputFile, ok := strings.Cut(target, "=")
+		if !ok || templateFile == "" || outputFile == "" {
+			return nil, fmt.Errorf("invalid template output %q (expected template:TEMPLATE=OUTPUT)", spec)
+		}
+		tmpl, err := loadOutputTemplate(templateFile)
+		if err != nil {
+			return nil, err
+		}
+		return &fileSink{path: outputFile, template: tmpl, compress: defaults.compress, out: defaults.output}, nil
+
+	case "sqlite":
+		return newSQLiteSink(target)
+
+	case "webhook":
+		trackSecret(target) // Webhook URLs are credentials, like Slack's
+		return newWebhookSink(target), nil
+
+	case "bleve":
+		return newBleveSink(target, defaults.output)
+
+	case "chunks":
+		return &chunkSink{
+			path:     target,
+			compress: defaults.compress,
+			size:     defaults.chunkSize,
+			overlap:  defaults.chunkOverlap,
+			embedder: defaults.embedder,
+			out:      defaults.output,
+		}, nil
+
+	case "kafka":
+		// kafka:BROKER[,BROKER...]/TOPIC
+		brokers, topic, ok := strings.Cut(target, "/")
+		if !ok || topic == "" {
+			return nil, fmt.Errorf("invalid kafka output %q (expected kafka:BROKERS/TOPIC)", spec)
+		}
+		return newKafkaSink(strings.Split(brokers, ","), topic, defaults.kafkaKey, defaults.kafkaPartitioner)
+
+	case "postgres":
+		return newPostgresSink(target, defaults.postgresTable)
+	}
+
+	return nil, fmt.Errorf("unknown output type %q (use json, ndjson, rss, atom, html, dot, graphml, graph-json, template, chunks, sqlite, bleve, webhook, kafka or postgres)", kind)
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"manual-blog-crawler/urlfilter"
+)
+
+// siteProfile holds the settings for blogs whose base URL matches Match
+type siteProfile struct {
+	Match           string                   `json:"match"` // Host, optionally followed by a path prefix: "example.com/blog"
+	QueryParams     urlfilter.QueryParamRule `json:"query_params"`
+	Budget          crawlBudget              `json:"budget"`               // Overrides the command line's budget field by field
+	Emulation       browserEmulation         `json:"emulation"`            // Overrides the command line's viewport, timezone and Accept-Language field by field
+	Script          string                   `json:"script,omitempty"`     // Starlark file with hooks, relative to the profiles file
+	Inject          string                   `json:"inject,omitempty"`     // JavaScript run on every page once it has loaded
+	SearchURL       string                   `json:"search_url,omitempty"` // Search results template for the search strategy, see searchPageURL
+	ExcludePatterns urlfilter.ExcludeRule    `json:"exclude_patterns"`
+	CategoryPages   []string                 `json:"category_pages,omitempty"` // Category page patterns, see urlfilter.CategoryPattern
+
+	PostLinkSelectors []string `json:"post_link_selectors,omitempty"` // Tried before postLinkSelectors
+	NextLinkSelectors []string `json:"next_link_selectors,omitempty"` // Tried before nextLinkSelectors
+	Strategy          string   `json:"strategy,omitempty"`            // Used when --strategy is auto, see crawlStrategies
+	PostPattern       string   `json:"post_pattern,omitempty"`        // Regular expression a post URL's path matches; other links aren't posts
+
+	scriptFile  string // Script resolved against the profiles file
+	script      *siteScript
+	postPattern *regexp.Regexp
+}
+
+// siteConfig is the file format of --sites
+type siteConfig struct {
+	Sites []siteProfile `json:"sites"`
+}
+
+// builtinSiteProfiles cover the sites the crawler has special support for.
+// Profiles from --sites are tried first and so override them.
+var builtinSiteProfiles = []siteProfile{
+	// Uber post links carry parameters like ?uclick_id=... that are kept
+	{
+		Match:         "uber.com",
+		QueryParams:   urlfilter.QueryParamRule{Keep: []string{"*"}},
+		CategoryPages: urlfilter.UberCategoryPages,
+	},
+	{Match: "linkedin.com/blog", CategoryPages: urlfilter.LinkedInCategoryPages},
+}
+
+// loadSiteProfiles reads a --sites file
+func loadSiteProfiles(filename string) ([]siteProfile, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read site profiles: %w", err)
+	}
+
+	var config siteConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse site profiles %s: %w", filename, err)
+	}
+	return checkSiteProfiles(config.Sites, filename)
+}
+
+// checkSiteProfiles validates the profiles read from filename and resolves
+// their scripts, which are relative to it. The scripts are run later by
+// loadSiteScripts, once it's known where their output goes.
+func checkSiteProfiles(profiles []siteProfile, filename string) ([]siteProfile, error) {
+	for i, profile := range profiles {
+		if profile.Match == "" {
+			return nil, fmt.Errorf("site profile %d in %s has no match", i+1, filename)
+		}
+		if profile.SearchURL != "" && !validSearchURL(profile.SearchURL) {
+			return nil, fmt.Errorf("search_url of site profile %d in %s has no {page} or {page0}", i+1, filename)
+		}
+		if err := urlfilter.ValidateExcludes(profile.ExcludePatterns.Add); err != nil {
+			return nil, fmt.Errorf("site profile %d in %s: %w", i+1, filename, err)
+		}
+		if err := urlfilter.ValidateCategories(profile.CategoryPages); err != nil {
+			return nil, fmt.Errorf("site profile %d in %s: %w", i+1, filename, err)
+		}
+		if profile.Strategy != "" && !contains(crawlStrategies, profile.Strategy) {
+			return nil, fmt.Errorf("strategy of site profile %d in %s must be one of %s", i+1, filename, strings.Join(crawlStrategies, ", "))
+		}
+		if err := profile.Emulation.validate(); err != nil {
+			return nil, fmt.Errorf("emulation of site profile %d in %s: %w", i+1, filename, err)
+		}
+		if profile.PostPattern != "" {
+			pattern, err := regexp.Compile(profile.PostPattern)
+			if err != nil {
+				return nil, fmt.Errorf("post_pattern of site profile %d in %s: %w", i+1, filename, err)
+			}
+			profiles[i].postPattern = pattern
+		}
+		if profile.Script != "" {
+			scriptFile := profile.Script
+			if !filepath.IsAbs(scriptFile) {
+				scriptFile = filepath.Join(filepath.Dir(filename), scriptFile)
+			}
+			profiles[i].scriptFile = scriptFile
+		}
+	}
+	return profiles, nil
+}
+
+// loadSiteScripts loads the scripts of the profiles, printing what they
+// print while loading to out
+func loadSiteScripts(profiles []siteProfile, out io.Writer) error {
+	for i, profile := range profiles {
+		if profile.scriptFile == "" {
+			continue
+		}
+		script, err := loadSiteScript(profile.scriptFile, out)
+		if err != nil {
+			return err
+		}
+		profiles[i].script = script
+	}
+	return nil
+}
+
+// matches reports whether the profile applies to the blog at baseURL
+func (p siteProfile) matches(baseURL *url.URL) bool {
+	host, prefix, _ := strings.Cut(strings.ToLower(p.Match), "/")
+	baseHost := strings.TrimPrefix(strings.ToLower(baseURL.Hostname()), "www.")
+	if !urlfilter.HostMatches(baseHost, []string{strings.TrimPrefix(host, "www.")}) {
+		return false
+	}
+
+	basePath := strings.Trim(strings.ToLower(baseURL.Path), "/") + "/"
+	return prefix == "" || strings.HasPrefix(basePath, strings.Trim(prefix, "/")+"/")
+}
+
+// siteProfileFor returns the first of profiles, then of the built-in
+// profiles, that matches baseURL, or an empty profile
+func siteProfileFor(baseURL string, profiles []siteProfile) siteProfile {
+	parsedURL, err := url.Parse(baseURL)
+	if err != nil {
+		return siteProfile{}
+	}
+
+	for _, candidates := range [][]siteProfile{profiles, builtinSiteProfiles} {
+		for _, profile := range candidates {
+			if profile.matches(parsedURL) {
+				return profile
+			}
+		}
+	}
+	return siteProfile{}
+}
+
+// postLinkSelectors returns the selectors finding post links on this
+// blog's listing pages: the site profile's, then the generic ones
+func (bc *BlogCrawler) postLinkSelectors() []string {
+	return append(append([]string(nil), bc.site.PostLinkSelectors...), postLinkSelectors...)
+}
+
+// nextLinkSelectors returns the selectors finding this blog's next page
+// link: the site profile's, then the generic ones
+func (bc *BlogCrawler) nextLinkSelectors() []string {
+	return append(append([]string(nil), bc.site.NextLinkSelectors...), nextLinkSelectors...)
+}
+
+// queryParamRule returns the rule for this crawl's post links: the one from
+// the command line if given, otherwise the site profile's
+func (bc *BlogCrawler) queryParamRule() urlfilter.QueryParamRule {
+	if len(bc.opts.KeepQueryParams) > 0 || len(bc.opts.DropQueryParams) > 0 {
+		return urlfilter.QueryParamRule{Keep: bc.opts.KeepQueryParams, Drop: bc.opts.DropQueryParams}
+	}
+	return bc.site.QueryParams
+}
+
+// injectSiteJS runs the site profile's Inject snippet on the loaded page,
+// for things like expanding collapsed sections or picking the "All posts"
+// filter, and waits for the page to settle again. The snippet is the body
+// of an async function, so it may await.
+func (bc *BlogCrawler) injectSiteJS(ctx context.Context, pageURL string) {
+	if bc.site.Inject == "" {
+		return
+	}
+
+	injectCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if _, err := bc.page.Eval(injectCtx, "async () => {\n"+bc.site.Inject+"\n}"); err != nil {
+		bc.warnf("Injected JavaScript failed on %s: %v", pageURL, err)
+		return
+	}
+	if err := bc.waitForContent(ctx); err != nil {
+		bc.warnf("Timeout waiting for content on %s after the injected JavaScript: %v", pageURL, err)
+	}
+}
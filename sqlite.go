@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteSchema keeps every run, the latest known metadata of every post and
+// which posts each run saw, so history can be queried across runs
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	base_url    TEXT NOT NULL,
+	crawled_at  TEXT NOT NULL,
+	total_count INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS posts (
+	url          TEXT PRIMARY KEY,
+	base_url     TEXT NOT NULL,
+	first_seen   TEXT NOT NULL,
+	last_seen    TEXT NOT NULL,
+	title        TEXT,
+	published    TEXT,
+	category     TEXT,
+	content_hash TEXT
+);
+CREATE TABLE IF NOT EXISTS run_posts (
+	run_id INTEGER NOT NULL REFERENCES runs(id),
+	url    TEXT NOT NULL,
+	PRIMARY KEY (run_id, url)
+);
+CREATE INDEX IF NOT EXISTS posts_base_url ON posts(base_url);
+`
+
+// sqliteSink records results in a local SQLite database
+type sqliteSink struct {
+	db *sql.DB
+}
+
+func openSQLite(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+	// SQLite allows a single writer; one connection avoids "database is locked"
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create sqlite schema: %w", err)
+	}
+	return db, nil
+}
+
+func newSQLiteSink(path string) (*sqliteSink, error) {
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteSink{db: db}, nil
+}
+
+// nullable stores empty strings as NULL so COALESCE keeps earlier values
+func nullable(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func (ss *sqliteSink) Write(ctx context.Context, result *CrawlResult) error {
+	crawledAt := result.CrawledAt
+	if crawledAt == "" {
+		crawledAt = time.Now().Format(time.RFC3339)
+	}
+
+	tx, err := ss.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO runs (base_url, crawled_at, total_count) VALUES (?, ?, ?)`,
+		result.BaseURL, crawledAt, result.TotalCount)
+	if err != nil {
+		return fmt.Errorf("failed to record run: %w", err)
+	}
+	runID, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to read run id: %w", err)
+	}
+
+	for _, record := range postRecords(result) {
+		var post Post
+		if record.Post != nil {
+			post = *record.Post
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO posts (url, base_url, first_seen, last_seen, title, published, category, content_hash)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (url) DO UPDATE SET
+				base_url     = excluded.base_url,
+				last_seen    = MAX(posts.last_seen, excluded.last_seen),
+				title        = COALESCE(excluded.title, posts.title),
+				published    = COALESCE(excluded.published, posts.published),
+				category     = COALESCE(excluded.category, posts.category),
+				content_hash = COALESCE(excluded.content_hash, posts.content_hash)`,
+			record.URL, record.BaseURL, crawledAt, crawledAt,
+			nullable(post.Title), nullable(post.Published), nullable(post.Category), nullable(post.ContentHash))
+		if err != nil {
+			return fmt.Errorf("failed to upsert %s: %w", record.URL, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO run_posts (run_id, url) VALUES (?, ?)`, runID, record.URL); err != nil {
+			return fmt.Errorf("failed to link %s to run: %w", record.URL, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+func (ss *sqliteSink) Close() error {
+	return ss.db.Close()
+}
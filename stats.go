@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CrawlStats summarizes how a crawl went
+type CrawlStats struct {
+	PagesVisited     int              `json:"pages_visited"` // Page loads, listing and post pages alike
+	ScrollIterations int              `json:"scroll_iterations"`
+	AvgScrollWaitMS  float64          `json:"avg_scroll_wait_ms,omitempty"` // Waiting for each scroll's posts, see waitAfterScroll
+	DurationSeconds  float64          `json:"duration_seconds"`
+	AvgPageLoadMS    float64          `json:"avg_page_load_ms"`
+	URLsByCategory   map[string]int   `json:"urls_by_category,omitempty"`
+	RejectedByRule   map[string]int   `json:"rejected_by_rule,omitempty"` // Distinct links each rule turned down
+	RateLimits       []RateLimit      `json:"rate_limits,omitempty"`      // 429 and 503 responses the crawl waited out
+	BudgetExhausted  string           `json:"budget_exhausted,omitempty"` // Why the crawl stopped early, see crawlBudget
+	DuplicateTitles  []DuplicateTitle `json:"duplicate_titles,omitempty"`
+	StaleAdapters    []StaleAdapter   `json:"stale_adapters,omitempty"` // Built-in adapter checks that failed, see checkAdapter
+	Traffic          *TrafficStats    `json:"traffic,omitempty"`
+	ListingCacheHits int              `json:"listing_cache_hits,omitempty"` // Listing pages unchanged since the cached run, see listingFromCache
+	PaywalledPosts   int              `json:"paywalled_posts,omitempty"`    // Posts whose content stops at a paywall
+}
+
+// uncategorized counts posts whose category isn't known
+const uncategorized = "uncategorized"
+
+// recordLoad counts a finished page load that began at start
+func (bc *BlogCrawler) recordLoad(start time.Time) {
+	bc.loads++
+	bc.loadTime += time.Since(start)
+}
+
+// recordRejection counts a rejected link under its rule, once per link
+func (bc *BlogCrawler) recordRejection(linkURL, rule string) {
+	if bc.rejected == nil {
+		bc.rejected = make(map[string]bool)
+		bc.rejections = make(map[string]int)
+	}
+	if bc.rejected[linkURL] {
+		return
+	}
+	bc.rejected[linkURL] = true
+	bc.rejections[rule]++
+	bc.journal(journalEvent{Event: "rejected", URL: linkURL, Reason: rule})
+}
+
+// stats builds the result's stats block for the final urls and posts
+func (bc *BlogCrawler) stats(started time.Time, urls []string, posts []Post) *CrawlStats {
+	stats := &CrawlStats{
+		PagesVisited:     bc.loads,
+		ScrollIterations: bc.scrolls,
+		DurationSeconds:  time.Since(started).Round(time.Millisecond).Seconds(),
+		URLsByCategory:   make(map[string]int),
+		RejectedByRule:   bc.rejections,
+		RateLimits:       bc.rateLimits,
+		BudgetExhausted:  bc.budget.exhausted(),
+		DuplicateTitles:  bc.duplicates,
+		StaleAdapters:    bc.staleAdapters,
+		Traffic:          bc.traffic.snapshot(),
+		ListingCacheHits: bc.listingCacheHits,
+	}
+	if bc.loads > 0 {
+		stats.AvgPageLoadMS = float64((bc.loadTime / time.Duration(bc.loads)).Milliseconds())
+	}
+	if bc.scrolls > 0 && bc.scrollWait > 0 {
+		stats.AvgScrollWaitMS = float64((bc.scrollWait / time.Duration(bc.scrolls)).Milliseconds())
+	}
+
+	categories := make(map[string]string, len(posts))
+	for _, post := range posts {
+		if post.Category != "" {
+			categories[post.URL] = post.Category
+		}
+		if post.Paywalled {
+			stats.PaywalledPosts++
+		}
+	}
+	for _, postURL := range urls {
+		category, ok := categories[postURL]
+		if !ok {
+			category = bc.urlCategory(postURL)
+		}
+		stats.URLsByCategory[category]++
+	}
+	return stats
+}
+
+// urlCategory guesses a post's category from its URL: the first path
+// segment after the base path, when a slug follows it
+// (/blog/<category>/<slug>). Posts directly under the base path are
+// uncategorized.
+func (bc *BlogCrawler) urlCategory(postURL string) string {
+	parsedURL, err := url.Parse(postURL)
+	if err != nil {
+		return uncategorized
+	}
+	postPath := strings.Trim(parsedURL.Path, "/")
+	if baseURLParsed, err := url.Parse(bc.baseURL); err == nil {
+		basePath := strings.Trim(baseURLParsed.Path, "/")
+		if basePath != "" && strings.HasPrefix(postPath, basePath+"/") {
+			postPath = strings.TrimPrefix(postPath, basePath+"/")
+		}
+	}
+
+	segments := strings.Split(postPath, "/")
+	if len(segments) < 2 {
+		return uncategorized
+	}
+	return segments[0]
+}
+
+// printStats prints the stats block at the end of a crawl
+func printStats(w io.Writer, stats *CrawlStats) {
+	if stats == nil {
+		return
+	}
+
+	fmt.Fprintf(w, "\nCrawl stats:\n")
+	fmt.Fprintf(w, "  %d pages visited, %d scroll iterations in %.1fs (%.0fms average page load)\n",
+		stats.PagesVisited, stats.ScrollIterations, stats.DurationSeconds, stats.AvgPageLoadMS)
+	if stats.AvgScrollWaitMS > 0 {
+		fmt.Fprintf(w, "  %.0fms average wait for a scroll's posts\n", stats.AvgScrollWaitMS)
+	}
+	printTraffic(w, stats.Traffic)
+	printCounts(w, "  URLs by category:", stats.URLsByCategory)
+	printCounts(w, "  Rejected links by rule:", stats.RejectedByRule)
+	if len(stats.RateLimits) > 0 {
+		fmt.Fprintf(w, "  Rate limited %d times\n", len(stats.RateLimits))
+	}
+	if len(stats.DuplicateTitles) > 0 {
+		fmt.Fprintf(w, "  %d titles shared by several URLs\n", len(stats.DuplicateTitles))
+	}
+	if stats.PaywalledPosts > 0 {
+		fmt.Fprintf(w, "  %d posts behind a paywall, their content cut short\n", stats.PaywalledPosts)
+	}
+	if stats.ListingCacheHits > 0 {
+		fmt.Fprintf(w, "  %d listing pages unchanged, taken from the cache\n", stats.ListingCacheHits)
+	}
+	if stats.BudgetExhausted != "" {
+		fmt.Fprintf(w, "  Stopped early: %s\n", stats.BudgetExhausted)
+	}
+	if len(stats.StaleAdapters) > 0 {
+		fmt.Fprintf(w, "  Stale adapters: %s\n", strings.Join(staleAdapterNames(stats.StaleAdapters), ", "))
+	}
+}
+
+// printCounts prints counts under heading, largest first
+func printCounts(w io.Writer, heading string, counts map[string]int) {
+	if len(counts) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	fmt.Fprintln(w, heading)
+	for _, key := range keys {
+		fmt.Fprintf(w, "    %6d  %s\n", counts[key], key)
+	}
+}
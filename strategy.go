@@ -0,0 +1,40 @@
+package main
+
+// crawlStrategies are the values --strategy accepts. "auto" paginates the
+// blogs with known pagination, walks the archive months of Medium
+// publications, follows next links where the first page has one and
+// scrolls everything else, unless a search URL is set, which makes it
+// page through the blog's search. "tabs" is only used when asked for.
+var crawlStrategies = []string{"auto", "scroll", "archive-months", "next-link", "tabs", "search"}
+
+// strategy resolves opts.Strategy for this blog, the site profile's
+// strategy standing in for auto. Strategies that don't apply to it fall
+// back to scrolling, with a warning.
+func (bc *BlogCrawler) strategy() string {
+	strategy := bc.opts.Strategy
+	if (strategy == "" || strategy == "auto") && bc.site.Strategy != "" {
+		strategy = bc.site.Strategy
+	}
+
+	switch strategy {
+	case "", "auto":
+		if bc.searchURL() != "" {
+			return "search"
+		}
+		if mediumArchiveRoot(bc.baseURL) != "" {
+			return "archive-months"
+		}
+		return "auto"
+	case "archive-months":
+		if mediumArchiveRoot(bc.baseURL) == "" {
+			bc.warnf("Strategy archive-months needs a Medium publication; scrolling the feed instead")
+			return "scroll"
+		}
+	case "search":
+		if bc.searchURL() == "" {
+			bc.warnf("Strategy search needs --search-url or a site profile's search_url; scrolling the feed instead")
+			return "scroll"
+		}
+	}
+	return strategy
+}
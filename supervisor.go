@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// maxBrowserRestarts caps how often one crawl relaunches a crashed or hung
+// browser before giving up
+const maxBrowserRestarts = 3
+
+// browserResponsive reports whether both the browser and the crawl's page
+// still answer over CDP within a few seconds
+func (bc *BlogCrawler) browserResponsive(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if err := bc.browser.Ping(ctx); err != nil {
+		return false
+	}
+	if _, err := bc.page.Eval(ctx, `() => true`); err != nil {
+		return false
+	}
+	return true
+}
+
+// restartBrowser kills the current browser and launches a fresh one with an
+// empty page. Callers reload whatever page they were on. With a shared
+// browser only the crawl's incognito context (or tab) is replaced.
+func (bc *BlogCrawler) restartBrowser(ctx context.Context, cause error) error {
+	if bc.restarts >= maxBrowserRestarts {
+		return fmt.Errorf("browser already restarted %d times", bc.restarts)
+	}
+	bc.restarts++
+	bc.warnf("Browser stopped responding (%v), restarting (%d/%d)", cause, bc.restarts, maxBrowserRestarts)
+
+	// A hung browser may not answer Close, so make sure the process goes away
+	closeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	bc.closeBrowser(closeCtx)
+	cancel()
+	if bc.launcher != nil {
+		bc.launcher.Kill()
+	}
+
+	if err := bc.initializeBrowser(ctx); err != nil {
+		return err
+	}
+	bc.navigations = 0
+	return bc.openPage()
+}
+
+// recoverScroll restarts the browser during infinite scroll, reloads the
+// feed and scrolls it as far as it had got before the crash
+func (bc *BlogCrawler) recoverScroll(ctx context.Context, cause error, scrolls int) error {
+	if err := bc.restartBrowser(ctx, cause); err != nil {
+		return err
+	}
+	if err := bc.loadPage(ctx, bc.listing()); err != nil {
+		return err
+	}
+
+	bc.printf("Restoring scroll position (%d scrolls)...\n", scrolls)
+	return bc.fastForward(ctx, scrolls, 1*time.Second)
+}
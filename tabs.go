@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// tabSelectors find the client-side tabs or filters a listing splits its
+// posts over, most explicit first. Links only count when they stay on the
+// page (href="#...").
+var tabSelectors = []string{
+	`[role="tab"]`,
+	`[role="tablist"] button`,
+	`.tabs button`,
+	`.tabs a[href^="#"]`,
+	`.filters button`,
+	`button[data-filter]`,
+	`[data-tab]`,
+}
+
+// findTabsJS returns the first selector matching at least two visible
+// controls, with their labels
+const findTabsJS = `(selectors) => {
+	const visible = el => el.offsetParent !== null;
+	for (const selector of selectors) {
+		const tabs = Array.from(document.querySelectorAll(selector)).filter(visible);
+		if (tabs.length >= 2) {
+			return {selector, labels: tabs.map(t => (t.innerText || t.getAttribute('aria-label') || '').trim())};
+		}
+	}
+	return {selector: '', labels: []};
+}`
+
+// clickTabJS clicks the index-th visible control matching selector
+const clickTabJS = `(selector, index) => {
+	const tabs = Array.from(document.querySelectorAll(selector)).filter(el => el.offsetParent !== null);
+	if (index >= tabs.length) return false;
+	tabs[index].click();
+	return true;
+}`
+
+// findTabs returns the selector of the current page's tabs and their
+// labels, or "" if it has none
+func (bc *BlogCrawler) findTabs(ctx context.Context) (string, []string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := bc.page.Eval(ctx, findTabsJS, tabSelectors)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to look for tabs: %w", err)
+	}
+	var labels []string
+	for _, label := range res.Get("labels").Arr() {
+		labels = append(labels, label.Str())
+	}
+	return res.Get("selector").Str(), labels, nil
+}
+
+// crawlTabs clicks through the tabs or filters of a listing that shows its
+// posts one category at a time on a single URL, extracting the posts of
+// each and keeping them all. A page without tabs is extracted as it is.
+func (bc *BlogCrawler) crawlTabs(ctx context.Context, urlSet map[string]bool) {
+	selector, labels, err := bc.findTabs(ctx)
+	if err != nil {
+		bc.warnf("Error on %s: %v", bc.baseURL, err)
+	}
+	if selector == "" {
+		bc.warnf("Strategy tabs found no tabs on %s; extracting the page as it is", bc.baseURL)
+		labels = []string{""}
+	} else {
+		bc.printf("Found %d tabs (%s)\n", len(labels), selector)
+	}
+
+	for i, label := range labels {
+		if ctx.Err() != nil {
+			return
+		}
+		if selector != "" {
+			bc.printf("Clicking tab %d/%d: %s\n", i+1, len(labels), label)
+			res, err := bc.page.Eval(ctx, clickTabJS, selector, i)
+			if err != nil || !res.Bool() {
+				bc.warnf("Could not click tab %q on %s: %v", label, bc.baseURL, err)
+				bc.recordPage(i+1, bc.baseURL, 0, fmt.Errorf("tab %q could not be clicked", label))
+				continue
+			}
+			if err := sleepContext(ctx, bc.opts.scrollDelay()); err != nil {
+				return
+			}
+			if err := bc.waitForContent(ctx); err != nil {
+				bc.warnf("Timeout waiting for tab %q: %v", label, err)
+			}
+		}
+
+		bc.setSource("tab", i+1, bc.baseURL)
+		urls, err := bc.extractBlogURLs(ctx)
+		bc.recordPage(i+1, bc.baseURL, len(urls), err)
+		if err != nil {
+			bc.warnf("Error extracting URLs of tab %q: %v", label, err)
+			continue
+		}
+		for _, url := range urls {
+			urlSet[url] = true
+		}
+		bc.printf("  Found %d blog URLs in tab %d (total: %d unique URLs)\n", len(urls), i+1, len(urlSet))
+		bc.reportProgress(ProgressEvent{Kind: "tab", Step: i + 1, Steps: len(labels), URL: bc.baseURL, URLsFound: len(urls), TotalURLs: len(urlSet)})
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	telegramAPI = "https://api.telegram.org"
+	// Telegram rejects messages longer than 4096 characters
+	telegramMaxMessage = 4000
+)
+
+// telegramNotifier posts new posts to a chat through the Telegram Bot API
+type telegramNotifier struct {
+	http   *http.Client
+	token  string
+	chatID string
+}
+
+func newTelegramNotifier(token, chatID string) *telegramNotifier {
+	return &telegramNotifier{
+		http:   &http.Client{Timeout: 30 * time.Second},
+		token:  token,
+		chatID: chatID,
+	}
+}
+
+// telegramMessages renders the new posts of result, split so that no
+// message exceeds Telegram's length limit
+func telegramMessages(result *CrawlResult) []string {
+	titles := newPostTitles(result)
+	header := fmt.Sprintf("%d new post(s) on %s\n", len(result.New), result.BaseURL)
+
+	var messages []string
+	current := header
+	for _, url := range result.New {
+		line := "\n" + url + "\n"
+		if title, ok := titles[url]; ok {
+			line = "\n" + title + "\n" + url + "\n"
+		}
+		if len(current)+len(line) > telegramMaxMessage {
+			messages = append(messages, current)
+			current = ""
+		}
+		current += line
+	}
+	return append(messages, strings.TrimSpace(current))
+}
+
+func (tn *telegramNotifier) send(ctx context.Context, text string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"chat_id":                  tn.chatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, telegramAPI+"/bot"+tn.token+"/sendMessage", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := tn.http.Do(req)
+	if err != nil {
+		// The request URL contains the token, so don't echo the error's URL
+		return fmt.Errorf("telegram request failed")
+	}
+	defer resp.Body.Close()
+
+	var apiResponse struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
+		return fmt.Errorf("failed to decode telegram response: %w", err)
+	}
+	if !apiResponse.OK {
+		return fmt.Errorf("telegram API error: %s", apiResponse.Description)
+	}
+	return nil
+}
+
+func (tn *telegramNotifier) Notify(ctx context.Context, result *CrawlResult) error {
+	for _, message := range telegramMessages(result) {
+		if err := tn.send(ctx, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
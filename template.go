@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are available to --template files in addition to the
+// text/template builtins
+func templateFuncs(result *CrawlResult) template.FuncMap {
+	posts := make(map[string]Post, len(result.Posts))
+	for _, post := range result.Posts {
+		posts[post.URL] = post
+	}
+
+	return template.FuncMap{
+		"join":    strings.Join,
+		"lower":   strings.ToLower,
+		"upper":   strings.ToUpper,
+		"replace": strings.ReplaceAll,
+		// post looks up per-post data by URL; unknown URLs yield a Post with only URL set
+		"post": func(url string) Post {
+			if post, ok := posts[url]; ok {
+				return post
+			}
+			return Post{URL: url}
+		},
+		// title is the fetched title, or one derived from the URL slug
+		"title": func(url string) string {
+			if post, ok := posts[url]; ok && post.Title != "" {
+				return post.Title
+			}
+			return postTitleFromURL(url)
+		},
+		// csv quotes its arguments as one CSV record, without the trailing newline
+		"csv": func(fields ...string) (string, error) {
+			var builder strings.Builder
+			writer := csv.NewWriter(&builder)
+			if err := writer.Write(fields); err != nil {
+				return "", err
+			}
+			writer.Flush()
+			return strings.TrimSuffix(builder.String(), "\n"), writer.Error()
+		},
+	}
+}
+
+// loadOutputTemplate parses a template file. The funcs are bound to a
+// result at render time, so placeholders are registered here for parsing.
+func loadOutputTemplate(filename string) (*template.Template, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+
+	tmpl, err := template.New(filepath.Base(filename)).Funcs(templateFuncs(&CrawlResult{})).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// writeWithTemplate renders result through tmpl into w
+func writeWithTemplate(w io.Writer, result *CrawlResult, tmpl *template.Template) error {
+	bound, err := tmpl.Clone()
+	if err != nil {
+		return fmt.Errorf("failed to clone template: %w", err)
+	}
+	bound.Funcs(templateFuncs(result))
+
+	if err := bound.Execute(w, result); err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+	return nil
+}
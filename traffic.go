@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// topTrafficHosts is how many hosts the traffic stats list
+const topTrafficHosts = 10
+
+// TrafficStats is what a crawl requested and received over the network:
+// everything its tabs loaded, page resources included, and the posts it
+// fetched without the browser
+type TrafficStats struct {
+	Requests       int                        `json:"requests"`
+	Bytes          int64                      `json:"bytes"`                     // As transferred, compressed
+	FailedRequests int                        `json:"failed_requests,omitempty"` // Failed or blocked before a response
+	ByType         map[string]ResourceTraffic `json:"by_type,omitempty"`         // By resource type, like "Document", "Image" or "Script"
+	TopHosts       []HostTraffic              `json:"top_hosts,omitempty"`       // Most bytes first
+}
+
+// ResourceTraffic is the traffic of one kind of resource or one host
+type ResourceTraffic struct {
+	Requests int   `json:"requests"`
+	Bytes    int64 `json:"bytes"`
+}
+
+// HostTraffic is the traffic to one host
+type HostTraffic struct {
+	Host string `json:"host"`
+	ResourceTraffic
+}
+
+// trafficCounter counts a crawl's requests and bytes. Post workers share
+// their crawler's counter.
+type trafficCounter struct {
+	ctx context.Context
+
+	mu       sync.Mutex
+	stats    TrafficStats
+	hosts    map[string]ResourceTraffic
+	inFlight map[proto.NetworkRequestID]trafficRequest // Browser requests waiting for their size
+}
+
+// trafficRequest is what the bytes of a browser request are counted under
+type trafficRequest struct {
+	resourceType string
+	host         string
+}
+
+func newTrafficCounter(ctx context.Context) *trafficCounter {
+	return &trafficCounter{
+		ctx:      ctx,
+		stats:    TrafficStats{ByType: make(map[string]ResourceTraffic)},
+		hosts:    make(map[string]ResourceTraffic),
+		inFlight: make(map[proto.NetworkRequestID]trafficRequest),
+	}
+}
+
+// watch counts the requests page makes until the crawl ends
+func (t *trafficCounter) watch(page *rod.Page) {
+	if t == nil {
+		return
+	}
+	wait := page.Context(t.ctx).EachEvent(
+		func(e *proto.NetworkRequestWillBeSent) {
+			// Data URLs never reach the network
+			if strings.HasPrefix(e.Request.URL, "data:") {
+				return
+			}
+			resourceType := string(e.Type)
+			if resourceType == "" {
+				resourceType = "Other"
+			}
+			t.requested(e.RequestID, resourceType, e.Request.URL)
+		},
+		func(e *proto.NetworkLoadingFinished) { t.finished(e.RequestID, int64(e.EncodedDataLength)) },
+		func(e *proto.NetworkLoadingFailed) { t.failed(e.RequestID) },
+	)
+	go wait()
+}
+
+// fetched counts a request the crawl made over its own HTTP client and
+// the n bytes of its response
+func (t *trafficCounter) fetched(requestURL string, n int64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.add(trafficRequest{resourceType: "Fetch (HTTP client)", host: trafficHost(requestURL)}, 1, n)
+}
+
+func (t *trafficCounter) requested(id proto.NetworkRequestID, resourceType, requestURL string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	request := trafficRequest{resourceType: resourceType, host: trafficHost(requestURL)}
+	// Every hop of a redirect is a request of its own, under the same ID
+	t.add(request, 1, 0)
+	t.inFlight[id] = request
+}
+
+func (t *trafficCounter) finished(id proto.NetworkRequestID, n int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if request, ok := t.inFlight[id]; ok {
+		t.add(request, 0, n)
+		delete(t.inFlight, id)
+	}
+}
+
+func (t *trafficCounter) failed(id proto.NetworkRequestID) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.inFlight[id]; ok {
+		t.stats.FailedRequests++
+		delete(t.inFlight, id)
+	}
+}
+
+func (t *trafficCounter) add(request trafficRequest, requests int, n int64) {
+	t.stats.Requests += requests
+	t.stats.Bytes += n
+	byType := t.stats.ByType[request.resourceType]
+	byType.Requests += requests
+	byType.Bytes += n
+	t.stats.ByType[request.resourceType] = byType
+	host := t.hosts[request.host]
+	host.Requests += requests
+	host.Bytes += n
+	t.hosts[request.host] = host
+}
+
+// trafficHost is the host a request is counted under
+func trafficHost(requestURL string) string {
+	parsed, err := url.Parse(requestURL)
+	if err != nil || parsed.Hostname() == "" {
+		return "(none)"
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+// snapshot returns the traffic so far, nil without a counter or traffic
+func (t *trafficCounter) snapshot() *TrafficStats {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stats.Requests == 0 {
+		return nil
+	}
+
+	stats := t.stats
+	stats.ByType = make(map[string]ResourceTraffic, len(t.stats.ByType))
+	for resourceType, traffic := range t.stats.ByType {
+		stats.ByType[resourceType] = traffic
+	}
+	for host, traffic := range t.hosts {
+		stats.TopHosts = append(stats.TopHosts, HostTraffic{Host: host, ResourceTraffic: traffic})
+	}
+	sort.Slice(stats.TopHosts, func(i, j int) bool {
+		if stats.TopHosts[i].Bytes != stats.TopHosts[j].Bytes {
+			return stats.TopHosts[i].Bytes > stats.TopHosts[j].Bytes
+		}
+		return stats.TopHosts[i].Host < stats.TopHosts[j].Host
+	})
+	if len(stats.TopHosts) > topTrafficHosts {
+		stats.TopHosts = stats.TopHosts[:topTrafficHosts]
+	}
+	return &stats
+}
+
+// printTraffic prints the traffic block of the stats
+func printTraffic(w io.Writer, traffic *TrafficStats) {
+	if traffic == nil {
+		return
+	}
+	fmt.Fprintf(w, "  %d requests, %s transferred", traffic.Requests, formatBytes(traffic.Bytes))
+	if traffic.FailedRequests > 0 {
+		fmt.Fprintf(w, ", %d failed", traffic.FailedRequests)
+	}
+	fmt.Fprintln(w)
+
+	types := make([]string, 0, len(traffic.ByType))
+	for resourceType := range traffic.ByType {
+		types = append(types, resourceType)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		a, b := traffic.ByType[types[i]], traffic.ByType[types[j]]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return types[i] < types[j]
+	})
+	fmt.Fprintln(w, "  Traffic by resource type:")
+	for _, resourceType := range types {
+		t := traffic.ByType[resourceType]
+		fmt.Fprintf(w, "    %6d  %9s  %s\n", t.Requests, formatBytes(t.Bytes), resourceType)
+	}
+	fmt.Fprintln(w, "  Traffic by host:")
+	for _, host := range traffic.TopHosts {
+		fmt.Fprintf(w, "    %6d  %9s  %s\n", host.Requests, formatBytes(host.Bytes), host.Host)
+	}
+}
+
+// formatBytes writes n as B, KB or MB
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
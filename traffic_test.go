@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTrafficCounter(t *testing.T) {
+	traffic := newTrafficCounter(context.Background())
+	if traffic.snapshot() != nil {
+		t.Error("snapshot without traffic isn't nil")
+	}
+
+	traffic.requested("1", "Document", "https://blog.example.com/")
+	traffic.finished("1", 5000)
+	// A redirect: two hops, one ID, the size arrives once
+	traffic.requested("2", "Document", "http://blog.example.com/post")
+	traffic.requested("2", "Document", "https://blog.example.com/post")
+	traffic.finished("2", 3000)
+	traffic.requested("3", "Image", "https://cdn.example.com/cover.png")
+	traffic.finished("3", 20000)
+	traffic.requested("4", "Script", "https://analytics.example.net/tag.js")
+	traffic.failed("4")
+	traffic.fetched("https://blog.example.com/other-post", 4000)
+
+	stats := traffic.snapshot()
+	if stats.Requests != 6 || stats.Bytes != 32000 || stats.FailedRequests != 1 {
+		t.Errorf("%d requests, %d bytes, %d failed; want 6, 32000 and 1", stats.Requests, stats.Bytes, stats.FailedRequests)
+	}
+	if documents := stats.ByType["Document"]; documents != (ResourceTraffic{Requests: 3, Bytes: 8000}) {
+		t.Errorf("documents %+v, want 3 requests of 8000 bytes", documents)
+	}
+	if script := stats.ByType["Script"]; script != (ResourceTraffic{Requests: 1}) {
+		t.Errorf("failed script %+v, want 1 request of no bytes", script)
+	}
+	if len(stats.TopHosts) != 3 || stats.TopHosts[0].Host != "cdn.example.com" || stats.TopHosts[1] != (HostTraffic{"blog.example.com", ResourceTraffic{4, 12000}}) {
+		t.Errorf("hosts %+v, want cdn.example.com, then blog.example.com with 4 requests of 12000 bytes", stats.TopHosts)
+	}
+}
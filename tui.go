@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	// dashboardLogLines is how many lines of crawler output the dashboard keeps
+	dashboardLogLines = 200
+
+	// dashboardFrame is the shortest time between redraws, so a burst of
+	// output is drawn once
+	dashboardFrame = 100 * time.Millisecond
+)
+
+// dashboard is the --tui screen of watch mode: a row per watched blog with
+// its state, last and next crawl and post counts, and the latest crawler
+// output below. The crawls print to its log, given to them as their
+// CrawlOptions.Output, rather than to the terminal.
+type dashboard struct {
+	blogs    []*blogStatus
+	interval time.Duration
+	quit     func() // Stops the watch, for the q key
+	log      *dashboardLog
+
+	term      *os.File    // The terminal the dashboard is drawn on
+	termState *term.State // Terminal settings to restore
+	keys      *os.File    // Where keys are read from, closed to stop reading
+	changed   chan struct{}
+	done      chan struct{}
+	wg        sync.WaitGroup
+
+	mu       sync.Mutex
+	selected int
+}
+
+// dashboardLog keeps the last lines written to it for the dashboard's
+// output pane. It is safe for concurrent use by the crawls.
+type dashboardLog struct {
+	changed chan struct{}
+
+	mu      sync.Mutex
+	lines   []string
+	partial string // Written after the last newline
+}
+
+func newDashboardLog() *dashboardLog {
+	return &dashboardLog{changed: make(chan struct{}, 1)}
+}
+
+func (l *dashboardLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	lines := strings.Split(l.partial+string(p), "\n")
+	l.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) != "" {
+			l.lines = append(l.lines, line)
+		}
+	}
+	if len(l.lines) > dashboardLogLines {
+		l.lines = l.lines[len(l.lines)-dashboardLogLines:]
+	}
+	l.mu.Unlock()
+	notify(l.changed)
+	return len(p), nil
+}
+
+// last returns up to n of the latest lines
+func (l *dashboardLog) last(n int) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.lines[max(0, len(l.lines)-n):]...)
+}
+
+// blogStatus is a watched blog's row on the dashboard. Its methods do
+// nothing on a nil status, so watch can call them without a dashboard.
+type blogStatus struct {
+	URL     string
+	recrawl chan struct{} // A value runs the blog's next crawl right away
+	changed chan struct{}
+
+	mu sync.Mutex
+	blogState
+}
+
+// blogState is what the dashboard shows of a blog
+type blogState struct {
+	state     string // "waiting", "queued", "crawling" or "failed"
+	step      string // Latest progress of the running crawl
+	lastCrawl time.Time
+	nextCrawl time.Time
+	posts     int // Posts found by the last crawl
+	newPosts  int // New posts found by the last crawl
+	totalNew  int // New posts found since the watch started
+	err       string
+}
+
+// newDashboard returns the dashboard for watching blogs every interval,
+// showing the output written to log; quit is called when the user asks to
+// stop
+func newDashboard(blogs []string, interval time.Duration, log *dashboardLog, quit func()) *dashboard {
+	d := &dashboard{
+		interval: interval,
+		quit:     quit,
+		log:      log,
+		changed:  log.changed,
+		done:     make(chan struct{}),
+	}
+	for _, blog := range blogs {
+		d.blogs = append(d.blogs, &blogStatus{
+			URL:       blog,
+			recrawl:   make(chan struct{}, 1),
+			changed:   d.changed,
+			blogState: blogState{state: "waiting"},
+		})
+	}
+	return d
+}
+
+// startDashboard starts the dashboard of the watched blogs when there's a
+// log for it, the crawls' output with --tui, returning nil otherwise. It
+// exits when the terminal can't show one.
+func startDashboard(log *dashboardLog, blogs []string, interval time.Duration, quit func()) *dashboard {
+	if log == nil {
+		return nil
+	}
+	d := newDashboard(blogs, interval, log, quit)
+	if err := d.start(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	return d
+}
+
+// start takes over the terminal: it switches to the alternate screen and
+// raw mode to read keys without waiting for Enter. It fails when stdin and
+// stdout aren't a terminal.
+func (d *dashboard) start() error {
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return fmt.Errorf("--tui needs a terminal")
+	}
+	keys, err := openKeys()
+	if err != nil {
+		return fmt.Errorf("failed to read keys: %w", err)
+	}
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		closeKeys(keys)
+		return fmt.Errorf("failed to set up the terminal: %w", err)
+	}
+	d.term, d.termState, d.keys = os.Stdout, state, keys
+	fmt.Fprint(d.term, "\x1b[?1049h\x1b[?25l") // Alternate screen, no cursor
+
+	d.wg.Add(2)
+	go d.redraw()
+	go d.readKeys()
+	return nil
+}
+
+// close gives the terminal back and prints the last lines of output that
+// were shown on the dashboard. It does nothing on a nil dashboard.
+func (d *dashboard) close() {
+	if d == nil {
+		return
+	}
+	close(d.done)
+	closeKeys(d.keys)
+	d.wg.Wait()
+
+	fmt.Fprint(d.term, "\x1b[?25h\x1b[?1049l")
+	term.Restore(int(os.Stdin.Fd()), d.termState)
+
+	for _, line := range d.log.last(20) {
+		fmt.Fprintln(d.term, line)
+	}
+}
+
+// redraw draws the dashboard whenever something changed, at most once a
+// frame, and every second for the clocks
+func (d *dashboard) redraw() {
+	defer d.wg.Done()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		width, height, err := term.GetSize(int(d.term.Fd()))
+		if err != nil || width == 0 || height == 0 {
+			width, height = 80, 24
+		}
+		lines := d.render(width, height, time.Now())
+		fmt.Fprint(d.term, "\x1b[H\x1b[2J"+strings.Join(lines, "\r\n"))
+
+		select {
+		case <-d.done:
+			return
+		case <-time.After(dashboardFrame):
+		}
+		select {
+		case <-d.done:
+			return
+		case <-d.changed:
+		case <-ticker.C:
+		}
+	}
+}
+
+// readKeys handles the dashboard's keys: arrows or j and k select a blog,
+// r re-crawls it now and q or Ctrl-C quits. It returns when close closes
+// the keys.
+func (d *dashboard) readKeys() {
+	defer d.wg.Done()
+	reader := bufio.NewReader(d.keys)
+	for {
+		key, err := reader.ReadByte()
+		if err != nil {
+			return
+		}
+		if key == 0x1b { // Arrow keys are ESC [ A and ESC [ B
+			if next, _ := reader.ReadByte(); next == '[' {
+				key, _ = reader.ReadByte()
+			}
+		}
+
+		switch key {
+		case 'k', 'A':
+			d.move(-1)
+		case 'j', 'B':
+			d.move(1)
+		case 'r':
+			d.mu.Lock()
+			blog := d.blogs[d.selected]
+			d.mu.Unlock()
+			notify(blog.recrawl)
+		case 'q', 0x03: // Raw mode turns Ctrl-C into a key
+			d.quit()
+			return
+		}
+	}
+}
+
+func (d *dashboard) move(delta int) {
+	d.mu.Lock()
+	d.selected = min(max(d.selected+delta, 0), len(d.blogs)-1)
+	d.mu.Unlock()
+	notify(d.changed)
+}
+
+// render returns the dashboard's lines for a terminal of the given size
+func (d *dashboard) render(width, height int, now time.Time) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	plural := "s"
+	if len(d.blogs) == 1 {
+		plural = ""
+	}
+	title := fmt.Sprintf("Watching %d blog%s every %v", len(d.blogs), plural, d.interval)
+	lines := []string{
+		title + strings.Repeat(" ", max(1, width-len(title)-8)) + now.Format("15:04:05"),
+		"",
+	}
+
+	urlWidth := max(20, width-62)
+	row := "  %-*s  %-22s  %-8s  %-8s  %6s  %5s  %9s"
+	lines = append(lines, fmt.Sprintf(row, urlWidth, "BLOG", "STATE", "LAST", "NEXT", "POSTS", "NEW", "TOTAL NEW"))
+	var selectedErr string
+	for i, blog := range d.blogs {
+		status := blog.snapshot()
+		line := fmt.Sprintf(row, urlWidth, truncate(blog.URL, urlWidth), truncate(status.describe(), 22),
+			clock(status.lastCrawl), countdown(status.nextCrawl, now), count(status.posts, status.lastCrawl),
+			count(status.newPosts, status.lastCrawl), count(status.totalNew, status.lastCrawl))
+		if i == d.selected {
+			line = ">" + line[1:]
+			selectedErr = status.err
+		}
+		lines = append(lines, line)
+	}
+	if selectedErr != "" {
+		lines = append(lines, "", "  Last error: "+selectedErr)
+	}
+
+	footer := "↑/↓ select  r re-crawl now  q quit"
+	lines = append(lines, "", "Output:")
+	if room := height - len(lines) - 2; room > 0 {
+		lines = append(lines, d.log.last(room)...)
+	}
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, footer)
+
+	for i, line := range lines {
+		lines[i] = truncate(line, width)
+	}
+	return lines
+}
+
+func (s *blogStatus) queued() {
+	s.set(func() { s.state, s.step = "queued", "" })
+}
+
+func (s *blogStatus) crawling() {
+	s.set(func() { s.state, s.step = "crawling", "" })
+}
+
+// progress is the OnProgress of the blog's crawls
+func (s *blogStatus) progress(event ProgressEvent) {
+	s.set(func() { s.step = fmt.Sprintf("%s %d, %d URLs", event.Kind, event.Step, event.TotalURLs) })
+}
+
+// finished records the outcome of a crawl and when the next one is due
+func (s *blogStatus) finished(result *CrawlResult, err error, next time.Time) {
+	s.set(func() {
+		s.state, s.step, s.nextCrawl, s.err = "waiting", "", next, ""
+		if err != nil {
+			s.state, s.err = "failed", redact(err.Error())
+		}
+		if result != nil {
+			s.lastCrawl = time.Now()
+			s.posts = result.TotalCount
+			s.newPosts = len(result.New)
+			s.totalNew += len(result.New)
+		}
+	})
+}
+
+func (s *blogStatus) set(update func()) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	update()
+	s.mu.Unlock()
+	notify(s.changed)
+}
+
+// snapshot returns a copy of the state for rendering
+func (s *blogStatus) snapshot() blogState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.blogState
+}
+
+// describe is the state column: the state, with the progress while crawling
+func (s blogState) describe() string {
+	if s.state == "crawling" && s.step != "" {
+		return s.step
+	}
+	return s.state
+}
+
+// notify sends on a channel of capacity one without blocking; a pending
+// value already says the same
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// clock formats a time of today for the dashboard, "-" for none
+func clock(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("15:04:05")
+}
+
+// countdown formats the time left until t, "-" for none
+func countdown(t, now time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return max(0, t.Sub(now)).Truncate(time.Second).String()
+}
+
+// count formats a count, "-" before the first crawl
+func count(n int, lastCrawl time.Time) string {
+	if lastCrawl.IsZero() {
+		return "-"
+	}
+	return fmt.Sprint(n)
+}
+
+// truncate shortens s to width runes
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width < 1 {
+		return ""
+	}
+	return string(runes[:width-1]) + "…"
+}
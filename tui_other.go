@@ -0,0 +1,16 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// openKeys fails: a pending read of the console can't be ended when the
+// dashboard closes
+func openKeys() (*os.File, error) {
+	return nil, errors.New("--tui is only supported on Unix terminals")
+}
+
+func closeKeys(keys *os.File) {}
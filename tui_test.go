@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDashboardRender(t *testing.T) {
+	d := newDashboard([]string{"https://blog.example.com/", "https://eng.example.org/"}, time.Hour, newDashboardLog(), func() {})
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	d.blogs[0].finished(&CrawlResult{TotalCount: 42, New: []string{"https://blog.example.com/new"}}, nil, now.Add(30*time.Minute))
+	d.blogs[1].crawling()
+	d.blogs[1].progress(ProgressEvent{Kind: "page", Step: 3, TotalURLs: 57})
+	fmt.Fprintln(d.log, "Crawling page 3: https://eng.example.org/page/3/")
+
+	lines := d.render(120, 20, now)
+	if len(lines) != 20 {
+		t.Fatalf("render gave %d lines, want the terminal's 20", len(lines))
+	}
+	screen := strings.Join(lines, "\n")
+	for _, want := range []string{"Watching 2 blogs every 1h0m0s", "> https://blog.example.com/", "30m0s", "42", "page 3, 57 URLs", "Crawling page 3", "r re-crawl now"} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("dashboard lacks %q:\n%s", want, screen)
+		}
+	}
+
+	d.blogs[0].finished(nil, errors.New("crawling failed: timeout"), now.Add(time.Hour))
+	screen = strings.Join(d.render(120, 20, now), "\n")
+	if !strings.Contains(screen, "failed") || !strings.Contains(screen, "Last error: crawling failed: timeout") {
+		t.Errorf("dashboard doesn't show the failed crawl:\n%s", screen)
+	}
+	for _, line := range d.render(40, 10, now) {
+		if len([]rune(line)) > 40 {
+			t.Errorf("line wider than the terminal: %q", line)
+		}
+	}
+}
+
+func TestDashboardLog(t *testing.T) {
+	log := newDashboardLog()
+	fmt.Fprint(log, "Crawling page 1")
+	if lines := log.last(10); len(lines) != 0 {
+		t.Fatalf("unfinished line shown: %q", lines)
+	}
+	fmt.Fprint(log, ": https://blog.example.com/\r\n\nFound 3 posts\n")
+	if lines := log.last(10); strings.Join(lines, "|") != "Crawling page 1: https://blog.example.com/|Found 3 posts" {
+		t.Errorf("log has %q", lines)
+	}
+	select {
+	case <-log.changed:
+	default:
+		t.Error("writing didn't ask for a redraw")
+	}
+
+	for i := range dashboardLogLines + 5 {
+		fmt.Fprintf(log, "line %d\n", i)
+	}
+	lines := log.last(dashboardLogLines + 10)
+	if len(lines) != dashboardLogLines || lines[len(lines)-1] != fmt.Sprintf("line %d", dashboardLogLines+4) {
+		t.Errorf("log keeps %d lines ending in %q, want the last %d", len(lines), lines[len(lines)-1], dashboardLogLines)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// openKeys returns a copy of stdin for the dashboard to read keys from. It
+// is in non-blocking mode, so closing it ends a pending read.
+func openKeys() (*os.File, error) {
+	fd, err := syscall.Dup(syscall.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	return os.NewFile(uintptr(fd), "/dev/stdin"), nil
+}
+
+// closeKeys closes the copy of stdin and puts stdin, which shares its mode,
+// back in blocking mode
+func closeKeys(keys *os.File) {
+	keys.Close()
+	syscall.SetNonblock(syscall.Stdin, false)
+}
@@ -0,0 +1,100 @@
+package urlfilter
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// CategoryPattern matches the paths of a blog's category pages. Patterns
+// are globs on the lowercase path without its trailing slash, where *
+// stands for one segment ("/blog/*"), or regular expressions after "re:".
+// A prefix says what else makes a matching link a category page:
+//
+//	/blog/*/*       nothing, the path is enough
+//	nav:/blog/*     the link is also in the page's navigation
+//	grid:/blog/*/*  the page itself lists posts, checked once the listing is crawled
+type CategoryPattern struct {
+	Source string
+	Nav    bool // nav: prefix
+	Grid   bool // grid: prefix, never matched by Classify
+	glob   string
+	re     *regexp.Regexp
+}
+
+// Category patterns of the sites with built-in support
+var (
+	// Uber posts sit directly under /blog/; categories and subcategories
+	// are in the navigation, or at least list posts
+	UberCategoryPages = []string{"nav:/blog/*", "nav:/blog/*/*", "grid:/blog/*/*"}
+	// LinkedIn posts live at /blog/<section>/<category>/<slug>
+	LinkedInCategoryPages = []string{"/blog/*/*"}
+)
+
+// CompileCategory compiles a category pattern
+func CompileCategory(source string) (CategoryPattern, error) {
+	pattern := CategoryPattern{Source: source}
+	expr := source
+	if rest, ok := strings.CutPrefix(expr, "nav:"); ok {
+		pattern.Nav, expr = true, rest
+	} else if rest, ok := strings.CutPrefix(expr, "grid:"); ok {
+		pattern.Grid, expr = true, rest
+	}
+
+	if rest, ok := strings.CutPrefix(expr, "re:"); ok {
+		re, err := regexp.Compile(rest)
+		if err != nil {
+			return CategoryPattern{}, fmt.Errorf("invalid category pattern %q: %w", source, err)
+		}
+		pattern.re = re
+		return pattern, nil
+	}
+	pattern.glob = strings.ToLower(strings.TrimSuffix(expr, "/"))
+	if _, err := path.Match(pattern.glob, ""); err != nil {
+		return CategoryPattern{}, fmt.Errorf("invalid category pattern %q: %w", source, err)
+	}
+	return pattern, nil
+}
+
+// ValidateCategories checks the globs and regular expressions among
+// patterns
+func ValidateCategories(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := CompileCategory(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the pattern matches the lowercase path pathLower
+func (p CategoryPattern) Matches(pathLower string) bool {
+	pathLower = strings.TrimSuffix(pathLower, "/")
+	if p.re != nil {
+		return p.re.MatchString(pathLower)
+	}
+	matched, _ := path.Match(p.glob, pathLower)
+	return matched
+}
+
+// NavKey is the form of a path navigation links are matched on
+func NavKey(linkPath string) string {
+	return strings.TrimSuffix(strings.ToLower(linkPath), "/")
+}
+
+// categoryRule returns the rule rejecting the link with the lowercase path
+// pathLower as a category page, or "" if no pattern says it is one. Links
+// matching a grid: pattern are left to the crawler's post grid check.
+func categoryRule(pathLower string, rules Rules) string {
+	for _, pattern := range rules.Categories {
+		if pattern.Grid || !pattern.Matches(pathLower) {
+			continue
+		}
+		if pattern.Nav && !rules.NavPaths[NavKey(pathLower)] {
+			continue
+		}
+		return fmt.Sprintf("category pattern %q", pattern.Source)
+	}
+	return ""
+}
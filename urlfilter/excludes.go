@@ -0,0 +1,95 @@
+package urlfilter
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Excludes reject links to the pages most blogs have besides their posts.
+// A link containing one of them (case-insensitively) isn't a post. Site
+// profiles and flags add and remove entries, see ExcludeRule.
+var Excludes = []string{
+	"/about",
+	"/archive",
+	"/tag/",
+	"/search",
+	"/@",
+	"/latest",
+	"/membership",
+	"/settings",
+	"/me/",
+	"/?source=",
+	"/page/", // Pagination pages
+	"/category/",
+	"/categories/",
+	"/author/",
+	"/authors/",
+	"/feed",
+	"/rss",
+	"/sitemap",
+	"/contact",
+	"/privacy",
+	"/terms",
+	"/careers",
+}
+
+// ExcludeRule changes the exclude patterns: Add appends patterns and Remove
+// drops patterns added before it, the defaults included. A pattern starting
+// with "re:" is a regular expression matched against the lowercase path.
+type ExcludeRule struct {
+	Add    []string `json:"add,omitempty"`
+	Remove []string `json:"remove,omitempty"`
+}
+
+// Apply returns patterns changed by the rule
+func (r ExcludeRule) Apply(patterns []string) []string {
+	patterns = append(patterns, r.Add...)
+	kept := patterns[:0]
+	for _, pattern := range patterns {
+		if !slices.Contains(r.Remove, pattern) {
+			kept = append(kept, pattern)
+		}
+	}
+	return kept
+}
+
+// ExcludePattern is a compiled exclude pattern
+type ExcludePattern struct {
+	Source string
+	re     *regexp.Regexp // Set for "re:" patterns
+}
+
+// CompileExclude compiles an exclude pattern: a substring of the URL, or a
+// regular expression on the path after "re:"
+func CompileExclude(source string) (ExcludePattern, error) {
+	expr, ok := strings.CutPrefix(source, "re:")
+	if !ok {
+		return ExcludePattern{Source: source}, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return ExcludePattern{}, fmt.Errorf("invalid exclude pattern %q: %w", source, err)
+	}
+	return ExcludePattern{Source: source, re: re}, nil
+}
+
+// ValidateExcludes checks the regular expressions among patterns
+func ValidateExcludes(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := CompileExclude(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matches reports whether the link with the lowercase URL urlLower and
+// path pathLower is excluded
+func (p ExcludePattern) matches(urlLower, pathLower string) bool {
+	if p.re != nil {
+		return p.re.MatchString(pathLower)
+	}
+	return strings.Contains(urlLower, strings.ToLower(p.Source))
+}
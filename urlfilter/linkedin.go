@@ -0,0 +1,42 @@
+package urlfilter
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// LinkedInBlog splits a LinkedIn blog URL into its section and category,
+// /blog/engineering/data into "engineering" and "data". The category is
+// empty for a section's front page; ok is false for other URLs.
+func LinkedInBlog(rawURL string) (section, category string, ok bool) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || !HostMatches(strings.ToLower(parsedURL.Hostname()), []string{"linkedin.com"}) {
+		return "", "", false
+	}
+	parts := strings.Split(strings.Trim(strings.ToLower(parsedURL.Path), "/"), "/")
+	if len(parts) < 2 || parts[0] != "blog" {
+		return "", "", false
+	}
+	if len(parts) > 2 {
+		category = parts[2]
+	}
+	return parts[1], category, true
+}
+
+// classifyLinkedIn classifies a link on the LinkedIn blog at baseURL: posts
+// live at /blog/<section>/<category>/<slug> in the blog's section.
+// Category pages, a single segment after the section, were rejected by the
+// built-in profile's category pattern.
+func classifyLinkedIn(parsedURL *url.URL, baseURL string) (bool, string) {
+	if parsedURL.Query().Has("page0") {
+		return false, "linkedin: pagination page"
+	}
+
+	section, _, _ := LinkedInBlog(baseURL)
+	linkSection, category, ok := LinkedInBlog(parsedURL.String())
+	if !ok || linkSection != section || category == "" {
+		return false, fmt.Sprintf("linkedin: not under /blog/%s/<category>/", section)
+	}
+	return true, fmt.Sprintf("linkedin: post under /blog/%s/<category>/", section)
+}
@@ -0,0 +1,48 @@
+package urlfilter
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	// Date archives such as /2023/, /2023/04/ or /2023/04/17/
+	dateArchivePattern = regexp.MustCompile(`(^|/)(19|20)\d{2}(/\d{1,2}){0,2}/?$`)
+	// Trailing pagination of an archive, e.g. /2023/page/2/
+	archivePagePattern = regexp.MustCompile(`/page/\d+/?$`)
+)
+
+// authorTagPatterns mark author and tag pages, which are only followed in
+// --expand-authors-tags mode since they mostly repeat posts found elsewhere
+var authorTagPatterns = []string{"/author/", "/authors/", "/@", "/tag/", "/tags/", "/tagged/", "/topic/", "/topics/"}
+
+// IsListing reports whether rawURL looks like a page that lists posts
+// rather than a post: date archives, archive indexes and category indexes,
+// plus author and tag pages when authorsAndTags is set
+func IsListing(rawURL string, authorsAndTags bool) bool {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	path := strings.ToLower(parsedURL.Path)
+	path = archivePagePattern.ReplaceAllString(path, "/")
+
+	if dateArchivePattern.MatchString(path) {
+		return true
+	}
+	for _, pattern := range []string{"/archive", "/category/", "/categories/"} {
+		if strings.Contains(path, pattern) {
+			return true
+		}
+	}
+	if authorsAndTags {
+		for _, pattern := range authorTagPatterns {
+			if strings.Contains(path, pattern) {
+				return true
+			}
+		}
+	}
+	return false
+}
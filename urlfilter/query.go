@@ -0,0 +1,52 @@
+package urlfilter
+
+import (
+	"net/url"
+	"path"
+	"slices"
+)
+
+// QueryParamRule decides which query parameters of post links are part of
+// the post's identity. An empty rule drops all of them; Drop alone keeps
+// everything else.
+type QueryParamRule struct {
+	Keep []string `json:"keep,omitempty"` // Parameter names or globs like "utm_*"; "*" keeps all
+	Drop []string `json:"drop,omitempty"` // Dropped even when Keep matches them
+}
+
+// Apply removes the query parameters of u the rule doesn't keep
+func (r QueryParamRule) Apply(u *url.URL) {
+	keep := r.Keep
+	if len(keep) == 0 {
+		if len(r.Drop) == 0 {
+			u.RawQuery = ""
+			return
+		}
+		keep = []string{"*"}
+	}
+	// Keeping everything leaves the query exactly as the site wrote it
+	if len(r.Drop) == 0 && slices.Contains(keep, "*") {
+		return
+	}
+
+	query := u.Query()
+	for name := range query {
+		if !paramMatches(name, keep) || paramMatches(name, r.Drop) {
+			query.Del(name)
+		}
+	}
+	u.RawQuery = query.Encode()
+}
+
+// paramMatches reports whether name matches one of the globs in patterns
+func paramMatches(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if pattern == name {
+			return true
+		}
+		if matched, err := path.Match(pattern, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,163 @@
+// Package urlfilter holds the crawler's URL heuristics: normalizing the
+// links found on listing pages and deciding which of them are blog posts.
+// Everything here is a function of its arguments alone, so the rules can
+// be tested on URLs without a browser or a crawl.
+package urlfilter
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Rules is what classifying a link depends on besides the link itself
+type Rules struct {
+	BaseURL    string            // The blog's base URL
+	Excludes   []ExcludePattern  // Pages that aren't posts, see Excludes
+	Categories []CategoryPattern // Category pages, see CompileCategory
+	NavPaths   map[string]bool   // NavKey of every path the listing's navigation links to, for nav: patterns
+}
+
+// Normalize resolves href against baseURL and drops its fragment and the
+// query parameters query doesn't keep; the zero rule drops them all
+func Normalize(baseURL, href string, query QueryParamRule) (string, error) {
+	// Parse base URL to get scheme and host
+	baseURLParsed, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse base URL: %w", err)
+	}
+
+	// Parse the href
+	hrefParsed, err := url.Parse(href)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse href: %w", err)
+	}
+
+	// Resolve relative URLs
+	absoluteURL := baseURLParsed.ResolveReference(hrefParsed)
+	query.Apply(absoluteURL)
+	absoluteURL.Fragment = ""
+
+	return absoluteURL.String(), nil
+}
+
+// IsBlogPost reports whether rawURL is a blog post under rules
+func IsBlogPost(rawURL string, rules Rules) bool {
+	accepted, _ := Classify(rawURL, rules)
+	return accepted
+}
+
+// Classify decides whether rawURL is a blog post and names the rule that
+// made the decision, for --dry-run
+func Classify(rawURL string, rules Rules) (bool, string) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return false, "unparseable URL"
+	}
+
+	path := strings.ToLower(parsedURL.Path)
+	urlLower := strings.ToLower(rawURL)
+
+	// Parse base URL to get base path
+	baseURLParsed, err := url.Parse(rules.BaseURL)
+	if err != nil {
+		return false, "unparseable base URL"
+	}
+	basePath := strings.ToLower(baseURLParsed.Path)
+
+	// Category pages, by the site's patterns
+	if rule := categoryRule(path, rules); rule != "" {
+		return false, rule
+	}
+
+	// For LinkedIn blog: /blog/<section>/<category>/<post-slug>
+	if _, _, ok := LinkedInBlog(rules.BaseURL); ok {
+		return classifyLinkedIn(parsedURL, rules.BaseURL)
+	}
+
+	// For Uber blog: check if it's a blog post URL pattern
+	// Pattern: /blog/<post-slug>/ or /blog/<category>/<post-slug>/
+	if strings.Contains(rules.BaseURL, "uber.com") {
+		// Uber blog posts follow pattern: /blog/<slug>/
+		// Exclude pagination, category pages, etc.
+		if strings.Contains(path, "/page/") {
+			return false, "uber: pagination page"
+		}
+		// Include if it matches /blog/<something>/ pattern and is not a category
+		if strings.HasPrefix(path, "/blog/") {
+			// Get the part after /blog/
+			blogPath := strings.TrimPrefix(path, "/blog/")
+			blogPath = strings.Trim(blogPath, "/")
+			parts := strings.Split(blogPath, "/")
+
+			// Category pages were rejected by the site profile's patterns,
+			// so if it has a slug, it's likely a blog post
+			if len(parts) > 0 && parts[0] != "" {
+				return true, "uber: post under /blog/"
+			}
+		}
+		return false, "uber: not under /blog/"
+	}
+
+	// Filter out common non-blog URLs for other sites
+	for _, pattern := range rules.Excludes {
+		if pattern.matches(urlLower, path) {
+			return false, fmt.Sprintf("exclude pattern %q", pattern.Source)
+		}
+	}
+
+	// Date archives (/2023/, /2023/04/) list posts rather than being one
+	if dateArchivePattern.MatchString(archivePagePattern.ReplaceAllString(path, "/")) {
+		return false, "date archive"
+	}
+
+	// Get relative path
+	relativePath := strings.TrimPrefix(path, basePath)
+	relativePath = strings.Trim(relativePath, "/")
+
+	// Exclude if it's just the base path or empty
+	if relativePath == "" || relativePath == "/" {
+		return false, "base page"
+	}
+
+	// Exclude language codes and pagination in path
+	pathParts := strings.Split(relativePath, "/")
+	for _, part := range pathParts {
+		// Skip language codes (en-US, es-US, etc.)
+		if strings.Contains(part, "-us") || (strings.Contains(part, "-") && len(part) <= 6) {
+			continue
+		}
+		// Skip pagination
+		if part == "page" {
+			return false, "pagination segment"
+		}
+	}
+
+	// Include URLs that look like blog posts
+	// Should have at least one meaningful path segment after the base
+	if len(pathParts) > 0 && pathParts[0] != "" {
+		// Check if it contains typical blog post indicators
+		if strings.Contains(path, "/blog/") ||
+			strings.Contains(path, "/post/") ||
+			strings.Contains(path, "/article/") ||
+			(len(pathParts) >= 2 && pathParts[0] == "blog") {
+			return true, "blog, post or article path"
+		}
+		// For other sites: if it's a direct path under base, it's likely a post
+		if strings.HasPrefix(path, basePath) && len(pathParts) >= 1 {
+			return true, "path under base URL"
+		}
+	}
+
+	return false, "outside base path"
+}
+
+// HostMatches reports whether host is one of hosts or a subdomain of one
+func HostMatches(host string, hosts []string) bool {
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,323 @@
+package urlfilter
+
+import (
+	"net/url"
+	"testing"
+)
+
+// rulesFor builds the rules the crawler uses for a blog: the default
+// excludes, the given category patterns and navigation paths
+func rulesFor(t *testing.T, baseURL string, categories []string, nav ...string) Rules {
+	t.Helper()
+	rules := Rules{BaseURL: baseURL, NavPaths: make(map[string]bool)}
+	for _, source := range Excludes {
+		pattern, err := CompileExclude(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules.Excludes = append(rules.Excludes, pattern)
+	}
+	for _, source := range categories {
+		pattern, err := CompileCategory(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules.Categories = append(rules.Categories, pattern)
+	}
+	for _, path := range nav {
+		rules.NavPaths[NavKey(path)] = true
+	}
+	return rules
+}
+
+func TestClassify(t *testing.T) {
+	medium := rulesFor(t, "https://medium.com/netflix-techblog", nil)
+	linkedIn := rulesFor(t, "https://www.linkedin.com/blog/engineering", LinkedInCategoryPages)
+	uber := rulesFor(t, "https://www.uber.com/blog/engineering/", UberCategoryPages,
+		"/blog/", "/blog/engineering/", "/blog/engineering/backend/", "/blog/careers/")
+	wordPress := rulesFor(t, "https://engineering.fb.com/", nil)
+	ghost := rulesFor(t, "https://blog.cloudflare.com/", nil)
+	subPath := rulesFor(t, "https://github.blog/engineering/", nil)
+	substack := rulesFor(t, "https://newsletter.pragmaticengineer.com/", nil)
+	docsBlog := rulesFor(t, "https://aws.amazon.com/blogs/architecture/", nil)
+
+	tests := []struct {
+		name   string
+		rules  Rules
+		url    string
+		accept bool
+		rule   string
+	}{
+		// Medium publications
+		{"medium post", medium, "https://medium.com/netflix-techblog/introducing-impressions-at-netflix-e2b67c88c9fb", true, "path under base URL"},
+		{"medium post with tracking", medium, "https://medium.com/netflix-techblog/netflix-graph-search-3c8f5a0a1d7b?source=collection_home---4------2", true, "path under base URL"},
+		{"medium publication", medium, "https://medium.com/netflix-techblog", false, "base page"},
+		{"medium archive", medium, "https://medium.com/netflix-techblog/archive", false, `exclude pattern "/archive"`},
+		{"medium about", medium, "https://medium.com/netflix-techblog/about", false, `exclude pattern "/about"`},
+		{"medium author", medium, "https://medium.com/@netflixtechblog", false, `exclude pattern "/@"`},
+		{"medium sign in", medium, "https://medium.com/m/signin?operation=login", false, "outside base path"},
+		{"medium other publication", medium, "https://medium.com/airbnb-engineering/some-post-12ab34cd", false, "outside base path"},
+		{"medium membership", medium, "https://medium.com/membership", false, `exclude pattern "/membership"`},
+
+		// LinkedIn Engineering
+		{"linkedin post", linkedIn, "https://www.linkedin.com/blog/engineering/data/openhouse-a-control-plane-for-tables", true, "linkedin: post under /blog/engineering/<category>/"},
+		{"linkedin post in another category", linkedIn, "https://www.linkedin.com/blog/engineering/infrastructure/scaling-the-feature-store", true, "linkedin: post under /blog/engineering/<category>/"},
+		{"linkedin category", linkedIn, "https://www.linkedin.com/blog/engineering/data", false, `category pattern "/blog/*/*"`},
+		{"linkedin category with slash", linkedIn, "https://www.linkedin.com/blog/engineering/data/", false, `category pattern "/blog/*/*"`},
+		{"linkedin section", linkedIn, "https://www.linkedin.com/blog/engineering", false, "linkedin: not under /blog/engineering/<category>/"},
+		{"linkedin other section", linkedIn, "https://www.linkedin.com/blog/member/product/new-feed", false, "linkedin: not under /blog/engineering/<category>/"},
+		{"linkedin pagination", linkedIn, "https://www.linkedin.com/blog/engineering/data/some-post?page0=2", false, "linkedin: pagination page"},
+		{"linkedin legal", linkedIn, "https://www.linkedin.com/legal/privacy-policy", false, "linkedin: not under /blog/engineering/<category>/"},
+
+		// Uber Engineering
+		{"uber post", uber, "https://www.uber.com/blog/kafka-tiered-storage/?uclick_id=4f2a1c", true, "uber: post under /blog/"},
+		{"uber post without slash", uber, "https://www.uber.com/blog/cinnamon-auto-tuner", true, "uber: post under /blog/"},
+		{"uber category in the navigation", uber, "https://www.uber.com/blog/engineering/", false, `category pattern "nav:/blog/*"`},
+		{"uber other category in the navigation", uber, "https://www.uber.com/blog/careers/", false, `category pattern "nav:/blog/*"`},
+		{"uber subcategory in the navigation", uber, "https://www.uber.com/blog/engineering/backend/", false, `category pattern "nav:/blog/*/*"`},
+		{"uber subcategory left to the grid check", uber, "https://www.uber.com/blog/engineering/data/", true, "uber: post under /blog/"},
+		{"uber pagination", uber, "https://www.uber.com/blog/engineering/page/2/", false, "uber: pagination page"},
+		{"uber blog home", uber, "https://www.uber.com/blog/", false, "uber: not under /blog/"},
+		{"uber ride page", uber, "https://www.uber.com/us/en/ride/", false, "uber: not under /blog/"},
+
+		// WordPress at the root
+		{"wordpress post", wordPress, "https://engineering.fb.com/2024/03/12/data-center-engineering/building-metas-genai-infrastructure/", true, "path under base URL"},
+		{"wordpress day archive", wordPress, "https://engineering.fb.com/2024/03/12/", false, "date archive"},
+		{"wordpress month archive", wordPress, "https://engineering.fb.com/2024/03/", false, "date archive"},
+		{"wordpress year archive page", wordPress, "https://engineering.fb.com/2023/page/2/", false, `exclude pattern "/page/"`},
+		{"wordpress category", wordPress, "https://engineering.fb.com/category/ai-research/", false, `exclude pattern "/category/"`},
+		{"wordpress tag", wordPress, "https://engineering.fb.com/tag/kafka/", false, `exclude pattern "/tag/"`},
+		{"wordpress feed", wordPress, "https://engineering.fb.com/feed/", false, `exclude pattern "/feed"`},
+		{"wordpress home", wordPress, "https://engineering.fb.com/", false, "base page"},
+		{"wordpress careers", wordPress, "https://engineering.fb.com/careers/", false, `exclude pattern "/careers"`},
+
+		// Ghost
+		{"ghost post", ghost, "https://blog.cloudflare.com/how-we-built-pingora-the-proxy-that-connects-cloudflare-to-the-internet/", true, "path under base URL"},
+		{"ghost tag", ghost, "https://blog.cloudflare.com/tag/developers/", false, `exclude pattern "/tag/"`},
+		{"ghost author", ghost, "https://blog.cloudflare.com/author/john-graham-cumming/", false, `exclude pattern "/author/"`},
+		{"ghost rss", ghost, "https://blog.cloudflare.com/rss/", false, `exclude pattern "/rss"`},
+		{"ghost pagination", ghost, "https://blog.cloudflare.com/page/2/", false, `exclude pattern "/page/"`},
+		{"ghost search", ghost, "https://blog.cloudflare.com/search/?q=quic", false, `exclude pattern "/search"`},
+
+		// Blog under a path
+		{"sub-path post", subPath, "https://github.blog/engineering/architecture-optimization/how-we-improved-push-processing/", true, "path under base URL"},
+		{"sub-path listing", subPath, "https://github.blog/engineering/", false, "base page"},
+		{"sub-path other section", subPath, "https://github.blog/news-insights/company-news/", false, "outside base path"},
+		{"sub-path changelog", subPath, "https://github.blog/changelog/2024-05-01-some-change/", false, "outside base path"},
+		{"sub-path with blog segment", docsBlog, "https://aws.amazon.com/blogs/architecture/lets-architect-streaming-data/", true, "path under base URL"},
+		{"sub-path sibling blog", docsBlog, "https://aws.amazon.com/blogs/compute/some-post/", false, "outside base path"},
+
+		// Substack
+		{"substack post", substack, "https://newsletter.pragmaticengineer.com/p/the-platform-team", true, "path under base URL"},
+		{"substack about", substack, "https://newsletter.pragmaticengineer.com/about", false, `exclude pattern "/about"`},
+		{"substack archive", substack, "https://newsletter.pragmaticengineer.com/archive?sort=new", false, `exclude pattern "/archive"`},
+
+		// Anything
+		{"language code segment", ghost, "https://blog.cloudflare.com/es-es/how-we-built-pingora/", true, "path under base URL"},
+		{"pagination segment", substack, "https://newsletter.pragmaticengineer.com/posts/page", false, "pagination segment"},
+		{"unparseable", ghost, "https://blog.cloudflare.com/%zz", false, "unparseable URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accept, rule := Classify(tt.url, tt.rules)
+			if accept != tt.accept || rule != tt.rule {
+				t.Errorf("Classify(%q) = %t, %q, want %t, %q", tt.url, accept, rule, tt.accept, tt.rule)
+			}
+			if got := IsBlogPost(tt.url, tt.rules); got != tt.accept {
+				t.Errorf("IsBlogPost(%q) = %t, want %t", tt.url, got, tt.accept)
+			}
+		})
+	}
+}
+
+func TestClassifyPatterns(t *testing.T) {
+	tests := []struct {
+		name       string
+		excludes   ExcludeRule
+		categories []string
+		nav        []string
+		url        string
+		accept     bool
+		rule       string
+	}{
+		{"default exclude", ExcludeRule{}, nil, nil, "https://example.com/careers-at-example-how-we-hire", false, `exclude pattern "/careers"`},
+		{"removed default", ExcludeRule{Remove: []string{"/careers"}}, nil, nil, "https://example.com/careers-at-example-how-we-hire", true, "path under base URL"},
+		{"regular expression", ExcludeRule{Remove: []string{"/careers"}, Add: []string{"re:^/careers(/|$)"}}, nil, nil, "https://example.com/careers/", false, `exclude pattern "re:^/careers(/|$)"`},
+		{"regular expression no match", ExcludeRule{Remove: []string{"/careers"}, Add: []string{"re:^/careers(/|$)"}}, nil, nil, "https://example.com/careers-day", true, "path under base URL"},
+		{"added substring is case-insensitive", ExcludeRule{Add: []string{"/Events/"}}, nil, nil, "https://example.com/events/meetup", false, `exclude pattern "/Events/"`},
+		{"category glob", ExcludeRule{}, []string{"/topics/*"}, nil, "https://example.com/topics/databases/", false, `category pattern "/topics/*"`},
+		{"category glob one segment only", ExcludeRule{}, []string{"/topics/*"}, nil, "https://example.com/topics/databases/postgres-tuning", true, "path under base URL"},
+		{"category regular expression", ExcludeRule{}, []string{"re:^/[a-z]+$"}, nil, "https://example.com/engineering", false, `category pattern "re:^/[a-z]+$"`},
+		{"nav category in the navigation", ExcludeRule{}, []string{"nav:/*"}, []string{"/engineering/"}, "https://example.com/engineering", false, `category pattern "nav:/*"`},
+		{"nav category elsewhere", ExcludeRule{}, []string{"nav:/*"}, []string{"/engineering/"}, "https://example.com/my-first-post", true, "path under base URL"},
+		{"grid category not decided here", ExcludeRule{}, []string{"grid:/*"}, nil, "https://example.com/engineering", true, "path under base URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := rulesFor(t, "https://example.com/", tt.categories, tt.nav...)
+			rules.Excludes = nil
+			for _, source := range tt.excludes.Apply(append([]string(nil), Excludes...)) {
+				pattern, err := CompileExclude(source)
+				if err != nil {
+					t.Fatal(err)
+				}
+				rules.Excludes = append(rules.Excludes, pattern)
+			}
+
+			accept, rule := Classify(tt.url, rules)
+			if accept != tt.accept || rule != tt.rule {
+				t.Errorf("Classify(%q) = %t, %q, want %t, %q", tt.url, accept, rule, tt.accept, tt.rule)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	const base = "https://example.com/blog/"
+	tests := []struct {
+		name  string
+		href  string
+		query QueryParamRule
+		want  string
+	}{
+		{"absolute", "https://example.com/blog/post", QueryParamRule{}, "https://example.com/blog/post"},
+		{"relative", "post-slug", QueryParamRule{}, "https://example.com/blog/post-slug"},
+		{"root relative", "/about", QueryParamRule{}, "https://example.com/about"},
+		{"protocol relative", "//cdn.example.com/a.png", QueryParamRule{}, "https://cdn.example.com/a.png"},
+		{"fragment", "/blog/post#comments", QueryParamRule{}, "https://example.com/blog/post"},
+		{"query dropped", "/blog/post?utm_source=x&id=3", QueryParamRule{}, "https://example.com/blog/post"},
+		{"query kept", "/blog/post?uclick_id=4f2a1c", QueryParamRule{Keep: []string{"*"}}, "https://example.com/blog/post?uclick_id=4f2a1c"},
+		{"kept as written", "/blog/post?b=2&a=1", QueryParamRule{Keep: []string{"*"}}, "https://example.com/blog/post?b=2&a=1"},
+		{"keep by name", "/blog/post?id=3&ref=home", QueryParamRule{Keep: []string{"id"}}, "https://example.com/blog/post?id=3"},
+		{"drop by glob", "/blog/post?id=3&utm_source=x&utm_medium=y", QueryParamRule{Drop: []string{"utm_*"}}, "https://example.com/blog/post?id=3"},
+		{"drop wins over keep", "/blog/post?lang=en&ref=x", QueryParamRule{Keep: []string{"*"}, Drop: []string{"ref"}}, "https://example.com/blog/post?lang=en"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(base, tt.href, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.href, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Normalize(base, "http://[::1", QueryParamRule{}); err == nil {
+		t.Error("Normalize accepted an unparseable href")
+	}
+}
+
+func TestIsListing(t *testing.T) {
+	tests := []struct {
+		url            string
+		authorsAndTags bool
+		want           bool
+	}{
+		{"https://example.com/2023/", false, true},
+		{"https://example.com/2023/04/", false, true},
+		{"https://example.com/2023/04/17/", false, true},
+		{"https://example.com/2023/page/2/", false, true},
+		{"https://example.com/archive", false, true},
+		{"https://example.com/category/databases/", false, true},
+		{"https://example.com/categories/", false, true},
+		{"https://example.com/author/jane/", false, false},
+		{"https://example.com/author/jane/", true, true},
+		{"https://example.com/tag/kafka/", true, true},
+		{"https://example.com/2023/04/17/a-post/", false, false},
+		{"https://example.com/scaling-kafka-2023", false, false},
+	}
+	for _, tt := range tests {
+		if got := IsListing(tt.url, tt.authorsAndTags); got != tt.want {
+			t.Errorf("IsListing(%q, %t) = %t, want %t", tt.url, tt.authorsAndTags, got, tt.want)
+		}
+	}
+}
+
+func TestLinkedInBlog(t *testing.T) {
+	tests := []struct {
+		url               string
+		section, category string
+		ok                bool
+	}{
+		{"https://www.linkedin.com/blog/engineering", "engineering", "", true},
+		{"https://www.linkedin.com/blog/engineering/data", "engineering", "data", true},
+		{"https://www.linkedin.com/blog/engineering/data/some-post", "engineering", "data", true},
+		{"https://linkedin.com/blog/Member/", "member", "", true},
+		{"https://www.linkedin.com/blog", "", "", false},
+		{"https://www.linkedin.com/pulse/some-article", "", "", false},
+		{"https://notlinkedin.com/blog/engineering", "", "", false},
+	}
+	for _, tt := range tests {
+		section, category, ok := LinkedInBlog(tt.url)
+		if section != tt.section || category != tt.category || ok != tt.ok {
+			t.Errorf("LinkedInBlog(%q) = %q, %q, %t, want %q, %q, %t", tt.url, section, category, ok, tt.section, tt.category, tt.ok)
+		}
+	}
+}
+
+func TestCategoryPatternMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/blog/*", "/blog/engineering", true},
+		{"/blog/*", "/blog/engineering/", true},
+		{"/blog/*", "/blog", false},
+		{"/blog/*", "/blog/engineering/backend", false},
+		{"/Blog/*/", "/blog/engineering", true},
+		{"/blog/*/*", "/blog/engineering/backend/", true},
+		{"re:^/topics?/[^/]+$", "/topic/go", true},
+		{"re:^/topics?/[^/]+$", "/topics/go/generics", false},
+		{"nav:/blog/*", "/blog/engineering", true},
+		{"grid:/blog/*/*", "/blog/engineering/backend", true},
+	}
+	for _, tt := range tests {
+		pattern, err := CompileCategory(tt.pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := pattern.Matches(tt.path); got != tt.want {
+			t.Errorf("%q.Matches(%q) = %t, want %t", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestInvalidPatterns(t *testing.T) {
+	for _, pattern := range []string{"re:(", "nav:re:[", "/blog/["} {
+		if err := ValidateCategories([]string{pattern}); err == nil {
+			t.Errorf("ValidateCategories accepted %q", pattern)
+		}
+	}
+	if err := ValidateExcludes([]string{"/fine", "re:^/careers("}); err == nil {
+		t.Error("ValidateExcludes accepted an invalid regular expression")
+	}
+	if err := ValidateExcludes([]string{"/[not-a-glob", "re:^/ok$"}); err != nil {
+		t.Errorf("ValidateExcludes: %v", err)
+	}
+}
+
+func TestHostMatches(t *testing.T) {
+	hosts := []string{"medium.com", "github.com"}
+	for host, want := range map[string]bool{
+		"medium.com":         true,
+		"netflix.medium.com": true,
+		"notmedium.com":      false,
+		"github.com.evil.io": false,
+		"gist.github.com":    true,
+	} {
+		if got := HostMatches(host, hosts); got != want {
+			t.Errorf("HostMatches(%q) = %t, want %t", host, got, want)
+		}
+	}
+}
+
+func TestQueryParamRuleApply(t *testing.T) {
+	u, _ := url.Parse("https://example.com/post?id=3&utm_source=x")
+	QueryParamRule{Drop: []string{"utm_*"}}.Apply(u)
+	if u.RawQuery != "id=3" {
+		t.Errorf("RawQuery = %q, want %q", u.RawQuery, "id=3")
+	}
+}
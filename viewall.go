@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// viewAllJS returns the first link whose text or label reads like a link
+// to the full listing ("See all posts", "All articles", "Archive"). "Older
+// posts" and "More posts" usually lead to the next page instead.
+const viewAllJS = `() => {
+	const texts = /^((see|view|show|browse|read)\s+)?all\s+(posts|articles|stories|entries|blog posts)\s*[›»→]?$|^(blog |post )?archives?$/i;
+	for (const a of document.querySelectorAll('a[href]')) {
+		const text = (a.textContent || a.getAttribute('aria-label') || '').trim();
+		if (texts.test(text)) return a.href;
+	}
+	return '';
+}`
+
+// findViewAll returns the current page's link to the blog's full listing,
+// or "" if it has none. Links to other hosts and back to the page itself
+// don't count.
+func (bc *BlogCrawler) findViewAll(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := bc.page.Eval(ctx, viewAllJS)
+	if err != nil {
+		return "", fmt.Errorf("failed to look for a view-all link: %w", err)
+	}
+	link := res.Str()
+	if link == "" || urlKey(link) == urlKey(bc.baseURL) {
+		return "", nil
+	}
+
+	linkURL, err := url.Parse(link)
+	if err != nil {
+		return "", nil
+	}
+	baseURL, err := url.Parse(bc.baseURL)
+	if err != nil || !strings.EqualFold(linkURL.Hostname(), baseURL.Hostname()) {
+		return "", nil
+	}
+	return link, nil
+}
+
+// followViewAll moves the crawl from a front page that only features a
+// few posts to the full listing it links to, if there is one. Post URLs
+// are still classified against the base URL.
+func (bc *BlogCrawler) followViewAll(ctx context.Context) {
+	link, err := bc.findViewAll(ctx)
+	if err != nil {
+		bc.warnf("Error on %s: %v", bc.baseURL, err)
+		return
+	}
+	if link == "" {
+		return
+	}
+
+	bc.printf("Front page links to the full listing, crawling %s instead...\n", link)
+	if err := bc.loadPage(ctx, link); err != nil {
+		bc.warnf("Error loading the full listing %s, crawling the front page instead: %v", link, err)
+		if err := bc.loadPage(ctx, bc.baseURL); err != nil {
+			bc.warnf("Error reloading %s: %v", bc.baseURL, err)
+		}
+		return
+	}
+	bc.listingURL = link
+}
+
+// listing returns the URL the listing is crawled from: the base URL, or
+// the full listing it links to (see followViewAll)
+func (bc *BlogCrawler) listing() string {
+	if bc.listingURL != "" {
+		return bc.listingURL
+	}
+	return bc.baseURL
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Internet Archive APIs, variables so tests can point them at a local server
+var (
+	waybackSaveEndpoint      = "https://web.archive.org/save/"
+	waybackAvailableEndpoint = "https://archive.org/wayback/available"
+	waybackCDXEndpoint       = "https://web.archive.org/cdx/search/cdx"
+)
+
+const (
+	waybackMaxAttempts = 4
+
+	// waybackMinBackoff is the first retry's wait when --wayback-delay is
+	// shorter, so a throttled archive isn't asked again straight away
+	waybackMinBackoff = time.Second
+
+	// URLs asked of the CDX API per request, and in total when
+	// opts.WaybackDiscoverLimit is 0
+	waybackCDXPageSize          = 5000
+	defaultWaybackDiscoverLimit = 50000
+)
+
+// waybackClient talks to the Internet Archive, spacing requests out by delay
+// and retrying throttled or failed requests with exponential backoff.
+type waybackClient struct {
+	http        *http.Client
+	delay       time.Duration
+	lastRequest time.Time
+	out         io.Writer // Where retries are reported
+}
+
+func newWaybackClient(delay time.Duration, out io.Writer) *waybackClient {
+	return &waybackClient{
+		// Save Page Now can take a long time to capture a page
+		http:  &http.Client{Timeout: 2 * time.Minute},
+		delay: delay,
+		out:   out,
+	}
+}
+
+// get performs a rate-limited GET, retrying on network errors, 429 and 5xx
+func (wc *waybackClient) get(ctx context.Context, requestURL string) (*http.Response, error) {
+	var lastErr error
+	backoff := max(wc.delay, waybackMinBackoff)
+
+	for attempt := 1; attempt <= waybackMaxAttempts; attempt++ {
+		if wait := wc.delay - time.Since(wc.lastRequest); wait > 0 {
+			if err := sleepContext(ctx, wait); err != nil {
+				return nil, err
+			}
+		}
+		wc.lastRequest = time.Now()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := wc.http.Do(req)
+		if err == nil && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+			return resp, nil
+		}
+
+		if err != nil {
+			lastErr = err
+		} else {
+			lastErr = fmt.Errorf("unexpected status %s", resp.Status)
+			if retryAfter := parseRetryAfter(resp.Header.Get("Retry-After")); retryAfter > backoff {
+				backoff = retryAfter
+			}
+			resp.Body.Close()
+		}
+
+		if attempt < waybackMaxAttempts {
+			fmt.Fprintf(wc.out, "  Wayback request failed (%v), retrying in %v...\n", lastErr, backoff)
+			if err := sleepContext(ctx, backoff); err != nil {
+				return nil, err
+			}
+			backoff *= 2
+		}
+	}
+
+	return nil, fmt.Errorf("giving up after %d attempts: %w", waybackMaxAttempts, lastErr)
+}
+
+// parseRetryAfter understands both the delay-seconds and HTTP-date forms
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		return time.Until(when)
+	}
+	return 0
+}
+
+// save asks Save Page Now to capture postURL and returns the snapshot URL
+func (wc *waybackClient) save(ctx context.Context, postURL string) (string, error) {
+	resp, err := wc.get(ctx, waybackSaveEndpoint+postURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("save request returned %s", resp.Status)
+	}
+
+	// The save endpoint redirects to the new snapshot; older deployments
+	// report it in Content-Location instead.
+	if strings.HasPrefix(resp.Request.URL.Path, "/web/") {
+		return resp.Request.URL.String(), nil
+	}
+	if location := resp.Header.Get("Content-Location"); strings.HasPrefix(location, "/web/") {
+		return resp.Request.URL.ResolveReference(&url.URL{Path: location}).String(), nil
+	}
+
+	return "", fmt.Errorf("save response did not include a snapshot location")
+}
+
+type waybackAvailability struct {
+	ArchivedSnapshots struct {
+		Closest *struct {
+			Available bool   `json:"available"`
+			URL       string `json:"url"`
+			Timestamp string `json:"timestamp"`
+		} `json:"closest"`
+	} `json:"archived_snapshots"`
+}
+
+// lookup returns the most recent snapshot URL and timestamp for postURL, or
+// empty strings when the page has never been archived.
+func (wc *waybackClient) lookup(ctx context.Context, postURL string) (string, string, error) {
+	resp, err := wc.get(ctx, waybackAvailableEndpoint+"?url="+url.QueryEscape(postURL))
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("availability request returned %s", resp.Status)
+	}
+
+	var availability waybackAvailability
+	if err := json.NewDecoder(resp.Body).Decode(&availability); err != nil {
+		return "", "", fmt.Errorf("failed to decode availability response: %w", err)
+	}
+
+	closest := availability.ArchivedSnapshots.Closest
+	if closest == nil || !closest.Available {
+		return "", "", nil
+	}
+	return closest.URL, closest.Timestamp, nil
+}
+
+// archivedURLs returns one page of the distinct URLs under prefix that the
+// Wayback Machine captured as HTML with status 200, and the key resuming
+// after it ("" on the last page)
+func (wc *waybackClient) archivedURLs(ctx context.Context, prefix, resumeKey string, limit int) ([]string, string, error) {
+	params := url.Values{
+		"url":           {prefix + "*"},
+		"output":        {"json"},
+		"fl":            {"original"},
+		"collapse":      {"urlkey"},
+		"filter":        {"statuscode:200", "mimetype:text/html"},
+		"limit":         {strconv.Itoa(limit)},
+		"showResumeKey": {"true"},
+	}
+	if resumeKey != "" {
+		params.Set("resumeKey", resumeKey)
+	}
+	resp, err := wc.get(ctx, waybackCDXEndpoint+"?"+params.Encode())
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("CDX request returned %s", resp.Status)
+	}
+
+	// A header row, one row per URL and, when there are more, an empty row
+	// followed by the resume key
+	var rows [][]string
+	if err := json.NewDecoder(resp.Body).Decode(&rows); err != nil {
+		return nil, "", fmt.Errorf("failed to decode CDX response: %w", err)
+	}
+	var urls []string
+	next := ""
+	for i, row := range rows {
+		switch {
+		case i == 0:
+			continue
+		case len(row) == 0:
+			if i+1 < len(rows) && len(rows[i+1]) == 1 {
+				next = rows[i+1][0]
+			}
+			return urls, next, nil
+		default:
+			urls = append(urls, row[0])
+		}
+	}
+	return urls, "", nil
+}
+
+// discoverFromWayback adds the post-shaped URLs the Wayback Machine has
+// archived under the blog's path to urlSet, recovering posts the blog has
+// since unpublished or stopped linking to. They may no longer load.
+func (bc *BlogCrawler) discoverFromWayback(ctx context.Context, urlSet map[string]bool) {
+	baseURL, err := url.Parse(bc.baseURL)
+	if err != nil {
+		bc.warnf("Error querying the Wayback Machine: %v", err)
+		return
+	}
+	limit := bc.opts.WaybackDiscoverLimit
+	if limit <= 0 {
+		limit = defaultWaybackDiscoverLimit
+	}
+	host := strings.TrimPrefix(strings.ToLower(baseURL.Hostname()), "www.")
+	prefix := host + baseURL.EscapedPath()
+	bc.printf("Looking up archived URLs under %s in the Wayback Machine...\n", prefix)
+
+	client := newWaybackClient(bc.opts.WaybackDelay, bc.opts.output())
+	seen, added := 0, 0
+	resumeKey := ""
+	for page := 1; seen < limit && ctx.Err() == nil; page++ {
+		if err := bc.budget.request(); err != nil {
+			break
+		}
+		archived, next, err := client.archivedURLs(ctx, prefix, resumeKey, min(waybackCDXPageSize, limit-seen))
+		if err != nil {
+			bc.warnf("Error querying the Wayback Machine: %v", err)
+			break
+		}
+		seen += len(archived)
+
+		bc.setSource("wayback", page, "")
+		for _, archivedURL := range archived {
+			parsed, err := url.Parse(archivedURL)
+			if err != nil || strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.") != host {
+				continue
+			}
+			// Captures are often of the http:// or www. variant
+			parsed.Scheme = baseURL.Scheme
+			parsed.Host = baseURL.Host
+			normalizedURL, err := bc.normalizeURL(parsed.String(), true)
+			if err != nil {
+				continue
+			}
+			ok, rule := bc.classifyURL(normalizedURL)
+			bc.explain(normalizedURL, ok, rule)
+			bc.scriptLinkFound(normalizedURL, ok, rule)
+			if !ok {
+				continue
+			}
+			normalizedURL = bc.canonicalURL(normalizedURL)
+			bc.recordDiscovery(normalizedURL, "cdx")
+			if !urlSet[normalizedURL] {
+				urlSet[normalizedURL] = true
+				added++
+			}
+		}
+
+		if next == "" {
+			break
+		}
+		resumeKey = next
+	}
+	bc.printf("Wayback Machine knows %d posts the listing didn't (total: %d unique URLs)\n", added, len(urlSet))
+}
+
+// snapshotTimestamp extracts the 14-digit timestamp from a snapshot URL
+// like https://web.archive.org/web/20240101120000/https://example.com/post
+func snapshotTimestamp(snapshotURL string) string {
+	parts := strings.SplitN(strings.TrimPrefix(snapshotURL, "https://web.archive.org/web/"), "/", 2)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0]
+}
+
+// archivePosts annotates posts with existing snapshots and/or submits them to
+// Save Page Now. Failures are reported per post and never abort the crawl.
+func (bc *BlogCrawler) archivePosts(ctx context.Context, posts []Post) {
+	client := newWaybackClient(bc.opts.WaybackDelay, bc.opts.output())
+
+	for i := range posts {
+		if ctx.Err() != nil {
+			return
+		}
+		post := &posts[i]
+
+		if bc.opts.WaybackLookup {
+			snapshotURL, timestamp, err := client.lookup(ctx, post.URL)
+			if err != nil {
+				bc.warnf("Wayback lookup failed for %s: %v", post.URL, err)
+			} else if snapshotURL != "" {
+				post.WaybackURL = snapshotURL
+				post.WaybackTimestamp = timestamp
+			}
+		}
+
+		if bc.opts.WaybackSave {
+			bc.printf("Submitting post %d/%d to the Wayback Machine: %s\n", i+1, len(posts), post.URL)
+			snapshotURL, err := client.save(ctx, post.URL)
+			if err != nil {
+				bc.warnf("Wayback save failed for %s: %v", post.URL, err)
+				continue
+			}
+			post.WaybackURL = snapshotURL
+			post.WaybackTimestamp = snapshotTimestamp(snapshotURL)
+		}
+	}
+}
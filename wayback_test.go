@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWaybackLookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("url") {
+		case "https://blog.example.com/archived":
+			fmt.Fprint(w, `{"archived_snapshots": {"closest": {"available": true, "status": "200",
+				"url": "https://web.archive.org/web/20240101120000/https://blog.example.com/archived", "timestamp": "20240101120000"}}}`)
+		case "https://blog.example.com/never-archived":
+			fmt.Fprint(w, `{"archived_snapshots": {}}`)
+		case "https://blog.example.com/garbled":
+			fmt.Fprint(w, `<html>Service unavailable</html>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	defer func(endpoint string) { waybackAvailableEndpoint = endpoint }(waybackAvailableEndpoint)
+	waybackAvailableEndpoint = server.URL + "/wayback/available"
+
+	tests := []struct {
+		postURL       string
+		wantURL       string
+		wantTimestamp string
+		wantErr       string
+	}{
+		{"https://blog.example.com/archived", "https://web.archive.org/web/20240101120000/https://blog.example.com/archived", "20240101120000", ""},
+		{"https://blog.example.com/never-archived", "", "", ""},
+		{"https://blog.example.com/garbled", "", "", "failed to decode availability response"},
+		{"https://blog.example.com/missing", "", "", "availability request returned 404"},
+	}
+	client := newWaybackClient(0, io.Discard)
+	for _, tt := range tests {
+		snapshotURL, timestamp, err := client.lookup(context.Background(), tt.postURL)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("lookup(%q) error = %v, want %q", tt.postURL, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || snapshotURL != tt.wantURL || timestamp != tt.wantTimestamp {
+			t.Errorf("lookup(%q) = %q, %q, %v, want %q, %q", tt.postURL, snapshotURL, timestamp, err, tt.wantURL, tt.wantTimestamp)
+		}
+	}
+}
+
+func TestWaybackSave(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch postURL := strings.TrimPrefix(r.URL.Path, "/save/"); {
+		case strings.HasPrefix(r.URL.Path, "/web/"):
+			fmt.Fprint(w, "<html>Snapshot</html>")
+		case postURL == "https://blog.example.com/redirected":
+			// Not http.Redirect, which would clean the // out of the path
+			w.Header().Set("Location", "/web/20240101120000/"+postURL)
+			w.WriteHeader(http.StatusFound)
+		case postURL == "https://blog.example.com/located":
+			w.Header().Set("Content-Location", "/web/20240202120000/"+postURL)
+		case postURL == "https://blog.example.com/excluded":
+			http.Error(w, "This URL is excluded", http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+	defer func(endpoint string) { waybackSaveEndpoint = endpoint }(waybackSaveEndpoint)
+	waybackSaveEndpoint = server.URL + "/save/"
+
+	tests := []struct {
+		postURL string
+		want    string
+		wantErr string
+	}{
+		{"https://blog.example.com/redirected", server.URL + "/web/20240101120000/https://blog.example.com/redirected", ""},
+		{"https://blog.example.com/located", server.URL + "/web/20240202120000/https://blog.example.com/located", ""},
+		{"https://blog.example.com/excluded", "", "save request returned 403"},
+		{"https://blog.example.com/unsaved", "", "did not include a snapshot location"},
+	}
+	client := newWaybackClient(0, io.Discard)
+	for _, tt := range tests {
+		snapshotURL, err := client.save(context.Background(), tt.postURL)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("save(%q) error = %v, want %q", tt.postURL, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || snapshotURL != tt.want {
+			t.Errorf("save(%q) = %q, %v, want %q", tt.postURL, snapshotURL, err, tt.want)
+		}
+	}
+}
+
+func TestWaybackRetriesThrottledRequests(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			http.Error(w, "Slow down", http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"archived_snapshots": {}}`)
+	}))
+	defer server.Close()
+	defer func(endpoint string) { waybackAvailableEndpoint = endpoint }(waybackAvailableEndpoint)
+	waybackAvailableEndpoint = server.URL + "/wayback/available"
+
+	var out bytes.Buffer
+	if _, _, err := newWaybackClient(0, &out).lookup(context.Background(), "https://blog.example.com/a"); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 {
+		t.Errorf("made %d requests, want a retry after the 429", requests)
+	}
+	// Without --wayback-delay the retry still waits
+	if !strings.Contains(out.String(), "retrying in 1s") {
+		t.Errorf("retry not reported with the minimum backoff: %q", out.String())
+	}
+}
+
+func TestWaybackArchivedURLs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case query.Get("url") != "blog.example.com/*" || query.Get("limit") != "2" || query.Get("output") != "json":
+			http.Error(w, "unexpected query "+r.URL.RawQuery, http.StatusBadRequest)
+		case query.Get("resumeKey") == "":
+			fmt.Fprint(w, `[["original"], ["https://blog.example.com/a"], ["https://blog.example.com/b"], [], ["com,example,blog)/b"]]`)
+		case query.Get("resumeKey") == "com,example,blog)/b":
+			fmt.Fprint(w, `[["original"], ["https://blog.example.com/c"]]`)
+		default:
+			fmt.Fprint(w, `Resume key not found`)
+		}
+	}))
+	defer server.Close()
+	defer func(endpoint string) { waybackCDXEndpoint = endpoint }(waybackCDXEndpoint)
+	waybackCDXEndpoint = server.URL + "/cdx/search/cdx"
+
+	tests := []struct {
+		resumeKey string
+		wantURLs  []string
+		wantNext  string
+		wantErr   string
+	}{
+		{"", []string{"https://blog.example.com/a", "https://blog.example.com/b"}, "com,example,blog)/b", ""},
+		{"com,example,blog)/b", []string{"https://blog.example.com/c"}, "", ""},
+		{"stale", nil, "", "failed to decode CDX response"},
+	}
+	client := newWaybackClient(0, io.Discard)
+	for _, tt := range tests {
+		urls, next, err := client.archivedURLs(context.Background(), "blog.example.com/", tt.resumeKey, 2)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("archivedURLs(%q) error = %v, want %q", tt.resumeKey, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(urls, tt.wantURLs) || next != tt.wantNext {
+			t.Errorf("archivedURLs(%q) = %q, %q, %v, want %q, %q", tt.resumeKey, urls, next, err, tt.wantURLs, tt.wantNext)
+		}
+	}
+
+	if _, _, err := client.archivedURLs(context.Background(), "blog.example.com/", "", 50); err == nil || !strings.Contains(err.Error(), "CDX request returned 400") {
+		t.Errorf("rejected query: error = %v, want the status", err)
+	}
+}
+
+func TestDiscoverFromWaybackStopsAtLimit(t *testing.T) {
+	archived := []string{
+		"http://www.blog.example.com/scaling-the-ingest-queue", // Captured under another variant
+		"https://blog.example.com/moving-search-to-rust",
+		"https://blog.example.com/our-cache-eviction-policy",
+		"https://blog.example.com/a-year-of-feature-flags",
+		"https://blog.example.com/why-we-shard-by-tenant",
+	}
+	var limits []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		limits = append(limits, query.Get("limit"))
+		// Pages of at most two rows, however many were asked for
+		offset, _ := strconv.Atoi(query.Get("resumeKey"))
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		end := min(offset+min(limit, 2), len(archived))
+		rows := [][]string{{"original"}}
+		for _, archivedURL := range archived[offset:end] {
+			rows = append(rows, []string{archivedURL})
+		}
+		if end < len(archived) {
+			rows = append(rows, []string{}, []string{strconv.Itoa(end)})
+		}
+		json.NewEncoder(w).Encode(rows)
+	}))
+	defer server.Close()
+	defer func(endpoint string) { waybackCDXEndpoint = endpoint }(waybackCDXEndpoint)
+	waybackCDXEndpoint = server.URL + "/cdx/search/cdx"
+
+	bc := NewBlogCrawler(fakeBlogURL, 5*time.Second, CrawlOptions{WaybackDiscoverLimit: 3, Output: io.Discard})
+	urlSet := make(map[string]bool)
+	bc.discoverFromWayback(context.Background(), urlSet)
+
+	want := map[string]bool{
+		fakeBlogURL + "scaling-the-ingest-queue":  true,
+		fakeBlogURL + "moving-search-to-rust":     true,
+		fakeBlogURL + "our-cache-eviction-policy": true,
+	}
+	if !reflect.DeepEqual(urlSet, want) {
+		t.Errorf("discovered %v, want %v", urlSet, want)
+	}
+	// The second page only asks for what's left of the limit
+	if !reflect.DeepEqual(limits, []string{"3", "1"}) {
+		t.Errorf("asked for pages of %q, want [3 1]", limits)
+	}
+}
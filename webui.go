@@ -0,0 +1,409 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// webUIPage is the dashboard served by serve-grpc --http
+//
+//go:embed webui/index.html
+var webUIPage []byte
+
+// maxServerJobs is how many jobs the dashboard remembers; older ones are
+// forgotten, though their results stay in --data
+const maxServerJobs = 200
+
+// errTooManyCrawls refuses a crawl while --max-crawls are running
+var errTooManyCrawls = errors.New("too many crawls running, try again when one has finished")
+
+// serverJob is a crawl run by the server, for a gRPC call or from the
+// dashboard, or a past run read from --data
+type serverJob struct {
+	ID        string     `json:"id"`
+	BaseURL   string     `json:"base_url"`
+	Source    string     `json:"source"` // "grpc", "web" or "history"
+	State     string     `json:"state"`  // "running", "done", "failed" or "cancelled"
+	Started   time.Time  `json:"started"`
+	Finished  *time.Time `json:"finished,omitempty"`
+	Step      string     `json:"step,omitempty"` // Latest progress while running
+	URLs      int        `json:"urls"`           // Post URLs found so far, or in the result
+	New       []string   `json:"new,omitempty"`  // Posts the blog's previous run didn't have
+	Error     string     `json:"error,omitempty"`
+	HasResult bool       `json:"has_result"`
+
+	result *CrawlResult
+	cancel context.CancelFunc
+}
+
+// timelineRun is a finished run on a blog's new-post timeline
+type timelineRun struct {
+	Finished time.Time `json:"finished"`
+	JobID    string    `json:"job_id"`
+	Posts    int       `json:"posts"`
+	New      int       `json:"new"`
+}
+
+// jobBoard tracks the server's crawls for the dashboard. Its methods do
+// nothing on a nil board, so the gRPC service can report to it without a
+// dashboard.
+type jobBoard struct {
+	dataDir string        // Where finished results are kept, "" for nowhere
+	running chan struct{} // One slot per crawl allowed at once, nil for no limit
+
+	mu     sync.Mutex
+	jobs   []*serverJob            // Oldest first
+	latest map[string]*CrawlResult // Last result by base URL, to tell new posts
+	lastID int
+}
+
+// newJobBoard returns a board with the runs saved in dataDir, if any, as
+// its history
+func newJobBoard(dataDir string) (*jobBoard, error) {
+	b := &jobBoard{dataDir: dataDir, latest: make(map[string]*CrawlResult)}
+	if dataDir == "" {
+		return b, nil
+	}
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dataDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var results []*CrawlResult
+	for _, path := range paths {
+		result, err := loadResult(path)
+		if err != nil {
+			fmt.Printf("Warning: Skipping %s: %v\n", path, err)
+			continue
+		}
+		results = append(results, result)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].CrawledAt < results[j].CrawledAt })
+
+	for _, result := range results {
+		finished, _ := time.Parse(time.RFC3339, result.CrawledAt)
+		job := b.add(result.BaseURL, "history", nil)
+		job.Started = finished
+		b.complete(job, result, nil, finished)
+	}
+	return b, nil
+}
+
+// start records a crawl of baseURL that has begun; cancel stops it
+func (b *jobBoard) start(baseURL, source string, cancel context.CancelFunc) *serverJob {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.add(baseURL, source, cancel)
+}
+
+func (b *jobBoard) add(baseURL, source string, cancel context.CancelFunc) *serverJob {
+	b.lastID++
+	job := &serverJob{
+		ID:      strconv.Itoa(b.lastID),
+		BaseURL: baseURL,
+		Source:  source,
+		State:   "running",
+		Started: time.Now(),
+		cancel:  cancel,
+	}
+	b.jobs = append(b.jobs, job)
+	if len(b.jobs) > maxServerJobs {
+		b.jobs = b.jobs[len(b.jobs)-maxServerJobs:]
+	}
+	return job
+}
+
+// progress records a progress event of job's crawl
+func (b *jobBoard) progress(job *serverJob, event ProgressEvent) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	job.Step = fmt.Sprintf("%s %d", event.Kind, event.Step)
+	job.URLs = event.TotalURLs
+}
+
+// finish records the outcome of job's crawl and saves its result to the
+// data directory
+func (b *jobBoard) finish(job *serverJob, result *CrawlResult, err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.complete(job, result, err, time.Now())
+	b.mu.Unlock()
+
+	if result != nil && b.dataDir != "" {
+		if err := b.save(result); err != nil {
+			fmt.Printf("Warning: Error saving result of %s: %v\n", result.BaseURL, err)
+		}
+	}
+}
+
+func (b *jobBoard) complete(job *serverJob, result *CrawlResult, err error, finished time.Time) {
+	job.Finished = &finished
+	job.Step = ""
+	switch {
+	case errors.Is(err, context.Canceled):
+		job.State = "cancelled"
+	case err != nil:
+		job.State, job.Error = "failed", redact(err.Error())
+	default:
+		job.State = "done"
+	}
+	if result == nil {
+		return
+	}
+
+	job.result, job.HasResult = result, true
+	job.URLs = result.TotalCount
+	if previous := b.latest[result.BaseURL]; previous != nil {
+		job.New = diffResults(previous, result).Added
+	}
+	b.latest[result.BaseURL] = result
+}
+
+// save writes result to the data directory, named after its blog and time
+func (b *jobBoard) save(result *CrawlResult) error {
+	name := seedOutputName(result.BaseURL) + "-" + time.Now().Format("20060102-150405") + ".json"
+	file, err := os.Create(filepath.Join(b.dataDir, name))
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(file, result); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// list returns copies of the jobs, newest first
+func (b *jobBoard) list() []serverJob {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	jobs := make([]serverJob, 0, len(b.jobs))
+	for i := len(b.jobs) - 1; i >= 0; i-- {
+		jobs = append(jobs, *b.jobs[i])
+	}
+	return jobs
+}
+
+// job returns the job with the given ID, nil if there is none
+func (b *jobBoard) job(id string) *serverJob {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, job := range b.jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
+
+// timelines returns the finished runs of every blog, oldest first
+func (b *jobBoard) timelines() map[string][]timelineRun {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	timelines := make(map[string][]timelineRun)
+	for _, job := range b.jobs {
+		if job.result == nil {
+			continue
+		}
+		timelines[job.BaseURL] = append(timelines[job.BaseURL], timelineRun{
+			Finished: *job.Finished,
+			JobID:    job.ID,
+			Posts:    job.URLs,
+			New:      len(job.New),
+		})
+	}
+	return timelines
+}
+
+// crawlSlots returns a semaphore of n crawls, nil for no limit when n is 0
+func crawlSlots(n int) chan struct{} {
+	if n <= 0 {
+		return nil
+	}
+	return make(chan struct{}, n)
+}
+
+// limitCrawls allows at most n dashboard crawls at once, 0 for no limit
+func (b *jobBoard) limitCrawls(n int) {
+	b.running = crawlSlots(n)
+}
+
+// runWebCrawl starts a crawl asked for on the dashboard and returns its job
+// as it started. It returns errTooManyCrawls when the limit is reached.
+func (b *jobBoard) runWebCrawl(req CrawlJob) (serverJob, error) {
+	parsedURL, err := url.Parse(req.BaseURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return serverJob{}, fmt.Errorf("invalid base_url %q", req.BaseURL)
+	}
+	timeout := 30 * time.Second
+	if req.TimeoutSeconds > 0 {
+		timeout = time.Duration(req.TimeoutSeconds) * time.Second
+	}
+	if b.running != nil {
+		select {
+		case b.running <- struct{}{}:
+		default:
+			return serverJob{}, errTooManyCrawls
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := b.start(req.BaseURL, "web", cancel)
+	opts := CrawlOptions{
+		FetchContent: req.FetchContent,
+		OnProgress:   func(event ProgressEvent) { b.progress(job, event) },
+	}
+	go func() {
+		defer cancel()
+		if b.running != nil {
+			defer func() { <-b.running }()
+		}
+		result, err := NewBlogCrawler(req.BaseURL, timeout, opts).crawl(ctx)
+		b.finish(job, result, err)
+	}()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return *job, nil
+}
+
+// handler serves the dashboard and its JSON API:
+//
+//	GET  /                          the dashboard
+//	GET  /api/jobs                  jobs, newest first
+//	POST /api/jobs                  start a crawl: {"base_url": ..., "fetch_content": ...}
+//	POST /api/jobs/{id}/cancel      stop a running crawl
+//	GET  /api/jobs/{id}/result      download the result, ?format=json, ndjson, rss, atom or html
+//	GET  /api/timelines             finished runs by blog
+//
+// POST requests must be sent as application/json (see requireJSON).
+func (b *jobBoard) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(webUIPage)
+	})
+	mux.HandleFunc("GET /api/jobs", func(w http.ResponseWriter, r *http.Request) {
+		writeAPIJSON(w, http.StatusOK, b.list())
+	})
+	mux.HandleFunc("POST /api/jobs", func(w http.ResponseWriter, r *http.Request) {
+		if !requireJSON(w, r) {
+			return
+		}
+		var req CrawlJob
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+			return
+		}
+		job, err := b.runWebCrawl(req)
+		if errors.Is(err, errTooManyCrawls) {
+			writeAPIError(w, http.StatusTooManyRequests, err)
+			return
+		}
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeAPIJSON(w, http.StatusAccepted, job)
+	})
+	mux.HandleFunc("POST /api/jobs/{id}/cancel", func(w http.ResponseWriter, r *http.Request) {
+		if !requireJSON(w, r) {
+			return
+		}
+		job := b.job(r.PathValue("id"))
+		if job == nil {
+			writeAPIError(w, http.StatusNotFound, fmt.Errorf("no job %s", r.PathValue("id")))
+			return
+		}
+		if job.cancel != nil {
+			job.cancel()
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /api/jobs/{id}/result", func(w http.ResponseWriter, r *http.Request) {
+		job := b.job(r.PathValue("id"))
+		b.mu.Lock()
+		var result *CrawlResult
+		if job != nil {
+			result = job.result
+		}
+		b.mu.Unlock()
+		if result == nil {
+			writeAPIError(w, http.StatusNotFound, fmt.Errorf("no result for job %s", r.PathValue("id")))
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "json"
+		}
+		if !contains([]string{"json", "ndjson", "rss", "atom", "html"}, format) {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("unknown format %q", format))
+			return
+		}
+		ext := map[string]string{"json": ".json", "ndjson": ".ndjson", "rss": ".xml", "atom": ".xml", "html": ".html"}[format]
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", seedOutputName(result.BaseURL)+ext))
+		if err := writeResult(w, result, format); err != nil {
+			fmt.Printf("Warning: Error sending result of job %s: %v\n", job.ID, err)
+		}
+	})
+	mux.HandleFunc("GET /api/timelines", func(w http.ResponseWriter, r *http.Request) {
+		writeAPIJSON(w, http.StatusOK, b.timelines())
+	})
+	return mux
+}
+
+// requireJSON turns down requests not sent as application/json. Browsers
+// only send that to another site after a CORS preflight, which the
+// dashboard never allows, so a page on another site can't start or cancel
+// crawls through the user's browser. It reports whether to go on.
+func requireJSON(w http.ResponseWriter, r *http.Request) bool {
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		writeAPIError(w, http.StatusUnsupportedMediaType, fmt.Errorf("requests must be sent as application/json"))
+		return false
+	}
+	return true
+}
+
+// loopbackAddr binds a listen address without a host, like :8080, to the
+// loopback interface only; a host, even 0.0.0.0, is kept
+func loopbackAddr(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		return "127.0.0.1" + addr
+	}
+	return addr
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPIJSON(w, status, map[string]string{"error": err.Error()})
+}
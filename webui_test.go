@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJobBoardHistory(t *testing.T) {
+	dir := t.TempDir()
+	runs := []*CrawlResult{
+		{BaseURL: fakeBlogURL, CrawledAt: "2026-05-01T10:00:00Z", BlogURLs: []string{fakeBlogURL + "one"}, TotalCount: 1},
+		{BaseURL: fakeBlogURL, CrawledAt: "2026-05-02T10:00:00Z", BlogURLs: []string{fakeBlogURL + "one", fakeBlogURL + "two"}, TotalCount: 2},
+	}
+	for i, run := range runs {
+		file, err := os.Create(filepath.Join(dir, []string{"b.json", "a.json"}[i]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writeJSON(file, run); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+	}
+
+	board, err := newJobBoard(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(board.handler())
+	defer server.Close()
+
+	var jobs []serverJob
+	getJSON(t, server.URL+"/api/jobs", &jobs)
+	if len(jobs) != 2 || jobs[0].State != "done" || jobs[0].Source != "history" {
+		t.Fatalf("jobs = %+v, want the two runs from the data directory", jobs)
+	}
+	if !reflect.DeepEqual(jobs[0].New, []string{fakeBlogURL + "two"}) || jobs[1].New != nil {
+		t.Errorf("new posts = %q and %q, want the second run to add /two", jobs[0].New, jobs[1].New)
+	}
+
+	var timelines map[string][]timelineRun
+	getJSON(t, server.URL+"/api/timelines", &timelines)
+	if runs := timelines[fakeBlogURL]; len(runs) != 2 || runs[0].Posts != 1 || runs[1].New != 1 {
+		t.Errorf("timeline = %+v, want two runs, the second with one new post", runs)
+	}
+
+	resp, err := http.Get(server.URL + "/api/jobs/" + jobs[0].ID + "/result?format=rss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(resp.Header.Get("Content-Disposition"), "attachment") {
+		t.Errorf("result download: %s, %q", resp.Status, resp.Header.Get("Content-Disposition"))
+	}
+
+	resp, err = http.Post(server.URL+"/api/jobs", "application/json", strings.NewReader(`{"base_url": "not a url"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("crawl of an invalid URL: %s, want 400", resp.Status)
+	}
+
+	resp, err = http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("dashboard page: %s, %q", resp.Status, resp.Header.Get("Content-Type"))
+	}
+}
+
+func TestJobBoardRefusesCrossSiteAndExcessCrawls(t *testing.T) {
+	board, err := newJobBoard("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	board.limitCrawls(1)
+	board.running <- struct{}{} // A crawl is already running
+	server := httptest.NewServer(board.handler())
+	defer server.Close()
+
+	body := `{"base_url": "https://blog.example.com/"}`
+	for contentType, want := range map[string]int{
+		"text/plain":                        http.StatusUnsupportedMediaType, // What a form on another site can send
+		"application/x-www-form-urlencoded": http.StatusUnsupportedMediaType,
+		"application/json; charset=utf-8":   http.StatusTooManyRequests,
+	} {
+		resp, err := http.Post(server.URL+"/api/jobs", contentType, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("POST as %s: %s, want %d", contentType, resp.Status, want)
+		}
+	}
+	if jobs := board.list(); len(jobs) != 0 {
+		t.Errorf("%d jobs started, want none", len(jobs))
+	}
+
+	for addr, want := range map[string]string{":8080": "127.0.0.1:8080", "0.0.0.0:8080": "0.0.0.0:8080", "[::1]:8080": "[::1]:8080"} {
+		if got := loopbackAddr(addr); got != want {
+			t.Errorf("loopbackAddr(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
+
+func getJSON(t *testing.T, url string, v any) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("%s: %v", url, err)
+	}
+}
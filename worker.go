@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/redis/go-redis/v9"
+)
+
+// CrawlJob is a crawl request consumed from the job queue
+type CrawlJob struct {
+	ID             string `json:"id"`
+	BaseURL        string `json:"base_url"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty"`
+	FetchContent   bool   `json:"fetch_content,omitempty"`
+	ReplyTo        string `json:"reply_to,omitempty"` // List or subject for the result, instead of the worker's --results
+}
+
+// CrawlJobResult is published back to the queue once a job finishes
+type CrawlJobResult struct {
+	ID      string       `json:"id"`
+	BaseURL string       `json:"base_url"`
+	Worker  string       `json:"worker,omitempty"` // Host and process ID of the worker that ran it
+	Result  *CrawlResult `json:"result,omitempty"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// jobQueue is the transport a worker consumes jobs from and publishes
+// results to. The coordinator uses it the other way round.
+type jobQueue interface {
+	// Receive blocks until a payload is available or ctx is done
+	Receive(ctx context.Context) ([]byte, error)
+	// Publish sends payload to the named list or subject
+	Publish(ctx context.Context, name string, payload []byte) error
+	Close() error
+}
+
+// redisQueue pops payloads from one Redis list and pushes them onto others
+type redisQueue struct {
+	client     *redis.Client
+	receiveKey string
+}
+
+func (q *redisQueue) Receive(ctx context.Context) ([]byte, error) {
+	for {
+		// Block in short slices so cancellation is noticed promptly
+		values, err := q.client.BLPop(ctx, 5*time.Second, q.receiveKey).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []byte(values[1]), nil
+	}
+}
+
+func (q *redisQueue) Publish(ctx context.Context, name string, payload []byte) error {
+	return q.client.RPush(ctx, name, payload).Err()
+}
+
+func (q *redisQueue) Close() error {
+	return q.client.Close()
+}
+
+// natsQueue receives jobs through a queue group, so each job published on the
+// requests subject goes to exactly one of the running workers
+type natsQueue struct {
+	conn         *nats.Conn
+	subscription *nats.Subscription
+}
+
+func (q *natsQueue) Receive(ctx context.Context) ([]byte, error) {
+	msg, err := q.subscription.NextMsgWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return msg.Data, nil
+}
+
+func (q *natsQueue) Publish(ctx context.Context, name string, payload []byte) error {
+	if err := q.conn.Publish(name, payload); err != nil {
+		return err
+	}
+	return q.conn.FlushWithContext(ctx)
+}
+
+func (q *natsQueue) Close() error {
+	q.conn.Close()
+	return nil
+}
+
+// openJobQueue connects to the queue named by queueURL (redis:// or nats://)
+// to receive from the receive list or subject. With NATS, receivers in the
+// same group share the messages; an empty group receives them all.
+func openJobQueue(queueURL, receive, group string) (jobQueue, error) {
+	parsedURL, err := url.Parse(queueURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse queue URL: %w", err)
+	}
+
+	switch parsedURL.Scheme {
+	case "redis", "rediss":
+		options, err := redis.ParseURL(queueURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+		}
+		client := redis.NewClient(options)
+		if err := client.Ping(context.Background()).Err(); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+		}
+		return &redisQueue{client: client, receiveKey: receive}, nil
+
+	case "nats", "tls":
+		conn, err := nats.Connect(queueURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+		}
+		var subscription *nats.Subscription
+		if group != "" {
+			subscription, err = conn.QueueSubscribeSync(receive, group)
+		} else {
+			subscription, err = conn.SubscribeSync(receive)
+		}
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to subscribe to %s: %w", receive, err)
+		}
+		return &natsQueue{conn: conn, subscription: subscription}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported queue scheme %q (use redis:// or nats://)", parsedURL.Scheme)
+}
+
+// runJob crawls a single job. Failures are reported in the result rather
+// than returned, so the requester always hears back.
+func runJob(ctx context.Context, job CrawlJob) CrawlJobResult {
+	if job.BaseURL == "" {
+		return CrawlJobResult{ID: job.ID, Error: "job has no base_url"}
+	}
+
+	timeout := 30 * time.Second
+	if job.TimeoutSeconds > 0 {
+		timeout = time.Duration(job.TimeoutSeconds) * time.Second
+	}
+
+	crawler := NewBlogCrawler(job.BaseURL, timeout, CrawlOptions{FetchContent: job.FetchContent})
+	result, err := crawler.crawl(ctx)
+	if err != nil {
+		return CrawlJobResult{ID: job.ID, BaseURL: job.BaseURL, Error: err.Error()}
+	}
+
+	return CrawlJobResult{ID: job.ID, BaseURL: job.BaseURL, Result: result}
+}
+
+// workerName identifies this worker in its results, by host and process
+func workerName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// runWorker implements the worker subcommand
+func runWorker(args []string) {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+	queueURL := fs.String("queue", "redis://localhost:6379/0", "queue to consume from (redis://... or nats://...)")
+	requests := fs.String("requests", "blogcrawler:requests", "Redis list or NATS subject carrying crawl jobs")
+	results := fs.String("results", "blogcrawler:results", "Redis list or NATS subject to publish results to")
+	fs.Usage = func() {
+		fmt.Println("Usage: go run . worker [--queue URL] [--requests NAME] [--results NAME]")
+		fmt.Println()
+		fmt.Println("Flags:")
+		fs.PrintDefaults()
+	}
+	parseArgs(fs, args)
+
+	queue, err := openJobQueue(*queueURL, *requests, "blogcrawler-workers")
+	if err != nil {
+		fmt.Printf("Error opening queue: %v\n", err)
+		os.Exit(1)
+	}
+	defer queue.Close()
+
+	// On SIGINT/SIGTERM stop taking new jobs; a running crawl is finished first
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	worker := workerName()
+	fmt.Printf("Worker %s waiting for crawl jobs on %s (%s)\n", worker, *queueURL, *requests)
+	for {
+		payload, err := queue.Receive(ctx)
+		if ctx.Err() != nil {
+			fmt.Printf("Worker shutting down\n")
+			return
+		}
+		if err != nil {
+			fmt.Printf("Warning: Error receiving job: %v\n", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		var job CrawlJob
+		var jobResult CrawlJobResult
+		if err := json.Unmarshal(payload, &job); err != nil {
+			jobResult = CrawlJobResult{Error: fmt.Sprintf("invalid job: %v", err)}
+		} else {
+			// Shutdown only stops taking new jobs, so the crawl itself
+			// isn't tied to ctx
+			jobResult = runJob(context.Background(), job)
+		}
+		jobResult.Worker = worker
+		if jobResult.Error != "" {
+			fmt.Printf("Job %s failed: %s\n", jobResult.ID, jobResult.Error)
+		} else {
+			fmt.Printf("Job %s finished with %d blog URLs\n", jobResult.ID, jobResult.Result.TotalCount)
+		}
+
+		encoded, err := json.Marshal(jobResult)
+		if err != nil {
+			fmt.Printf("Warning: Error encoding result of job %s: %v\n", jobResult.ID, err)
+			continue
+		}
+		replyTo := *results
+		if job.ReplyTo != "" {
+			replyTo = job.ReplyTo
+		}
+		if err := queue.Publish(context.Background(), replyTo, encoded); err != nil {
+			fmt.Printf("Warning: Error publishing result of job %s: %v\n", jobResult.ID, err)
+		}
+	}
+}